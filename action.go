@@ -0,0 +1,76 @@
+package azurepush
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ActionsDataKey is the custom data key of the notification actions sent to Android devices,
+// as a JSON array string of the actions, e.g. [{"id":"accept","title":"Accept"}].
+// The app builds the notification buttons from it, see Notification.Actions.
+const ActionsDataKey = "actions"
+
+// NotificationAction is an action button of a notification, e.g. "Accept" or "Decline".
+//
+// Apple devices display the actions of a category registered by the app (UNNotificationCategory),
+// the notification only carries its identifier, see ActionCategory. Register a category for each
+// set of actions the backend sends, with the same action IDs, e.g. in Swift:
+//
+//	let accept = UNNotificationAction(identifier: "accept", title: "Accept")
+//	let decline = UNNotificationAction(identifier: "decline", title: "Decline", options: [.destructive])
+//	UNUserNotificationCenter.current().setNotificationCategories([
+//		UNNotificationCategory(identifier: "accept.decline", actions: [accept, decline], intentIdentifiers: []),
+//	])
+//
+// Android devices receive the actions as the ActionsDataKey custom data field
+// and Windows devices as toast actions.
+type NotificationAction struct {
+	// ID identifies the action to the app when tapped, e.g. "accept".
+	ID string `json:"id"`
+	// Title is the text of the button, e.g. "Accept".
+	Title string `json:"title"`
+	// Input makes the action ask for a text reply, e.g. "Reply".
+	Input bool `json:"input,omitempty"`
+	// InputPlaceholder is the placeholder text of the reply input.
+	InputPlaceholder string `json:"inputPlaceholder,omitempty"`
+}
+
+// ActionCategory returns the APNs category identifier of a set of actions:
+// their IDs joined by dots (e.g. "accept.decline"), or an empty string for no actions.
+// It is the identifier of the UNNotificationCategory the app should register, see NotificationAction.
+func ActionCategory(actions ...NotificationAction) string {
+	ids := make([]string, len(actions))
+	for i, action := range actions {
+		ids[i] = action.ID
+	}
+
+	return strings.Join(ids, ".")
+}
+
+// validateActions checks that the actions have unique IDs and titles.
+func validateActions(actions []NotificationAction) error {
+	seen := make(map[string]struct{}, len(actions))
+	for i, action := range actions {
+		if action.ID == "" || action.Title == "" {
+			return fmt.Errorf("action %d: id and title are required", i)
+		}
+
+		if strings.Contains(action.ID, ".") {
+			return fmt.Errorf("action: %s: id cannot contain dots", action.ID)
+		}
+
+		if _, ok := seen[action.ID]; ok {
+			return fmt.Errorf("action: %s: duplicate id", action.ID)
+		}
+		seen[action.ID] = struct{}{}
+	}
+
+	return nil
+}
+
+// actionsData returns the ActionsDataKey custom data value of the actions.
+func actionsData(actions []NotificationAction) string {
+	b, _ := json.Marshal(actions) // strings and booleans only, cannot fail.
+	return string(b)
+}
@@ -0,0 +1,71 @@
+package azurepush_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestNotification_Actions(t *testing.T) {
+	notification := azurepush.Notification{
+		Title: "Friend request",
+		Body:  "Maria wants to connect",
+		Actions: []azurepush.NotificationAction{
+			{ID: "accept", Title: "Accept"},
+			{ID: "reply", Title: "Reply", Input: true, InputPlaceholder: "Say hi"},
+		},
+	}
+
+	if category := azurepush.ActionCategory(notification.Actions...); category != "accept.reply" {
+		t.Fatalf("unexpected category: %s", category)
+	}
+
+	tests := []struct {
+		platform azurepush.Platform
+		expected string
+	}{
+		{
+			platform: azurepush.PlatformApple,
+			expected: `{"aps":{"alert":{"title":"Friend request","body":"Maria wants to connect"},"sound":"default","category":"accept.reply"}}`,
+		},
+		{
+			platform: azurepush.PlatformFCMV1,
+			expected: `{"message":{"notification":{"title":"Friend request","body":"Maria wants to connect"},"android":{"data":{"actions":` +
+				`"[{\"id\":\"accept\",\"title\":\"Accept\"},{\"id\":\"reply\",\"title\":\"Reply\",\"input\":true,\"inputPlaceholder\":\"Say hi\"}]"}}}}`,
+		},
+		{
+			platform: azurepush.PlatformWindows,
+			expected: `<toast><visual><binding template="ToastGeneric"><text>Friend request</text><text>Maria wants to connect</text></binding></visual>` +
+				`<actions><input id="reply" type="text" placeHolderContent="Say hi"/>` +
+				`<action content="Accept" arguments="accept"/><action content="Reply" arguments="reply" hint-inputId="reply"/></actions></toast>`,
+		},
+	}
+
+	for _, tt := range tests {
+		if err := notification.ValidateFor(tt.platform); err != nil {
+			t.Fatalf("%s: unexpected validation error: %v", tt.platform, err)
+		}
+
+		payload, _, err := notification.MarshalFor(tt.platform)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.platform, err)
+		}
+
+		if string(payload) != tt.expected {
+			t.Errorf("%s: unexpected payload:\nexpected: %s\ngot:      %s", tt.platform, tt.expected, payload)
+		}
+	}
+
+	invalid := []azurepush.Notification{
+		{Title: "Hi", Actions: []azurepush.NotificationAction{{ID: "accept"}}},
+		{Title: "Hi", Actions: []azurepush.NotificationAction{{ID: "accept", Title: "Accept"}, {ID: "accept", Title: "OK"}}},
+		{Title: "Hi", Actions: []azurepush.NotificationAction{{ID: "friend.accept", Title: "Accept"}}},
+		{Title: "Hi", Actions: []azurepush.NotificationAction{{ID: "accept", Title: "Accept"}}, Data: map[string]any{azurepush.ActionsDataKey: "[]"}},
+	}
+	for i, n := range invalid {
+		if err := n.Validate(); !errors.Is(err, azurepush.ErrInvalidNotification) {
+			t.Errorf("[%d] expected an invalid notification error, got: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Package api holds the OpenAPI 3 specification of the azurepushd REST API
+// and its generated request and response models.
+package api
+
+import _ "embed"
+
+//go:generate oapi-codegen -generate types -package api -o models.gen.go openapi.yaml
+
+// Spec is the OpenAPI 3 specification in YAML.
+//
+//go:embed openapi.yaml
+var Spec []byte
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/api"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSpec(t *testing.T) {
+	var spec struct {
+		OpenAPI string                    `yaml:"openapi"`
+		Paths   map[string]map[string]any `yaml:"paths"`
+	}
+	if err := yaml.Unmarshal(api.Spec, &spec); err != nil {
+		t.Fatalf("invalid specification: %v", err)
+	}
+
+	for path, method := range map[string]string{
+		"/v1/devices":      "post",
+		"/v1/devices/{id}": "delete",
+		"/v1/send":         "post",
+		"/v1/stats":        "get",
+		"/healthz":         "get",
+		"/readyz":          "get",
+		"/livez":           "get",
+		"/metrics":         "get",
+	} {
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("expected %s %s operation", method, path)
+		}
+	}
+}
+
+// TestRegistrationRequest_Compatible makes sure the generated model
+// matches the body accepted by azurepush.RegistrationHandler.
+func TestRegistrationRequest_Compatible(t *testing.T) {
+	id := "device-1"
+	data, err := json.Marshal(api.RegistrationRequest{
+		InstallationId: &id,
+		Platform:       api.FCMV1,
+		PushChannel:    "token",
+		Tags:           &[]string{"user:42"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var req azurepush.RegistrationRequest
+	if err = json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	if req.InstallationID != id || req.Platform != azurepush.InstallationFCMV1 || req.PushChannel != "token" || req.Tags[0] != "user:42" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+}
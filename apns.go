@@ -0,0 +1,286 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APNs endpoints.
+const (
+	APNsProductionEndpoint = "https://api.push.apple.com"
+	APNsSandboxEndpoint    = "https://api.sandbox.push.apple.com"
+)
+
+// apnsTokenRefresh is the validity of an APNs provider token,
+// Apple rejects tokens older than an hour and throttles refreshes more frequent than every 20 minutes.
+const apnsTokenRefresh = 50 * time.Minute
+
+// APNsOptions holds the settings of an APNsProvider.
+type APNsOptions struct {
+	// Key is the content of the .p8 authentication token signing key,
+	// created in the Apple Developer account (Keys).
+	Key []byte
+	// KeyID is the 10-character identifier of the key.
+	KeyID string
+	// TeamID is the 10-character identifier of the Apple Developer team.
+	TeamID string
+	// Topic is the bundle ID of the app, sent as the apns-topic header.
+	Topic string
+
+	// Sandbox sends to the development environment of APNs (apps built with a development provisioning profile).
+	Sandbox bool
+	// Endpoint overrides the APNs endpoint, e.g. to use a mock server in tests.
+	//
+	// Defaults to APNsProductionEndpoint, or APNsSandboxEndpoint when Sandbox is true.
+	Endpoint string
+
+	// Registry keeps the installations and their tags.
+	//
+	// Defaults to an in-memory registry, set a registry backed by a persistent Store in production.
+	Registry *DeviceRegistry
+
+	// HTTPClient is the client used for the send requests, its transport must support HTTP/2
+	// (the default transport negotiates it over TLS).
+	//
+	// Defaults to a client with a 30 seconds timeout.
+	HTTPClient *http.Client
+
+	// Concurrency is the maximum number of devices sent in parallel by Send,
+	// they are multiplexed over the same HTTP/2 connection.
+	//
+	// Defaults to 8.
+	Concurrency int
+}
+
+// APNsProvider is a PushProvider which sends directly to the Apple Push Notification service (HTTP/2 API)
+// with token-based authentication, bypassing the Notification Hub, e.g. during hub outages
+// or for latency-critical messages. It sends the same payload as the hub.
+//
+// APNs has no tags, so the installations and their tags are kept in a DeviceRegistry:
+// Register and Unregister maintain it (only Apple "apns" installations are accepted)
+// and Send resolves the tags to device tokens. Tokens reported as unregistered or invalid by APNs
+// are removed from the registry.
+//
+// Example usage:
+//
+//	apns, err := azurepush.NewAPNsProvider(azurepush.APNsOptions{
+//		Key:      p8,
+//		KeyID:    "ABC123DEFG",
+//		TeamID:   "DEF123GHIJ",
+//		Topic:    "com.example.app",
+//		Registry: azurepush.NewDeviceRegistry(store),
+//	})
+//	err = apns.Send(ctx, notification, "user:42")
+type APNsProvider struct {
+	opts APNsOptions
+	key  *ecdsa.PrivateKey
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+var _ PushProvider = (*APNsProvider)(nil)
+
+// NewAPNsProvider creates a new APNsProvider.
+// It returns an error if the key or the identifiers are invalid.
+func NewAPNsProvider(opts APNsOptions) (*APNsProvider, error) {
+	if opts.KeyID == "" || opts.TeamID == "" {
+		return nil, errors.New("APNs key ID and team ID are required")
+	}
+
+	if opts.Topic == "" {
+		return nil, errors.New("APNs topic (the app's bundle ID) is required")
+	}
+
+	block, _ := pem.Decode(opts.Key)
+	if block == nil {
+		return nil, errors.New("invalid APNs key: not PEM encoded")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %w", err)
+	}
+
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid APNs key: not an ECDSA key")
+	}
+
+	if opts.Endpoint == "" {
+		opts.Endpoint = APNsProductionEndpoint
+		if opts.Sandbox {
+			opts.Endpoint = APNsSandboxEndpoint
+		}
+	}
+
+	if opts.Registry == nil {
+		opts.Registry = NewDeviceRegistry(NewMemoryStore())
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+
+	return &APNsProvider{opts: opts, key: key}, nil
+}
+
+// Registry returns the registry of the provider's installations.
+func (p *APNsProvider) Registry() *DeviceRegistry {
+	return p.opts.Registry
+}
+
+// Register implements the PushProvider interface.
+// Only Apple installations (InstallationApple) are accepted, their push channel is the APNs device token.
+func (p *APNsProvider) Register(ctx context.Context, installation Installation) (string, error) {
+	if installation.Platform != InstallationApple {
+		return "", fmt.Errorf("%w: %q (APNs supports %q only)", errUnsupportedPlatform, installation.Platform, InstallationApple)
+	}
+
+	if installation.InstallationID == "" {
+		installation.InstallationID = uuid.NewString()
+	}
+
+	if err := p.opts.Registry.Put(ctx, installation); err != nil {
+		return "", err
+	}
+
+	return installation.InstallationID, nil
+}
+
+// Unregister implements the PushProvider interface.
+func (p *APNsProvider) Unregister(ctx context.Context, installationID string) error {
+	if installationID == "" {
+		return fmt.Errorf("installation ID cannot be empty")
+	}
+
+	return p.opts.Registry.Delete(ctx, installationID)
+}
+
+// Send implements the PushProvider interface.
+// The notification is sent to each Apple installation with any of the tags, see DeviceRegistry.Match.
+func (p *APNsProvider) Send(ctx context.Context, notification Notification, tags ...string) error {
+	if err := notification.ValidateFor(PlatformApple); err != nil {
+		return err
+	}
+
+	// Not pooled: the transport may still read a request body after the response.
+	var buf bytes.Buffer
+	if err := notification.encode(PlatformApple, &buf); err != nil {
+		return err
+	}
+	payload := buf.Bytes()
+
+	return sendToDevices(ctx, p.opts.Registry, InstallationApple, tags, p.opts.Concurrency, func(installation Installation) error {
+		return p.sendTo(ctx, payload, installation.PushChannel)
+	})
+}
+
+// sendTo sends the payload to a single device token.
+func (p *APNsProvider) sendTo(ctx context.Context, payload []byte, deviceToken string) error {
+	token, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.opts.Endpoint+"/3/device/"+deviceToken, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create APNs request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("apns-topic", p.opts.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send APNs request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	b, _ := io.ReadAll(resp.Body)
+
+	var apnsErr struct {
+		Reason string `json:"reason"`
+	}
+	_ = json.Unmarshal(b, &apnsErr)
+
+	switch apnsErr.Reason {
+	case "Unregistered":
+		// Only a 410 proves that the token is no longer valid for the topic, it is removed from the registry.
+		// A bad token or a token of another topic (e.g. a sandbox token or a misconfigured topic) is a send error.
+		if resp.StatusCode == http.StatusGone {
+			return errDeviceUnregistered
+		}
+	case "ExpiredProviderToken", "InvalidProviderToken":
+		p.mu.Lock()
+		p.token = "" // issue a new one on the next send.
+		p.mu.Unlock()
+	}
+
+	return &SendError{Platform: PlatformApple, StatusCode: resp.StatusCode, Body: string(b)}
+}
+
+// providerToken returns the signed JWT (ES256) of the token-based authentication, reused until it should be refreshed.
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.token != "" && now.Sub(p.issuedAt) < apnsTokenRefresh {
+		return p.token, nil
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "kid": p.opts.KeyID})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{"iss": p.opts.TeamID, "iat": now.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign APNs provider token: %w", err)
+	}
+
+	// JWS encodes the ES256 signature as the fixed size concatenation of r and s.
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	p.token = signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+	p.issuedAt = now
+	return p.token, nil
+}
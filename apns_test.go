@@ -0,0 +1,149 @@
+package azurepush_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestAPNsProvider(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	var (
+		mu       sync.Mutex
+		sent     = make(map[string]string) // device token to payload.
+		protocol string
+	)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "bearer ")
+		if !ok || !validES256(token, &key.PublicKey) || r.Header.Get("apns-topic") != "com.example.app" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, `{"reason":"InvalidProviderToken"}`)
+			return
+		}
+
+		deviceToken := strings.TrimPrefix(r.URL.Path, "/3/device/")
+		switch deviceToken {
+		case "stale-token":
+			w.WriteHeader(http.StatusGone)
+			_, _ = io.WriteString(w, `{"reason":"Unregistered","timestamp":1700000000000}`)
+			return
+		case "sandbox-token":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"reason":"BadDeviceToken"}`)
+			return
+		}
+
+		payload, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		sent[deviceToken] = string(payload)
+		protocol = r.Proto
+		mu.Unlock()
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	apns, err := azurepush.NewAPNsProvider(azurepush.APNsOptions{
+		Key:        p8,
+		KeyID:      "ABC123DEFG",
+		TeamID:     "DEF123GHIJ",
+		Topic:      "com.example.app",
+		Endpoint:   srv.URL,
+		HTTPClient: srv.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "iphone", Platform: azurepush.InstallationApple, PushChannel: "iphone-token", Tags: []string{"user:42"}},
+		{InstallationID: "old-iphone", Platform: azurepush.InstallationApple, PushChannel: "stale-token", Tags: []string{"user:42"}},
+	} {
+		if _, err = apns.Register(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = apns.Register(ctx, azurepush.Installation{Platform: azurepush.InstallationFCMV1, PushChannel: "fcm-token"}); err == nil {
+		t.Error("expected an error registering an Android device")
+	}
+
+	if err = apns.Send(ctx, azurepush.Notification{Title: "Hello", Body: "World", Data: map[string]any{"orderId": "42"}}, "user:42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if protocol != "HTTP/2.0" {
+		t.Errorf("expected HTTP/2, got: %s", protocol)
+	}
+
+	var payload map[string]any
+	if err = json.Unmarshal([]byte(sent["iphone-token"]), &payload); err != nil {
+		t.Fatalf("invalid payload: %q: %v", sent["iphone-token"], err)
+	}
+	if payload["orderId"] != "42" || payload["aps"] == nil {
+		t.Errorf("expected the hub's APNs payload, got: %v", payload)
+	}
+
+	if _, err = apns.Registry().Get(ctx, "old-iphone"); err == nil {
+		t.Error("expected the unregistered token to be removed from the registry")
+	}
+
+	if _, err = apns.Register(ctx, azurepush.Installation{
+		InstallationID: "dev-iphone", Platform: azurepush.InstallationApple, PushChannel: "sandbox-token", Tags: []string{"user:7"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err = apns.Send(ctx, azurepush.Notification{Title: "Hello"}, "user:7")
+	if sendErr, ok := errors.AsType[*azurepush.SendError](err); !ok || sendErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a send error for a bad device token, got: %v", err)
+	}
+	if _, err = apns.Registry().Get(ctx, "dev-iphone"); err != nil {
+		t.Errorf("expected a bad device token to be kept in the registry, got: %v", err)
+	}
+
+	if _, err = azurepush.NewAPNsProvider(azurepush.APNsOptions{Key: []byte("not a key"), KeyID: "A", TeamID: "B", Topic: "C"}); err == nil {
+		t.Error("expected an error for an invalid key")
+	}
+}
+
+func validES256(token string, key *ecdsa.PublicKey) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		return false
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	return ecdsa.Verify(key, digest[:], r, s)
+}
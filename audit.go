@@ -0,0 +1,165 @@
+package azurepush
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditOperation is the mutating operation of an AuditRecord.
+type AuditOperation string
+
+// Audited operations.
+const (
+	// AuditRegister is a device registration, see Client.RegisterDevice (and UpdateInstallation).
+	AuditRegister AuditOperation = "register"
+	// AuditDelete is the deletion of an installation (Client.DeleteDevice) or of a registration (BulkDelete).
+	AuditDelete AuditOperation = "delete"
+	// AuditPatch is a patch of an installation, e.g. a tag change, see Client.PatchInstallation (and BulkUpdateTags).
+	AuditPatch AuditOperation = "patch"
+	// AuditSend is a notification send, see Client.SendNotification.
+	AuditSend AuditOperation = "send"
+)
+
+// AuditRecord is the structured record of a mutating operation of a Client, see AuditSink.
+// The secrets are redacted: the push channel is recorded as a short hash (see RedactSecret),
+// the SAS tokens and the PNS headers of the sends are not recorded.
+type AuditRecord struct {
+	// Time is the time the operation completed.
+	Time time.Time `json:"time"`
+	// Actor is who requested the operation, see WithAuditActor.
+	Actor string `json:"actor,omitempty"`
+	// Operation is what was done.
+	Operation AuditOperation `json:"operation"`
+	// Hub is the "<namespace>/<hub name>" of the client, which tells apart the hubs of a ClientPool.
+	Hub string `json:"hub"`
+
+	// InstallationID is the registered, deleted or patched installation.
+	InstallationID string `json:"installationId,omitempty"`
+	// RegistrationID is the deleted registration of a device without installation.
+	RegistrationID string `json:"registrationId,omitempty"`
+	// Platform and PushChannel are the registered device, the push channel is redacted.
+	Platform    InstallationPlatform `json:"platform,omitempty"`
+	PushChannel string               `json:"pushChannel,omitempty"`
+	// Tags are the registered tags, or the tags targeted by a send.
+	Tags []string `json:"tags,omitempty"`
+	// Patch holds the operations of a patch, e.g. the added and removed tags.
+	Patch []PatchOperation `json:"patch,omitempty"`
+	// Notification is the sent notification.
+	Notification *Notification `json:"notification,omitempty"`
+	// NotificationIDs are the IDs of the sent notifications reported by the hub (Standard tier only).
+	NotificationIDs []string `json:"notificationIds,omitempty"`
+
+	// Outcome is "ok", or the ErrorClass name of the failure, e.g. "throttled".
+	Outcome string `json:"outcome"`
+	// Error is the error of a failed operation.
+	Error string `json:"error,omitempty"`
+	// DryRun reports that the operation was not sent to the hub, see Configuration.DryRun.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// AuditSink receives an AuditRecord for every mutating operation of a Client
+// (registrations, deletions, tag changes and sends), successful or not,
+// so regulated applications can prove which notifications were sent to whom.
+//
+// Audit is called synchronously once the operation completed, before it returns to the caller,
+// with the operation's context without its cancelation. Implementations must be safe for concurrent use
+// and should not block for long, e.g. buffer the records of a remote audit store.
+type AuditSink interface {
+	Audit(ctx context.Context, record AuditRecord)
+}
+
+// AuditSinkFunc is a function adapter for the AuditSink interface.
+type AuditSinkFunc func(ctx context.Context, record AuditRecord)
+
+// Audit implements the AuditSink interface.
+func (f AuditSinkFunc) Audit(ctx context.Context, record AuditRecord) {
+	f(ctx, record)
+}
+
+// JSONAuditSink is an AuditSink which writes the records as JSON lines, e.g. to an append-only file.
+type JSONAuditSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var _ AuditSink = (*JSONAuditSink)(nil)
+
+// NewJSONAuditSink returns a JSONAuditSink writing to w.
+// It panics if w is nil.
+//
+// Example usage:
+//
+//	f, err := os.OpenFile("audit.jsonl", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+//	if err != nil {
+//		return err
+//	}
+//	client.Audit = azurepush.NewJSONAuditSink(f)
+func NewJSONAuditSink(w io.Writer) *JSONAuditSink {
+	if w == nil {
+		panic("azurepush: nil audit writer")
+	}
+
+	return &JSONAuditSink{enc: json.NewEncoder(w)}
+}
+
+// Audit implements the AuditSink interface. Write errors are dropped.
+func (s *JSONAuditSink) Audit(_ context.Context, record AuditRecord) {
+	s.mu.Lock()
+	_ = s.enc.Encode(record)
+	s.mu.Unlock()
+}
+
+type auditActorContextKey struct{}
+
+// WithAuditActor returns a context whose operations are audited as requested by the actor,
+// e.g. the user or the service calling the Client.
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorContextKey{}, actor)
+}
+
+// AuditActor returns the actor of the context set by WithAuditActor,
+// or the Subject authenticated by the middlewares of this package.
+func AuditActor(ctx context.Context) string {
+	if actor, ok := ctx.Value(auditActorContextKey{}).(string); ok {
+		return actor
+	}
+
+	return Subject(ctx)
+}
+
+// RedactSecret returns a short hash of a secret (e.g. a push channel) for logs and audit records,
+// so the records of the same secret can be correlated without exposing it.
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// audit sends the record of an operation to the client's AuditSink, if any.
+func (c *Client) audit(ctx context.Context, record AuditRecord, err error) {
+	if c.Audit == nil {
+		return
+	}
+
+	cfg, _ := c.current()
+	record.Time = c.now()
+	record.Hub = cfg.Namespace + "/" + cfg.HubName
+	record.Actor = AuditActor(ctx)
+	record.Tags = slices.Clone(record.Tags)
+	record.DryRun = c.dryRun(ctx)
+	record.Outcome = "ok"
+	if err != nil {
+		record.Outcome, record.Error = ClassifyError(err).String(), err.Error()
+	}
+
+	c.Audit.Audit(context.WithoutCancel(ctx), record)
+}
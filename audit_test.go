@@ -0,0 +1,118 @@
+package azurepush_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_Audit(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	var (
+		mu      sync.Mutex
+		records []azurepush.AuditRecord
+	)
+	client := srv.NewClient()
+	client.Audit = azurepush.AuditSinkFunc(func(ctx context.Context, record azurepush.AuditRecord) {
+		if ctx.Err() != nil {
+			t.Error("expected the audit context not canceled")
+		}
+		mu.Lock()
+		records = append(records, record)
+		mu.Unlock()
+	})
+
+	ctx := azurepush.WithAuditActor(context.Background(), "admin@example.com")
+	installation := azurepush.Installation{InstallationID: "device", Platform: azurepush.InstallationApple, PushChannel: "secret-token", Tags: []string{"user:42"}}
+	if _, err := client.RegisterDevice(ctx, installation); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.PatchInstallation(ctx, "device", azurepush.AddTagPatch("topic:news")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+	srv.SetError(azurepushtest.OpSend, http.StatusForbidden, "denied")
+	if err := client.SendNotification(ctx, azurepush.Notification{Title: "Again"}, "user:42"); err == nil {
+		t.Fatal("expected a send error")
+	}
+	if err := client.DeleteDevice(ctx, "device"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeviceExists(ctx, "device"); err != nil { // not audited.
+		t.Fatal(err)
+	}
+
+	var operations []azurepush.AuditOperation
+	for _, record := range records {
+		operations = append(operations, record.Operation)
+		if record.Actor != "admin@example.com" || record.Hub == "" || record.Time.IsZero() {
+			t.Errorf("expected the actor, hub and time of the record, got: %+v", record)
+		}
+	}
+	if expected := []azurepush.AuditOperation{azurepush.AuditRegister, azurepush.AuditPatch, azurepush.AuditSend, azurepush.AuditSend, azurepush.AuditDelete}; !slices.Equal(operations, expected) {
+		t.Fatalf("expected the operations %v, got: %v", expected, operations)
+	}
+
+	if register := records[0]; register.InstallationID != "device" || register.PushChannel != azurepush.RedactSecret("secret-token") ||
+		!slices.Equal(register.Tags, []string{"user:42"}) || register.Outcome != "ok" {
+		t.Errorf("unexpected register record: %+v", register)
+	}
+	if patch := records[1]; len(patch.Patch) != 1 || patch.Patch[0].Value != "topic:news" {
+		t.Errorf("expected the tag change in the patch record, got: %+v", patch)
+	}
+	if send := records[2]; send.Notification == nil || send.Notification.Title != "Hi" || !slices.Equal(send.Tags, []string{"user:42"}) || send.Outcome != "ok" {
+		t.Errorf("unexpected send record: %+v", send)
+	}
+	if failed := records[3]; failed.Outcome != "auth" || !strings.Contains(failed.Error, "denied") {
+		t.Errorf("expected a failed send record, got: %+v", failed)
+	}
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("secret-token")) {
+		t.Errorf("expected the push channel redacted, got: %s", b)
+	}
+}
+
+func TestJSONAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := azurepush.NewJSONAuditSink(&buf)
+
+	sink.Audit(context.Background(), azurepush.AuditRecord{Operation: azurepush.AuditDelete, InstallationID: "a", Outcome: "ok"})
+	sink.Audit(context.Background(), azurepush.AuditRecord{Operation: azurepush.AuditDelete, InstallationID: "b", Outcome: "ok"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got: %q", buf.String())
+	}
+
+	var record azurepush.AuditRecord
+	if err := json.Unmarshal([]byte(lines[1]), &record); err != nil || record.InstallationID != "b" {
+		t.Errorf("expected the second record, got: %+v: %v", record, err)
+	}
+}
+
+func TestAuditActor(t *testing.T) {
+	ctx := context.Background()
+	if actor := azurepush.AuditActor(ctx); actor != "" {
+		t.Errorf("expected no actor, got: %q", actor)
+	}
+
+	if actor := azurepush.AuditActor(azurepush.WithAuditActor(ctx, "worker")); actor != "worker" {
+		t.Errorf("expected the actor, got: %q", actor)
+	}
+}
@@ -1 +1,16 @@
+// Package azurepush is a Go client for Azure Notification Hubs (APNs and FCM v1) with dynamic SAS authentication.
+//
+// Client is the single entry point of the data-plane operations (device installations and sends):
+// its requests are context-aware, use the Client's HTTPClient and are signed by its TokenManager,
+// the package's only SAS token implementation. Installation and Template describe the devices of a hub.
+//
+// Example usage:
+//
+//	client := azurepush.NewClient(cfg)
+//	id, err := client.RegisterDevice(ctx, azurepush.Installation{
+//		Platform:    azurepush.InstallationApple,
+//		PushChannel: deviceToken,
+//		Tags:        []string{"user:42"},
+//	})
+//	err = client.SendNotification(ctx, azurepush.Notification{Title: "Hello", Body: "World"}, "user:42")
 package azurepush
@@ -0,0 +1,129 @@
+package azurepushtest
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// blobHostSuffix is the host suffix of the Azure Storage blob service, handled by the Server's blob store.
+const blobHostSuffix = ".blob.core.windows.net"
+
+// isBlobRequest reports whether the request targets the blob storage.
+func isBlobRequest(r *http.Request) bool {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+
+	return strings.HasSuffix(strings.ToLower(hostname(host)), blobHostSuffix)
+}
+
+func hostname(host string) string {
+	if u, err := url.Parse("//" + host); err == nil {
+		return u.Hostname()
+	}
+
+	return host
+}
+
+// Blob returns the contents of a blob of the in-memory blob store by its path,
+// e.g. "container/import.jsonl", whatever its storage account is.
+func (s *Server) Blob(path string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.blobs[strings.TrimPrefix(path, "/")]
+	return data, ok
+}
+
+// PutBlob stores a blob in the in-memory blob store, e.g. the output file of an export job.
+func (s *Server) PutBlob(path string, data []byte) {
+	s.mu.Lock()
+	s.blobs[strings.TrimPrefix(path, "/")] = data
+	s.mu.Unlock()
+}
+
+// handleBlob serves the blob requests (authorized by their SAS query, which is not validated):
+// the block uploads (Put Block and Put Block List), the Put Blob and the Get Blob operations.
+func (s *Server) handleBlob(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpBlob) {
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.Contains(path, "/") {
+		http.Error(w, "InvalidUri", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		switch r.URL.Query().Get("comp") {
+		case "block":
+			if s.blocks[path] == nil {
+				s.blocks[path] = make(map[string][]byte)
+			}
+			s.blocks[path][r.URL.Query().Get("blockid")] = data
+		case "blocklist":
+			var list struct {
+				Blocks []string `xml:",any"`
+			}
+			if err = xml.Unmarshal(data, &list); err != nil {
+				http.Error(w, "InvalidXmlDocument", http.StatusBadRequest)
+				return
+			}
+
+			var blob []byte
+			for _, id := range list.Blocks {
+				block, ok := s.blocks[path][id]
+				if !ok {
+					http.Error(w, "InvalidBlockList", http.StatusBadRequest)
+					return
+				}
+				blob = append(blob, block...)
+			}
+			s.blobs[path] = blob
+			delete(s.blocks, path)
+		case "":
+			s.blobs[path] = data
+		default:
+			http.Error(w, "UnsupportedQueryParameter", http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet:
+		data, ok := s.Blob(path)
+		if !ok {
+			http.Error(w, "BlobNotFound", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(data)
+	default:
+		http.Error(w, "UnsupportedHttpVerb", http.StatusMethodNotAllowed)
+	}
+}
+
+// blobPath returns the blob store path of a blob URI.
+func blobPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(u.Path, "/")
+}
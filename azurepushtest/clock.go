@@ -0,0 +1,87 @@
+package azurepushtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+// FakeClock is an azurepush.Clock controlled by the test.
+// Use it to advance time and trigger token refresh and SAS expiry paths without sleeping.
+//
+// Example usage:
+//
+//	clock := azurepushtest.NewFakeClock(time.Now())
+//	client.SetClock(clock)
+//	clock.Advance(2 * time.Hour) // the next request generates a new token.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+var _ azurepush.TimerClock = (*FakeClock)(nil)
+
+// NewFakeClock returns a new FakeClock set to the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements the azurepush.Clock interface.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After implements the azurepush.TimerClock interface,
+// the channel receives the time once the clock is advanced (or set) by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, fakeTimer{at: c.now.Add(d), c: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing the due timers.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.fire()
+	c.mu.Unlock()
+}
+
+// Set sets the clock to the given time, firing the due timers.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	c.now = now
+	c.fire()
+	c.mu.Unlock()
+}
+
+// fire sends the time to the due timers, the caller must hold the lock.
+func (c *FakeClock) fire() {
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = waiters
+}
@@ -0,0 +1,54 @@
+package azurepushtest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestFakeClock_TokenRefresh(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	clock := azurepushtest.NewFakeClock(time.Now())
+	client.SetClock(clock)
+
+	var tokens []string
+	transport := client.HTTPClient.Transport
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		return transport.RoundTrip(r)
+	})
+
+	ctx := context.Background()
+	for range 2 {
+		if err := client.ValidateToken(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if tokens[0] != tokens[1] {
+		t.Fatal("expected the cached token to be reused")
+	}
+
+	// The configuration's token validity is 1 hour, tokens are refreshed 5 minutes before expiry.
+	clock.Advance(56 * time.Minute)
+	if err := client.ValidateToken(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tokens[2] == tokens[1] {
+		t.Error("expected a new token after advancing the clock")
+	}
+
+	// Tokens generated for the past are rejected by the (loose) SAS expiry check of the fake hub.
+	clock.Set(time.Now().Add(-2 * time.Hour))
+	if err := client.Reload(client.Config); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.ValidateToken(ctx); err == nil {
+		t.Error("expected an expired SAS token to be rejected")
+	}
+}
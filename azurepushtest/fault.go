@@ -0,0 +1,174 @@
+package azurepushtest
+
+import (
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Fault describes a failure injected by the FaultTransport.
+// The zero values of the failure fields are ignored,
+// e.g. a Fault with only Latency set delays the request and then sends it.
+type Fault struct {
+	// Operation limits the fault to a single operation, empty matches all requests.
+	Operation Operation
+	// Probability is the chance (0 to 1) the fault is applied to a matching request.
+	// Zero means always.
+	Probability float64
+
+	// Latency delays the request (or the failure) by the given duration.
+	Latency time.Duration
+	// StatusCode, when set, responds with this status code instead of sending the request,
+	// e.g. http.StatusTooManyRequests or http.StatusInternalServerError.
+	StatusCode int
+	// RetryAfter sets the Retry-After header of a StatusCode response.
+	RetryAfter time.Duration
+	// Timeout makes the request hang until its context is done
+	// and then fail with a timeout error.
+	Timeout bool
+	// Reset fails the request with a "connection reset by peer" error.
+	Reset bool
+}
+
+// FaultTransport is an http.RoundTripper which injects latency, timeouts,
+// error responses and connection resets, for chaos testing retry and failover configurations.
+// The first matching fault (by operation and probability) is applied.
+//
+// Example usage:
+//
+//	client.HTTPClient.Transport = &azurepushtest.FaultTransport{
+//		Base: client.HTTPClient.Transport,
+//		Faults: []azurepushtest.Fault{
+//			{Operation: azurepushtest.OpSend, Probability: 0.2, StatusCode: 429, RetryAfter: time.Second},
+//			{Probability: 0.05, Reset: true},
+//		},
+//	}
+type FaultTransport struct {
+	// Base is the transport used for requests that are not failed.
+	// Defaults to http.DefaultTransport.
+	Base   http.RoundTripper
+	Faults []Fault
+
+	// Rand returns a pseudo-random number in [0, 1).
+	// Defaults to math/rand/v2.Float64, override it for deterministic tests.
+	Rand func() float64
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *FaultTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if fault, ok := t.match(r); ok {
+		if fault.Latency > 0 {
+			select {
+			case <-time.After(fault.Latency):
+			case <-r.Context().Done():
+				closeBody(r)
+				return nil, r.Context().Err()
+			}
+		}
+
+		switch {
+		case fault.Timeout:
+			<-r.Context().Done()
+			closeBody(r)
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+		case fault.Reset:
+			closeBody(r)
+			return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+		case fault.StatusCode > 0:
+			closeBody(r)
+			return faultResponse(r, fault), nil
+		}
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return base.RoundTrip(r)
+}
+
+// closeBody closes the request body of an injected fault, as the http.RoundTripper contract requires
+// even on errors, so the streamed and pooled bodies of the client are released.
+func closeBody(r *http.Request) {
+	if r.Body != nil {
+		_ = r.Body.Close()
+	}
+}
+
+func (t *FaultTransport) match(r *http.Request) (Fault, bool) {
+	op := OperationOf(r)
+	for _, fault := range t.Faults {
+		if fault.Operation != "" && fault.Operation != op {
+			continue
+		}
+
+		if fault.Probability > 0 && fault.Probability < 1 {
+			random := t.Rand
+			if random == nil {
+				random = rand.Float64
+			}
+			if random() >= fault.Probability {
+				continue
+			}
+		}
+
+		return fault, true
+	}
+
+	return Fault{}, false
+}
+
+func faultResponse(r *http.Request, fault Fault) *http.Response {
+	header := make(http.Header)
+	if fault.RetryAfter > 0 {
+		header.Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Round(time.Second)/time.Second)))
+	}
+
+	body := "injected fault: " + http.StatusText(fault.StatusCode)
+	return &http.Response{
+		Status:        strconv.Itoa(fault.StatusCode) + " " + http.StatusText(fault.StatusCode),
+		StatusCode:    fault.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
+
+// OperationOf returns the Notification Hub operation of a request,
+// or an empty Operation if the request is not recognized.
+func OperationOf(r *http.Request) Operation {
+	switch {
+	case isBlobRequest(r):
+		return OpBlob
+	case strings.Contains(r.URL.Path, "/installations/"):
+		switch r.Method {
+		case http.MethodPut:
+			return OpRegister
+		case http.MethodGet:
+			return OpGet
+		case http.MethodPatch:
+			return OpPatch
+		case http.MethodDelete:
+			return OpDelete
+		}
+	case strings.Contains(r.URL.Path, "/registrations/") && r.Method == http.MethodDelete:
+		return OpDelete
+	case strings.Contains(r.URL.Path, "/messages") && r.Method == http.MethodPost:
+		return OpSend
+	case strings.Contains(r.URL.Path, "/jobs"):
+		return OpJob
+	}
+
+	return ""
+}
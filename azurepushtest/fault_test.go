@@ -0,0 +1,103 @@
+package azurepushtest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestFaultTransport(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	transport := &azurepushtest.FaultTransport{
+		Base: client.HTTPClient.Transport,
+		Faults: []azurepushtest.Fault{
+			{Operation: azurepushtest.OpSend, StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Second},
+			{Operation: azurepushtest.OpDelete, Reset: true},
+			{Operation: azurepushtest.OpGet, Timeout: true},
+		},
+	}
+	client.HTTPClient.Transport = transport
+
+	ctx := context.Background()
+	id, err := client.RegisterDevice(ctx, azurepush.Installation{Platform: azurepush.InstallationFCMV1, PushChannel: "fcm-token"})
+	if err != nil {
+		t.Fatalf("expected registrations to pass through, got: %v", err)
+	}
+
+	err = client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42")
+	if err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected injected 429, got: %v", err)
+	}
+
+	if err = client.DeleteDevice(ctx, id); !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("expected connection reset, got: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err = client.DeviceExists(timeoutCtx, id); err == nil {
+		t.Error("expected injected timeout")
+	}
+}
+
+func TestFaultTransport_Probability(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	random := 0.9
+	client.HTTPClient.Transport = &azurepushtest.FaultTransport{
+		Base:   client.HTTPClient.Transport,
+		Faults: []azurepushtest.Fault{{Probability: 0.5, StatusCode: http.StatusInternalServerError}},
+		Rand:   func() float64 { return random },
+	}
+
+	if err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}); err != nil {
+		t.Fatalf("expected the fault to be skipped, got: %v", err)
+	}
+
+	random = 0.1
+	if err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}); err == nil {
+		t.Fatal("expected the fault to be applied")
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestFaultTransport_ClosesBody(t *testing.T) {
+	for _, fault := range []azurepushtest.Fault{
+		{StatusCode: http.StatusServiceUnavailable},
+		{Reset: true},
+	} {
+		transport := &azurepushtest.FaultTransport{Faults: []azurepushtest.Fault{fault}}
+
+		body := &closeRecorder{Reader: strings.NewReader("{}")}
+		req, _ := http.NewRequest(http.MethodPost, "http://localhost/hub/messages/", body)
+		if resp, err := transport.RoundTrip(req); err == nil {
+			resp.Body.Close()
+		}
+
+		if !body.closed {
+			t.Errorf("expected the request body to be closed by the injected fault: %+v", fault)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package azurepushtest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+	"github.com/kataras/azurepush"
+)
+
+// RandomAPNSToken returns a structurally valid APNs device token:
+// 32 random bytes, hex encoded (64 characters).
+func RandomAPNSToken() string {
+	return hex.EncodeToString(randomBytes(32))
+}
+
+// RandomFCMToken returns a structurally valid FCM registration token:
+// a 16-byte instance ID (22 characters, as the Firebase installation IDs) and a 100-byte "APA91b" prefixed token,
+// base64url encoded and separated by a colon.
+func RandomFCMToken() string {
+	return base64.RawURLEncoding.EncodeToString(randomBytes(16)) + ":APA91b" + base64.RawURLEncoding.EncodeToString(randomBytes(100))
+}
+
+// RandomInstallation returns a valid installation for the given platform,
+// with a random installation ID and push channel and a random "user:" tag.
+// Platforms other than InstallationApple and InstallationFCMV1 get an opaque random push channel.
+func RandomInstallation(platform azurepush.InstallationPlatform) azurepush.Installation {
+	var pushChannel string
+	switch platform {
+	case azurepush.InstallationApple:
+		pushChannel = RandomAPNSToken()
+	case azurepush.InstallationFCMV1:
+		pushChannel = RandomFCMToken()
+	default:
+		pushChannel = base64.RawURLEncoding.EncodeToString(randomBytes(64))
+	}
+
+	return azurepush.Installation{
+		InstallationID: uuid.NewString(),
+		Platform:       platform,
+		PushChannel:    pushChannel,
+		Tags:           azurepush.Tags(azurepush.UserTag(uuid.NewString())),
+	}
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b) // never returns an error.
+	return b
+}
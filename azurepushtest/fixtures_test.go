@@ -0,0 +1,42 @@
+package azurepushtest_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestRandomTokens(t *testing.T) {
+	apns := azurepushtest.RandomAPNSToken()
+	if _, err := hex.DecodeString(apns); err != nil || len(apns) != 64 {
+		t.Errorf("expected a 64 characters hex APNs token, got: %s", apns)
+	}
+
+	fcm := azurepushtest.RandomFCMToken()
+	instanceID, token, ok := strings.Cut(fcm, ":")
+	if !ok || len(instanceID) != 22 || !strings.HasPrefix(token, "APA91b") {
+		t.Errorf("unexpected FCM token shape: %s", fcm)
+	}
+
+	if azurepushtest.RandomAPNSToken() == apns {
+		t.Error("expected random tokens to differ")
+	}
+}
+
+func TestRandomInstallation(t *testing.T) {
+	for _, platform := range []azurepush.InstallationPlatform{
+		azurepush.InstallationApple,
+		azurepush.InstallationFCMV1,
+		azurepush.InstallationBaidu,
+		azurepush.InstallationWNS,
+		azurepush.InstallationMPNS,
+	} {
+		installation := azurepushtest.RandomInstallation(platform)
+		if err := installation.Validate(); err != nil {
+			t.Errorf("%s: expected a valid installation, got: %v", platform, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package azurepushtest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Scenario is a script of responses for the fake Server, built step by step.
+// Each request consumes the next pending step of its operation,
+// once all steps of an operation are consumed the server behaves normally again.
+//
+// Example usage:
+//
+//	srv.Play(azurepushtest.NewScenario().
+//		On(azurepushtest.OpSend).Respond(http.StatusTooManyRequests).RetryAfter(2 * time.Second).
+//		On(azurepushtest.OpSend).Pass().
+//		On(azurepushtest.OpGet).Pass().ETag(`"stale"`))
+type Scenario struct {
+	steps []*scenarioStep
+}
+
+type scenarioStep struct {
+	op         Operation
+	statusCode int // zero passes the request through to the fake hub.
+	body       string
+	header     http.Header
+	times      int
+}
+
+// NewScenario returns a new empty Scenario.
+func NewScenario() *Scenario {
+	return new(Scenario)
+}
+
+// On adds a new step for the given operation,
+// the following calls configure this step. By default the step passes the request through once.
+func (s *Scenario) On(op Operation) *Scenario {
+	s.steps = append(s.steps, &scenarioStep{op: op, header: make(http.Header), times: 1})
+	return s
+}
+
+// Respond makes the current step respond with the given status code instead of handling the request.
+func (s *Scenario) Respond(statusCode int) *Scenario {
+	s.last().statusCode = statusCode
+	return s
+}
+
+// Body sets the response body of the current step.
+func (s *Scenario) Body(body string) *Scenario {
+	s.last().body = body
+	return s
+}
+
+// Header sets a response header of the current step.
+// Headers are also applied to passed through requests.
+func (s *Scenario) Header(key, value string) *Scenario {
+	s.last().header.Set(key, value)
+	return s
+}
+
+// RetryAfter sets the Retry-After response header (in seconds) of the current step.
+func (s *Scenario) RetryAfter(d time.Duration) *Scenario {
+	return s.Header("Retry-After", strconv.Itoa(int(d.Round(time.Second)/time.Second)))
+}
+
+// ETag sets the ETag response header of the current step, e.g. to simulate stale installation reads.
+func (s *Scenario) ETag(etag string) *Scenario {
+	return s.Header("ETag", etag)
+}
+
+// Pass makes the current step handle the request normally (it is the default).
+func (s *Scenario) Pass() *Scenario {
+	s.last().statusCode = 0
+	return s
+}
+
+// Times repeats the current step n times.
+func (s *Scenario) Times(n int) *Scenario {
+	if n < 1 {
+		n = 1
+	}
+	s.last().times = n
+	return s
+}
+
+func (s *Scenario) last() *scenarioStep {
+	if len(s.steps) == 0 {
+		panic("azurepushtest: scenario step configured before On")
+	}
+
+	return s.steps[len(s.steps)-1]
+}
+
+// Play replaces the server's pending scenario steps with the given scenario's ones.
+// Scenario steps take precedence over errors configured through SetError.
+func (s *Server) Play(scenario *Scenario) {
+	steps := make([]scenarioStep, 0, len(scenario.steps))
+	for _, step := range scenario.steps {
+		steps = append(steps, *step)
+	}
+
+	s.mu.Lock()
+	s.steps = steps
+	s.mu.Unlock()
+}
+
+// Pending returns the number of requests the current scenario still expects.
+func (s *Server) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, step := range s.steps {
+		n += step.times
+	}
+
+	return n
+}
+
+// nextStep consumes and returns the next step of the operation, if any.
+func (s *Server) nextStep(op Operation) (scenarioStep, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.steps {
+		step := &s.steps[i]
+		if step.op != op {
+			continue
+		}
+
+		current := *step
+		step.times--
+		if step.times <= 0 {
+			s.steps = append(s.steps[:i], s.steps[i+1:]...)
+		}
+
+		return current, true
+	}
+
+	return scenarioStep{}, false
+}
+
+// script applies the scenario steps before the request reaches the fake hub handlers.
+func (s *Server) script(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		step, ok := s.nextStep(OperationOf(r))
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		for key, values := range step.header {
+			w.Header()[key] = values
+		}
+
+		if step.statusCode == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.WriteHeader(step.statusCode)
+		_, _ = io.WriteString(w, step.body)
+	})
+}
@@ -0,0 +1,68 @@
+package azurepushtest_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestServer_Play(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	srv.Play(azurepushtest.NewScenario().
+		On(azurepushtest.OpSend).Respond(http.StatusTooManyRequests).RetryAfter(2 * time.Second).Body("throttled").
+		On(azurepushtest.OpGet).ETag(`"stale"`).
+		On(azurepushtest.OpDelete).Respond(http.StatusInternalServerError).Times(2))
+
+	if pending := srv.Pending(); pending != 4 {
+		t.Fatalf("expected 4 pending requests, got: %d", pending)
+	}
+
+	client := srv.NewClient()
+	ctx := context.Background()
+	notification := azurepush.Notification{Title: "Hi"}
+
+	// Sends are made per platform, the first one is throttled.
+	err := client.SendNotification(ctx, notification, "user:42")
+	if err == nil || !strings.Contains(err.Error(), "throttled") {
+		t.Fatalf("expected scripted 429, got: %v", err)
+	}
+	if err = client.SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatalf("expected second send to succeed, got: %v", err)
+	}
+
+	var etag string
+	transport := client.HTTPClient.Transport
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := transport.RoundTrip(r)
+		if err == nil && r.Method == http.MethodGet {
+			etag = resp.Header.Get("ETag")
+		}
+		return resp, err
+	})
+	if exists, err := client.DeviceExists(ctx, "missing"); err != nil || exists {
+		t.Fatalf("expected the passed through GET to report a missing device, got: %v, %v", exists, err)
+	}
+	if etag != `"stale"` {
+		t.Errorf("expected scripted ETag, got: %q", etag)
+	}
+
+	for range 2 {
+		if err = client.DeleteDevice(ctx, "id"); err == nil {
+			t.Fatal("expected scripted delete failure")
+		}
+	}
+	if err = client.DeleteDevice(ctx, "id"); err != nil {
+		t.Fatalf("expected normal behavior after the scenario, got: %v", err)
+	}
+
+	if pending := srv.Pending(); pending != 0 {
+		t.Errorf("expected the scenario to be consumed, got %d pending requests", pending)
+	}
+}
@@ -0,0 +1,955 @@
+// Package azurepushtest provides utilities for testing code that uses the azurepush package,
+// most notably a fake, in-memory Notification Hub server.
+package azurepushtest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+// Operation identifies a Notification Hub operation handled by the fake Server.
+type Operation string
+
+const (
+	// OpRegister is the create-or-replace installation operation (PUT).
+	OpRegister Operation = "register"
+	// OpGet is the read installation operation (GET).
+	OpGet Operation = "get"
+	// OpPatch is the patch installation operation (PATCH).
+	OpPatch Operation = "patch"
+	// OpDelete is the delete installation operation (DELETE).
+	OpDelete Operation = "delete"
+	// OpSend is the send notification operation (POST messages and schedulednotifications).
+	OpSend Operation = "send"
+	// OpTelemetry is the get notification telemetry operation (GET messages).
+	OpTelemetry Operation = "telemetry"
+	// OpList is the list registrations operation (GET registrations).
+	OpList Operation = "list"
+	// OpJob is the submit, get and list jobs operations.
+	OpJob Operation = "job"
+	// OpBlob is the blob storage operations (e.g. the import files of the jobs).
+	OpBlob Operation = "blob"
+)
+
+// SentNotification is a notification received by the fake Server.
+type SentNotification struct {
+	// ID is the notification ID, reported by the Location response header.
+	ID string
+	// Format is the ServiceBusNotification-Format header, e.g. "apple" or "fcmV1".
+	Format string
+	// Tags holds the targeted tags.
+	Tags []string
+	// Payload is the platform-specific JSON payload.
+	Payload json.RawMessage
+	// Header holds the request headers.
+	Header http.Header
+	// InstallationIDs holds the IDs of the registered installations matched by the tags and format.
+	InstallationIDs []string
+}
+
+type errorResponse struct {
+	statusCode int
+	body       string
+}
+
+// Server is a fake Azure Notification Hub, backed by an httptest.Server.
+// It stores installations in memory, validates SAS tokens loosely,
+// records sent notifications and can be configured to return errors per operation.
+//
+// Example usage:
+//
+//	srv := azurepushtest.NewServer()
+//	defer srv.Close()
+//
+//	client := srv.NewClient()
+//	// use client...
+//	sent := srv.Sent("user:42")
+type Server struct {
+	// URL is the base URL of the underlying httptest.Server.
+	URL string
+
+	server *httptest.Server
+
+	mu            sync.Mutex
+	installations map[string]azurepush.Installation
+	etags         map[string]string // by installation ID.
+	version       int
+	sent          []SentNotification
+	errors        map[Operation]errorResponse
+	steps         []scenarioStep
+	jobs          []azurepush.Job
+	jobPolls      int
+	pendingJobs   map[string]int               // remaining Running status requests by job ID.
+	blobs         map[string][]byte            // by container/blob path.
+	blocks        map[string]map[string][]byte // uncommitted blocks by blob path and block ID.
+	times         map[string]registrationTimes // by installation ID.
+	clock         azurepush.Clock
+	keys          []string // accepted shared access keys, any if empty.
+}
+
+// registrationTimes are the times of the registrations of an installation, reported by the list API.
+type registrationTimes struct {
+	created, updated, expiration time.Time
+}
+
+// NewServer starts and returns a new fake Notification Hub server.
+// The caller should call Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{
+		installations: make(map[string]azurepush.Installation),
+		etags:         make(map[string]string),
+		errors:        make(map[Operation]errorResponse),
+		pendingJobs:   make(map[string]int),
+		blobs:         make(map[string][]byte),
+		blocks:        make(map[string]map[string][]byte),
+		times:         make(map[string]registrationTimes),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /{hub}/installations/{id}", s.handleRegister)
+	mux.HandleFunc("GET /{hub}/installations/{id}", s.handleGet)
+	mux.HandleFunc("PATCH /{hub}/installations/{id}", s.handlePatch)
+	mux.HandleFunc("DELETE /{hub}/installations/{id}", s.handleDelete)
+	mux.HandleFunc("POST /{hub}/messages/", s.handleSend)
+	mux.HandleFunc("POST /{hub}/schedulednotifications/", s.handleSend)
+	mux.HandleFunc("GET /{hub}/messages/{id}", s.handleTelemetry)
+	mux.HandleFunc("GET /{hub}/registrations", s.handleList)
+	mux.HandleFunc("GET /{hub}/tags/{tag}/registrations", s.handleList)
+	mux.HandleFunc("DELETE /{hub}/registrations/{id}", s.handleDeleteRegistration)
+	mux.HandleFunc("POST /{hub}/jobs", s.handleSubmitJob)
+	mux.HandleFunc("GET /{hub}/jobs", s.handleListJobs)
+	mux.HandleFunc("GET /{hub}/jobs/{id}", s.handleGetJob)
+
+	hub := s.authorize(s.script(mux))
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isBlobRequest(r) {
+			s.handleBlob(w, r)
+			return
+		}
+
+		hub.ServeHTTP(w, r)
+	}))
+	s.URL = s.server.URL
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Configuration returns a valid configuration with dummy credentials.
+func (s *Server) Configuration() azurepush.Configuration {
+	return azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: "Endpoint=sb://azurepushtest.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=c2VjcmV0",
+		TokenValidity:    time.Hour,
+	}
+}
+
+// HTTPClient returns an HTTP client which routes all requests to the fake server,
+// whatever their host is.
+func (s *Server) HTTPClient() *http.Client {
+	target, _ := url.Parse(s.URL)
+	return &http.Client{
+		Transport: &rewriteTransport{target: target, base: s.server.Client().Transport},
+	}
+}
+
+// NewClient returns a new azurepush Client configured to talk to the fake server.
+func (s *Server) NewClient() *azurepush.Client {
+	client := azurepush.NewClient(s.Configuration())
+	client.HTTPClient = s.HTTPClient()
+	return client
+}
+
+// SetError makes every request of the given operation fail with the given status code and body,
+// until ClearErrors is called.
+func (s *Server) SetError(op Operation, statusCode int, body string) {
+	s.mu.Lock()
+	s.errors[op] = errorResponse{statusCode: statusCode, body: body}
+	s.mu.Unlock()
+}
+
+// ClearErrors removes all errors configured by SetError.
+func (s *Server) ClearErrors() {
+	s.mu.Lock()
+	clear(s.errors)
+	s.mu.Unlock()
+}
+
+// SetClock sets the clock of the creation and update times of the registrations (see the list API),
+// defaults to the system clock.
+func (s *Server) SetClock(clock azurepush.Clock) {
+	s.mu.Lock()
+	s.clock = clock
+	s.mu.Unlock()
+}
+
+// SetExpiration sets the expiration time of the registrations of an installation,
+// e.g. to test the cleanup of expired channels. It reports false if the installation does not exist.
+func (s *Server) SetExpiration(id string, expiration time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	times, ok := s.times[id]
+	if ok {
+		times.expiration = expiration
+		s.times[id] = times
+	}
+	return ok
+}
+
+// Installation returns a stored installation by its ID.
+func (s *Server) Installation(id string) (azurepush.Installation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	installation, ok := s.installations[id]
+	return installation, ok
+}
+
+// Installations returns all stored installations, sorted by their ID.
+func (s *Server) Installations() []azurepush.Installation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	installations := make([]azurepush.Installation, 0, len(s.installations))
+	for _, installation := range s.installations {
+		installations = append(installations, installation)
+	}
+	slices.SortFunc(installations, func(a, b azurepush.Installation) int {
+		return strings.Compare(a.InstallationID, b.InstallationID)
+	})
+
+	return installations
+}
+
+// AllSent returns all notifications received by the server, in order.
+func (s *Server) AllSent() []SentNotification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.sent)
+}
+
+// Sent returns the notifications which targeted the given tag, in order.
+func (s *Server) Sent(tag string) []SentNotification {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sent []SentNotification
+	for _, n := range s.sent {
+		if slices.Contains(n.Tags, tag) {
+			sent = append(sent, n)
+		}
+	}
+
+	return sent
+}
+
+// Jobs returns the submitted jobs, in order.
+func (s *Server) Jobs() []azurepush.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.jobs)
+}
+
+// Reset removes all installations, sent notifications, jobs, blobs, configured errors and scenario steps.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	clear(s.installations)
+	clear(s.etags)
+	s.sent = nil
+	s.jobs = nil
+	s.jobPolls = 0
+	clear(s.pendingJobs)
+	clear(s.blobs)
+	clear(s.blocks)
+	clear(s.times)
+	clear(s.errors)
+	s.steps = nil
+	s.mu.Unlock()
+}
+
+// SetKeys sets the shared access keys accepted by the server (as the Azure Portal shows them),
+// e.g. to test a key rotation: the signatures of the SAS tokens are verified against them.
+// Without keys (the default) the tokens are validated loosely, see authorize.
+func (s *Server) SetKeys(keys ...string) {
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+}
+
+// authorize validates the SAS token loosely: its shape and expiry,
+// and its signature only if keys are set (see SetKeys).
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values, ok := validSASToken(r.Header.Get("Authorization"))
+		if !ok {
+			http.Error(w, "InvalidToken: the SAS token is missing, malformed or expired", http.StatusUnauthorized)
+			return
+		}
+
+		s.mu.Lock()
+		keys := s.keys
+		s.mu.Unlock()
+
+		if len(keys) > 0 && !slices.ContainsFunc(keys, func(key string) bool { return validSignature(values, key) }) {
+			http.Error(w, "InvalidSignature: the token has an invalid signature", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func validSASToken(token string) (url.Values, bool) {
+	params, ok := strings.CutPrefix(token, "SharedAccessSignature ")
+	if !ok {
+		return nil, false
+	}
+
+	values, err := url.ParseQuery(params)
+	if err != nil {
+		return nil, false
+	}
+
+	for _, key := range []string{"sr", "sig", "se", "skn"} {
+		if values.Get(key) == "" {
+			return nil, false
+		}
+	}
+
+	expiry, err := strconv.ParseInt(values.Get("se"), 10, 64)
+	return values, err == nil && time.Now().Unix() < expiry
+}
+
+// validSignature reports whether the signature of the token's values is signed by the key.
+func validSignature(values url.Values, key string) bool {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(url.QueryEscape(values.Get("sr")) + "\n" + values.Get("se")))
+	return hmac.Equal([]byte(base64.StdEncoding.EncodeToString(h.Sum(nil))), []byte(values.Get("sig")))
+}
+
+// failed writes the configured error of the operation, if any.
+func (s *Server) failed(w http.ResponseWriter, op Operation) bool {
+	s.mu.Lock()
+	errResp, ok := s.errors[op]
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	w.WriteHeader(errResp.statusCode)
+	_, _ = io.WriteString(w, errResp.body)
+	return true
+}
+
+// store creates or replaces an installation and returns its new ETag, the caller must hold the lock.
+func (s *Server) store(installation azurepush.Installation) string {
+	now := time.Now()
+	if s.clock != nil {
+		now = s.clock.Now()
+	}
+
+	times, ok := s.times[installation.InstallationID]
+	if !ok {
+		times.created = now
+	}
+	times.updated = now
+
+	s.version++
+	etag := strconv.Quote(strconv.Itoa(s.version))
+	s.installations[installation.InstallationID] = installation
+	s.etags[installation.InstallationID] = etag
+	s.times[installation.InstallationID] = times
+	return etag
+}
+
+// remove deletes an installation, the caller must hold the lock.
+func (s *Server) remove(id string) {
+	delete(s.installations, id)
+	delete(s.etags, id)
+	delete(s.times, id)
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpRegister) {
+		return
+	}
+
+	var installation azurepush.Installation
+	if err := json.NewDecoder(r.Body).Decode(&installation); err != nil {
+		http.Error(w, "invalid installation: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	installation.InstallationID = r.PathValue("id")
+	if err := installation.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	// Optimistic concurrency: If-Match must match the current ETag of the installation.
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != "*" && ifMatch != s.etags[installation.InstallationID] {
+		s.mu.Unlock()
+		http.Error(w, "precondition failed", http.StatusPreconditionFailed)
+		return
+	}
+
+	etag := s.store(installation)
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpGet) {
+		return
+	}
+
+	s.mu.Lock()
+	installation, ok := s.installations[r.PathValue("id")]
+	etag := s.etags[r.PathValue("id")]
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "installation not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(installation)
+}
+
+// handlePatch applies the JSON Patch operations of the tags and the push channel of an installation.
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpPatch) {
+		return
+	}
+
+	var ops []azurepush.PatchOperation
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		http.Error(w, "invalid patch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	installation, ok := s.installations[id]
+	if !ok {
+		http.Error(w, "installation not found", http.StatusNotFound)
+		return
+	}
+	installation.Tags = slices.Clone(installation.Tags)
+
+	for _, op := range ops {
+		switch {
+		case op.Op == azurepush.PatchAdd && op.Path == "/tags":
+			if !slices.Contains(installation.Tags, op.Value) {
+				installation.Tags = append(installation.Tags, op.Value)
+			}
+		case op.Op == azurepush.PatchRemove && strings.HasPrefix(op.Path, "/tags/"):
+			tag := strings.NewReplacer("~1", "/", "~0", "~").Replace(strings.TrimPrefix(op.Path, "/tags/"))
+			installation.Tags = slices.DeleteFunc(installation.Tags, func(t string) bool { return t == tag })
+		case op.Op == azurepush.PatchReplace && op.Path == "/pushChannel":
+			installation.PushChannel = op.Value
+		default:
+			http.Error(w, "unsupported patch operation: "+op.Op+" "+op.Path, http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.store(installation)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpDelete) {
+		return
+	}
+
+	s.mu.Lock()
+	s.remove(r.PathValue("id"))
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleDeleteRegistration deletes a registration, the registration ID of an installation is its ID.
+func (s *Server) handleDeleteRegistration(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpDelete) {
+		return
+	}
+
+	s.mu.Lock()
+	_, ok := s.installations[r.PathValue("id")]
+	s.remove(r.PathValue("id"))
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "registration not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpSend) {
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil || !json.Valid(payload) {
+		http.Error(w, "invalid notification payload", http.StatusBadRequest)
+		return
+	}
+
+	format := r.Header.Get("ServiceBusNotification-Format")
+	tags := parseTags(r.Header.Get("ServiceBusNotification-Tags"))
+
+	s.mu.Lock()
+	var ids []string
+	for id, installation := range s.installations {
+		if matchesFormat(installation.Platform, format) && matchesTags(installation, tags) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+
+	notificationID := strconv.Itoa(len(s.sent) + 1)
+	s.sent = append(s.sent, SentNotification{
+		ID:              notificationID,
+		Format:          format,
+		Tags:            tags,
+		Payload:         payload,
+		Header:          r.Header.Clone(),
+		InstallationIDs: ids,
+	})
+	s.mu.Unlock()
+
+	resource := "messages"
+	if strings.Contains(r.URL.Path, "/schedulednotifications/") {
+		resource = "schedulednotifications"
+	}
+	w.Header().Set("Location", "https://"+r.Host+"/"+r.PathValue("hub")+"/"+resource+"/"+notificationID+"?api-version=2020-06")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// outcomeCountsElements are the telemetry elements of the outcome counts per notification format.
+var outcomeCountsElements = map[string]string{
+	"apple":        "ApnsOutcomeCounts",
+	"fcmV1":        "FcmV1OutcomeCounts",
+	"baidu":        "BaiduOutcomeCounts",
+	"windows":      "WnsOutcomeCounts",
+	"windowsphone": "MpnsOutcomeCounts",
+	"adm":          "AdmOutcomeCounts",
+}
+
+// handleTelemetry reports every matched installation of a sent notification as a successful delivery.
+func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpTelemetry) {
+		return
+	}
+
+	s.mu.Lock()
+	index := slices.IndexFunc(s.sent, func(n SentNotification) bool { return n.ID == r.PathValue("id") })
+	var n SentNotification
+	if index >= 0 {
+		n = s.sent[index]
+	}
+	s.mu.Unlock()
+
+	if index < 0 {
+		http.Error(w, "notification not found", http.StatusNotFound)
+		return
+	}
+
+	state := azurepush.NotificationStateCompleted
+	if len(n.InstallationIDs) == 0 {
+		state = azurepush.NotificationStateNoTargetFound
+	}
+
+	var b strings.Builder
+	b.WriteString(`<NotificationDetails xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">`)
+	b.WriteString("<NotificationId>")
+	_ = xml.EscapeText(&b, []byte(n.ID))
+	b.WriteString("</NotificationId><State>" + state + "</State>")
+	b.WriteString("<TargetPlatforms>" + n.Format + "</TargetPlatforms>")
+	if element, ok := outcomeCountsElements[n.Format]; ok && len(n.InstallationIDs) > 0 {
+		b.WriteString("<" + element + "><Outcome><Name>Success</Name><Count>" + strconv.Itoa(len(n.InstallationIDs)) + "</Count></Outcome></" + element + ">")
+	}
+	b.WriteString("</NotificationDetails>")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = io.WriteString(w, b.String())
+}
+
+// handleList lists the registrations backing the installations (one per installation and template),
+// as an Atom feed paginated by the $top and ContinuationToken query parameters.
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpList) {
+		return
+	}
+
+	tag := r.PathValue("tag")
+
+	s.mu.Lock()
+	var entries []string
+	for _, id := range slices.Sorted(maps.Keys(s.installations)) {
+		installation, times := s.installations[id], s.times[id]
+		if tag != "" && !slices.Contains(installation.Tags, tag) {
+			continue
+		}
+
+		entries = append(entries, registrationEntry(installation, "", times))
+		for _, name := range slices.Sorted(maps.Keys(installation.Templates)) {
+			entries = append(entries, registrationEntry(installation, name, times))
+		}
+	}
+	s.mu.Unlock()
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("ContinuationToken"))
+	top, err := strconv.Atoi(r.URL.Query().Get("$top"))
+	if err != nil || top <= 0 {
+		top = 100
+	}
+	offset = min(offset, len(entries))
+	end := min(offset+top, len(entries))
+
+	if end < len(entries) {
+		w.Header().Set("X-MS-ContinuationToken", strconv.Itoa(end))
+	}
+	w.Header().Set("Content-Type", "application/atom+xml;type=feed;charset=utf-8")
+	_, _ = io.WriteString(w, `<feed xmlns="http://www.w3.org/2005/Atom">`+strings.Join(entries[offset:end], "")+`</feed>`)
+}
+
+// registrationEntry returns the Atom entry of a registration of an installation, of a template if not empty.
+func registrationEntry(installation azurepush.Installation, template string, times registrationTimes) string {
+	id, kind := installation.InstallationID, ""
+	if template != "" {
+		id, kind = id+"-"+template, "Template"
+	}
+
+	return `<entry><published>` + times.created.UTC().Format(time.RFC3339) + `</published><updated>` + times.updated.UTC().Format(time.RFC3339) + `</updated>` +
+		`<content type="application/xml">` + registrationDescription(id, installation, kind, times.expiration) + `</content></entry>`
+}
+
+// registrationDescription returns the XML registration description of an installation, e.g. <AppleRegistrationDescription>.
+// Its tags include the $InstallationId tag of the installation, like the hub reports them.
+func registrationDescription(id string, installation azurepush.Installation, kind string, expiration time.Time) string {
+	var name, channel string
+	switch installation.Platform {
+	case azurepush.InstallationApple:
+		name, channel = "Apple", "DeviceToken"
+	case azurepush.InstallationFCMV1:
+		name, channel = "FcmV1", "FcmV1RegistrationId"
+	case azurepush.InstallationBaidu:
+		name, channel = "Baidu", "BaiduChannelId"
+	case azurepush.InstallationWNS:
+		name, channel = "Windows", "ChannelUri"
+	case azurepush.InstallationMPNS:
+		name, channel = "Mpns", "ChannelUri"
+	case azurepush.InstallationADM:
+		name, channel = "Adm", "AdmRegistrationId"
+	}
+	name += kind + "RegistrationDescription"
+	tags := append(slices.Clone(installation.Tags), string(azurepush.InstallationTag(installation.InstallationID)))
+
+	var b strings.Builder
+	b.WriteString(`<` + name + ` xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><RegistrationId>`)
+	_ = xml.EscapeText(&b, []byte(id))
+	b.WriteString(`</RegistrationId><Tags>`)
+	_ = xml.EscapeText(&b, []byte(strings.Join(tags, ",")))
+	b.WriteString(`</Tags>`)
+	if !expiration.IsZero() {
+		b.WriteString(`<ExpirationTime>` + expiration.UTC().Format(time.RFC3339) + `</ExpirationTime>`)
+	}
+	b.WriteString(`<` + channel + `>`)
+	_ = xml.EscapeText(&b, []byte(installation.PushChannel))
+	b.WriteString(`</` + channel + `></` + name + `>`)
+	return b.String()
+}
+
+// handleSubmitJob stores a submitted job. The jobs complete immediately, without accessing their blobs:
+// their output properties hold the paths of the output files (and of the failed lines of the imports).
+func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpJob) {
+		return
+	}
+
+	var entry struct {
+		Job struct {
+			Type               azurepush.JobType `xml:"Type"`
+			OutputContainerURI string            `xml:"OutputContainerUri"`
+			ImportFileURI      string            `xml:"ImportFileUri"`
+		} `xml:"content>NotificationHubJob"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&entry); err != nil || entry.Job.Type == "" || entry.Job.OutputContainerURI == "" {
+		http.Error(w, "invalid job", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	now := time.Now().UTC().Truncate(time.Second)
+	job := azurepush.Job{
+		JobID:              strconv.Itoa(len(s.jobs) + 1),
+		Type:               entry.Job.Type,
+		OutputContainerURI: entry.Job.OutputContainerURI,
+		ImportFileURI:      entry.Job.ImportFileURI,
+		Status:             azurepush.JobStatusCompleted,
+		Progress:           100,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	container, _, _ := strings.Cut(job.OutputContainerURI, "?")
+	job.OutputProperties = map[string]string{azurepush.JobOutputFilePath: container + "/" + job.JobID + "/Output.txt"}
+	if job.Type.Import() {
+		job.OutputProperties[azurepush.JobFailedFilePath] = container + "/" + job.JobID + "/Failed.txt"
+		s.runImport(&job)
+	} else {
+		s.runExport(&job)
+	}
+	s.jobs = append(s.jobs, job)
+	if s.jobPolls > 0 {
+		s.pendingJobs[job.JobID] = s.jobPolls
+		job.Status, job.Progress = azurepush.JobStatusStarted, 0
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/atom+xml;type=entry;charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = io.WriteString(w, jobEntry(job))
+}
+
+// runImport applies the import file of an installations import job, the caller must hold the lock.
+// The processed lines are written to its output file and the failed ones to its failed file.
+// The registrations import jobs are completed without changes.
+func (s *Server) runImport(job *azurepush.Job) {
+	if !strings.HasSuffix(string(job.Type), "Installations") {
+		return
+	}
+
+	data, ok := s.blobs[blobPath(job.ImportFileURI)]
+	if !ok {
+		job.Status, job.Failure = azurepush.JobStatusFailed, "import file not found"
+		return
+	}
+
+	var output, failed strings.Builder
+	for line := range strings.Lines(string(data)) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var installation azurepush.Installation
+		if err := json.Unmarshal([]byte(line), &installation); err != nil || installation.InstallationID == "" {
+			failed.WriteString(line)
+			continue
+		}
+
+		_, exists := s.installations[installation.InstallationID]
+		switch {
+		case job.Type == azurepush.JobImportDeleteInstallations:
+			s.remove(installation.InstallationID)
+		case job.Type == azurepush.JobImportCreateInstallations && exists,
+			job.Type == azurepush.JobImportUpdateInstallations && !exists,
+			installation.Validate() != nil:
+			failed.WriteString(line)
+			continue
+		default:
+			s.store(installation)
+		}
+		output.WriteString(line)
+	}
+
+	s.blobs[blobPath(job.OutputProperties[azurepush.JobOutputFilePath])] = []byte(output.String())
+	s.blobs[blobPath(job.OutputProperties[azurepush.JobFailedFilePath])] = []byte(failed.String())
+}
+
+// runExport writes the output file of an export job, the caller must hold the lock:
+// the installations as JSON lines, or their registrations as XML registration descriptions.
+func (s *Server) runExport(job *azurepush.Job) {
+	var output strings.Builder
+	for _, id := range slices.Sorted(maps.Keys(s.installations)) {
+		installation := s.installations[id]
+		switch job.Type {
+		case azurepush.JobExportInstallations:
+			b, _ := json.Marshal(installation)
+			output.Write(b)
+		default:
+			output.WriteString(registrationDescription(installation.InstallationID, installation, "", s.times[id].expiration))
+		}
+		output.WriteByte('\n')
+	}
+
+	s.blobs[blobPath(job.OutputProperties[azurepush.JobOutputFilePath])] = []byte(output.String())
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpJob) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, job := range s.jobs {
+		if job.JobID == r.PathValue("id") {
+			if remaining := s.pendingJobs[job.JobID]; remaining > 0 {
+				s.pendingJobs[job.JobID]--
+				job = s.runningJob(job, remaining)
+			}
+
+			w.Header().Set("Content-Type", "application/atom+xml;type=entry;charset=utf-8")
+			_, _ = io.WriteString(w, jobEntry(job))
+			return
+		}
+	}
+
+	http.Error(w, "job not found", http.StatusNotFound)
+}
+
+// SetJobPolls makes the jobs submitted afterwards report the Running status, with an increasing progress,
+// to their first n status requests (GetJob) before their final status. Zero completes them immediately.
+func (s *Server) SetJobPolls(n int) {
+	s.mu.Lock()
+	s.jobPolls = n
+	s.mu.Unlock()
+}
+
+// runningJob returns a job as running, with the given remaining status requests, the caller must hold the lock.
+func (s *Server) runningJob(job azurepush.Job, remaining int) azurepush.Job {
+	job.Status = azurepush.JobStatusRunning
+	job.Progress = float64(100*(s.jobPolls-remaining+1)) / float64(s.jobPolls+1)
+	job.Failure, job.OutputProperties = "", nil
+	return job
+}
+
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	if s.failed(w, OpJob) {
+		return
+	}
+
+	s.mu.Lock()
+	var entries []string
+	for _, job := range s.jobs {
+		if remaining := s.pendingJobs[job.JobID]; remaining > 0 {
+			job = s.runningJob(job, remaining)
+		}
+		entries = append(entries, jobEntry(job))
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/atom+xml;type=feed;charset=utf-8")
+	_, _ = io.WriteString(w, `<feed xmlns="http://www.w3.org/2005/Atom">`+strings.Join(entries, "")+`</feed>`)
+}
+
+func jobEntry(job azurepush.Job) string {
+	var b strings.Builder
+	element := func(name, value string) {
+		if value == "" {
+			return
+		}
+		b.WriteString("<" + name + ">")
+		_ = xml.EscapeText(&b, []byte(value))
+		b.WriteString("</" + name + ">")
+	}
+
+	b.WriteString(`<entry xmlns="http://www.w3.org/2005/Atom"><content type="application/xml">` +
+		`<NotificationHubJob xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect">`)
+	element("JobId", job.JobID)
+	element("Progress", strconv.FormatFloat(job.Progress, 'f', 2, 64))
+	element("Type", string(job.Type))
+	element("Status", string(job.Status))
+	element("OutputContainerUri", job.OutputContainerURI)
+	element("ImportFileUri", job.ImportFileURI)
+	element("Failure", job.Failure)
+	b.WriteString(`<OutputProperties xmlns:d3p1="http://schemas.microsoft.com/2003/10/Serialization/Arrays">`)
+	for _, key := range slices.Sorted(maps.Keys(job.OutputProperties)) {
+		b.WriteString("<d3p1:KeyValueOfstringstring>")
+		element("d3p1:Key", key)
+		element("d3p1:Value", job.OutputProperties[key])
+		b.WriteString("</d3p1:KeyValueOfstringstring>")
+	}
+	b.WriteString(`</OutputProperties>`)
+	element("CreatedAt", job.CreatedAt.Format(time.RFC3339))
+	element("UpdatedAt", job.UpdatedAt.Format(time.RFC3339))
+	b.WriteString(`</NotificationHubJob></content></entry>`)
+	return b.String()
+}
+
+// parseTags splits a tag header, tags are separated by commas or "||" (OR expressions).
+func parseTags(header string) []string {
+	var tags []string
+	for field := range strings.SplitSeq(strings.ReplaceAll(header, "||", ","), ",") {
+		if tag := strings.TrimSpace(field); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func matchesTags(installation azurepush.Installation, targets []string) bool {
+	if len(targets) == 0 {
+		return true // broadcast.
+	}
+
+	for _, target := range targets {
+		// The built-in $InstallationId:{id} tag targets a single installation.
+		if slices.Contains(installation.Tags, target) || target == azurepush.InstallationTag(installation.InstallationID).String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesFormat(platform azurepush.InstallationPlatform, format string) bool {
+	return platform.Format() == azurepush.Platform(format)
+}
+
+// rewriteTransport sends all requests to the target server,
+// the original Host header is kept.
+type rewriteTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+func (t *rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	if r.Host == "" {
+		r.Host = r.URL.Host
+	}
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+
+	return t.base.RoundTrip(r)
+}
@@ -0,0 +1,94 @@
+package azurepushtest_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestServer(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	id, err := client.RegisterDevice(ctx, azurepush.Installation{
+		Platform:    azurepush.InstallationApple,
+		PushChannel: "apns-token",
+		Tags:        []string{"user:42"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected registration error: %v", err)
+	}
+
+	if _, ok := srv.Installation(id); !ok {
+		t.Fatalf("expected installation %s to be stored", id)
+	}
+
+	exists, err := client.DeviceExists(ctx, id)
+	if err != nil || !exists {
+		t.Fatalf("expected device to exist, got: %v, %v", exists, err)
+	}
+
+	if err = client.SendNotification(ctx, azurepush.Notification{Title: "Hi", Body: "Hello"}, "user:42"); err != nil {
+		t.Fatalf("unexpected send error: %v", err)
+	}
+
+	sent := srv.Sent("user:42")
+	if len(sent) != 2 {
+		t.Fatalf("expected one notification per platform, got: %d", len(sent))
+	}
+	if sent[0].Format != "apple" || len(sent[0].InstallationIDs) != 1 || sent[0].InstallationIDs[0] != id {
+		t.Errorf("expected the apple send to match the installation, got: %+v", sent[0])
+	}
+	if !strings.Contains(string(sent[0].Payload), `"aps"`) {
+		t.Errorf("expected an APNs payload, got: %s", sent[0].Payload)
+	}
+
+	if err = client.DeleteDevice(ctx, id); err != nil {
+		t.Fatalf("unexpected delete error: %v", err)
+	}
+	if len(srv.Installations()) != 0 {
+		t.Error("expected installation to be deleted")
+	}
+}
+
+func TestServer_SetError(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	srv.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "outage")
+
+	err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}, "user:42")
+	if err == nil || !strings.Contains(err.Error(), "outage") {
+		t.Fatalf("expected configured error, got: %v", err)
+	}
+
+	srv.ClearErrors()
+	if err = client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatalf("unexpected error after clearing errors: %v", err)
+	}
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, "https://azurepushtest.servicebus.windows.net/hub/installations/id", nil)
+	req.Header.Set("Authorization", "Bearer nope")
+	resp, err := srv.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an invalid SAS token, got: %d", resp.StatusCode)
+	}
+}
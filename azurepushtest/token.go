@@ -0,0 +1,22 @@
+package azurepushtest
+
+import "github.com/kataras/azurepush"
+
+// StaticTokenProvider returns a token provider which always returns the given token.
+//
+// Example usage:
+//
+//	client.TokenProvider = azurepushtest.StaticTokenProvider("SharedAccessSignature sr=...")
+func StaticTokenProvider(token string) azurepush.TokenProvider {
+	return azurepush.TokenProviderFunc(func() (string, error) {
+		return token, nil
+	})
+}
+
+// ErrorTokenProvider returns a token provider which always fails with the given error,
+// to exercise token failure paths.
+func ErrorTokenProvider(err error) azurepush.TokenProvider {
+	return azurepush.TokenProviderFunc(func() (string, error) {
+		return "", err
+	})
+}
@@ -0,0 +1,59 @@
+package azurepushtest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestStaticTokenProvider(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	client.TokenProvider = azurepushtest.StaticTokenProvider("not-a-sas-token")
+
+	// The fake server rejects malformed tokens.
+	err := client.ValidateToken(context.Background())
+	if err == nil {
+		t.Fatal("expected unauthorized error")
+	}
+
+	var authorization string
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		authorization = r.Header.Get("Authorization")
+		return nil, errors.New("stop")
+	})
+	_ = client.ValidateToken(context.Background())
+	if authorization != "not-a-sas-token" {
+		t.Errorf("expected the static token to be sent, got: %s", authorization)
+	}
+}
+
+func TestErrorTokenProvider(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	expectedErr := errors.New("key vault unavailable")
+	client := srv.NewClient()
+	client.TokenProvider = azurepushtest.ErrorTokenProvider(expectedErr)
+
+	_, err := client.RegisterDevice(context.Background(), azurepushtest.RandomInstallation(azurepush.InstallationApple))
+	if !errors.Is(err, expectedErr) {
+		t.Fatalf("expected token error, got: %v", err)
+	}
+
+	if len(srv.Installations()) != 0 {
+		t.Error("expected no request to reach the hub")
+	}
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,167 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// blobBlockSize is the size of the blocks of UploadBlob.
+const blobBlockSize = 4 << 20 // 4 MiB.
+
+// blobAPIVersion is the x-ms-version of the blob requests.
+const blobAPIVersion = "2021-08-06"
+
+// BlobURI returns the URI of a blob of the container of a SAS URI, with the SAS query of the container,
+// e.g. the import file of a job or an output file of a job (see Job.OutputProperties).
+// The blob name may be a path relative to the container or an absolute URI of a blob of the same account.
+//
+// Example usage:
+//
+//	uri, err := azurepush.BlobURI("https://account.blob.core.windows.net/push?sv=...&sig=...", "devices.jsonl")
+//	// https://account.blob.core.windows.net/push/devices.jsonl?sv=...&sig=...
+func BlobURI(containerSASURI, blobName string) (string, error) {
+	container, err := neturl.Parse(containerSASURI)
+	if err != nil || container.Scheme == "" || container.Host == "" {
+		return "", fmt.Errorf("invalid container SAS URI")
+	}
+
+	if blobName == "" {
+		return "", fmt.Errorf("blob name cannot be empty")
+	}
+
+	blob := *container
+	if u, err := neturl.Parse(blobName); err == nil && u.IsAbs() {
+		if u.Host != container.Host {
+			return "", fmt.Errorf("blob of another storage account: %s", u.Host)
+		}
+		blob.Path, blob.RawPath = u.Path, u.RawPath
+	} else {
+		blob.Path = strings.TrimSuffix(container.Path, "/") + "/" + strings.TrimPrefix(blobName, "/")
+		blob.RawPath = ""
+	}
+
+	return blob.String(), nil
+}
+
+// UploadBlob uploads the contents of r as a block blob to the container of a SAS URI
+// (with create and write permissions) and returns the blob's SAS URI, see BlobURI.
+// The contents are streamed in blocks of 4 MiB, so large files (e.g. the import file of millions of devices)
+// are not loaded into memory. The requests are sent with the client's HTTPClient.
+//
+// Example usage:
+//
+//	uri, err := client.UploadBlob(ctx, containerSASURI, "devices.jsonl", file)
+func (c *Client) UploadBlob(ctx context.Context, containerSASURI, blobName string, r io.Reader) (string, error) {
+	blobURI, err := BlobURI(containerSASURI, blobName)
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		blockIDs []string
+		block    = make([]byte, blobBlockSize)
+	)
+	for {
+		n, err := io.ReadFull(r, block)
+		if n > 0 {
+			blockID := base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "block-%08d", len(blockIDs)))
+			query := neturl.Values{"comp": {"block"}, "blockid": {blockID}}
+			if err := c.doBlobRequest(ctx, http.MethodPut, withQuery(blobURI, query), block[:n], nil); err != nil {
+				return "", fmt.Errorf("failed to upload block %d of blob: %s: %w", len(blockIDs), blobName, err)
+			}
+			blockIDs = append(blockIDs, blockID)
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read blob contents: %w", err)
+		}
+	}
+
+	blockList, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"BlockList"`
+		Latest  []string `xml:"Latest"`
+	}{Latest: blockIDs})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode block list: %w", err)
+	}
+
+	body := append([]byte(xml.Header), blockList...)
+	if err = c.doBlobRequest(ctx, http.MethodPut, withQuery(blobURI, neturl.Values{"comp": {"blocklist"}}), body, nil); err != nil {
+		return "", fmt.Errorf("failed to commit blob: %s: %w", blobName, err)
+	}
+
+	return blobURI, nil
+}
+
+// OpenBlob opens a blob of a SAS URI (with read permissions) for reading, e.g. an output file of a job.
+// The caller must close the returned reader.
+func (c *Client) OpenBlob(ctx context.Context, blobSASURI string) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	if err := c.doBlobRequest(ctx, http.MethodGet, blobSASURI, nil, &body); err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+
+	return body, nil
+}
+
+// doBlobRequest sends a request to the blob storage, the response body is set to body (if not nil) or closed.
+func (c *Client) doBlobRequest(ctx context.Context, method, uri string, data []byte, body *io.ReadCloser) error {
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-ms-version", blobAPIVersion)
+
+	if mutating(req) && c.dryRun(ctx) {
+		return nil // the data is built but not uploaded, see Configuration.DryRun.
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return fmt.Errorf("%s: %s", resp.Status, string(b))
+	}
+
+	if body != nil {
+		*body = resp.Body
+		return nil
+	}
+
+	return resp.Body.Close()
+}
+
+// withQuery returns the URI with the given query parameters added.
+func withQuery(uri string, query neturl.Values) string {
+	u, err := neturl.Parse(uri)
+	if err != nil {
+		return uri
+	}
+
+	q := u.Query()
+	for key, values := range query {
+		q[key] = values
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
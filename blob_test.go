@@ -0,0 +1,73 @@
+package azurepush_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestBlobURI(t *testing.T) {
+	const container = "https://account.blob.core.windows.net/push?sv=2021-08-06&sig=abc%3D"
+
+	tests := []struct {
+		blobName string
+		expected string
+	}{
+		{"devices.jsonl", "https://account.blob.core.windows.net/push/devices.jsonl?sv=2021-08-06&sig=abc%3D"},
+		{"/1/Failed.txt", "https://account.blob.core.windows.net/push/1/Failed.txt?sv=2021-08-06&sig=abc%3D"},
+		{"https://account.blob.core.windows.net/push/1/Output.txt", "https://account.blob.core.windows.net/push/1/Output.txt?sv=2021-08-06&sig=abc%3D"},
+	}
+	for _, tt := range tests {
+		uri, err := azurepush.BlobURI(container, tt.blobName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uri != tt.expected {
+			t.Errorf("%s: expected: %s, got: %s", tt.blobName, tt.expected, uri)
+		}
+	}
+
+	if _, err := azurepush.BlobURI(container, "https://other.blob.core.windows.net/push/x"); err == nil {
+		t.Error("expected a blob of another account error")
+	}
+	if _, err := azurepush.BlobURI("push", "x"); err == nil {
+		t.Error("expected an invalid container error")
+	}
+}
+
+func TestClient_UploadBlob(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	// Larger than a block, so it is uploaded in multiple blocks.
+	data := bytes.Repeat([]byte("0123456789"), 1<<20)
+	uri, err := client.UploadBlob(ctx, "https://account.blob.core.windows.net/push?sig=abc", "large.bin", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored, ok := srv.Blob("push/large.bin"); !ok || !bytes.Equal(stored, data) {
+		t.Fatalf("unexpected stored blob of %d bytes", len(stored))
+	}
+
+	r, err := client.OpenBlob(ctx, uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if read, _ := io.ReadAll(r); !bytes.Equal(read, data) {
+		t.Errorf("unexpected read blob of %d bytes", len(read))
+	}
+
+	if _, err = client.OpenBlob(ctx, "https://account.blob.core.windows.net/push/missing?sig=abc"); err == nil {
+		t.Error("expected a blob not found error")
+	}
+}
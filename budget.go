@@ -0,0 +1,222 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OperationClass is a class of hub operations with its own OperationBudget, see Configuration.Budgets.
+type OperationClass string
+
+// Operation classes.
+const (
+	// OperationSend is the class of the notification sends.
+	OperationSend OperationClass = "send"
+	// OperationRegistration is the class of the changes of installations and registrations:
+	// create, replace, patch and delete.
+	OperationRegistration OperationClass = "registration"
+	// OperationQuery is the class of the reads of installations and registrations, e.g. DeviceExists and the listings.
+	OperationQuery OperationClass = "query"
+	// OperationJob is the class of the import and export job requests.
+	OperationJob OperationClass = "job"
+)
+
+// operationClassOf returns the operation class of a hub request by its resource and method.
+// The resource is the path segment after the hub's name, so a hub named e.g. "messages-prod" is not a send.
+func operationClassOf(r *http.Request) OperationClass {
+	_, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/") // skip the hub's name.
+	resource, _, _ = strings.Cut(resource, "/")
+
+	switch {
+	case resource == "messages" || resource == "schedulednotifications":
+		return OperationSend
+	case resource == "jobs":
+		return OperationJob
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return OperationQuery
+	default:
+		return OperationRegistration
+	}
+}
+
+// OperationBudget is the retry and timeout budget of the hub requests of an operation class.
+// Its zero value sends each request once, bound only by the caller's context and the HTTPClient's timeout.
+//
+// Example configuration:
+//
+//	Budgets:
+//	  Send:
+//	    MaxRetries: 3
+//	    Timeout: 5s
+//	  Registration:
+//	    MaxRetries: 5
+//	    Timeout: 30s
+type OperationBudget struct {
+	// MaxRetries is the maximum number of retries of a request which failed with a network error,
+	// a 429 Too Many Requests or a 5xx response. The retries back off exponentially from 200ms (up to 5s),
+	// or wait as long as the Retry-After header of the response.
+	// Note that a retried send may be delivered twice if its first attempt reached the hub.
+	MaxRetries int `yaml:"MaxRetries" json:"MaxRetries,omitempty"`
+	// Timeout is the total time budget of a request, its retries and the read of its response included.
+	// A retry which would not start within the budget is not attempted.
+	Timeout time.Duration `yaml:"Timeout" json:"Timeout,omitempty"`
+}
+
+// operationBudgetJSON encodes OperationBudget with its timeout as a string, like the Configuration's durations.
+type operationBudgetJSON struct {
+	MaxRetries int          `json:"MaxRetries,omitempty"`
+	Timeout    jsonDuration `json:"Timeout,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface, the timeout is encoded as a string (e.g. "5s").
+func (b OperationBudget) MarshalJSON() ([]byte, error) {
+	return json.Marshal(operationBudgetJSON{MaxRetries: b.MaxRetries, Timeout: jsonDuration(b.Timeout)})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The timeout can be a time.Duration string (e.g. "5s") or a nanoseconds number.
+func (b *OperationBudget) UnmarshalJSON(data []byte) error {
+	var decoded operationBudgetJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*b = OperationBudget{MaxRetries: decoded.MaxRetries, Timeout: time.Duration(decoded.Timeout)}
+	return nil
+}
+
+func (b OperationBudget) validate() error {
+	if b.MaxRetries < 0 {
+		return errors.New("negative MaxRetries")
+	}
+
+	if b.Timeout < 0 {
+		return errors.New("negative Timeout")
+	}
+
+	return nil
+}
+
+// Budgets holds the OperationBudget of each operation class, see Configuration.Budgets.
+type Budgets struct {
+	Send         OperationBudget `yaml:"Send" json:"Send,omitzero"`
+	Registration OperationBudget `yaml:"Registration" json:"Registration,omitzero"`
+	Query        OperationBudget `yaml:"Query" json:"Query,omitzero"`
+	Job          OperationBudget `yaml:"Job" json:"Job,omitzero"`
+}
+
+// Of returns the budget of an operation class.
+func (b Budgets) Of(class OperationClass) OperationBudget {
+	switch class {
+	case OperationSend:
+		return b.Send
+	case OperationRegistration:
+		return b.Registration
+	case OperationQuery:
+		return b.Query
+	case OperationJob:
+		return b.Job
+	default:
+		return OperationBudget{}
+	}
+}
+
+func (b Budgets) validate() error {
+	for _, class := range []OperationClass{OperationSend, OperationRegistration, OperationQuery, OperationJob} {
+		if err := b.Of(class).validate(); err != nil {
+			return fmt.Errorf("invalid %s budget: %w", class, err)
+		}
+	}
+
+	return nil
+}
+
+// roundTrip is the request pipeline of the hub requests: it applies the budget of the request's operation class
+// (see Configuration.Budgets) to the attempts of the request.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	cfg, _ := c.current()
+	budget := cfg.Budgets.Of(operationClassOf(req))
+
+	ctx, cancel := req.Context(), context.CancelFunc(func() {})
+	if budget.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, budget.Timeout)
+		req = req.WithContext(ctx)
+	}
+
+	for retry := 0; ; retry++ {
+		resp, err := c.attempt(req)
+
+		wait, retriable := retryDelay(resp, err, retry)
+		if retriable && retry < budget.MaxRetries && ctx.Err() == nil && (req.Body == nil || req.GetBody != nil) {
+			deadline, ok := ctx.Deadline()
+			if !ok || time.Until(deadline) > wait {
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // reuse the connection.
+					resp.Body.Close()
+				}
+
+				if err = sleepContext(ctx, wait); err != nil {
+					cancel()
+					return nil, err
+				}
+
+				if req.GetBody != nil {
+					if req.Body, err = req.GetBody(); err != nil {
+						cancel()
+						return nil, fmt.Errorf("failed to replay request body: %w", err)
+					}
+				}
+				continue
+			}
+		}
+
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: cancel} // the budget covers the read of the body.
+		return resp, nil
+	}
+}
+
+// retryDelay reports whether an attempt failed with a retriable error and how long to wait before the retry.
+func retryDelay(resp *http.Response, err error, retry int) (time.Duration, bool) {
+	if err != nil {
+		return backoff(retry), !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		return backoff(retry), true
+	default:
+		return 0, false
+	}
+}
+
+// backoff returns the exponential delay of a retry, from 200ms up to 5s.
+func backoff(retry int) time.Duration {
+	return min(200*time.Millisecond<<min(retry, 5), 5*time.Second)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
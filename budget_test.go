@@ -0,0 +1,128 @@
+package azurepush_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_Budgets(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	cfg := srv.Configuration()
+	cfg.Budgets = azurepush.Budgets{
+		Send:         azurepush.OperationBudget{MaxRetries: 2, Timeout: 5 * time.Second},
+		Registration: azurepush.OperationBudget{MaxRetries: 1},
+		Query:        azurepush.OperationBudget{Timeout: 20 * time.Millisecond},
+	}
+	client := azurepush.NewClient(cfg)
+
+	var (
+		base     = srv.HTTPClient().Transport
+		attempts = make(map[azurepushtest.Operation]*atomic.Int32)
+		failures = map[azurepushtest.Operation]int32{azurepushtest.OpSend: 2, azurepushtest.OpRegister: 10}
+	)
+	for op := range failures {
+		attempts[op] = new(atomic.Int32)
+	}
+	client.HTTPClient = &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		op := azurepushtest.OperationOf(r)
+		if op == azurepushtest.OpGet {
+			<-r.Context().Done() // hangs until the budget's timeout.
+			return nil, r.Context().Err()
+		}
+
+		if counter, ok := attempts[op]; ok && counter.Add(1) <= failures[op] {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody, Header: make(http.Header), Request: r}, nil
+		}
+		return base.RoundTrip(r)
+	})}
+
+	// The registrations fail twice, their single retry is not enough.
+	if _, err := client.RegisterDevice(t.Context(), azurepush.Installation{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a", Tags: []string{"user:1"}}); err == nil {
+		t.Fatal("expected a registration error")
+	}
+	if n := attempts[azurepushtest.OpRegister].Load(); n != 2 {
+		t.Fatalf("expected 2 registration attempts, got: %d", n)
+	}
+
+	failures[azurepushtest.OpRegister] = 0
+	if _, err := client.RegisterDevice(t.Context(), azurepush.Installation{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a", Tags: []string{"user:1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first platform's send fails twice and succeeds on its second retry, with its body replayed.
+	if err := client.SendNotification(t.Context(), azurepush.Notification{Title: "Hi"}, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := srv.Sent("user:1"); len(sent) != 2 || !strings.Contains(string(sent[0].Payload), "Hi") {
+		t.Fatalf("expected the retried send, got: %+v", sent)
+	}
+
+	start := time.Now()
+	if _, err := client.DeviceExists(t.Context(), "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the query budget exceeded, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the query to time out after 20ms, took: %s", elapsed)
+	}
+}
+
+func TestConfiguration_Budgets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "configuration.yml")
+	if err := os.WriteFile(path, []byte(`
+HubName: testhub
+ConnectionString: "Endpoint=sb://testnamespace.servicebus.windows.net/;SharedAccessKeyName=testKey;SharedAccessKey=testSecret"
+Budgets:
+  Send:
+    MaxRetries: 3
+    Timeout: 5s
+  Registration:
+    MaxRetries: 5
+    Timeout: 30s
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := azurepush.LoadConfiguration(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := azurepush.Budgets{
+		Send:         azurepush.OperationBudget{MaxRetries: 3, Timeout: 5 * time.Second},
+		Registration: azurepush.OperationBudget{MaxRetries: 5, Timeout: 30 * time.Second},
+	}
+	if cfg.Budgets != expected || cfg.Budgets.Of(azurepush.OperationSend) != expected.Send {
+		t.Fatalf("unexpected budgets: %+v", cfg.Budgets)
+	}
+
+	b, err := json.Marshal(cfg.Budgets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"Send":{"MaxRetries":3,"Timeout":"5s"},"Registration":{"MaxRetries":5,"Timeout":"30s"}}` {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+
+	var decoded azurepush.Budgets
+	if err = json.Unmarshal(b, &decoded); err != nil || decoded != expected {
+		t.Fatalf("unexpected decoded budgets: %+v: %v", decoded, err)
+	}
+
+	cfg.Budgets.Query.MaxRetries = -1
+	if err = cfg.Validate(); err == nil {
+		t.Fatal("expected a negative MaxRetries error")
+	}
+}
@@ -0,0 +1,352 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BulkTagOptions holds the settings of Client.BulkUpdateTags.
+type BulkTagOptions struct {
+	// DryRun reports the installations which would be updated, without patching them.
+	DryRun bool
+
+	// Concurrency is the maximum number of installations patched in parallel.
+	//
+	// Defaults to 8.
+	Concurrency int
+
+	// OnUpdate is called (if not nil) for each updated (or, on dry run, matched) installation
+	// with its patch operations and the error of the patch, if any. It may be called concurrently.
+	OnUpdate func(installationID string, ops []PatchOperation, err error)
+}
+
+// BulkTagReport is the result of Client.BulkUpdateTags.
+type BulkTagReport struct {
+	// Matched is the number of registrations matching the filter (templates excluded).
+	Matched int
+	// Updated is the number of patched installations,
+	// on dry run the number of installations which would be patched.
+	Updated int
+	// Unchanged is the number of installations which already have the added tags and none of the removed ones.
+	Unchanged int
+	// Skipped is the number of registrations which are not installations (e.g. legacy "gcm" registrations)
+	// or whose installation was deleted meanwhile.
+	Skipped int
+	// Failed is the number of installations whose patch failed.
+	Failed int
+}
+
+// BulkUpdateTags adds and removes tags on the installations matching a filter, e.g. to rename a tag scheme
+// across a hub ("plan:gold" to "tier:gold"). The matching registrations are listed page by page and
+// each installation is patched (see Client.PatchInstallation) with the missing added tags and the present
+// removed ones only, so installations already up to date are not touched and a bulk update can be safely repeated.
+//
+// The matching installations are listed before any is patched, so removing a tag of the filter
+// does not disturb the pagination of the list API.
+//
+// An error is returned if the tags are invalid or the hub cannot be listed, or joined with the failed patches.
+//
+// Example usage:
+//
+//	report, err := client.BulkUpdateTags(ctx, azurepush.TagFilter{TagExpression: "plan:gold"},
+//		[]string{"tier:gold"}, []string{"plan:gold"}, azurepush.BulkTagOptions{DryRun: true})
+func (c *Client) BulkUpdateTags(ctx context.Context, filter TagFilter, add, remove []string, opts BulkTagOptions) (*BulkTagReport, error) {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, fmt.Errorf("no tags to add or remove")
+	}
+
+	for _, tag := range slices.Concat(add, remove) {
+		if err := Tag(tag).Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, tag := range add {
+		if slices.Contains(remove, tag) {
+			return nil, fmt.Errorf("tag: %s: both added and removed", tag)
+		}
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+
+	type update struct {
+		installationID string
+		ops            []PatchOperation
+	}
+
+	var (
+		report  = new(BulkTagReport)
+		updates []update
+	)
+	for r, err := range c.filterRegistrations(ctx, filter) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list registrations: %w", err)
+		}
+		report.Matched++
+
+		installation, ok := registrationInstallation(r)
+		if !ok {
+			report.Skipped++
+			continue
+		}
+
+		ops := tagPatches(installation.Tags, add, remove)
+		if len(ops) == 0 {
+			report.Unchanged++
+			continue
+		}
+
+		updates = append(updates, update{installationID: installation.InstallationID, ops: ops})
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, opts.Concurrency)
+	)
+	for _, u := range updates {
+		if opts.DryRun {
+			report.Updated++
+			if opts.OnUpdate != nil {
+				opts.OnUpdate(u.installationID, u.ops, nil)
+			}
+			continue
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			err := c.PatchInstallation(ctx, u.installationID, u.ops...)
+
+			mu.Lock()
+			switch {
+			case errors.Is(err, ErrNotFound):
+				report.Skipped++
+				err = nil
+			case err != nil:
+				report.Failed++
+				errs = append(errs, fmt.Errorf("installation: %s: %w", u.installationID, err))
+			default:
+				report.Updated++
+			}
+			mu.Unlock()
+
+			if opts.OnUpdate != nil {
+				opts.OnUpdate(u.installationID, u.ops, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// tagPatches returns the operations which add the missing tags and remove the present ones.
+func tagPatches(tags, add, remove []string) []PatchOperation {
+	var ops []PatchOperation
+	for _, tag := range add {
+		if !slices.Contains(tags, tag) {
+			ops = append(ops, AddTagPatch(tag))
+		}
+	}
+
+	for _, tag := range remove {
+		if slices.Contains(tags, tag) {
+			ops = append(ops, RemoveTagPatch(tag))
+		}
+	}
+
+	return ops
+}
+
+// DeleteFilter selects the registrations of Client.BulkDelete, a registration must match all the set criteria.
+type DeleteFilter struct {
+	TagFilter
+
+	// RegisteredBefore, if not zero, matches the registrations created before this time.
+	RegisteredBefore time.Time
+	// UpdatedBefore, if not zero, matches the registrations not updated since this time.
+	UpdatedBefore time.Time
+	// ExpiredChannels matches the registrations whose expiration time has passed.
+	ExpiredChannels bool
+
+	// All must be set to delete all the registrations of the hub with an otherwise empty filter,
+	// a safeguard against deleting a whole hub by mistake.
+	All bool
+}
+
+// empty reports whether the filter has no criteria.
+func (f DeleteFilter) empty() bool {
+	return f.TagExpression == "" && len(f.Platforms) == 0 && f.RegisteredBefore.IsZero() && f.UpdatedBefore.IsZero() && !f.ExpiredChannels
+}
+
+// match reports whether a registration matches the time criteria of the filter,
+// the tag and platform ones are matched by the listing.
+func (f DeleteFilter) match(r Registration, now time.Time) bool {
+	switch {
+	case !f.RegisteredBefore.IsZero() && (r.CreatedAt.IsZero() || !r.CreatedAt.Before(f.RegisteredBefore)):
+		return false
+	case !f.UpdatedBefore.IsZero() && (r.UpdatedAt.IsZero() || !r.UpdatedAt.Before(f.UpdatedBefore)):
+		return false
+	case f.ExpiredChannels && (r.ExpirationTime.IsZero() || r.ExpirationTime.After(now)):
+		return false
+	default:
+		return true
+	}
+}
+
+// BulkDeleteOptions holds the settings of Client.BulkDelete.
+type BulkDeleteOptions struct {
+	// DryRun counts and reports the registrations which would be deleted, without deleting them.
+	DryRun bool
+
+	// Concurrency is the maximum number of registrations deleted in parallel.
+	//
+	// Defaults to 8.
+	Concurrency int
+
+	// OnProgress is called (if not nil) after each deleted (or, on dry run, matched) registration.
+	// Calls are serialized.
+	OnProgress func(BulkDeleteProgress)
+}
+
+// BulkDeleteProgress is the progress of Client.BulkDelete, see BulkDeleteOptions.OnProgress.
+type BulkDeleteProgress struct {
+	// Registration is the deleted (or, on dry run, matched) registration.
+	Registration Registration
+	// Err is the error of its deletion, if any.
+	Err error
+	// Done is the number of processed registrations so far, out of Total matched ones.
+	Done, Total int
+}
+
+// BulkDeleteReport is the result of Client.BulkDelete.
+type BulkDeleteReport struct {
+	// Matched is the number of registrations matching the filter (templates excluded).
+	Matched int
+	// Deleted is the number of deleted registrations, on dry run the number of registrations which would be deleted.
+	Deleted int
+	// Failed is the number of registrations whose deletion failed.
+	Failed int
+}
+
+// BulkDelete deletes the registrations matching a filter, e.g. the expired channels or the devices of a platform
+// not updated for months, for cleanup campaigns. Run it with DryRun first to count the matching registrations.
+// The registrations of installations are deleted with their installation (see DeleteDevice),
+// the legacy ones by their registration ID.
+//
+// The matching registrations are listed before any is deleted, so the deletions do not disturb
+// the pagination of the list API. An empty filter is rejected unless its All field is set.
+//
+// An error is returned if the hub cannot be listed, or joined with the failed deletions.
+//
+// Example usage:
+//
+//	report, err := client.BulkDelete(ctx, azurepush.DeleteFilter{
+//		TagFilter:     azurepush.TagFilter{Platforms: []azurepush.InstallationPlatform{azurepush.InstallationMPNS}},
+//		UpdatedBefore: time.Now().AddDate(0, -6, 0),
+//	}, azurepush.BulkDeleteOptions{DryRun: true})
+func (c *Client) BulkDelete(ctx context.Context, filter DeleteFilter, opts BulkDeleteOptions) (*BulkDeleteReport, error) {
+	if filter.empty() && !filter.All {
+		return nil, fmt.Errorf("empty delete filter: set its All field to delete all registrations")
+	}
+
+	now := c.now()
+
+	var matched []Registration
+	for r, err := range c.filterRegistrations(ctx, filter.TagFilter) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list registrations: %w", err)
+		}
+
+		if filter.match(r, now) {
+			matched = append(matched, r)
+		}
+	}
+
+	return c.deleteRegistrations(ctx, matched, opts)
+}
+
+// deleteRegistrations deletes the registrations with bounded concurrency, see BulkDelete.
+func (c *Client) deleteRegistrations(ctx context.Context, registrations []Registration, opts BulkDeleteOptions) (*BulkDeleteReport, error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+
+	var (
+		report = &BulkDeleteReport{Matched: len(registrations)}
+		done   int
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		sem    = make(chan struct{}, opts.Concurrency)
+	)
+	progress := func(r Registration, err error) {
+		done++
+		if opts.OnProgress != nil {
+			opts.OnProgress(BulkDeleteProgress{Registration: r, Err: err, Done: done, Total: len(registrations)})
+		}
+	}
+
+	for _, r := range registrations {
+		if opts.DryRun {
+			report.Deleted++
+			progress(r, nil)
+			continue
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			err := c.deleteRegistered(ctx, r)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				report.Failed++
+				errs = append(errs, fmt.Errorf("registration: %s: %w", r.RegistrationID, err))
+			} else {
+				report.Deleted++
+			}
+			progress(r, err)
+		})
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// deleteRegistered deletes the installation of a registration, or the registration itself if legacy.
+func (c *Client) deleteRegistered(ctx context.Context, r Registration) error {
+	if id := r.InstallationID(); id != "" {
+		return c.DeleteDevice(ctx, id)
+	}
+
+	return c.deleteRegistration(ctx, r.RegistrationID)
+}
@@ -0,0 +1,189 @@
+package azurepush_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_BulkUpdateTags(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	// More than a page of the list API, every other one on the gold plan.
+	for i := range 250 {
+		installation := azurepush.Installation{
+			InstallationID: fmt.Sprintf("device-%03d", i),
+			Platform:       azurepush.InstallationApple,
+			PushChannel:    fmt.Sprintf("token-%d", i),
+			Tags:           []string{"plan:silver"},
+		}
+		if i%2 == 0 {
+			installation.Tags = []string{"plan:gold"}
+		}
+		if i%10 == 0 {
+			installation.Platform = azurepush.InstallationFCMV1
+		}
+		if i == 0 {
+			installation.Tags = append(installation.Tags, "tier:gold")
+		}
+
+		if _, err := client.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	filter := azurepush.TagFilter{TagExpression: "plan:gold"}
+	add, remove := []string{"tier:gold"}, []string{"plan:gold"}
+
+	report, err := client.BulkUpdateTags(ctx, filter, add, remove, azurepush.BulkTagOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 125 || report.Updated != 125 {
+		t.Fatalf("unexpected dry run report: %+v", *report)
+	}
+	if installation, _ := srv.Installation("device-002"); !slices.Equal(installation.Tags, []string{"plan:gold"}) {
+		t.Fatalf("dry run patched an installation: %v", installation.Tags)
+	}
+
+	var updated atomic.Int32
+	report, err = client.BulkUpdateTags(ctx, azurepush.TagFilter{TagExpression: "plan:gold", Platforms: []azurepush.InstallationPlatform{azurepush.InstallationApple}}, add, remove, azurepush.BulkTagOptions{
+		Concurrency: 4,
+		OnUpdate: func(installationID string, ops []azurepush.PatchOperation, err error) {
+			if err != nil || len(ops) != 2 {
+				t.Errorf("unexpected update of %s: %v: %v", installationID, ops, err)
+			}
+			updated.Add(1)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 100 || report.Updated != 100 || updated.Load() != 100 {
+		t.Fatalf("unexpected report: %+v", *report)
+	}
+
+	// The FCM ones, device-000 already has the added tag.
+	report, err = client.BulkUpdateTags(ctx, filter, add, remove, azurepush.BulkTagOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 25 || report.Updated != 25 {
+		t.Fatalf("unexpected report: %+v", *report)
+	}
+
+	for _, installation := range srv.Installations() {
+		if slices.Contains(installation.Tags, "plan:gold") {
+			t.Fatalf("installation %s still has the removed tag: %v", installation.InstallationID, installation.Tags)
+		}
+	}
+	if installation, _ := srv.Installation("device-000"); !slices.Equal(installation.Tags, []string{"tier:gold"}) {
+		t.Errorf("unexpected tags: %v", installation.Tags)
+	}
+
+	// Nothing left to update.
+	report, err = client.BulkUpdateTags(ctx, azurepush.TagFilter{TagExpression: "tier:gold"}, add, remove, azurepush.BulkTagOptions{})
+	if err != nil || report.Matched != 125 || report.Unchanged != 125 || report.Updated != 0 {
+		t.Fatalf("expected all unchanged, got: %+v: %v", report, err)
+	}
+
+	srv.SetError(azurepushtest.OpPatch, http.StatusInternalServerError, "boom")
+	report, err = client.BulkUpdateTags(ctx, azurepush.TagFilter{TagExpression: "tier:gold"}, []string{"vip"}, nil, azurepush.BulkTagOptions{})
+	if err == nil || report.Failed != 125 {
+		t.Fatalf("expected failed patches, got: %+v: %v", report, err)
+	}
+
+	if _, err = client.BulkUpdateTags(ctx, filter, []string{"a"}, []string{"a"}, azurepush.BulkTagOptions{}); err == nil {
+		t.Error("expected a tag both added and removed error")
+	}
+	if _, err = client.BulkUpdateTags(ctx, filter, []string{"bad tag"}, nil, azurepush.BulkTagOptions{}); err == nil {
+		t.Error("expected an invalid tag error")
+	}
+}
+
+func TestClient_BulkDelete(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	clock := azurepushtest.NewFakeClock(time.Now().Truncate(time.Second))
+	srv.SetClock(clock)
+
+	client := srv.NewClient()
+	client.SetClock(clock)
+	ctx := context.Background()
+
+	register := func(id string, platform azurepush.InstallationPlatform, tags ...string) {
+		t.Helper()
+		if _, err := client.RegisterDevice(ctx, azurepush.Installation{InstallationID: id, Platform: platform, PushChannel: "token-" + id, Tags: tags}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	register("old-apple", azurepush.InstallationApple, "beta")
+	register("old-mpns", azurepush.InstallationMPNS)
+	clock.Advance(200 * 24 * time.Hour)
+	register("new-apple", azurepush.InstallationApple, "beta")
+	register("new-fcm", azurepush.InstallationFCMV1)
+	srv.SetExpiration("new-fcm", clock.Now().Add(-time.Minute))
+
+	cutoff := clock.Now().AddDate(0, 0, -180)
+
+	var progress []azurepush.BulkDeleteProgress
+	report, err := client.BulkDelete(ctx, azurepush.DeleteFilter{UpdatedBefore: cutoff}, azurepush.BulkDeleteOptions{
+		DryRun:     true,
+		OnProgress: func(p azurepush.BulkDeleteProgress) { progress = append(progress, p) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 2 || report.Deleted != 2 || len(srv.Installations()) != 4 {
+		t.Fatalf("unexpected dry run: %+v", *report)
+	}
+	if len(progress) != 2 || progress[1].Done != 2 || progress[1].Total != 2 {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+
+	report, err = client.BulkDelete(ctx, azurepush.DeleteFilter{
+		TagFilter:        azurepush.TagFilter{TagExpression: "beta"},
+		RegisteredBefore: cutoff,
+	}, azurepush.BulkDeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Installation("old-apple"); ok || report.Deleted != 1 || len(srv.Installations()) != 3 {
+		t.Fatalf("expected only the old beta installation deleted, got: %+v", *report)
+	}
+
+	if report, err = client.BulkDelete(ctx, azurepush.DeleteFilter{ExpiredChannels: true}, azurepush.BulkDeleteOptions{}); err != nil || report.Deleted != 1 {
+		t.Fatalf("expected the expired channel deleted, got: %+v: %v", report, err)
+	}
+	if _, ok := srv.Installation("new-fcm"); ok {
+		t.Fatal("expected the expired channel deleted")
+	}
+
+	if _, err = client.BulkDelete(ctx, azurepush.DeleteFilter{}, azurepush.BulkDeleteOptions{}); err == nil {
+		t.Fatal("expected an empty filter error")
+	}
+
+	srv.SetError(azurepushtest.OpDelete, http.StatusInternalServerError, "boom")
+	report, err = client.BulkDelete(ctx, azurepush.DeleteFilter{All: true}, azurepush.BulkDeleteOptions{})
+	if err == nil || report.Failed != 2 {
+		t.Fatalf("expected failed deletions, got: %+v: %v", report, err)
+	}
+	srv.ClearErrors()
+
+	if report, err = client.BulkDelete(ctx, azurepush.DeleteFilter{All: true}, azurepush.BulkDeleteOptions{}); err != nil || report.Deleted != 2 || len(srv.Installations()) != 0 {
+		t.Fatalf("expected all deleted, got: %+v: %v", report, err)
+	}
+}
@@ -0,0 +1,483 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"time"
+)
+
+// DefaultCampaignChunkSize is the default number of tags per send of a campaign,
+// the maximum number of OR-ed tags of a tag expression.
+const DefaultCampaignChunkSize = 20
+
+// Audience holds the devices targeted by a campaign.
+// Exactly one of its fields must be set.
+type Audience struct {
+	// TagExpression targets the devices matching a tag expression,
+	// e.g. "follows:RedSox && location:Boston". It is sent at once.
+	TagExpression string
+	// Tags targets the devices with any of the tags, e.g. thousands of "user:<id>" tags.
+	// They are sent in chunks, see Campaign.ChunkSize.
+	Tags []string
+	// Broadcast targets all devices of the hub.
+	Broadcast bool
+}
+
+// Campaign is a notification sent to an audience, optionally scheduled and throttled.
+//
+// Example usage:
+//
+//	report, err := client.RunCampaign(ctx, azurepush.Campaign{
+//		ID:       "spring-sale",
+//		Audience: azurepush.Audience{Tags: userTags},
+//		Message:  azurepush.Notification{Title: "Spring sale", Body: "Everything is 20% off today"},
+//		Throttle: 50,
+//	})
+//
+// A personalized campaign renders a template per recipient instead, see Campaign.Template.
+type Campaign struct {
+	// ID identifies the campaign, it prefixes the IDs of its sends (e.g. "spring-sale/0").
+	ID       string
+	Audience Audience
+	Message  Notification
+
+	// StartAt schedules the campaign, RunCampaign waits until then.
+	// A zero or past time starts the campaign immediately.
+	StartAt time.Time
+
+	// Throttle is the maximum number of sends per second, zero means no limit.
+	// Each chunk of tags is a single send.
+	Throttle float64
+
+	// ChunkSize is the maximum number of tags per send.
+	//
+	// Defaults to DefaultCampaignChunkSize.
+	ChunkSize int
+
+	// Concurrency is the maximum number of sends in parallel, see SenderOptions.Concurrency.
+	Concurrency int
+
+	// Drip spreads the sends evenly over the given duration instead of sending them as fast
+	// as Throttle allows, see Sender.SendDrip. When resuming, the remaining sends are spread
+	// over the remaining share of the duration.
+	Drip time.Duration
+
+	// ResumeFrom skips the first sends of the campaign, e.g. the CampaignReport.Next
+	// of a run interrupted by a shutdown. Sends are identified by their position,
+	// so the campaign must be resumed with the same audience, chunk size and variants.
+	// Resuming is at least once: sends in flight when the run was interrupted may be sent again.
+	ResumeFrom int
+
+	// Template, if not nil, is rendered for each tag of the audience with the data returned by RecipientData
+	// and sent instead of Message, each tag on its own (see Sender.SendPersonalized).
+	// It requires an Audience of Tags and cannot be combined with Variants.
+	// Recipients whose data or rendering fails are reported as failed sends.
+	//
+	// Personalized campaigns cannot be checkpointed by a CampaignRunner, as templates and functions are not stored.
+	Template *NotificationTemplate `json:"-"`
+	// RecipientData returns the template data of a tag, e.g. a user record for a "user:<id>" tag.
+	// Required with Template.
+	RecipientData RecipientDataFunc `json:"-"`
+
+	// Variants splits the audience into A/B test variants, each one receiving its own message
+	// instead of Message. It requires an Audience of Tags, which are assigned to variants
+	// deterministically (see VariantOf), so re-running the campaign keeps the same split.
+	Variants []Variant
+}
+
+// Variant is an A/B test variant of a campaign.
+type Variant struct {
+	// Name identifies the variant in the CampaignReport, e.g. "A".
+	Name string
+	// Weight is the relative share of the audience, e.g. 1 and 1 for a 50/50 split
+	// or 9 and 1 for a 90/10 one.
+	//
+	// Defaults to 1.
+	Weight  int
+	Message Notification
+}
+
+func (v Variant) weight() int {
+	if v.Weight <= 0 {
+		return 1
+	}
+	return v.Weight
+}
+
+// VariantOf returns the name of the variant the tag is assigned to, or empty if the campaign has no variants.
+// The assignment is a hash of the campaign ID and the tag, so it is stable across runs
+// and independent between campaigns. Use it to join the variants with analytics events.
+func (c Campaign) VariantOf(tag string) string {
+	if len(c.Variants) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, v := range c.Variants {
+		total += v.weight()
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(c.ID))
+	h.Write([]byte{0})
+	h.Write([]byte(tag))
+	n := int(h.Sum64() % uint64(total))
+
+	for _, v := range c.Variants {
+		if n -= v.weight(); n < 0 {
+			return v.Name
+		}
+	}
+
+	return c.Variants[len(c.Variants)-1].Name // unreachable.
+}
+
+// Validate checks if the campaign has all required fields set.
+func (c Campaign) Validate() error {
+	if c.ID == "" {
+		return errors.New("campaign ID is required")
+	}
+
+	targets := 0
+	if c.Audience.TagExpression != "" {
+		targets++
+	}
+	if len(c.Audience.Tags) > 0 {
+		targets++
+	}
+	if c.Audience.Broadcast {
+		targets++
+	}
+	if targets != 1 {
+		return errors.New("campaign audience requires exactly one of tag expression, tags or broadcast")
+	}
+
+	if c.ResumeFrom < 0 {
+		return errors.New("campaign resume position cannot be negative")
+	}
+
+	if c.Template != nil {
+		return c.validateTemplate()
+	}
+
+	if len(c.Variants) > 0 {
+		return c.validateVariants()
+	}
+
+	if c.Message.Title == "" && c.Message.Body == "" {
+		return errors.New("campaign message title or body is required")
+	}
+
+	return nil
+}
+
+func (c Campaign) validateTemplate() error {
+	if c.RecipientData == nil {
+		return errors.New("campaign template requires recipient data")
+	}
+
+	if len(c.Audience.Tags) == 0 {
+		return errors.New("campaign template requires an audience of tags")
+	}
+
+	if len(c.Variants) > 0 {
+		return errors.New("campaign template cannot be combined with variants")
+	}
+
+	return nil
+}
+
+func (c Campaign) validateVariants() error {
+	if len(c.Audience.Tags) == 0 {
+		return errors.New("campaign variants require an audience of tags")
+	}
+
+	names := make(map[string]struct{}, len(c.Variants))
+	for _, v := range c.Variants {
+		if v.Name == "" {
+			return errors.New("campaign variant name is required")
+		}
+
+		if _, ok := names[v.Name]; ok {
+			return fmt.Errorf("duplicate campaign variant: %s", v.Name)
+		}
+		names[v.Name] = struct{}{}
+
+		if v.Message.Title == "" && v.Message.Body == "" {
+			return fmt.Errorf("campaign variant: %s: message title or body is required", v.Name)
+		}
+	}
+
+	return nil
+}
+
+// chunks returns the sends of the campaign, and the variant of each one (by send ID) if the campaign has variants.
+func (c Campaign) chunks() ([]TargetedNotification, map[string]string) {
+	switch {
+	case c.Audience.TagExpression != "":
+		return []TargetedNotification{{ID: c.ID + "/0", Notification: c.Message, Tags: []string{c.Audience.TagExpression}}}, nil
+	case c.Audience.Broadcast:
+		return []TargetedNotification{{ID: c.ID + "/0", Notification: c.Message}}, nil
+	}
+
+	size := c.ChunkSize
+	if size <= 0 {
+		size = DefaultCampaignChunkSize
+	}
+	if c.Template != nil {
+		size = 1 // rendered per recipient, see render.
+	}
+
+	if len(c.Variants) == 0 {
+		return chunkTags(nil, c.ID, c.Message, c.Audience.Tags, size), nil
+	}
+
+	audiences := make(map[string][]string, len(c.Variants))
+	for _, tag := range c.Audience.Tags {
+		name := c.VariantOf(tag)
+		audiences[name] = append(audiences[name], tag)
+	}
+
+	var (
+		chunks    []TargetedNotification
+		variantOf = make(map[string]string)
+	)
+	for _, v := range c.Variants {
+		n := len(chunks)
+		chunks = chunkTags(chunks, c.ID+"/"+v.Name, v.Message, audiences[v.Name], size)
+		for _, chunk := range chunks[n:] {
+			variantOf[chunk.ID] = v.Name
+		}
+	}
+
+	return chunks, variantOf
+}
+
+// chunkTags appends the sends of the tags, in chunks of size, to dst.
+func chunkTags(dst []TargetedNotification, prefix string, message Notification, tags []string, size int) []TargetedNotification {
+	for i, n := 0, 0; i < len(tags); i, n = i+size, n+1 {
+		dst = append(dst, TargetedNotification{
+			ID:           fmt.Sprintf("%s/%d", prefix, n),
+			Notification: message,
+			Tags:         tags[i:min(i+size, len(tags))],
+		})
+	}
+
+	return dst
+}
+
+// render renders the template of a personalized campaign for the tag of each chunk.
+// It returns the rendered chunks and the errors of the failed ones, by send ID.
+func (c Campaign) render(ctx context.Context, chunks []TargetedNotification) ([]TargetedNotification, map[string]error) {
+	var (
+		rendered = make([]TargetedNotification, 0, len(chunks))
+		failures = make(map[string]error)
+	)
+	for _, chunk := range chunks {
+		n, err := renderFor(ctx, c.Template, chunk.Tags[0], c.RecipientData)
+		if err != nil {
+			failures[chunk.ID] = err
+			continue
+		}
+
+		chunk.Notification = n
+		rendered = append(rendered, chunk)
+	}
+
+	return rendered, failures
+}
+
+// CampaignReport aggregates the outcome of a campaign.
+type CampaignReport struct {
+	CampaignID string
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// Sends is the number of sends (chunks) of the campaign.
+	Sends int
+	// Succeeded is the number of successful sends.
+	Succeeded int
+	// Failed is the number of failed sends, see Failures.
+	Failed int
+
+	// Next is the position of the first send not dispatched, equal to the number of sends
+	// of the campaign once it completes. Set it to Campaign.ResumeFrom to resume an interrupted campaign.
+	Next int
+
+	// NotificationIDs holds the IDs of the sent notifications reported by the hub (Standard tier only),
+	// they can be used to fetch the per message telemetry.
+	NotificationIDs []string
+	// Failures holds the results of the failed sends.
+	Failures []SendResult
+
+	// Variants holds the outcome per variant (by name) of an A/B test campaign.
+	Variants map[string]*VariantReport
+}
+
+// VariantReport aggregates the outcome of a campaign's variant.
+type VariantReport struct {
+	// Tags is the number of tags assigned to the variant.
+	Tags      int
+	Sends     int
+	Succeeded int
+	Failed    int
+	// NotificationIDs holds the IDs of the variant's sent notifications,
+	// their telemetry measures the performance of the variant.
+	NotificationIDs []string
+}
+
+// RunCampaign waits until the campaign's start time, sends it in chunks and reports its outcome.
+// Failed sends are reported in the CampaignReport, an error is returned if the campaign is invalid
+// or the context is done (a partial report is returned as well once sending started).
+//
+// See CampaignRunner to checkpoint the progress of a campaign and resume it after a crash.
+func (c *Client) RunCampaign(ctx context.Context, campaign Campaign) (*CampaignReport, error) {
+	if err := campaign.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c.runCampaign(ctx, campaign, nil, nil)
+}
+
+// runCampaign runs a valid campaign. The chunks of done (by send ID) were already sent by a previous run,
+// they are not sent again but are included in the report. The onResult callback (if not nil)
+// is called as soon as each send completes.
+func (c *Client) runCampaign(ctx context.Context, campaign Campaign, done map[string]SendResult, onResult func(SendResult)) (*CampaignReport, error) {
+	if wait := time.Until(campaign.StartAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	sender := NewSender(c, SenderOptions{
+		Concurrency: campaign.Concurrency,
+		RateLimit:   campaign.Throttle,
+		OnResult:    onResult,
+	})
+
+	chunks, variantOf := campaign.chunks()
+
+	report := &CampaignReport{CampaignID: campaign.ID, StartedAt: time.Now()}
+	if len(campaign.Variants) > 0 {
+		report.Variants = make(map[string]*VariantReport, len(campaign.Variants))
+		for _, v := range campaign.Variants {
+			report.Variants[v.Name] = new(VariantReport)
+		}
+		for _, chunk := range chunks {
+			report.Variants[variantOf[chunk.ID]].Tags += len(chunk.Tags)
+		}
+	}
+
+	var (
+		remaining []TargetedNotification
+		positions []int // positions of the remaining chunks.
+	)
+	for i := min(campaign.ResumeFrom, len(chunks)); i < len(chunks); i++ {
+		if result, ok := done[chunks[i].ID]; ok {
+			report.addSend(result, variantOf)
+			continue
+		}
+
+		remaining = append(remaining, chunks[i])
+		positions = append(positions, i)
+	}
+
+	sendable, failures := remaining, map[string]error(nil)
+	if campaign.Template != nil {
+		sendable, failures = campaign.render(ctx, remaining)
+	}
+
+	var results []SendResult
+	if campaign.Drip > 0 {
+		over := campaign.Drip * time.Duration(len(remaining)) / time.Duration(max(len(chunks), 1))
+		results = sender.SendDrip(ctx, sendable, over)
+	} else {
+		results = sender.SendBatch(ctx, sendable)
+		if ctx.Err() != nil {
+			// Resume from the first send interrupted by the context,
+			// the concurrent sends after it may be sent again.
+			if i := slices.IndexFunc(results, func(r SendResult) bool { return errors.Is(r.Err, ctx.Err()) }); i >= 0 {
+				results = results[:i]
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		results = mergeRenderFailures(remaining, results, failures, onResult)
+	}
+
+	report.Next = len(chunks)
+	if len(results) < len(remaining) {
+		report.Next = positions[len(results)]
+	}
+
+	for _, result := range results {
+		report.addSend(result, variantOf)
+	}
+	report.FinishedAt = time.Now()
+
+	return report, ctx.Err()
+}
+
+// mergeRenderFailures merges the results of the rendered chunks with the render failures, in the order of the chunks.
+// The failures after the last result of an interrupted run are dropped, so they are rendered again on resume.
+func mergeRenderFailures(chunks []TargetedNotification, results []SendResult, failures map[string]error, onResult func(SendResult)) []SendResult {
+	merged := make([]SendResult, 0, len(chunks))
+	for _, chunk := range chunks {
+		if err, ok := failures[chunk.ID]; ok {
+			result := SendResult{ID: chunk.ID, Tags: chunk.Tags, Err: err}
+			if onResult != nil {
+				onResult(result)
+			}
+			merged = append(merged, result)
+			continue
+		}
+
+		if len(results) == 0 {
+			break
+		}
+		merged, results = append(merged, results[0]), results[1:]
+	}
+
+	return merged
+}
+
+// addSend adds the result to the report and to the report of its variant, if any.
+func (r *CampaignReport) addSend(result SendResult, variantOf map[string]string) {
+	r.add(result)
+	if name, ok := variantOf[result.ID]; ok {
+		r.Variants[name].add(result)
+	}
+}
+
+func (r *CampaignReport) add(result SendResult) {
+	r.Sends++
+	r.NotificationIDs = append(r.NotificationIDs, result.NotificationIDs...)
+
+	if result.Err != nil {
+		r.Failed++
+		r.Failures = append(r.Failures, result)
+		return
+	}
+
+	r.Succeeded++
+}
+
+func (r *VariantReport) add(result SendResult) {
+	r.Sends++
+	r.NotificationIDs = append(r.NotificationIDs, result.NotificationIDs...)
+
+	if result.Err != nil {
+		r.Failed++
+		return
+	}
+
+	r.Succeeded++
+}
@@ -0,0 +1,251 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_RunCampaign(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	tags := make([]string, 45)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("user:%d", i)
+	}
+
+	report, err := client.RunCampaign(ctx, azurepush.Campaign{
+		ID:       "spring-sale",
+		Audience: azurepush.Audience{Tags: tags},
+		Message:  azurepush.Notification{Title: "Spring sale", Body: "Everything is 20% off today"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.CampaignID != "spring-sale" || report.Sends != 3 || report.Succeeded != 3 || report.Failed != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if len(report.NotificationIDs) != 6 { // 3 chunks * 2 platforms.
+		t.Errorf("expected 6 notification IDs, got: %v", report.NotificationIDs)
+	}
+	sent := srv.AllSent()
+	if len(sent) != 6 {
+		t.Errorf("expected 6 sends, got: %d", len(sent))
+	}
+	for _, n := range sent { // chunks are sent concurrently, in any order.
+		if len(n.Tags) != azurepush.DefaultCampaignChunkSize && len(n.Tags) != 5 {
+			t.Errorf("expected chunks of %d tags, got: %d", azurepush.DefaultCampaignChunkSize, len(n.Tags))
+		}
+	}
+	if len(srv.Sent("user:44")) != 2 {
+		t.Error("expected the last tag to be sent")
+	}
+}
+
+func TestClient_RunCampaign_Failures(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "internal error")
+
+	report, err := srv.NewClient().RunCampaign(context.Background(), azurepush.Campaign{
+		ID:       "outage",
+		Audience: azurepush.Audience{TagExpression: "follows:RedSox && location:Boston"},
+		Message:  azurepush.Notification{Title: "Game on"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Failed != 1 || len(report.Failures) != 1 || report.Failures[0].ID != "outage/0" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestClient_RunCampaign_Template(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	tmpl, err := azurepush.ParseNotificationTemplate(azurepush.Notification{Title: "Hi {{.Name}}", Body: "Your cart is waiting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]string{"user:1": "Ann", "user:3": "Bob"}
+	campaign := azurepush.Campaign{
+		ID:       "cart",
+		Audience: azurepush.Audience{Tags: []string{"user:1", "user:2", "user:3"}},
+		Template: tmpl,
+		RecipientData: func(ctx context.Context, tag string) (any, error) {
+			name, ok := names[tag]
+			if !ok {
+				return nil, errors.New("unknown recipient")
+			}
+			return map[string]string{"Name": name}, nil
+		},
+	}
+
+	report, err := srv.NewClient().RunCampaign(context.Background(), campaign)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Sends != 3 || report.Succeeded != 2 || report.Failed != 1 || report.Failures[0].ID != "cart/1" || report.Next != 3 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	for tag, name := range names {
+		sent := srv.Sent(tag)
+		if len(sent) != 2 || !strings.Contains(string(sent[0].Payload), "Hi "+name) {
+			t.Errorf("expected the notifications of %s to be rendered for %s, got: %d", tag, name, len(sent))
+		}
+	}
+	if len(srv.Sent("user:2")) != 0 {
+		t.Error("expected no send to the recipient whose data failed")
+	}
+
+	campaign.RecipientData = nil
+	if _, err = srv.NewClient().RunCampaign(context.Background(), campaign); err == nil {
+		t.Error("expected an error for a template without recipient data")
+	}
+}
+
+func TestClient_RunCampaign_Schedule(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	campaign := azurepush.Campaign{
+		ID:       "later",
+		Audience: azurepush.Audience{Broadcast: true},
+		Message:  azurepush.Notification{Title: "Hi"},
+		StartAt:  time.Now().Add(time.Hour),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.RunCampaign(ctx, campaign); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded while waiting for the start time, got: %v", err)
+	}
+	if len(srv.AllSent()) != 0 {
+		t.Error("expected nothing to be sent before the start time")
+	}
+
+	campaign.Audience.Tags = []string{"user:1"}
+	if _, err := client.RunCampaign(context.Background(), campaign); err == nil {
+		t.Error("expected error for an ambiguous audience")
+	}
+}
+
+func TestClient_RunCampaign_Variants(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	tags := make([]string, 100)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("user:%d", i)
+	}
+
+	campaign := azurepush.Campaign{
+		ID:       "onboarding",
+		Audience: azurepush.Audience{Tags: tags},
+		Variants: []azurepush.Variant{
+			{Name: "A", Message: azurepush.Notification{Title: "Welcome!"}},
+			{Name: "B", Weight: 3, Message: azurepush.Notification{Title: "Get started"}},
+		},
+	}
+
+	report, err := srv.NewClient().RunCampaign(context.Background(), campaign)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	a, b := report.Variants["A"], report.Variants["B"]
+	if a == nil || b == nil {
+		t.Fatalf("expected a report per variant, got: %v", report.Variants)
+	}
+	if a.Tags+b.Tags != len(tags) || a.Tags == 0 || b.Tags <= a.Tags {
+		t.Errorf("expected a weighted split of %d tags, got: A=%d B=%d", len(tags), a.Tags, b.Tags)
+	}
+	if a.Sends+b.Sends != report.Sends || a.Failed+b.Failed != 0 || len(a.NotificationIDs) != 2*a.Sends {
+		t.Errorf("unexpected variant reports: A=%+v B=%+v", a, b)
+	}
+
+	titles := map[string]string{"A": "Welcome!", "B": "Get started"}
+	for _, tag := range tags {
+		variant := campaign.VariantOf(tag)
+		if variant != campaign.VariantOf(tag) {
+			t.Fatalf("expected a deterministic variant for tag: %s", tag)
+		}
+
+		sent := srv.Sent(tag)
+		if len(sent) == 0 {
+			t.Fatalf("expected tag: %s to be sent", tag)
+		}
+		if !strings.Contains(string(sent[0].Payload), titles[variant]) {
+			t.Fatalf("expected tag: %s to receive variant %s, got: %s", tag, variant, sent[0].Payload)
+		}
+	}
+
+	campaign.Audience = azurepush.Audience{TagExpression: "follows:RedSox"}
+	if err = campaign.Validate(); err == nil {
+		t.Error("expected variants to require an audience of tags")
+	}
+}
+
+func TestClient_RunCampaign_DripResume(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+
+	tags := make([]string, 10)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("user:%d", i)
+	}
+
+	campaign := azurepush.Campaign{
+		ID:        "digest",
+		Audience:  azurepush.Audience{Tags: tags},
+		Message:   azurepush.Notification{Title: "Your daily digest"},
+		ChunkSize: 1,
+		Drip:      500 * time.Millisecond,
+	}
+
+	// Interrupted run, e.g. by a shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+
+	report, err := client.RunCampaign(ctx, campaign)
+	if err == nil {
+		t.Fatal("expected the context error")
+	}
+	if report.Next == 0 || report.Next >= len(tags) || report.Sends != report.Next {
+		t.Fatalf("expected a partial drip, got: %+v", report)
+	}
+
+	campaign.ResumeFrom = report.Next
+	resumed, err := client.RunCampaign(context.Background(), campaign)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resumed.Next != len(tags) || resumed.Sends != len(tags)-report.Next || resumed.Failed != 0 {
+		t.Fatalf("unexpected resumed report: %+v", resumed)
+	}
+
+	for _, tag := range tags {
+		if len(srv.Sent(tag)) == 0 {
+			t.Errorf("expected tag: %s to be sent", tag)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// campaignKeyPrefix is the Store key prefix of the checkpointed campaigns.
+const campaignKeyPrefix = "campaigns/"
+
+// CampaignRunner runs campaigns with their progress checkpointed in a Store, one entry per sent chunk,
+// so a campaign interrupted by a crash or a shutdown can be resumed without sending its chunks twice.
+//
+// A chunk in flight when the process crashed (sent, but not checkpointed yet) is sent again on resume,
+// at most Campaign.Concurrency chunks.
+//
+// The succeeded chunks are checkpointed, and so are the chunks which failed permanently, e.g. a rejected
+// request or no matching devices, with their error. A chunk which failed with a retryable error
+// (an outage or a cancellation, see ErrorClassOutage and ErrorClassCanceled) is not checkpointed:
+// its campaign is not finished, it stays pending (see Pending) and Resume retries that chunk.
+//
+// Example usage:
+//
+//	runner := azurepush.NewCampaignRunner(client, store)
+//
+//	// On startup, resume the campaigns interrupted by the previous process.
+//	ids, err := runner.Pending(ctx)
+//	for _, id := range ids {
+//		report, err := runner.Resume(ctx, id)
+//	}
+//
+//	report, err := runner.Run(ctx, campaign)
+type CampaignRunner struct {
+	client *Client
+	store  Store
+}
+
+// NewCampaignRunner creates a new CampaignRunner.
+func NewCampaignRunner(client *Client, store Store) *CampaignRunner {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if store == nil {
+		panic("azurepush: nil store")
+	}
+
+	return &CampaignRunner{client: client, store: store}
+}
+
+// checkpoint is the stored JSON document of a sent chunk, with the error of a permanently failed one.
+type checkpoint struct {
+	ID              string        `json:"id"`
+	Tags            []string      `json:"tags,omitempty"`
+	NotificationIDs []string      `json:"notificationIds,omitempty"`
+	Duration        time.Duration `json:"duration"`
+	Error           string        `json:"error,omitempty"`
+}
+
+func (c checkpoint) result() SendResult {
+	result := SendResult{ID: c.ID, Tags: c.Tags, NotificationIDs: c.NotificationIDs, Duration: c.Duration}
+	if c.Error != "" {
+		result.Err = errors.New(c.Error)
+	}
+
+	return result
+}
+
+// retryable reports whether a failed chunk is left for resume instead of being checkpointed.
+func retryable(err error) bool {
+	return err != nil && ClassifyError(err).Has(ErrorClassOutage|ErrorClassCanceled)
+}
+
+// Run stores and runs a new campaign, see Client.RunCampaign.
+// Running a campaign ID which was already started is an error, resume it instead.
+func (r *CampaignRunner) Run(ctx context.Context, campaign Campaign) (*CampaignReport, error) {
+	if err := campaign.Validate(); err != nil {
+		return nil, err
+	}
+
+	if campaign.Template != nil {
+		return nil, fmt.Errorf("personalized campaign: %s cannot be checkpointed, run it with Client.RunCampaign", campaign.ID)
+	}
+
+	if strings.Contains(campaign.ID, "/") {
+		return nil, fmt.Errorf("checkpointed campaign ID cannot contain '/': %s", campaign.ID)
+	}
+
+	key := campaignKeyPrefix + campaign.ID + "/campaign"
+	if _, err := r.store.Get(ctx, key); err == nil {
+		return nil, fmt.Errorf("campaign: %s already started, resume it instead", campaign.ID)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to get campaign: %s: %w", campaign.ID, err)
+	}
+
+	value, err := json.Marshal(campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.store.Put(ctx, key, value); err != nil {
+		return nil, fmt.Errorf("failed to store campaign: %s: %w", campaign.ID, err)
+	}
+
+	return r.run(ctx, campaign, nil)
+}
+
+// Resume continues a stored campaign where it left off: its checkpointed chunks are not sent again,
+// the ones which failed with a retryable error and the remaining ones are sent.
+// The report covers the whole campaign, including the chunks sent by the previous runs.
+// Resuming a finished campaign sends nothing and returns its report.
+func (r *CampaignRunner) Resume(ctx context.Context, campaignID string) (*CampaignReport, error) {
+	value, err := r.store.Get(ctx, campaignKeyPrefix+campaignID+"/campaign")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign: %s: %w", campaignID, err)
+	}
+
+	var campaign Campaign
+	if err = json.Unmarshal(value, &campaign); err != nil {
+		return nil, fmt.Errorf("invalid stored campaign: %s: %w", campaignID, err)
+	}
+
+	prefix := campaignKeyPrefix + campaignID + "/chunks/"
+	entries, err := r.store.List(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load campaign checkpoints: %s: %w", campaignID, err)
+	}
+
+	done := make(map[string]SendResult, len(entries))
+	for _, entry := range entries {
+		var c checkpoint
+		if err = json.Unmarshal(entry.Value, &c); err != nil {
+			return nil, fmt.Errorf("invalid campaign checkpoint: %s: %w", entry.Key, err)
+		}
+		done[c.ID] = c.result()
+	}
+
+	return r.run(ctx, campaign, done)
+}
+
+// Pending returns the IDs of the started campaigns which did not finish, sorted.
+func (r *CampaignRunner) Pending(ctx context.Context) ([]string, error) {
+	entries, err := r.store.List(ctx, campaignKeyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaigns: %w", err)
+	}
+
+	var (
+		started  []string
+		finished = make(map[string]struct{})
+	)
+	for _, entry := range entries {
+		id, name, _ := strings.Cut(strings.TrimPrefix(entry.Key, campaignKeyPrefix), "/")
+		switch name {
+		case "campaign":
+			started = append(started, id)
+		case "finished":
+			finished[id] = struct{}{}
+		}
+	}
+
+	pending := slices.DeleteFunc(started, func(id string) bool {
+		_, ok := finished[id]
+		return ok
+	})
+	slices.Sort(pending)
+
+	return pending, nil
+}
+
+func (r *CampaignRunner) run(ctx context.Context, campaign Campaign, done map[string]SendResult) (*CampaignReport, error) {
+	var (
+		mu       sync.Mutex
+		errs     []error
+		prefix   = campaignKeyPrefix + campaign.ID + "/chunks/"
+		storeCtx = context.WithoutCancel(ctx) // the checkpoints of the last sends are stored on shutdown too.
+	)
+
+	onResult := func(result SendResult) {
+		if retryable(result.Err) {
+			return // failed by an outage or interrupted, resend it on resume.
+		}
+
+		c := checkpoint{ID: result.ID, Tags: result.Tags, NotificationIDs: result.NotificationIDs, Duration: result.Duration}
+		if result.Err != nil {
+			c.Error = result.Err.Error()
+		}
+		value, err := json.Marshal(c)
+		if err == nil {
+			err = r.store.Put(storeCtx, prefix+result.ID, value)
+		}
+
+		if err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("failed to store campaign checkpoint: %s: %w", result.ID, err))
+			mu.Unlock()
+		}
+	}
+
+	report, err := r.client.runCampaign(ctx, campaign, done, onResult)
+	if err == nil && !slices.ContainsFunc(report.Failures, func(result SendResult) bool { return retryable(result.Err) }) {
+		// all chunks were sent or failed permanently.
+		finished, _ := json.Marshal(report.FinishedAt)
+		err = r.store.Put(storeCtx, campaignKeyPrefix+campaign.ID+"/finished", finished)
+	}
+
+	return report, errors.Join(append(errs, err)...)
+}
@@ -0,0 +1,185 @@
+package azurepush_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestCampaignRunner_Resume(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	store := azurepush.NewMemoryStore()
+	runner := azurepush.NewCampaignRunner(srv.NewClient(), store)
+
+	tags := make([]string, 10)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("user:%d", i)
+	}
+
+	campaign := azurepush.Campaign{
+		ID:        "black-friday",
+		Audience:  azurepush.Audience{Tags: tags},
+		Message:   azurepush.Notification{Title: "Black Friday", Body: "Up to 70% off"},
+		ChunkSize: 1,
+		Drip:      500 * time.Millisecond,
+	}
+
+	// Interrupted run, e.g. by a crash.
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Millisecond)
+	defer cancel()
+
+	if _, err := runner.Run(ctx, campaign); err == nil {
+		t.Fatal("expected the context error")
+	}
+
+	pending, err := runner.Pending(context.Background())
+	if err != nil || len(pending) != 1 || pending[0] != campaign.ID {
+		t.Fatalf("expected the campaign to be pending, got: %v, error: %v", pending, err)
+	}
+
+	checkpoints, _ := store.List(context.Background(), "campaigns/black-friday/chunks/")
+	if len(checkpoints) == 0 || len(checkpoints) == len(tags) {
+		t.Fatalf("expected a partial progress, got: %d checkpoints", len(checkpoints))
+	}
+
+	if _, err = runner.Run(context.Background(), campaign); err == nil {
+		t.Error("expected an error running a started campaign")
+	}
+
+	sentBefore := len(srv.AllSent())
+
+	// A new runner, e.g. after a restart.
+	runner = azurepush.NewCampaignRunner(srv.NewClient(), store)
+	report, err := runner.Resume(context.Background(), campaign.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Sends != len(tags) || report.Succeeded != len(tags) || report.Next != len(tags) {
+		t.Errorf("expected the report to cover the whole campaign, got: %+v", report)
+	}
+	if resent := (len(srv.AllSent()) - sentBefore) / 2; resent != len(tags)-len(checkpoints) {
+		t.Errorf("expected only the remaining %d chunks to be sent, got: %d", len(tags)-len(checkpoints), resent)
+	}
+	for _, tag := range tags {
+		if len(srv.Sent(tag)) == 0 {
+			t.Errorf("expected tag: %s to be sent", tag)
+		}
+	}
+
+	if pending, _ = runner.Pending(context.Background()); len(pending) != 0 {
+		t.Errorf("expected no pending campaigns, got: %v", pending)
+	}
+
+	// Resuming a finished campaign sends nothing.
+	sentBefore = len(srv.AllSent())
+	if report, err = runner.Resume(context.Background(), campaign.ID); err != nil || report.Sends != len(tags) {
+		t.Fatalf("unexpected report: %+v, error: %v", report, err)
+	}
+	if len(srv.AllSent()) != sentBefore {
+		t.Error("expected a finished campaign not to be sent again")
+	}
+}
+
+func TestCampaignRunner_ResumeFailed(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	store := azurepush.NewMemoryStore()
+	runner := azurepush.NewCampaignRunner(srv.NewClient(), store)
+
+	campaign := azurepush.Campaign{
+		ID:        "outage",
+		Audience:  azurepush.Audience{Tags: []string{"user:1", "user:2", "user:3"}},
+		Message:   azurepush.Notification{Title: "Game on"},
+		ChunkSize: 1,
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusServiceUnavailable, "outage")
+	report, err := runner.Run(context.Background(), campaign)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Failed != 3 {
+		t.Fatalf("expected 3 failed chunks, got: %+v", report)
+	}
+
+	if checkpoints, _ := store.List(context.Background(), "campaigns/outage/chunks/"); len(checkpoints) != 0 {
+		t.Errorf("expected the failed chunks not to be checkpointed, got: %d", len(checkpoints))
+	}
+	if pending, _ := runner.Pending(context.Background()); len(pending) != 1 {
+		t.Fatalf("expected the failed campaign to be pending, got: %v", pending)
+	}
+
+	srv.ClearErrors()
+	if report, err = runner.Resume(context.Background(), campaign.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Succeeded != 3 || report.Failed != 0 {
+		t.Errorf("expected the failed chunks to be retried, got: %+v", report)
+	}
+	for _, tag := range campaign.Audience.Tags {
+		if len(srv.Sent(tag)) == 0 {
+			t.Errorf("expected tag: %s to be sent", tag)
+		}
+	}
+	if pending, _ := runner.Pending(context.Background()); len(pending) != 0 {
+		t.Errorf("expected no pending campaigns, got: %v", pending)
+	}
+}
+
+func TestCampaignRunner_PermanentFailures(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	store := azurepush.NewMemoryStore()
+	runner := azurepush.NewCampaignRunner(srv.NewClient(), store)
+
+	campaign := azurepush.Campaign{
+		ID:        "rejected",
+		Audience:  azurepush.Audience{Tags: []string{"user:1", "user:2"}},
+		Message:   azurepush.Notification{Title: "Game on"},
+		ChunkSize: 1,
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusBadRequest, "invalid payload")
+	report, err := runner.Run(context.Background(), campaign)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Failed != 2 {
+		t.Fatalf("expected 2 failed chunks, got: %+v", report)
+	}
+
+	if checkpoints, _ := store.List(context.Background(), "campaigns/rejected/chunks/"); len(checkpoints) != 2 {
+		t.Errorf("expected the permanently failed chunks to be checkpointed, got: %d", len(checkpoints))
+	}
+	if pending, _ := runner.Pending(context.Background()); len(pending) != 0 {
+		t.Fatalf("expected the campaign to be finished, got pending: %v", pending)
+	}
+
+	// Resuming sends nothing and reports the stored failures with their errors.
+	srv.ClearErrors()
+	if report, err = runner.Resume(context.Background(), campaign.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Failed != 2 || report.Succeeded != 0 {
+		t.Fatalf("expected the stored failures, got: %+v", report)
+	}
+	for _, failure := range report.Failures {
+		if failure.Err == nil || !strings.Contains(failure.Err.Error(), "invalid payload") {
+			t.Errorf("expected the stored error of chunk: %s, got: %v", failure.ID, failure.Err)
+		}
+	}
+	if sent := srv.AllSent(); len(sent) != 0 {
+		t.Errorf("expected no sends, got: %d", len(sent))
+	}
+}
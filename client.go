@@ -1,15 +1,16 @@
 package azurepush
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"maps"
 	"net/http"
+	neturl "net/url"
+	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,11 +30,55 @@ type Client struct {
 	Config       Configuration
 	TokenManager *TokenManager
 
+	// TokenProvider, if not nil, overrides the TokenManager as the source of SAS tokens.
+	// It is kept on Reload.
+	TokenProvider TokenProvider
+
+	// Audit, if not nil, receives a record of every mutating operation (registrations, deletions, tag changes and sends).
+	// It is kept on Reload.
+	Audit AuditSink
+
 	// HTTPClient is the client used for HTTP requests.
 	// It can be overridden for testing.
 	HTTPClient *http.Client
+
+	// sharedHTTPClient reports whether the HTTPClient is shared with other clients (see ClientPool),
+	// so Close keeps its idle connections.
+	sharedHTTPClient bool
+
+	// sendTokenManager signs the sends when the configuration has a send access policy (see Configuration.SendKeyName).
+	sendTokenManager *TokenManager
+
+	// mu protects Config, TokenManager, sendTokenManager, TokenProvider, tier and limit against concurrent Reload calls.
+	mu    sync.RWMutex
+	tier  Tier
+	clock Clock
+	limit concurrencyLimit // see Configuration.MaxConcurrentRequests.
+
+	health    clientHealth    // see ReadinessHandler and LivenessHandler.
+	stats     clientStats     // see Stats.
+	lifecycle clientLifecycle // see Close.
+	rotation  sync.Mutex      // serializes RotateCredentials.
 }
 
+// HubClient describes the data-plane operations of a Notification Hub client.
+// Application code can depend on HubClient instead of *Client
+// so it can be unit tested with mocks.
+//
+// It is implemented by *Client and *FailoverClient.
+type HubClient interface {
+	ValidateToken(ctx context.Context) error
+	RegisterDevice(ctx context.Context, installation Installation) (string, error)
+	DeviceExists(ctx context.Context, installationID string) (bool, error)
+	DeleteDevice(ctx context.Context, installationID string) error
+	SendNotification(ctx context.Context, notification Notification, tags ...string) error
+}
+
+var (
+	_ HubClient = (*Client)(nil)
+	_ HubClient = (*FailoverClient)(nil)
+)
+
 // NewClient creates and validates a new push notification client.
 // NewClient creates a new Azure Notification Hub client with the given configuration.
 // It automatically initializes a TokenManager for SAS token generation and reuse.
@@ -45,43 +90,229 @@ type Client struct {
 //	client := azurepush.NewClient(azureCfg)
 //	err := client.SendNotification(context.Background(), notification, "user:42")
 func NewClient(cfg Configuration) *Client {
-	if err := cfg.Validate(); err != nil {
+	client, err := newClient(context.Background(), cfg, nil)
+	if err != nil {
 		panic(err)
 	}
 
+	return client
+}
+
+// newClient creates a new client, it is shared by NewClient and the ClientPool.
+// A nil httpClient defaults to NewHTTPClient, a non-nil one is shared with other clients.
+func newClient(ctx context.Context, cfg Configuration, httpClient *http.Client) (*Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
 	client := &Client{
-		Config:       cfg,
-		TokenManager: NewTokenManager(cfg),
-		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		Config:           cfg,
+		TokenManager:     NewTokenManager(cfg),
+		sendTokenManager: newSendTokenManager(cfg),
+		HTTPClient:       httpClient,
+		sharedHTTPClient: httpClient != nil,
+		tier:             cfg.Tier,
+		limit:            newConcurrencyLimit(cfg.MaxConcurrentRequests),
+	}
+	if client.HTTPClient == nil {
+		client.HTTPClient = NewHTTPClient(cfg)
 	}
 
 	if cfg.ConnectivityCheck {
-		ctx, cancelFunc := context.WithTimeout(context.Background(), 15*time.Second)
+		ctx, cancelFunc := context.WithTimeout(ctx, 15*time.Second)
 		defer cancelFunc()
 
 		if err := client.ValidateToken(ctx); err != nil {
-			panic(err)
+			return nil, err
+		}
+	}
+
+	if cfg.WarmConnections > 0 {
+		ctx, cancelFunc := context.WithTimeout(ctx, 15*time.Second)
+		defer cancelFunc()
+
+		_ = client.Warm(ctx, cfg.WarmConnections) // best effort.
+	}
+
+	return client, nil
+}
+
+// Reload validates and swaps the client's configuration at runtime,
+// e.g. after a key rotation (see ManagementClient.RegenerateKey).
+// The TokenManager is reconfigured (see TokenManager.Reconfigure), so its cached tokens are stale
+// and the next request is signed with the new credentials; its rotation hooks are kept.
+//
+// It is safe to call Reload while other goroutines are using the client.
+func (c *Client) Reload(cfg Configuration) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.Config = cfg
+	if c.TokenManager != nil {
+		c.TokenManager.Reconfigure(cfg)
+	} else {
+		c.TokenManager = NewTokenManager(cfg)
+	}
+	switch {
+	case !cfg.sendPolicy():
+		c.sendTokenManager = nil
+	case c.sendTokenManager != nil:
+		c.sendTokenManager.Reconfigure(cfg.sendConfiguration())
+	default:
+		c.sendTokenManager = newSendTokenManager(cfg)
+	}
+	if c.clock != nil {
+		c.TokenManager.SetClock(c.clock)
+		if c.sendTokenManager != nil {
+			c.sendTokenManager.SetClock(c.clock)
 		}
+	}
+	if cfg.Tier != TierUnknown {
+		c.tier = cfg.Tier
+	}
+	if cfg.MaxConcurrentRequests != cap(c.limit) {
+		c.limit = newConcurrencyLimit(cfg.MaxConcurrentRequests) // the in-flight requests release their old slots.
+	}
+	c.mu.Unlock()
 
+	return nil
+}
+
+// SetClock replaces the clock of the client's TokenManager, now and after Reload.
+// It is meant for tests, see the azurepushtest.FakeClock.
+func (c *Client) SetClock(clock Clock) {
+	c.mu.Lock()
+	c.clock = clock
+	c.TokenManager.SetClock(clock)
+	if c.sendTokenManager != nil {
+		c.sendTokenManager.SetClock(clock)
 	}
+	c.mu.Unlock()
+}
 
-	return client
+// now returns the current time of the client's clock, see SetClock.
+func (c *Client) now() time.Time {
+	c.mu.RLock()
+	clock := c.clock
+	c.mu.RUnlock()
+
+	if clock == nil {
+		clock = SystemClock
+	}
+	return clock.Now()
+}
+
+// current returns the active configuration and token provider.
+func (c *Client) current() (Configuration, TokenProvider) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.TokenProvider != nil {
+		return c.Config, c.TokenProvider
+	}
+
+	return c.Config, c.TokenManager
+}
+
+// currentSend returns the active configuration and the token provider of the sends,
+// the send access policy's TokenManager if configured.
+func (c *Client) currentSend() (Configuration, TokenProvider) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.TokenProvider != nil {
+		return c.Config, c.TokenProvider
+	}
+
+	if c.sendTokenManager != nil {
+		return c.Config, c.sendTokenManager
+	}
+
+	return c.Config, c.TokenManager
+}
+
+// hubURL returns the URL of a resource of the hub's REST API, built by hubPath, with the api-version query parameter
+// (omitted if empty). All the hub requests are built with it, so their IDs and tags are escaped.
+//
+//	url := cfg.hubURL(hubPath("installations", installationID), "2020-06")
+func (cfg Configuration) hubURL(resource, apiVersion string) string {
+	url := "https://" + cfg.Namespace + ".servicebus.windows.net/" + escapePathSegment(cfg.HubName) + "/" + resource
+	if apiVersion != "" {
+		url += "?api-version=" + neturl.QueryEscape(apiVersion)
+	}
+
+	return url
+}
+
+// hubPath joins the escaped path segments of a hub resource, e.g. hubPath("tags", tag, "registrations").
+func hubPath(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = escapePathSegment(segment)
+	}
+
+	return strings.Join(escaped, "/")
+}
+
+// escapePathSegment escapes a path segment, e.g. an installation ID with spaces, '/' or non-ASCII characters.
+// Unlike url.PathEscape it escapes '+' too, which the hub's front end would read as a space.
+func escapePathSegment(segment string) string {
+	return strings.ReplaceAll(neturl.PathEscape(segment), "+", "%2B")
+}
+
+// newSendTokenManager returns the TokenManager of the configuration's send access policy, if any.
+func newSendTokenManager(cfg Configuration) *TokenManager {
+	if !cfg.sendPolicy() {
+		return nil
+	}
+
+	return NewTokenManager(cfg.sendConfiguration())
 }
 
+// InstallationPlatform is the platform of a device installation, e.g. "apns".
+// Note that the hub names the platforms of the installations differently than the notification formats
+// of the sends, see the Format method and the Platform type.
+type InstallationPlatform string
+
 // Installation platform types for Azure Notification Hubs.
 const (
 	// InstallationApple is the platform type for Apple devices (iOS/APNs).
-	InstallationApple = "apns"
+	InstallationApple InstallationPlatform = "apns"
 	// InstallationFCMV1 is the platform type for Android devices (Firebase Cloud Messaging v1).
-	InstallationFCMV1 = "FCMV1"
+	InstallationFCMV1 InstallationPlatform = "FCMV1"
 	// InstallationBaidu is the platform type for Baidu Push.
-	InstallationBaidu = "baidu"
+	InstallationBaidu InstallationPlatform = "baidu"
 	// InstallationWNS is the platform type for Windows Notification Service.
-	InstallationWNS = "wns"
+	InstallationWNS InstallationPlatform = "wns"
 	// InstallationMPNS is the platform type for Microsoft Push Notification Service.
-	InstallationMPNS = "mpns"
+	InstallationMPNS InstallationPlatform = "mpns"
+	// InstallationADM is the platform type for Amazon Device Messaging (Fire OS devices).
+	InstallationADM InstallationPlatform = "adm"
 )
 
+// Format returns the notification format of the platform's devices, e.g. PlatformApple for InstallationApple,
+// or an empty Platform for an unknown platform.
+func (p InstallationPlatform) Format() Platform {
+	switch p {
+	case InstallationApple:
+		return PlatformApple
+	case InstallationFCMV1:
+		return PlatformFCMV1
+	case InstallationBaidu:
+		return PlatformBaidu
+	case InstallationWNS:
+		return PlatformWindows
+	case InstallationMPNS:
+		return PlatformWindowsPhone
+	case InstallationADM:
+		return PlatformADM
+	default:
+		return ""
+	}
+}
+
 type (
 	// Installation represents a device installation for Azure Notification Hubs.
 	Installation struct {
@@ -96,7 +327,8 @@ type (
 		// Baidu	| "baidu"
 		// WNS		| "wns"
 		// MPNS		| "mpns"
-		Platform string `json:"platform"`
+		// ADM		| "adm"
+		Platform InstallationPlatform `json:"platform"`
 
 		// PushChannel is the device-specific token to receive notifications.
 		// For APNs: the device token from Apple.
@@ -111,6 +343,11 @@ type (
 		// Templates defines push notification templates for the device.
 		// This is optional and only needed for advanced templated notifications.
 		Templates map[string]Template `json:"templates,omitempty"`
+
+		// extra holds the JSON fields unknown to the struct, see MarshalJSON.
+		extra map[string]json.RawMessage
+		// etag is the version of the installation read by GetInstallation, see ETag.
+		etag string
 	}
 
 	// Template is used for advanced push templates (optional).
@@ -123,10 +360,10 @@ type (
 // Validate checks if the installation has all required fields set.
 func (i Installation) Validate() error {
 	switch i.Platform {
-	case InstallationApple, InstallationFCMV1, InstallationBaidu, InstallationWNS, InstallationMPNS:
+	case InstallationApple, InstallationFCMV1, InstallationBaidu, InstallationWNS, InstallationMPNS, InstallationADM:
 		// OK
 	default:
-		return fmt.Errorf("invalid platform: %q (must be 'apns', 'FCMV1', 'baidu', 'wns' or 'mpns')", i.Platform)
+		return fmt.Errorf("invalid platform: %q (must be 'apns', 'FCMV1', 'baidu', 'wns', 'mpns' or 'adm')", i.Platform)
 	}
 	if i.InstallationID == "" {
 		return fmt.Errorf("installation ID is required")
@@ -138,15 +375,45 @@ func (i Installation) Validate() error {
 }
 
 // ValidateToken performs a simple GET request to a dummy installation ID
-// to verify if the SAS token is valid and authorized.
-// Returns nil if authorized (even if installation doesn't exist), or an error if unauthorized.
+// to verify if the SAS token is valid and authorized, see ValidateSASToken.
+// Returns nil if authorized (even if installation doesn't exist), or an *UnauthorizedError if unauthorized.
 func (c *Client) ValidateToken(ctx context.Context) error {
-	token, err := c.TokenManager.GetToken()
+	done, err := c.begin()
 	if err != nil {
 		return err
 	}
+	defer done()
 
-	return ValidateSASToken(ctx, c.HTTPClient, c.Config.Namespace, c.Config.HubName, token)
+	cfg, tm := c.current()
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return err
+	}
+
+	release, err := c.concurrencyLimit().acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for a request slot: %w", err)
+	}
+	defer release()
+
+	result, err := ValidateSASToken(ctx, ValidateSASTokenOptions{
+		Token:      token,
+		Namespace:  cfg.Namespace,
+		HubName:    cfg.HubName,
+		HTTPClient: c.HTTPClient,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = result.Err(); err != nil {
+		c.health.lastSuccess.Store(0)
+		return err
+	}
+
+	c.health.lastSuccess.Store(c.now().UnixNano())
+	return nil
 }
 
 // RegisterDevice registers a device installation with Azure Notification Hubs.
@@ -155,7 +422,21 @@ func (c *Client) ValidateToken(ctx context.Context) error {
 // You use the tags you assign during registration to send notifications, as this is how you target specific devices.
 // For example, if you register a device with the tag "user:123", you can send a notification to that device
 // by targeting the "user:123" tag.
-func (c *Client) RegisterDevice(ctx context.Context, installation Installation) (string, error) {
+//
+// If the installation has an ETag (e.g. it was read by GetInstallation) it is only replaced
+// if it was not modified meanwhile, otherwise a *PreconditionFailedError is returned.
+func (c *Client) RegisterDevice(ctx context.Context, installation Installation) (_ string, err error) {
+	cfg, tm := c.current()
+	defer func() {
+		c.audit(ctx, AuditRecord{
+			Operation:      AuditRegister,
+			InstallationID: installation.InstallationID,
+			Platform:       installation.Platform,
+			PushChannel:    RedactSecret(installation.PushChannel),
+			Tags:           installation.Tags,
+		}, err)
+	}()
+
 	if installation.InstallationID == "" {
 		// Azure doesn't return an InstallationID
 		// It's a "create-or-replace" operation: PUT /installations/{installationId}
@@ -167,215 +448,289 @@ func (c *Client) RegisterDevice(ctx context.Context, installation Installation)
 		return "", fmt.Errorf("invalid installation data: %w", err)
 	}
 
-	token, err := c.TokenManager.GetToken()
+	token, err := tm.GetToken()
 	if err != nil {
 		return "", fmt.Errorf("failed to get SAS token: %w", err)
 	}
 
-	jsonData, err := json.Marshal(installation)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal installation: %w", err)
-	}
-
-	url := fmt.Sprintf("https://%s.servicebus.windows.net/%s/installations/%s?api-version=2020-06",
-		c.Config.Namespace, c.Config.HubName, installation.InstallationID)
+	url := cfg.hubURL(hubPath("installations", installation.InstallationID), "2020-06")
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
+	setJSONStreamBody(req, installation)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", token)
+	if installation.etag != "" {
+		req.Header.Set("If-Match", installation.etag)
+	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to send registration: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusPreconditionFailed {
+		return "", &PreconditionFailedError{InstallationID: installation.InstallationID, ETag: installation.etag}
+	}
+
 	if resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("registration failed: installation: %s: %s: %s", string(jsonData), resp.Status, string(b))
+		// Registrations are available on every tier, only the active devices quota is reported.
+		if err = tierErrorFromResponse("", TierUnknown, c.Tier(), resp.StatusCode, string(b)); err != nil {
+			return "", fmt.Errorf("registration failed: %w", err)
+		}
+		return "", fmt.Errorf("registration failed: installation: %s: %s: %s", installation.InstallationID, resp.Status, string(b))
 	}
 
 	return installation.InstallationID, nil
 }
 
-// Notification holds the title, body and custom data for a notification sent to both iOS and Android.
-type Notification struct {
-	Title string
-	Body  string
-	Data  map[string]any // any custom data.
-}
-
 // SendNotification sends a cross-platform push notification to all devices for a given user (e.g. tag with "user:42").
 func (c *Client) SendNotification(ctx context.Context, notification Notification, tags ...string) error {
-	token, err := c.TokenManager.GetToken()
-	if err != nil {
-		return fmt.Errorf("failed to get SAS token: %w", err)
-	}
-
-	msg := notificationMessage{
-		Title: notification.Title,
-		Body:  notification.Body,
-	}
+	_, err := c.sendNotification(ctx, notification, SendOptions{}, tags...)
+	return err
+}
 
-	noDevices := 0
-	for _, platform := range availablePlatforms {
-		if err := sendPlatformNotification(ctx, c.HTTPClient, c.Config.HubName, c.Config.Namespace, token, platform, msg, notification.Data, tags...); err != nil {
-			if errors.Is(err, errDeviceNotFound) {
-				noDevices++
-				continue // skip if no devices found. Unless both platforms fail.
-			}
+// SendOptions holds the optional settings of a single send, see SendNotificationWithOptions.
+type SendOptions struct {
+	// PNSHeaders are extra headers attached to the send requests of all platforms,
+	// the hub forwards the supported ones to the push notification services
+	// (e.g. "apns-priority", "apns-collapse-id" or "X-WNS-TTL").
+	// They allow using new PNS features before they are supported by this package.
+	//
+	// The headers set by the client (Authorization, Content-Type and ServiceBusNotification-*) cannot be overridden.
+	PNSHeaders map[string]string
+
+	// ScheduleTime, if not zero, schedules the notification to be sent at that time
+	// instead of immediately. Scheduled sends require the Standard tier,
+	// a lower tier is reported with a *TierError.
+	ScheduleTime time.Time
+}
 
-			return err
+// validate checks that the options do not override the headers set by the client.
+func (opts SendOptions) validate() error {
+	for key := range opts.PNSHeaders {
+		switch key = http.CanonicalHeaderKey(key); {
+		case key == "Authorization", key == "Content-Type", strings.HasPrefix(key, "Servicebusnotification-"):
+			return fmt.Errorf("invalid send options: PNS header %q is set by the client", key)
 		}
 	}
 
-	if noDevices == len(availablePlatforms) {
-		return fmt.Errorf("%w: for tag(s): %s", errDeviceNotFound, strings.Join(tags, ", "))
-	}
-
 	return nil
 }
 
-type notificationMessage struct {
-	Title string `json:"title"`
-	Body  string `json:"body"`
+// SendNotificationWithOptions is like SendNotification but it accepts send options,
+// e.g. headers forwarded to the push notification services.
+//
+// Example usage:
+//
+//	err := client.SendNotificationWithOptions(ctx, notification, azurepush.SendOptions{
+//		PNSHeaders: map[string]string{"apns-priority": "5"},
+//	}, "user:42")
+func (c *Client) SendNotificationWithOptions(ctx context.Context, notification Notification, opts SendOptions, tags ...string) error {
+	_, err := c.sendNotification(ctx, notification, opts, tags...)
+	return err
 }
 
-// appleNotificationWithData allows embedding custom data alongside the APS payload.
-type appleNotificationWithData map[string]interface{}
-
-// fcmV1NotificationPayload is the Azure NH wrapper for FCMv1.
-// FCMv1 requires the payload under a "message" object with string-only data values.
-// See: https://learn.microsoft.com/en-us/azure/notification-hubs/firebase-migration-rest
-type fcmV1NotificationPayload struct {
-	Message fcmV1Message `json:"message"`
+// SendNotificationWithIDs is like SendNotificationWithOptions but it returns the IDs of the sent notifications,
+// one per platform, as reported by the hub (Standard tier only, the lower tiers report none).
+// They identify the notifications of GetNotificationTelemetry.
+//
+// Example usage:
+//
+//	ids, err := client.SendNotificationWithIDs(ctx, notification, azurepush.SendOptions{}, "user:42")
+func (c *Client) SendNotificationWithIDs(ctx context.Context, notification Notification, opts SendOptions, tags ...string) ([]string, error) {
+	return c.sendNotification(ctx, notification, opts, tags...)
 }
 
-type fcmV1Message struct {
-	Notification notificationMessage `json:"notification"`
-	Android      *fcmV1Android       `json:"android,omitempty"`
-}
+// sendNotification sends the notification to all platforms and returns the IDs of the sent notifications,
+// as reported by the hub (Standard tier only, see https://learn.microsoft.com/en-us/rest/api/notificationhubs/get-notification-message-telemetry).
+func (c *Client) sendNotification(ctx context.Context, notification Notification, opts SendOptions, tags ...string) (ids []string, err error) {
+	defer func() {
+		c.stats.send(c.now(), err)
+		c.audit(ctx, AuditRecord{Operation: AuditSend, Tags: tags, Notification: &notification, NotificationIDs: ids}, err)
+	}()
 
-type fcmV1Android struct {
-	Data map[string]string `json:"data,omitempty"`
-}
+	// The send is drained by Close as a whole, the requests of all its platforms are sent.
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 
-// toStringMap converts map[string]any to map[string]string for FCMv1 compatibility.
-func toStringMap(m map[string]any) map[string]string {
-	if len(m) == 0 {
-		return nil
+	cfg, tm := c.currentSend()
+	platforms := cfg.sendPlatforms()
+
+	if err := notification.validateFor(platforms); err != nil {
+		return nil, err
 	}
-	result := make(map[string]string, len(m))
-	for k, v := range m {
-		result[k] = fmt.Sprintf("%v", v)
+
+	if err := opts.validate(); err != nil {
+		return nil, err
 	}
-	return result
-}
 
-const (
-	applePlatform = "apple"
-	fcmV1Platform = "fcmV1"
-)
+	if err := validateSendTags(tags); err != nil {
+		return nil, err
+	}
+
+	resource := "messages/"
+	if !opts.ScheduleTime.IsZero() {
+		if err := c.RequireTier(FeatureScheduledSend, TierStandard); err != nil {
+			return nil, err
+		}
+		resource = "schedulednotifications/"
+	}
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAS token: %w", err)
+	}
 
-var availablePlatforms = []string{applePlatform, fcmV1Platform}
+	url := cfg.hubURL(resource, "2020-06")
+	tagExpression := strings.Join(tags, ",")
+
+	noDevices := 0
+	for _, platform := range platforms {
+		id, err := sendPlatformNotification(ctx, c.roundTrip, url, token, platform, notification, tagExpression, opts, c.Tier())
+		if err != nil {
+			if errors.Is(err, errDeviceNotFound) {
+				noDevices++
+				continue // skip if no devices found. Unless all platforms fail.
+			}
+
+			return ids, err
+		}
+
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	if noDevices == len(platforms) {
+		return nil, fmt.Errorf("%w: for tag(s): %s", errDeviceNotFound, strings.Join(tags, ", "))
+	}
+
+	return ids, nil
+}
 
 var errDeviceNotFound = fmt.Errorf("no device found")
 
 // sendPlatformNotification sends a platform-specific push notification.
 // Usage:
 //
-//	_ = sendPlatformNotification(ctx, client, hubName, namespace, token, "fcmV1", msg, map[string]any{
-//		"type":     "chat_message",
-//		"threadId": "abc123",
-//	}, "user:42")
+//	id, err := sendPlatformNotification(ctx, c.roundTrip, messagesURL, token, PlatformFCMV1, Notification{
+//		Title: "New message",
+//		Data: map[string]any{
+//			"type":     "chat_message",
+//			"threadId": "abc123",
+//		},
+//	}, "user:42", SendOptions{}, c.Tier())
 func sendPlatformNotification(
 	ctx context.Context,
-	client *http.Client,
-	hubName, namespace, sasToken, platform string,
-	msg notificationMessage,
-	data map[string]any,
-	tags ...string,
-) error {
-	var (
-		payload []byte
-		err     error
-	)
-
-	switch platform {
-	case applePlatform:
-		// APNs supports custom fields alongside "aps"
-		apnsPayload := appleNotificationWithData{
-			"aps": map[string]any{
-				"alert": msg,
-				"sound": "default",
-			},
-		}
-		maps.Copy(apnsPayload, data)
-
-		payload, err = json.Marshal(apnsPayload)
-	case fcmV1Platform:
-		// FCMv1 requires message wrapper and string-only data values.
-		fcmV1Payload := fcmV1NotificationPayload{
-			Message: fcmV1Message{
-				Notification: msg,
-			},
-		}
-		if len(data) > 0 {
-			fcmV1Payload.Message.Android = &fcmV1Android{
-				Data: toStringMap(data),
-			}
-		}
-		payload, err = json.Marshal(fcmV1Payload)
-	default:
-		return fmt.Errorf("unsupported platform: %s", platform)
-	}
-
+	do func(*http.Request) (*http.Response, error),
+	url, sasToken string,
+	platform Platform,
+	notification Notification,
+	tagExpression string,
+	opts SendOptions,
+	tier Tier,
+) (string, error) {
+	// The payload is encoded into a pooled buffer,
+	// which is released once the request is done and the transport closed its body readers.
+	buf := getBuffer()
+	if err := notification.encode(platform, buf); err != nil {
+		putBuffer(buf)
+		return "", err
+	}
+	payload := newPooledPayload(buf)
+	defer payload.release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload for %s: %w", platform, err)
+		return "", fmt.Errorf("failed to create %s request: %w", platform, err)
 	}
+	payload.setBody(req)
 
-	url := fmt.Sprintf("https://%s.servicebus.windows.net/%s/messages/?api-version=2020-06", namespace, hubName)
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payload))
-	if err != nil {
-		return fmt.Errorf("failed to create %s request: %w", platform, err)
+	for key, value := range opts.PNSHeaders {
+		req.Header.Set(key, value)
 	}
-
-	req.Header.Set("Content-Type", "application/json")
+	setPlatformHeaders(req.Header.Set, platform)
 	req.Header.Set("Authorization", sasToken)
-	req.Header.Set("ServiceBusNotification-Format", platform)
-	req.Header.Set("ServiceBusNotification-Tags", strings.Join(tags, ","))
+	req.Header.Set("ServiceBusNotification-Format", string(platform))
+	req.Header.Set("ServiceBusNotification-Tags", tagExpression)
+	if !opts.ScheduleTime.IsZero() {
+		req.Header.Set("ServiceBusNotification-ScheduleTime", opts.ScheduleTime.UTC().Format("2006-01-02T15:04:05"))
+	}
 
-	resp, err := client.Do(req)
+	resp, err := do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send %s request: %w", platform, err)
+		return "", fmt.Errorf("failed to send %s request: %w", platform, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
-		return fmt.Errorf("%w: %s notification skipped", errDeviceNotFound, platform)
+		return "", fmt.Errorf("%w: %s notification skipped", errDeviceNotFound, platform)
 	}
 
 	if resp.StatusCode >= 300 {
 		// Bad request? invalid payload or missing required fields.
 		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to send %s notification with status: %d and body: %s", platform, resp.StatusCode, string(b))
+		// Immediate sends are available on every tier, only their quota is reported.
+		feature, required := "", TierUnknown
+		if !opts.ScheduleTime.IsZero() {
+			feature, required = FeatureScheduledSend, TierStandard
+		}
+		if err = tierErrorFromResponse(feature, required, tier, resp.StatusCode, string(b)); err != nil {
+			return "", fmt.Errorf("failed to send %s notification: %w", platform, err)
+		}
+		return "", &SendError{Platform: platform, StatusCode: resp.StatusCode, Body: string(b)}
 	}
-	return nil
+	return notificationID(resp.Header.Get("Location")), nil
+}
+
+// notificationID returns the notification ID of the Location header of a sent notification,
+// e.g. https://{namespace}.servicebus.windows.net/{hub}/messages/{id}?api-version=2020-06.
+func notificationID(location string) string {
+	u, err := neturl.Parse(location)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+
+	return path.Base(u.Path)
+}
+
+// setJSONStreamBody sets the request body to the JSON encoding of v, streamed through a pipe
+// instead of being marshaled fully into memory first (installations may hold many tags and templates).
+// GetBody is set as well, so the body can be replayed on retries and redirects.
+func setJSONStreamBody(req *http.Request, v any) {
+	getBody := func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			// The transport closes the reader on errors, which stops the encoder.
+			pw.CloseWithError(json.NewEncoder(pw).Encode(v))
+		}()
+		return pr, nil
+	}
+
+	req.Body, _ = getBody()
+	req.GetBody = getBody
+	req.ContentLength = -1 // unknown, sent with chunked transfer encoding.
 }
 
 // DeviceExists checks if a device installation with the given ID exists in Azure Notification Hub.
 // Returns true if the device is found (HTTP 200), false if not found (HTTP 404).
 func (c *Client) DeviceExists(ctx context.Context, installationID string) (bool, error) {
-	token, err := c.TokenManager.GetToken()
+	cfg, tm := c.current()
+
+	token, err := tm.GetToken()
 	if err != nil {
 		return false, err
 	}
 
-	url := fmt.Sprintf("https://%s.servicebus.windows.net/%s/installations/%s?api-version=2020-06",
-		c.Config.Namespace, c.Config.HubName, installationID)
+	url := cfg.hubURL(hubPath("installations", installationID), "2020-06")
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -383,7 +738,7 @@ func (c *Client) DeviceExists(ctx context.Context, installationID string) (bool,
 	}
 	req.Header.Set("Authorization", token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return false, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -410,19 +765,17 @@ func (c *Client) DeviceExists(ctx context.Context, installationID string) (bool,
 // Example:
 //
 //	err := client.DeleteDevice(context.Background(), "device-uuid-123")
-func (c *Client) DeleteDevice(ctx context.Context, installationID string) error {
+func (c *Client) DeleteDevice(ctx context.Context, installationID string) (err error) {
+	cfg, tm := c.current()
+	defer func() { c.audit(ctx, AuditRecord{Operation: AuditDelete, InstallationID: installationID}, err) }()
+
 	if installationID == "" {
 		return fmt.Errorf("installation ID cannot be empty")
 	}
 
-	url := fmt.Sprintf(
-		"https://%s.servicebus.windows.net/%s/installations/%s?api-version=2020-06",
-		c.Config.Namespace,
-		c.Config.HubName,
-		installationID,
-	)
+	url := cfg.hubURL(hubPath("installations", installationID), "2020-06")
 
-	token, err := c.TokenManager.GetToken()
+	token, err := tm.GetToken()
 	if err != nil {
 		return fmt.Errorf("failed to get SAS token: %w", err)
 	}
@@ -434,7 +787,7 @@ func (c *Client) DeleteDevice(ctx context.Context, installationID string) error
 
 	req.Header.Set("Authorization", token)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send DELETE request: %w", err)
 	}
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
 )
 
 const testConnectionString = "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=secret"
@@ -128,3 +132,240 @@ func TestClient_SendNotification_Mocked(t *testing.T) {
 		t.Errorf("expected 2 calls (one per platform), got: %d", calls)
 	}
 }
+
+func TestClient_SendNotificationWithOptions(t *testing.T) {
+	var formats []string
+	client := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		formats = append(formats, r.Header.Get("ServiceBusNotification-Format"))
+		if r.Header.Get("apns-priority") != "5" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	notification := azurepush.Notification{Title: "Hi", Body: "Hello"}
+	err := client.SendNotificationWithOptions(context.Background(), notification, azurepush.SendOptions{
+		PNSHeaders: map[string]string{"apns-priority": "5"},
+	}, "user:42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(formats) != 2 || formats[0] != "apple" || formats[1] != "fcmV1" {
+		t.Errorf("unexpected sends: %v", formats)
+	}
+
+	// The headers of the client cannot be overridden.
+	err = client.SendNotificationWithOptions(context.Background(), notification, azurepush.SendOptions{
+		PNSHeaders: map[string]string{"servicebusnotification-tags": "everyone"},
+	}, "user:42")
+	if err == nil || len(formats) != 2 {
+		t.Errorf("expected an invalid send options error without requests, got: %v", err)
+	}
+}
+
+func TestClient_SendNotification_SendPlatforms(t *testing.T) {
+	var formats []string
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+		SendPlatforms:    []azurepush.Platform{azurepush.PlatformApple, azurepush.PlatformFCMV1, azurepush.PlatformADM},
+	})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		formats = append(formats, r.Header.Get("ServiceBusNotification-Format"))
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	if err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(formats, []string{"apple", "fcmV1", "adm"}) {
+		t.Errorf("unexpected sends: %v", formats)
+	}
+
+	cfg := azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString, SendPlatforms: []azurepush.Platform{azurepush.PlatformBaidu}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unsupported send platform error")
+	}
+}
+
+func TestClient_SendPolicy(t *testing.T) {
+	signers := make(map[string]string)
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:              "hub",
+		ConnectionString:     testConnectionString,
+		SendConnectionString: "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=DefaultSendSharedAccessSignature;SharedAccessKey=send-secret",
+	})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		params, _ := url.ParseQuery(strings.TrimPrefix(r.Header.Get("Authorization"), "SharedAccessSignature "))
+		signers[r.Method] = params.Get("skn")
+		return jsonResponse(http.StatusOK, "")
+	})
+
+	ctx := context.Background()
+	if err := client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.RegisterDevice(ctx, azurepush.Installation{Platform: azurepush.InstallationApple, PushChannel: "token"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if signers[http.MethodPost] != "DefaultSendSharedAccessSignature" {
+		t.Errorf("expected the sends signed by the send policy, got: %s", signers[http.MethodPost])
+	}
+	if signers[http.MethodPut] != "DefaultFullSharedAccessSignature" {
+		t.Errorf("expected the registrations signed by the manage policy, got: %s", signers[http.MethodPut])
+	}
+
+	cfg := azurepush.Configuration{
+		HubName:              "hub",
+		ConnectionString:     testConnectionString,
+		SendConnectionString: "Endpoint=sb://other.servicebus.windows.net/;SharedAccessKeyName=DefaultSendSharedAccessSignature;SharedAccessKey=send-secret",
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a send policy of a different namespace error")
+	}
+
+	cfg = azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString, SendKeyName: "DefaultSendSharedAccessSignature"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected a missing send key value error")
+	}
+}
+
+func TestClient_RegisterDevice_StreamedBody(t *testing.T) {
+	installation := azurepush.Installation{
+		InstallationID: "test-device",
+		Platform:       azurepush.InstallationApple,
+		PushChannel:    "mock-token",
+		Tags:           []string{"user:42", "topic:news"},
+	}
+	expected, _ := json.Marshal(installation)
+
+	httpClient := mockHTTPClient(func(r *http.Request) *http.Response {
+		body, _ := io.ReadAll(r.Body)
+		if string(bytes.TrimSpace(body)) != string(expected) {
+			t.Errorf("unexpected body: %s", body)
+		}
+
+		if r.GetBody == nil {
+			t.Fatal("expected GetBody to be set for replays")
+		}
+		replay, _ := r.GetBody()
+		replayed, _ := io.ReadAll(replay)
+		if string(replayed) != string(body) {
+			t.Errorf("expected replayed body to match, got: %s", replayed)
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}
+	})
+
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+	client.HTTPClient = httpClient
+
+	if _, err := client.RegisterDevice(context.Background(), installation); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_SendNotification_ReplayableBody(t *testing.T) {
+	httpClient := mockHTTPClient(func(r *http.Request) *http.Response {
+		defer r.Body.Close()
+		body, _ := io.ReadAll(r.Body)
+
+		if r.GetBody == nil {
+			t.Fatal("expected GetBody to be set for retries")
+		}
+		replay, err := r.GetBody()
+		if err != nil {
+			t.Fatalf("unexpected GetBody error: %v", err)
+		}
+		defer replay.Close()
+		replayed, _ := io.ReadAll(replay)
+
+		if len(body) == 0 || string(replayed) != string(body) {
+			t.Errorf("expected replayed body to match %s, got: %s", body, replayed)
+		}
+		if r.ContentLength != int64(len(body)) {
+			t.Errorf("expected content length %d, got: %d", len(body), r.ContentLength)
+		}
+
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}
+	})
+
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+	client.HTTPClient = httpClient
+
+	if err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi", Body: "Hello"}, "user:42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestClient_EscapedInstallationID(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	var paths []string
+	client := srv.NewClient()
+	base := client.HTTPClient.Transport
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.EscapedPath())
+		return base.RoundTrip(r)
+	})
+
+	ctx := context.Background()
+	const id = "device id+1/ü"
+	if _, err := client.RegisterDevice(ctx, azurepush.Installation{InstallationID: id, Platform: azurepush.InstallationApple, PushChannel: "token", Tags: []string{"topic:#news"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Installation(id); !ok {
+		t.Fatalf("expected the installation %q registered, got: %v", id, srv.Installations())
+	}
+
+	if exists, err := client.DeviceExists(ctx, id); err != nil || !exists {
+		t.Fatalf("expected the installation to exist, got: %v: %v", exists, err)
+	}
+	if err := client.PatchInstallation(ctx, id, azurepush.AddTagPatch("user:42")); err != nil {
+		t.Fatal(err)
+	}
+	if installation, err := client.GetInstallation(ctx, id); err != nil || !slices.Contains(installation.Tags, "user:42") {
+		t.Fatalf("expected the patched installation, got: %+v: %v", installation, err)
+	}
+	if registrations, err := client.ListRegistrationsByTag(ctx, "topic:#news"); err != nil || len(registrations) != 1 {
+		t.Fatalf("expected the registration of the tag, got: %d: %v", len(registrations), err)
+	}
+	if err := client.DeleteDevice(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Installation(id); ok {
+		t.Fatal("expected the installation deleted")
+	}
+
+	for _, expected := range []string{"/hub/installations/device%20id%2B1%2F%C3%BC", "/hub/tags/topic:%23news/registrations"} {
+		if !slices.Contains(paths, expected) {
+			t.Errorf("expected a request to %s, got: %v", expected, paths)
+		}
+	}
+}
+
+func TestClient_SendNotification_InvalidTags(t *testing.T) {
+	client := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		t.Errorf("unexpected request with tags: %q", r.Header.Get("ServiceBusNotification-Tags"))
+		return &http.Response{StatusCode: http.StatusCreated, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}
+	})
+
+	for _, tag := range []string{"user 42", "user+42", "user:42,admin", "用户:42", "user:42\r\nX-Injected: 1", "user:42 || ", "$InstallationId:{ü}"} {
+		err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}, tag)
+		if !errors.Is(err, azurepush.ErrInvalidNotification) {
+			t.Errorf("expected an invalid notification error for the tag %q, got: %v", tag, err)
+		}
+	}
+}
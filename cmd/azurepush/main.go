@@ -0,0 +1,388 @@
+// Command azurepush is the operations command line tool of azurepush,
+// e.g. for feeding device lists edited with spreadsheets into bulk registration.
+//
+// Usage:
+//
+//	azurepush convert -in devices.csv -out devices.jsonl
+//	azurepush import  -config configuration.yml -container <container SAS URI> devices.csv
+//	azurepush export  -config configuration.yml -container <container SAS URI> -out devices.csv
+//	azurepush delete  -config configuration.yml -platform mpns -updated-before 2025-01-01 [-confirm]
+//
+// The file format is detected by its extension: ".csv" (see azurepush.ReadInstallationsCSV for the schema),
+// ".jsonl" or ".ndjson" (one JSON installation per line). Use "-" for the standard input or output
+// with the -from and -to flags. Instead of a configuration file, the hub can be set through the environment,
+// e.g. AZUREPUSH_HUB_NAME and AZUREPUSH_CONNECTION_STRING (see azurepush.ConfigurationFromEnv).
+//
+// The delete command only counts the matching registrations (a dry run) unless -confirm is set,
+// see azurepush.Client.BulkDelete.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+const usage = `usage: azurepush <command> [flags]
+
+commands:
+  convert  converts an installations file between the CSV and NDJSON formats
+  import   imports the installations of a file through an import job
+  export   exports the installations of the hub through an export job
+  delete   deletes the registrations matching a filter, a dry run without -confirm`
+
+// Installations file formats.
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// newClient returns the client of a configuration file (or the environment), tests replace it.
+var newClient = func(configPath string) (*azurepush.Client, error) {
+	if configPath != "" {
+		cfg, err := azurepush.LoadConfiguration(configPath)
+		if err != nil {
+			return nil, err
+		}
+		return azurepush.NewClient(*cfg), nil
+	}
+
+	cfg, err := azurepush.ConfigurationFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return azurepush.NewClient(cfg), nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("azurepush: %v", err)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "convert":
+		return convert(args[1:], stdin, stdout)
+	case "import":
+		return importInstallations(ctx, args[1:], stdin)
+	case "export":
+		return exportInstallations(ctx, args[1:], stdout)
+	case "delete":
+		return deleteRegistrations(ctx, args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command: %s\n%s", args[0], usage)
+	}
+}
+
+func convert(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	var (
+		in   = fs.String("in", "-", "path of the input file")
+		out  = fs.String("out", "-", "path of the output file")
+		from = fs.String("from", "", "format of the input: csv or ndjson, defaults to the extension of the input file")
+		to   = fs.String("to", "", "format of the output: csv or ndjson, defaults to the extension of the output file")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	installations, closeInput, err := openInstallations(*in, *from, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	return writeInstallations(*out, *to, stdout, installations)
+}
+
+func importInstallations(ctx context.Context, args []string, stdin io.Reader) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "path of the YAML configuration file, defaults to the environment")
+		container  = fs.String("container", "", "SAS URI of the blob container of the import file, with create, write and read permissions")
+		from       = fs.String("from", "", "format of the input: csv or ndjson, defaults to the extension of the input file")
+		jobType    = fs.String("type", string(azurepush.JobImportUpsertInstallations), "type of the import job")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *container == "" {
+		return errors.New("import: -container is required")
+	}
+
+	in := "-"
+	if fs.NArg() > 0 {
+		in = fs.Arg(0)
+	}
+
+	installations, closeInput, err := openInstallations(in, *from, stdin)
+	if err != nil {
+		return err
+	}
+	defer closeInput()
+
+	client, err := newClient(*configPath)
+	if err != nil {
+		return err
+	}
+
+	// The first invalid row stops the import, so the job never runs with a partial file.
+	var readErr error
+	job, err := client.ImportInstallations(ctx, *container, untilError(installations, &readErr), azurepush.ImportOptions{
+		Type: azurepush.JobType(*jobType),
+		OnProgress: func(p azurepush.ImportProgress) {
+			log.Printf("import %s: %s %.0f%%", p.Job.JobID, p.Job.Status, p.Percent)
+			if p.FailedFileURI != "" {
+				log.Printf("import %s: failed lines: %s", p.Job.JobID, p.FailedFileURI)
+			}
+		},
+	})
+	if readErr != nil {
+		return readErr
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("import %s: %s", job.JobID, job.Status)
+	return nil
+}
+
+func exportInstallations(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "path of the YAML configuration file, defaults to the environment")
+		container  = fs.String("container", "", "SAS URI of the blob container of the export file, with create, write and read permissions")
+		out        = fs.String("out", "-", "path of the output file")
+		to         = fs.String("to", "", "format of the output: csv or ndjson, defaults to the extension of the output file")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *container == "" {
+		return errors.New("export: -container is required")
+	}
+
+	client, err := newClient(*configPath)
+	if err != nil {
+		return err
+	}
+
+	job, err := client.SubmitJob(ctx, azurepush.Job{
+		Type:               azurepush.JobExportInstallations,
+		OutputContainerURI: *container,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !job.Done() {
+		if job, err = client.WaitForJob(ctx, job.JobID, azurepush.PollOptions{
+			OnPoll: func(job *azurepush.Job) {
+				log.Printf("export %s: %s %.0f%%", job.JobID, job.Status, job.Progress)
+			},
+		}); err != nil {
+			return err
+		}
+	} else if job.Status == azurepush.JobStatusFailed {
+		return &azurepush.JobFailedError{Job: job}
+	}
+
+	output, err := client.OpenJobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	return writeInstallations(*out, *to, stdout, azurepush.ReadInstallationsNDJSON(output))
+}
+
+func deleteRegistrations(ctx context.Context, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	var (
+		configPath       = fs.String("config", "", "path of the YAML configuration file, defaults to the environment")
+		tagExpression    = fs.String("tags", "", `tag expression of the registrations, e.g. "tenant:acme && !beta"`)
+		platforms        = fs.String("platform", "", "comma-separated platforms of the registrations, e.g. mpns,baidu")
+		registeredBefore = fs.String("registered-before", "", "matches the registrations created before this date (YYYY-MM-DD or RFC 3339)")
+		updatedBefore    = fs.String("updated-before", "", "matches the registrations not updated since this date (YYYY-MM-DD or RFC 3339)")
+		expired          = fs.Bool("expired", false, "matches the registrations whose channel has expired")
+		all              = fs.Bool("all", false, "allows an empty filter, which matches all the registrations of the hub")
+		confirm          = fs.Bool("confirm", false, "deletes the registrations, instead of a dry run")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := azurepush.DeleteFilter{
+		TagFilter:       azurepush.TagFilter{TagExpression: *tagExpression},
+		ExpiredChannels: *expired,
+		All:             *all,
+	}
+	for platform := range strings.SplitSeq(*platforms, ",") {
+		if platform = strings.TrimSpace(platform); platform != "" {
+			filter.Platforms = append(filter.Platforms, azurepush.InstallationPlatform(platform))
+		}
+	}
+
+	var err error
+	if filter.RegisteredBefore, err = parseDate(*registeredBefore); err != nil {
+		return err
+	}
+	if filter.UpdatedBefore, err = parseDate(*updatedBefore); err != nil {
+		return err
+	}
+
+	client, err := newClient(*configPath)
+	if err != nil {
+		return err
+	}
+
+	report, err := client.BulkDelete(ctx, filter, azurepush.BulkDeleteOptions{
+		DryRun: !*confirm,
+		OnProgress: func(p azurepush.BulkDeleteProgress) {
+			if p.Err != nil {
+				log.Printf("delete %s: %v", p.Registration.RegistrationID, p.Err)
+			}
+			if p.Done%1000 == 0 || p.Done == p.Total {
+				log.Printf("delete: %d/%d", p.Done, p.Total)
+			}
+		},
+	})
+	switch {
+	case report == nil:
+	case *confirm:
+		fmt.Fprintf(stdout, "matched: %d, deleted: %d, failed: %d\n", report.Matched, report.Deleted, report.Failed)
+	default:
+		fmt.Fprintf(stdout, "matched: %d (dry run, set -confirm to delete them)\n", report.Matched)
+	}
+
+	return err
+}
+
+// parseDate parses a YYYY-MM-DD or RFC 3339 date, an empty string is the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s: must be YYYY-MM-DD or RFC 3339", s)
+	}
+	return t, nil
+}
+
+// openInstallations returns the installations of a file, or of stdin if path is "-".
+func openInstallations(path, format string, stdin io.Reader) (iter.Seq2[azurepush.Installation, error], func(), error) {
+	format, err := fileFormat(path, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, closeFn := stdin, func() {}
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		r, closeFn = f, func() { f.Close() }
+	}
+
+	if format == formatCSV {
+		return azurepush.ReadInstallationsCSV(r), closeFn, nil
+	}
+	return azurepush.ReadInstallationsNDJSON(r), closeFn, nil
+}
+
+// writeInstallations writes the installations to a file, or to stdout if path is "-".
+// It stops at the first read error.
+func writeInstallations(path, format string, stdout io.Writer, installations iter.Seq2[azurepush.Installation, error]) error {
+	format, err := fileFormat(path, format)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	var readErr error
+	if format == formatCSV {
+		err = azurepush.WriteInstallationsCSV(w, untilError(installations, &readErr))
+	} else {
+		err = azurepush.WriteInstallationsNDJSON(w, untilError(installations, &readErr))
+	}
+
+	if readErr != nil {
+		return readErr
+	}
+	return err
+}
+
+// untilError returns an iterator over the installations which stops at the first error, stored to err.
+func untilError(installations iter.Seq2[azurepush.Installation, error], err *error) iter.Seq[azurepush.Installation] {
+	return func(yield func(azurepush.Installation) bool) {
+		for installation, readErr := range installations {
+			if readErr != nil {
+				*err = readErr
+				return
+			}
+			if !yield(installation) {
+				return
+			}
+		}
+	}
+}
+
+// fileFormat returns the format of a file, the given one or the one of its extension.
+func fileFormat(path, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			format = formatCSV
+		case ".jsonl", ".ndjson":
+			format = formatNDJSON
+		default:
+			return "", fmt.Errorf("unknown format of file: %s: set it with -from or -to", path)
+		}
+	}
+
+	switch format {
+	case formatCSV, formatNDJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format: %s: must be csv or ndjson", format)
+	}
+}
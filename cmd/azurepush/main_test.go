@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestConvert(t *testing.T) {
+	const input = "installationId,platform,pushChannel,tags\n" +
+		"a,apns,token-a,user:1;vip\n" +
+		"b,FCMV1,token-b,\n"
+
+	path := filepath.Join(t.TempDir(), "devices.jsonl")
+	if err := run(t.Context(), []string{"convert", "-from", "csv", "-out", path}, strings.NewReader(input), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := run(t.Context(), []string{"convert", "-in", path, "-to", "csv"}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "installationId,platform,pushChannel,tags,templates\n" +
+		"a,apns,token-a,user:1;vip,\n" +
+		"b,FCMV1,token-b,,\n"; out.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	err := run(t.Context(), []string{"convert", "-from", "csv", "-to", "ndjson"}, strings.NewReader(input+"c,ios,token-c,\n"), &out)
+	if err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Fatalf("expected an invalid line 4 error, got: %v", err)
+	}
+
+	if err = run(t.Context(), []string{"convert", "-in", "devices.txt"}, nil, &out); err == nil {
+		t.Fatal("expected an unknown format error")
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	hub := azurepushtest.NewServer()
+	defer hub.Close()
+
+	defaultNewClient := newClient
+	newClient = func(string) (*azurepush.Client, error) { return hub.NewClient(), nil }
+	defer func() { newClient = defaultNewClient }()
+
+	const container = "https://account.blob.core.windows.net/devices?sig=abc"
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "devices.csv")
+	if err := os.WriteFile(csvPath, []byte("installationId,platform,pushChannel,tags\na,apns,token-a,user:1\nb,fcmv1,token-b,\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := run(ctx, []string{"import", "-container", container, csvPath}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hub.Installations(); len(got) != 2 || got[0].Tags[0] != "user:1" {
+		t.Fatalf("expected the imported installations, got: %#v", got)
+	}
+
+	var out bytes.Buffer
+	if err := run(ctx, []string{"export", "-container", container, "-to", "csv"}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 3 {
+		t.Fatalf("expected the header and 2 exported installations, got:\n%s", out.String())
+	}
+}
+
+func TestDelete(t *testing.T) {
+	hub := azurepushtest.NewServer()
+	defer hub.Close()
+
+	defaultNewClient := newClient
+	newClient = func(string) (*azurepush.Client, error) { return hub.NewClient(), nil }
+	defer func() { newClient = defaultNewClient }()
+
+	client := hub.NewClient()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "a", Platform: azurepush.InstallationMPNS, PushChannel: "https://channel/a"},
+		{InstallationID: "b", Platform: azurepush.InstallationApple, PushChannel: "token-b"},
+	} {
+		if _, err := client.RegisterDevice(t.Context(), installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := run(t.Context(), []string{"delete", "-platform", "mpns"}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "matched: 1 (dry run") || len(hub.Installations()) != 2 {
+		t.Fatalf("expected a dry run, got: %s", out.String())
+	}
+
+	out.Reset()
+	if err := run(t.Context(), []string{"delete", "-platform", "mpns", "-updated-before", "2999-01-01", "-confirm"}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hub.Installation("a"); ok || out.String() != "matched: 1, deleted: 1, failed: 0\n" {
+		t.Fatalf("expected the mpns installation deleted, got: %s", out.String())
+	}
+
+	if err := run(t.Context(), []string{"delete", "-confirm"}, nil, &out); err == nil {
+		t.Fatal("expected an empty filter error")
+	}
+}
@@ -0,0 +1,159 @@
+// Command azurepushd is a standalone REST push service backed by azurepush,
+// for teams that just need an internal push API deployed as a container.
+//
+// Usage:
+//
+//	AZUREPUSHD_API_KEYS=key1,key2 azurepushd -config configuration.yml -profile prod -addr :8080 -rate-limit 10
+//
+// Instead of a configuration file, the hub can be set through the environment,
+// e.g. AZUREPUSH_HUB_NAME and AZUREPUSH_CONNECTION_STRING (see azurepush.ConfigurationFromEnv).
+//
+// With -audit-log (AZUREPUSHD_AUDIT_LOG) every registration, deletion and send is recorded as a JSON line
+// with the API key subject as the actor, see azurepush.AuditRecord.
+//
+// Routes (all but health, probes and metrics require an "Authorization: Bearer <api key>" header,
+// their requests are limited per API key (AZUREPUSHD_RATE_LIMIT) and their bodies validated):
+//
+//	POST   /v1/devices       registers a device, see azurepush.RegistrationRequest
+//	DELETE /v1/devices/{id}  deletes a device, 404 if it is not registered
+//	POST   /v1/send          sends a notification: {"notification": {"title", "body", "data"}, "tags": [...]}
+//	GET    /v1/stats         operational snapshot for dashboards (see azurepush.Client.Stats)
+//	GET    /healthz          reports whether the service is up
+//	GET    /readyz           readiness probe: the token is valid and the hub reachable (see azurepush.Client.ReadinessHandler)
+//	GET    /livez            liveness probe (see azurepush.Client.LivenessHandler)
+//	GET    /metrics          request and send counters in the Prometheus text format
+//	GET    /openapi.yaml     the OpenAPI 3 specification of the API (see the api package)
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "", "path of the YAML configuration file, defaults to the environment")
+		profile    = flag.String("profile", os.Getenv(azurepush.EnvProfile), "profile of the configuration file, e.g. staging")
+		addr       = flag.String("addr", envOr("AZUREPUSHD_ADDR", ":8080"), "listen address")
+		rateLimit  = flag.String("rate-limit", envOr("AZUREPUSHD_RATE_LIMIT", "10"), "requests per second of each API key, 0 disables the limit")
+		auditLog   = flag.String("audit-log", os.Getenv("AZUREPUSHD_AUDIT_LOG"), `path of the JSON lines audit log of the registrations, deletions and sends, "-" for stdout`)
+	)
+	flag.Parse()
+
+	requestsPerSecond, err := strconv.ParseFloat(*rateLimit, 64)
+	if err != nil || requestsPerSecond < 0 {
+		log.Fatalf("azurepushd: invalid rate limit: %s", *rateLimit)
+	}
+
+	cfg, err := loadConfiguration(*configPath, *profile)
+	if err != nil {
+		log.Fatalf("azurepushd: %v", err)
+	}
+
+	apiKeys := splitList(os.Getenv("AZUREPUSHD_API_KEYS"))
+	if len(apiKeys) == 0 {
+		log.Fatal("azurepushd: AZUREPUSHD_API_KEYS is required")
+	}
+
+	client := azurepush.NewClient(*cfg)
+	if *auditLog != "" {
+		audit, err := openAuditLog(*auditLog)
+		if err != nil {
+			log.Fatalf("azurepushd: %v", err)
+		}
+		defer audit.Close()
+		client.Audit = azurepush.NewJSONAuditSink(audit)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           newServer(client, apiKeys, requestsPerSecond),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("azurepushd: listening on %s", *addr)
+	if err = serve(ctx, srv, client); err != nil {
+		log.Fatalf("azurepushd: %v", err)
+	}
+}
+
+// serve runs the server until the context is done, then shuts it down and closes the client.
+// ListenAndServe returns as soon as Shutdown is called, serve returns after the drain
+// of the in-flight requests and sends, so the deferred audit log is closed after the last sends.
+func serve(ctx context.Context, srv *http.Server, client *azurepush.Client) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+		if err := client.Close(shutdownCtx); err != nil { // drains the sends still in flight after a Shutdown timeout.
+			log.Printf("azurepushd: %v", err)
+		}
+	}()
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	<-done
+
+	return nil
+}
+
+func loadConfiguration(path, profile string) (*azurepush.Configuration, error) {
+	if path != "" {
+		return azurepush.LoadConfigurationProfile(path, profile)
+	}
+
+	cfg, err := azurepush.ConfigurationFromEnv()
+	return &cfg, err
+}
+
+// openAuditLog opens the audit log file for appending, or stdout for "-".
+func openAuditLog(path string) (*os.File, error) {
+	if path == "-" {
+		return os.Stdout, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	return f, nil
+}
+
+func envOr(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return def
+}
+
+func splitList(s string) []string {
+	var list []string
+	for field := range strings.SplitSeq(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			list = append(list, field)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/api"
+)
+
+// server is the REST API of azurepushd.
+type server struct {
+	client  azurepush.HubClient
+	metrics *metrics
+	mux     *http.ServeMux
+}
+
+// newServer returns the API of the client, its routes are authenticated by the API keys
+// and limited to rateLimit requests per second of each key (zero disables the limit).
+func newServer(client azurepush.HubClient, apiKeys []string, rateLimit float64) *server {
+	s := &server{
+		client:  client,
+		metrics: newMetrics(),
+		mux:     http.NewServeMux(),
+	}
+
+	protect := []azurepush.Middleware{azurepush.APIKeyAuth(apiKeys...)}
+	if rateLimit > 0 {
+		protect = append(protect, azurepush.RateLimit(azurepush.RateLimitOptions{RequestsPerSecond: rateLimit, Burst: int(2 * rateLimit)}))
+	}
+	protected := func(h http.Handler, middlewares ...azurepush.Middleware) http.Handler {
+		return azurepush.Chain(h, append(slices.Clone(protect), middlewares...)...)
+	}
+
+	s.handle("POST /v1/devices", protected(azurepush.RegistrationHandler(client, azurepush.RegistrationHandlerOptions{}), azurepush.ValidateRegistrationRequest(0)))
+	s.handle("DELETE /v1/devices/{id}", protected(http.HandlerFunc(s.deleteDevice)))
+	s.handle("POST /v1/send", protected(http.HandlerFunc(s.send), azurepush.ValidateJSON[api.SendRequest](1<<20, nil)))
+	if stats, ok := client.(interface{ Stats() azurepush.ClientStats }); ok {
+		s.handle("GET /v1/stats", protected(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			writeJSON(w, http.StatusOK, stats.Stats())
+		})))
+	}
+	s.handle("GET /healthz", http.HandlerFunc(health))
+	if probes, ok := client.(interface {
+		ReadinessHandler() http.Handler
+		LivenessHandler() http.Handler
+	}); ok {
+		s.handle("GET /readyz", probes.ReadinessHandler())
+		s.handle("GET /livez", probes.LivenessHandler())
+	}
+	s.handle("GET /metrics", http.HandlerFunc(s.metrics.serve))
+	s.handle("GET /openapi.yaml", http.HandlerFunc(openAPISpec))
+
+	return s
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handle registers the handler and counts its responses by route and status code.
+func (s *server) handle(pattern string, h http.Handler) {
+	s.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		s.metrics.request(pattern, rec.statusCode)
+	}))
+}
+
+func (s *server) deleteDevice(w http.ResponseWriter, r *http.Request) {
+	// DeleteDevice succeeds for a missing installation, the API reports it.
+	exists, err := s.client.DeviceExists(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, api.Error{Error: err.Error()})
+		return
+	}
+	if !exists {
+		writeJSON(w, http.StatusNotFound, api.Error{Error: "device not found: " + r.PathValue("id")})
+		return
+	}
+
+	if err = s.client.DeleteDevice(r.Context(), r.PathValue("id")); err != nil {
+		writeJSON(w, http.StatusBadGateway, api.Error{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) send(w http.ResponseWriter, r *http.Request) {
+	var req api.SendRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, api.Error{Error: fmt.Sprintf("invalid send body: %v", err)})
+		return
+	}
+
+	notification := azurepush.Notification{
+		Title: deref(req.Notification.Title),
+		Body:  deref(req.Notification.Body),
+		Data:  deref(req.Notification.Data),
+	}
+	if notification.Title == "" && notification.Body == "" {
+		writeJSON(w, http.StatusBadRequest, api.Error{Error: "notification title or body is required"})
+		return
+	}
+
+	err := s.client.SendNotification(r.Context(), notification, deref(req.Tags)...)
+	s.metrics.send(err)
+	if err != nil {
+		statusCode := http.StatusBadGateway
+		if errors.Is(err, azurepush.ErrQuotaExceeded) {
+			statusCode = http.StatusTooManyRequests
+		} else if errors.Is(err, azurepush.ErrInvalidNotification) {
+			statusCode = http.StatusBadRequest
+		}
+		writeJSON(w, statusCode, api.Error{Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func health(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, api.Health{Status: "ok"})
+}
+
+func openAPISpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(api.Spec)
+}
+
+func deref[T any](v *T) T {
+	if v == nil {
+		var zero T
+		return zero
+	}
+
+	return *v
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// metrics holds the request and send counters.
+type metrics struct {
+	mu       sync.Mutex
+	requests map[string]int64 // "route\x00code" -> count.
+
+	sent, failed atomic.Int64
+}
+
+func newMetrics() *metrics {
+	return &metrics{requests: make(map[string]int64)}
+}
+
+func (m *metrics) request(route string, statusCode int) {
+	m.mu.Lock()
+	m.requests[route+"\x00"+strconv.Itoa(statusCode)]++
+	m.mu.Unlock()
+}
+
+func (m *metrics) send(err error) {
+	if err != nil {
+		m.failed.Add(1)
+		return
+	}
+
+	m.sent.Add(1)
+}
+
+// serve writes the counters in the Prometheus text exposition format.
+func (m *metrics) serve(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	fmt.Fprintln(w, "# HELP azurepushd_requests_total Total HTTP requests by route and status code.")
+	fmt.Fprintln(w, "# TYPE azurepushd_requests_total counter")
+	for _, key := range keys {
+		route, code, _ := strings.Cut(key, "\x00")
+		fmt.Fprintf(w, "azurepushd_requests_total{route=%q,code=%q} %d\n", route, code, m.requests[key])
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP azurepushd_notifications_total Total notifications sent to the hub by result.")
+	fmt.Fprintln(w, "# TYPE azurepushd_notifications_total counter")
+	fmt.Fprintf(w, "azurepushd_notifications_total{result=\"success\"} %d\n", m.sent.Load())
+	fmt.Fprintf(w, "azurepushd_notifications_total{result=\"failure\"} %d\n", m.failed.Load())
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/api"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestServer(t *testing.T) {
+	hub := azurepushtest.NewServer()
+	defer hub.Close()
+
+	var audit bytes.Buffer
+	client := hub.NewClient()
+	client.Audit = azurepush.NewJSONAuditSink(&audit)
+	s := newServer(client, []string{"secret"}, 0)
+
+	do := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodPost, "/v1/send", "wrong", `{}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for an invalid API key, got: %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "/v1/devices", "secret", `{"platform":"apns","pushChannel":"token","tags":["user:42"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d: %s", rec.Code, rec.Body)
+	}
+	var resp azurepush.RegistrationResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+
+	if rec = do(http.MethodPost, "/v1/send", "secret", `{"notification":{"title":"Hi"},"tags":["user:42"]}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got: %d: %s", rec.Code, rec.Body)
+	}
+	if sent := hub.Sent("user:42"); len(sent) != 2 {
+		t.Errorf("expected 2 sent notifications, got: %d", len(sent))
+	}
+
+	if rec = do(http.MethodGet, "/v1/stats", "", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 for the stats without an API key, got: %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/v1/stats", "secret", "")
+	var stats api.Stats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("expected the stats, got: %d: %v", rec.Code, err)
+	}
+	if stats.Sends["ok"] != 1 || stats.Window != azurepush.StatsWindow.String() {
+		t.Errorf("expected 1 successful send in the stats, got: %+v", stats)
+	}
+
+	if rec = do(http.MethodPost, "/v1/send", "secret", `{"notification":{}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an empty notification, got: %d", rec.Code)
+	}
+
+	if rec = do(http.MethodDelete, "/v1/devices/"+resp.InstallationID, "secret", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("expected status 204, got: %d: %s", rec.Code, rec.Body)
+	}
+	if _, ok := hub.Installation(resp.InstallationID); ok {
+		t.Error("expected installation to be deleted")
+	}
+	if rec = do(http.MethodDelete, "/v1/devices/"+resp.InstallationID, "secret", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a deleted device, got: %d: %s", rec.Code, rec.Body)
+	}
+
+	for line := range strings.Lines(audit.String()) {
+		var record azurepush.AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil || !strings.HasPrefix(record.Actor, "apikey:") {
+			t.Errorf("expected the API key subject as the audit actor, got: %s", line)
+		}
+	}
+	if n := strings.Count(audit.String(), "\n"); n != 3 {
+		t.Errorf("expected 3 audit records (register, send, delete), got: %d", n)
+	}
+
+	if rec = do(http.MethodGet, "/healthz", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected health status 200, got: %d", rec.Code)
+	}
+
+	for _, probe := range []string{"/readyz", "/livez"} {
+		if rec = do(http.MethodGet, probe, "", ""); rec.Code != http.StatusOK {
+			t.Errorf("expected %s status 200, got: %d: %s", probe, rec.Code, rec.Body)
+		}
+	}
+
+	if rec = do(http.MethodGet, "/openapi.yaml", "", ""); rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "openapi: 3") {
+		t.Errorf("expected the OpenAPI specification, got: %d", rec.Code)
+	}
+
+	rec = do(http.MethodGet, "/metrics", "", "")
+	metrics := rec.Body.String()
+	for _, expected := range []string{
+		`azurepushd_requests_total{route="POST /v1/send",code="202"} 1`,
+		`azurepushd_requests_total{route="POST /v1/send",code="401"} 1`,
+		`azurepushd_notifications_total{result="success"} 1`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("expected metrics to contain %s, got:\n%s", expected, metrics)
+		}
+	}
+}
+
+func TestServer_Protection(t *testing.T) {
+	hub := azurepushtest.NewServer()
+	defer hub.Close()
+
+	s := newServer(hub.NewClient(), []string{"secret", "other"}, 1)
+
+	do := func(apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/devices", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("secret", `{"platform":"apns","pushChannel":"token","os":"ios"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown field, got: %d", rec.Code)
+	}
+
+	if rec := do("secret", `{"platform":"apns","pushChannel":"token"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d: %s", rec.Code, rec.Body)
+	}
+
+	if rec := do("secret", `{"platform":"apns","pushChannel":"token"}`); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected status 429 over the burst, got: %d", rec.Code)
+	}
+
+	if rec := do("other", `{"platform":"apns","pushChannel":"token"}`); rec.Code != http.StatusOK {
+		t.Errorf("expected the other API key not limited, got: %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestServe_Shutdown(t *testing.T) {
+	hub := azurepushtest.NewServer()
+	defer hub.Close()
+
+	client := hub.NewClient()
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: newServer(client, []string{"secret"}, 0)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := serve(ctx, srv, client); err != nil {
+		t.Fatalf("unexpected serve error: %v", err)
+	}
+	if !client.Stats().Closed {
+		t.Error("expected the client to be closed (drained) when serve returns")
+	}
+}
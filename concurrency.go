@@ -0,0 +1,62 @@
+package azurepush
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// concurrencyLimit is the semaphore of the client's in-flight hub requests, see Configuration.MaxConcurrentRequests.
+// The requests waiting for a slot acquire it in their arrival order, whatever their operation,
+// so a burst of registrations queues the sends behind it instead of starving them.
+// A nil concurrencyLimit does not limit.
+type concurrencyLimit chan struct{}
+
+func newConcurrencyLimit(n int) concurrencyLimit {
+	if n <= 0 {
+		return nil
+	}
+
+	return make(concurrencyLimit, n)
+}
+
+// acquire waits for a free slot until the context is done, the returned function releases it.
+func (l concurrencyLimit) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l <- struct{}{}:
+		var once sync.Once
+		return func() { once.Do(func() { <-l }) }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// releaseBody is a response body which releases the request's slot when closed,
+// the connection is busy until the body is read.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// InFlightRequests returns the number of the client's in-flight hub requests
+// and the limit of Configuration.MaxConcurrentRequests (zero if not limited).
+func (c *Client) InFlightRequests() (n, limit int) {
+	l := c.concurrencyLimit()
+	return len(l), cap(l)
+}
+
+func (c *Client) concurrencyLimit() concurrencyLimit {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.limit
+}
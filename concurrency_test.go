@@ -0,0 +1,95 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+// concurrencyTransport records the maximum number of concurrent requests.
+type concurrencyTransport struct {
+	base          http.RoundTripper
+	current, peak atomic.Int32
+}
+
+func (t *concurrencyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	n := t.current.Add(1)
+	defer t.current.Add(-1)
+	for {
+		peak := t.peak.Load()
+		if n <= peak || t.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	return t.base.RoundTrip(r)
+}
+
+func TestClient_MaxConcurrentRequests(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	cfg := srv.Configuration()
+	cfg.MaxConcurrentRequests = 2
+	client := azurepush.NewClient(cfg)
+	transport := &concurrencyTransport{base: srv.HTTPClient().Transport}
+	client.HTTPClient = &http.Client{Transport: transport}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			id := fmt.Sprintf("device-%d", i)
+			if _, err := client.RegisterDevice(t.Context(), azurepush.Installation{InstallationID: id, Platform: azurepush.InstallationApple, PushChannel: "token-" + id, Tags: []string{"all"}}); err != nil {
+				t.Error(err)
+			}
+		})
+		wg.Go(func() {
+			_ = client.SendNotification(t.Context(), azurepush.Notification{Title: "Hi"}, "all")
+		})
+	}
+	wg.Wait()
+
+	if peak := transport.peak.Load(); peak != 2 {
+		t.Fatalf("expected at most 2 concurrent requests, got: %d", peak)
+	}
+
+	if n, limit := client.InFlightRequests(); n != 0 || limit != 2 {
+		t.Fatalf("expected all slots released, got: %d/%d", n, limit)
+	}
+
+	// The waiting requests respect their context.
+	cfg.MaxConcurrentRequests = 1
+	if err := client.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+	block := make(chan struct{})
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		<-block
+		return transport.base.RoundTrip(r)
+	})
+	go func() { _, _ = client.DeviceExists(context.Background(), "device-1") }()
+	for n, _ := client.InFlightRequests(); n == 0; n, _ = client.InFlightRequests() {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.DeviceExists(ctx, "device-2"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error while waiting for a slot, got: %v", err)
+	}
+	close(block)
+
+	cfg.MaxConcurrentRequests = -1
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected a negative MaxConcurrentRequests error")
+	}
+}
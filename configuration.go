@@ -1,10 +1,13 @@
 package azurepush
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/url"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,11 +17,17 @@ import (
 // Configuration holds Azure Notification Hub credentials and settings.
 //
 // The Validate method MUST be called after loading the configuration to ensure all required fields are present.
+//
+// The fields have yaml, json and env struct tags, so the configuration can be loaded by most configuration systems.
+// The env tags hold the environment variable names (e.g. for environment loading packages),
+// see ConfigurationFromEnv for the variables read by this package.
+// In JSON the durations are encoded as time.Duration strings (e.g. "1h"),
+// nanoseconds numbers are accepted too. MarshalJSON redacts the secrets.
 type Configuration struct {
 	// HubName is the name of your Azure Notification Hub instance.
 	// You can find this in the Azure Portal under your Notification Hub resource.
 	// Example: "myhubname"
-	HubName string `yaml:"HubName"`
+	HubName string `yaml:"HubName" json:"HubName,omitempty" env:"AZUREPUSH_HUB_NAME"`
 
 	// ConnectionString is the full connection string for the Azure Notification Hub.
 	// This is used to extract the Namespace, KeyName, and KeyValue.
@@ -27,17 +36,17 @@ type Configuration struct {
 	// ConnectionString is the full connection string for the Azure Notification Hub.
 	//
 	// If this field is present, the individual fields (Namespace, KeyName, KeyValue) are ignored.
-	ConnectionString string `yaml:"ConnectionString"`
+	ConnectionString string `yaml:"ConnectionString" json:"ConnectionString,omitempty" env:"AZUREPUSH_CONNECTION_STRING"`
 
 	// Namespace is the Azure Service Bus namespace where the Notification Hub lives.
 	// This should be the prefix (without .servicebus.windows.net).
 	// Example: "my-namespace"
-	Namespace string `yaml:"Namespace"`
+	Namespace string `yaml:"Namespace" json:"Namespace,omitempty" env:"AZUREPUSH_NAMESPACE"`
 
 	// KeyName is the name of the Shared Access Policy with send or full access.
 	// You can find this under "Access Policies" in your Notification Hub (left menu > Settings > Access Policies).
 	// Example: "DefaultFullSharedAccessSignature"
-	KeyName string `yaml:"KeyName"`
+	KeyName string `yaml:"KeyName" json:"KeyName,omitempty" env:"AZUREPUSH_KEY_NAME"`
 
 	// KeyValue is the primary or secondary key associated with the KeyName.
 	// To get this, go to the Notification Hub > Access Policies > select your policy > copy "Primary Key".
@@ -51,20 +60,228 @@ type Configuration struct {
 	// Example Connection String:
 	//   Endpoint=sb://<namespace>.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=YOUR_SECRET_KEY
 	// Use the value of `SharedAccessKey` as KeyValue.
-	KeyValue string `yaml:"KeyValue"`
+	KeyValue string `yaml:"KeyValue" json:"KeyValue,omitempty" env:"AZUREPUSH_KEY_VALUE"`
+
+	// SendConnectionString is the connection string of an optional second access policy with Send rights only
+	// (e.g. DefaultSendSharedAccessSignature), it must be of the same namespace.
+	// When set (or SendKeyName and SendKeyValue are), the sends are signed with this policy,
+	// while the registration and management calls are signed with the KeyName policy (Manage rights),
+	// so a leaked send token cannot modify the installations.
+	//
+	// If this field is present, the SendKeyName and SendKeyValue fields are ignored.
+	SendConnectionString string `yaml:"SendConnectionString" json:"SendConnectionString,omitempty" env:"AZUREPUSH_SEND_CONNECTION_STRING"`
+	// SendKeyName is the name of the Send access policy, see SendConnectionString.
+	SendKeyName string `yaml:"SendKeyName" json:"SendKeyName,omitempty" env:"AZUREPUSH_SEND_KEY_NAME"`
+	// SendKeyValue is the key of the SendKeyName access policy.
+	SendKeyValue string `yaml:"SendKeyValue" json:"SendKeyValue,omitempty" env:"AZUREPUSH_SEND_KEY_VALUE"`
+
+	// KeyEncoding is the encoding of the KeyValue and SendKeyValue: KeyEncodingBase64 (as the Azure Portal shows them)
+	// or KeyEncodingRaw (base64-decoded). Their surrounding whitespace is ignored.
+	//
+	// Defaults to KeyEncodingAuto, which detects the base64-decoded keys.
+	KeyEncoding KeyEncoding `yaml:"KeyEncoding" json:"KeyEncoding,omitempty" env:"AZUREPUSH_KEY_ENCODING"`
 
 	// TokenValidity is how long each generated SAS token should remain valid.
 	// It must be a valid Go duration string (e.g., "1h", "30m").
 	// Example: 2 * time.Hour
 	//
 	// Defaults to 1 week.
-	TokenValidity time.Duration `yaml:"TokenValidity"`
+	TokenValidity time.Duration `yaml:"TokenValidity" json:"TokenValidity,omitempty" env:"AZUREPUSH_TOKEN_VALIDITY"`
 
 	// ConnectivityCheck enables the connectivity check.
 	// If enabled, the NewClient will check the connection to the Azure Notification Hub before sending messages.
 	//
 	// Defaults to false.
-	ConnectivityCheck bool `yaml:"ConnectivityCheck"`
+	ConnectivityCheck bool `yaml:"ConnectivityCheck" json:"ConnectivityCheck,omitempty" env:"AZUREPUSH_CONNECTIVITY_CHECK"`
+
+	// RequestTimeout is the timeout of each HTTP request to the hub.
+	//
+	// Defaults to 10 seconds.
+	RequestTimeout time.Duration `yaml:"RequestTimeout" json:"RequestTimeout,omitempty" env:"AZUREPUSH_REQUEST_TIMEOUT"`
+
+	// MaxIdleConnsPerHost is the maximum number of idle (keep-alive) connections to the hub.
+	// Raise it for high-concurrency fan-out.
+	//
+	// Defaults to 100.
+	MaxIdleConnsPerHost int `yaml:"MaxIdleConnsPerHost" json:"MaxIdleConnsPerHost,omitempty" env:"AZUREPUSH_MAX_IDLE_CONNS_PER_HOST"`
+
+	// MaxConnsPerHost limits the total number of connections to the hub, zero means no limit.
+	MaxConnsPerHost int `yaml:"MaxConnsPerHost" json:"MaxConnsPerHost,omitempty" env:"AZUREPUSH_MAX_CONNS_PER_HOST"`
+
+	// MaxConcurrentRequests limits the in-flight requests of a Client to the hub across all operations
+	// (sends, registrations, listings and jobs), zero means no limit. The requests over the limit wait
+	// for a slot in their arrival order, so a burst of one operation cannot starve the others,
+	// and the namespace stays under its throttling thresholds.
+	//
+	// Defaults to 0.
+	MaxConcurrentRequests int `yaml:"MaxConcurrentRequests" json:"MaxConcurrentRequests,omitempty" env:"AZUREPUSH_MAX_CONCURRENT_REQUESTS"`
+
+	// Budgets are the retry and timeout budgets of the hub requests by operation class,
+	// e.g. sends retried up to 3 times within 5s, see OperationBudget.
+	//
+	// Defaults to a single attempt without a timeout.
+	Budgets Budgets `yaml:"Budgets" json:"Budgets,omitzero"`
+
+	// IdleConnTimeout is how long an idle connection is kept open.
+	//
+	// Defaults to 90 seconds.
+	IdleConnTimeout time.Duration `yaml:"IdleConnTimeout" json:"IdleConnTimeout,omitempty" env:"AZUREPUSH_IDLE_CONN_TIMEOUT"`
+
+	// WarmConnections is the number of connections NewClient establishes to the hub on startup
+	// (after resolving its host), zero disables the pre-warm. Failures are ignored.
+	//
+	// Defaults to 0.
+	WarmConnections int `yaml:"WarmConnections" json:"WarmConnections,omitempty" env:"AZUREPUSH_WARM_CONNECTIONS"`
+
+	// KeepWarm keeps idle connections open without a time limit (IdleConnTimeout is ignored),
+	// so warm connections survive quiet periods between send bursts.
+	//
+	// Defaults to false.
+	KeepWarm bool `yaml:"KeepWarm" json:"KeepWarm,omitempty" env:"AZUREPUSH_KEEP_WARM"`
+
+	// DryRun short-circuits the mutating calls of the client (sends, registrations, deletions, patches,
+	// jobs and blob uploads): their payloads are built and validated, the audit records and stats are recorded
+	// (see Client.Audit and Client.Stats), but no request leaves the process and they report success.
+	// The reads (e.g. DeviceExists and ListRegistrations) still call the hub.
+	// It is meant for staging environments which share the production configuration,
+	// see the AZUREPUSH_DRY_RUN environment variable of ConfigurationFromEnv.
+	//
+	// Defaults to false.
+	DryRun bool `yaml:"DryRun" json:"DryRun,omitempty" env:"AZUREPUSH_DRY_RUN"`
+
+	// DisableHTTP2 forces HTTP/1.1 connections.
+	//
+	// Defaults to false.
+	DisableHTTP2 bool `yaml:"DisableHTTP2" json:"DisableHTTP2,omitempty" env:"AZUREPUSH_DISABLE_HTTP2"`
+
+	// SendPlatforms are the notification formats SendNotification sends to, one request each.
+	// Add PlatformADM to reach the Amazon Fire OS devices, or PlatformWindows for the WNS toasts.
+	// Supported formats: PlatformApple, PlatformFCMV1, PlatformADM and PlatformWindows.
+	//
+	// Defaults to PlatformApple and PlatformFCMV1.
+	SendPlatforms []Platform `yaml:"SendPlatforms" json:"SendPlatforms,omitempty" env:"AZUREPUSH_SEND_PLATFORMS"`
+
+	// Tier is the pricing tier of the namespace ("Free", "Basic" or "Standard"), if known.
+	// It is used to report unsupported features before calling the hub,
+	// see Client.DetectTier to fetch it through the management client instead.
+	Tier Tier `yaml:"Tier" json:"Tier,omitempty" env:"AZUREPUSH_TIER"`
+
+	// SubscriptionID is the Azure subscription that owns the namespace.
+	// It is only required by the ManagementClient (Azure Resource Manager operations).
+	SubscriptionID string `yaml:"SubscriptionID" json:"SubscriptionID,omitempty" env:"AZUREPUSH_SUBSCRIPTION_ID"`
+
+	// ResourceGroup is the Azure resource group that contains the namespace.
+	// It is only required by the ManagementClient (Azure Resource Manager operations).
+	ResourceGroup string `yaml:"ResourceGroup" json:"ResourceGroup,omitempty" env:"AZUREPUSH_RESOURCE_GROUP"`
+
+	// ManagementEndpoint is the Azure Resource Manager endpoint used by NewManagementClientWithCredential,
+	// change it for sovereign clouds (e.g. "https://management.chinacloudapi.cn").
+	//
+	// Defaults to DefaultManagementEndpoint.
+	ManagementEndpoint string `yaml:"ManagementEndpoint" json:"ManagementEndpoint,omitempty" env:"AZUREPUSH_MANAGEMENT_ENDPOINT"`
+
+	// Pruning declares the pruning policies of the registrations, executed on their schedule
+	// by a Maintenance runner, see PrunePolicy.
+	Pruning []PrunePolicy `yaml:"Pruning" json:"Pruning,omitempty"`
+}
+
+// redacted replaces the secrets of a marshaled Configuration.
+const redacted = "REDACTED"
+
+// configurationJSON has the fields of Configuration without its methods.
+type configurationJSON Configuration
+
+// configurationJSONDurations encodes Configuration with its durations as strings,
+// the outer duration fields take precedence over the embedded ones.
+type configurationJSONDurations struct {
+	configurationJSON
+	TokenValidity   jsonDuration `json:"TokenValidity,omitempty"`
+	RequestTimeout  jsonDuration `json:"RequestTimeout,omitempty"`
+	IdleConnTimeout jsonDuration `json:"IdleConnTimeout,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+// The key value and the key of the connection string are replaced with "REDACTED",
+// so the configuration can be safely logged or exposed for debugging. Durations are encoded as strings (e.g. "1h0m0s").
+//
+// Example usage:
+//
+//	b, _ := json.Marshal(client.Config)
+//	log.Printf("push configuration: %s", b)
+func (cfg Configuration) MarshalJSON() ([]byte, error) {
+	if cfg.KeyValue != "" {
+		cfg.KeyValue = redacted
+	}
+	cfg.ConnectionString = redactConnectionString(cfg.ConnectionString)
+	if cfg.SendKeyValue != "" {
+		cfg.SendKeyValue = redacted
+	}
+	cfg.SendConnectionString = redactConnectionString(cfg.SendConnectionString)
+
+	return json.Marshal(configurationJSONDurations{
+		configurationJSON: configurationJSON(cfg),
+		TokenValidity:     jsonDuration(cfg.TokenValidity),
+		RequestTimeout:    jsonDuration(cfg.RequestTimeout),
+		IdleConnTimeout:   jsonDuration(cfg.IdleConnTimeout),
+	})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Durations can be time.Duration strings (e.g. "30s") or nanoseconds numbers.
+func (cfg *Configuration) UnmarshalJSON(data []byte) error {
+	var decoded configurationJSONDurations
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*cfg = Configuration(decoded.configurationJSON)
+	cfg.TokenValidity = time.Duration(decoded.TokenValidity)
+	cfg.RequestTimeout = time.Duration(decoded.RequestTimeout)
+	cfg.IdleConnTimeout = time.Duration(decoded.IdleConnTimeout)
+	return nil
+}
+
+// redactConnectionString replaces the SharedAccessKey of a connection string.
+func redactConnectionString(connStr string) string {
+	if connStr == "" {
+		return ""
+	}
+
+	parts := strings.Split(connStr, ";")
+	for i, part := range parts {
+		if strings.HasPrefix(part, "SharedAccessKey=") {
+			parts[i] = "SharedAccessKey=" + redacted
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// jsonDuration is a time.Duration encoded as a string in JSON.
+type jsonDuration time.Duration
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err = json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration: %s", data)
+		}
+		*d = jsonDuration(n)
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	*d = jsonDuration(parsed)
+	return nil
 }
 
 // 1 week.
@@ -90,10 +307,89 @@ func (cfg *Configuration) Validate() error {
 		return errors.New("missing Azure key value")
 	}
 
+	if (cfg.SendKeyName == "") != (cfg.SendKeyValue == "") {
+		return errors.New("send access policy requires both a key name and a key value")
+	}
+
+	if err := validateKey(cfg.KeyValue, cfg.KeyEncoding); err != nil {
+		return fmt.Errorf("invalid Azure key value: %w", err)
+	}
+
+	if cfg.SendKeyValue != "" {
+		if err := validateKey(cfg.SendKeyValue, cfg.KeyEncoding); err != nil {
+			return fmt.Errorf("invalid Azure send key value: %w", err)
+		}
+	}
+
+	if cfg.MaxConcurrentRequests < 0 {
+		return errors.New("negative MaxConcurrentRequests")
+	}
+
+	if err := cfg.Budgets.validate(); err != nil {
+		return err
+	}
+
 	if cfg.TokenValidity <= 0 {
 		cfg.TokenValidity = DefaultTokenValidity
 	}
 
+	for _, platform := range cfg.SendPlatforms {
+		if !slices.Contains(sendablePlatforms, platform) {
+			return fmt.Errorf("unsupported send platform: %q", platform)
+		}
+	}
+
+	if err := validatePrunePolicies(cfg.Pruning); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// sendPlatforms returns the notification formats of SendNotification.
+func (cfg *Configuration) sendPlatforms() []Platform {
+	if len(cfg.SendPlatforms) == 0 {
+		return availablePlatforms
+	}
+
+	return cfg.SendPlatforms
+}
+
+// sendPolicy reports whether a separate access policy is configured for the sends.
+func (cfg *Configuration) sendPolicy() bool {
+	return cfg.SendKeyName != "" && cfg.SendKeyValue != ""
+}
+
+// sendConfiguration returns the configuration signed with the send access policy.
+func (cfg Configuration) sendConfiguration() Configuration {
+	cfg.ConnectionString, cfg.SendConnectionString = "", ""
+	cfg.KeyName, cfg.KeyValue = cfg.SendKeyName, cfg.SendKeyValue
+	return cfg
+}
+
+// signingKey returns the KeyValue the SAS tokens are signed with, see KeyEncoding.
+func (cfg *Configuration) signingKey() string {
+	return signingKey(cfg.KeyValue, cfg.KeyEncoding)
+}
+
+// validateManagement checks the fields required by the ManagementClient.
+func (cfg *Configuration) validateManagement() error {
+	if err := cfg.parseConnectionString(); err != nil {
+		return err
+	}
+
+	if cfg.SubscriptionID == "" {
+		return errors.New("missing Azure subscription ID")
+	}
+
+	if cfg.ResourceGroup == "" {
+		return errors.New("missing Azure resource group")
+	}
+
+	if cfg.Namespace == "" {
+		return errors.New("missing Azure namespace")
+	}
+
 	return nil
 }
 
@@ -101,25 +397,46 @@ func (cfg *Configuration) Validate() error {
 // Expected format:
 // Endpoint=sb://<namespace>.servicebus.windows.net/;SharedAccessKeyName=<name>;SharedAccessKey=<key>
 func (cfg *Configuration) parseConnectionString() error {
-	connStr := cfg.ConnectionString
-	if connStr == "" {
-		return nil
+	if cfg.ConnectionString != "" {
+		namespace, keyName, keyValue, err := parseConnectionString(cfg.ConnectionString)
+		if err != nil {
+			return err
+		}
+
+		cfg.Namespace = namespace
+		cfg.KeyName = keyName
+		cfg.KeyValue = keyValue
+	}
+
+	if cfg.SendConnectionString != "" {
+		namespace, keyName, keyValue, err := parseConnectionString(cfg.SendConnectionString)
+		if err != nil {
+			return fmt.Errorf("invalid send connection string: %w", err)
+		}
+
+		if cfg.Namespace != "" && cfg.Namespace != namespace {
+			return fmt.Errorf("send connection string of a different namespace: %s", namespace)
+		}
+
+		cfg.Namespace = namespace
+		cfg.SendKeyName = keyName
+		cfg.SendKeyValue = keyValue
 	}
 
-	var (
-		namespace, keyName, keyValue string
-	)
+	return nil
+}
 
+func parseConnectionString(connStr string) (namespace, keyName, keyValue string, err error) {
 	parts := strings.Split(connStr, ";")
 	if len(parts) < 3 {
-		return errors.New("invalid connection string format")
+		return "", "", "", errors.New("invalid connection string format")
 	}
 	for _, part := range parts {
 		if strings.HasPrefix(part, "Endpoint=") {
 			e := strings.TrimPrefix(part, "Endpoint=")
 			u, err := url.Parse(e)
 			if err != nil {
-				return fmt.Errorf("invalid endpoint url: %w", err)
+				return "", "", "", fmt.Errorf("invalid endpoint url: %w", err)
 			}
 			namespace = strings.TrimSuffix(u.Hostname(), ".servicebus.windows.net")
 		} else if strings.HasPrefix(part, "SharedAccessKeyName=") {
@@ -129,17 +446,39 @@ func (cfg *Configuration) parseConnectionString() error {
 		}
 	}
 	if namespace == "" || keyName == "" || keyValue == "" {
-		return errors.New("missing required connection string parts")
+		return "", "", "", errors.New("missing required connection string parts")
 	}
 
-	cfg.Namespace = namespace
-	cfg.KeyName = keyName
-	cfg.KeyValue = keyValue
-	return nil
+	return namespace, keyName, keyValue, nil
 }
 
 // LoadConfiguration loads a YAML config from the given path.
+// If the AZUREPUSH_PROFILE environment variable is set, its profile of the file is loaded, see LoadConfigurationProfile.
 func LoadConfiguration(path string) (*Configuration, error) {
+	return LoadConfigurationProfile(path, os.Getenv(EnvProfile))
+}
+
+// LoadConfigurationProfile loads a named profile of a YAML config, e.g. one per environment.
+// The top-level fields of the file are shared by all profiles and the fields of a profile override them.
+// An empty name loads the top-level fields only. It returns an error if the file has no such profile.
+//
+// Example configuration.yml:
+//
+//	TokenValidity: 24h
+//	RequestTimeout: 5s
+//	Profiles:
+//	  dev:
+//	    HubName: myhub-dev
+//	    ConnectionString: "Endpoint=sb://myapp-dev.servicebus.windows.net/;..."
+//	  prod:
+//	    HubName: myhub
+//	    ConnectionString: "Endpoint=sb://myapp.servicebus.windows.net/;..."
+//	    MaxConcurrentRequests: 64
+//
+// Example usage:
+//
+//	cfg, err := azurepush.LoadConfigurationProfile("configuration.yml", "prod")
+func LoadConfigurationProfile(path, name string) (*Configuration, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
@@ -149,5 +488,24 @@ func LoadConfiguration(path string) (*Configuration, error) {
 		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
+	if name != "" {
+		var file struct {
+			Profiles map[string]yaml.Node `yaml:"Profiles"`
+		}
+		if err = yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal YAML profiles: %w", err)
+		}
+
+		profile, ok := file.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("configuration profile %q not found in %s (available: %s)",
+				name, path, strings.Join(slices.Sorted(maps.Keys(file.Profiles)), ", "))
+		}
+
+		if err = profile.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal YAML profile: %s: %w", name, err)
+		}
+	}
+
 	return &cfg, cfg.Validate()
 }
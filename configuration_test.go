@@ -1,7 +1,10 @@
 package azurepush_test
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -85,3 +88,94 @@ TokenValidity: "1h"
 		t.Errorf("expected TokenValidity 1h, got: %s", cfg.TokenValidity)
 	}
 }
+
+func TestLoadConfigurationProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "configuration.yml")
+	data := `
+TokenValidity: 24h
+RequestTimeout: 5s
+Profiles:
+  dev:
+    HubName: devhub
+    ConnectionString: "Endpoint=sb://devnamespace.servicebus.windows.net/;SharedAccessKeyName=devKey;SharedAccessKey=devSecret"
+  prod:
+    HubName: prodhub
+    ConnectionString: "Endpoint=sb://prodnamespace.servicebus.windows.net/;SharedAccessKeyName=prodKey;SharedAccessKey=prodSecret"
+    RequestTimeout: 2s
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := azurepush.LoadConfigurationProfile(path, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HubName != "devhub" || cfg.Namespace != "devnamespace" || cfg.TokenValidity != 24*time.Hour || cfg.RequestTimeout != 5*time.Second {
+		t.Errorf("expected the dev profile with the shared settings, got: %+v", cfg)
+	}
+
+	t.Setenv(azurepush.EnvProfile, "prod")
+	if cfg, err = azurepush.LoadConfiguration(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HubName != "prodhub" || cfg.RequestTimeout != 2*time.Second || cfg.TokenValidity != 24*time.Hour {
+		t.Errorf("expected the prod profile overriding the shared settings, got: %+v", cfg)
+	}
+
+	if _, err = azurepush.LoadConfigurationProfile(path, "staging"); err == nil || !strings.Contains(err.Error(), "available: dev, prod") {
+		t.Errorf("expected a missing profile error, got: %v", err)
+	}
+
+	if _, err = azurepush.LoadConfigurationProfile(path, ""); err == nil {
+		t.Error("expected the shared settings alone to be invalid without a hub")
+	}
+}
+
+func TestConfiguration_JSON(t *testing.T) {
+	cfg := azurepush.Configuration{
+		HubName:          "testhub",
+		ConnectionString: "Endpoint=sb://testnamespace.servicebus.windows.net/;SharedAccessKeyName=testKey;SharedAccessKey=testSecret",
+		KeyValue:         "testSecret",
+		SendKeyName:      "sendKey",
+		SendKeyValue:     "testSecret",
+		TokenValidity:    time.Hour,
+		RequestTimeout:   5 * time.Second,
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(b), "testSecret") {
+		t.Fatalf("expected redacted secrets, got: %s", b)
+	}
+	if !strings.Contains(string(b), `"TokenValidity":"1h0m0s"`) || strings.Contains(string(b), "IdleConnTimeout") {
+		t.Fatalf("expected string durations, got: %s", b)
+	}
+
+	var decoded azurepush.Configuration
+	if err = json.Unmarshal([]byte(`{
+		"HubName": "testhub",
+		"ConnectionString": "Endpoint=sb://testnamespace.servicebus.windows.net/;SharedAccessKeyName=testKey;SharedAccessKey=testSecret",
+		"TokenValidity": "2h",
+		"RequestTimeout": 3000000000,
+		"MaxIdleConnsPerHost": 50
+	}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = decoded.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.HubName != "testhub" || decoded.KeyValue != "testSecret" || decoded.TokenValidity != 2*time.Hour ||
+		decoded.RequestTimeout != 3*time.Second || decoded.MaxIdleConnsPerHost != 50 {
+		t.Fatalf("unexpected configuration: %#v", decoded)
+	}
+
+	if err = json.Unmarshal([]byte(`{"TokenValidity": "forever"}`), &decoded); err == nil {
+		t.Fatal("expected an invalid duration error")
+	}
+}
@@ -0,0 +1,148 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// CopyOptions holds the settings of CopyInstallations.
+type CopyOptions struct {
+	// TagExpression filters the copied installations, e.g. "tenant:acme" or "region:eu && !beta".
+	// Defaults to all installations.
+	TagExpression string
+
+	// DryRun reports the installations which would be copied, without creating them.
+	DryRun bool
+
+	// Concurrency is the maximum number of installations created in parallel.
+	//
+	// Defaults to 8.
+	Concurrency int
+
+	// OnCopy is called (if not nil) for each copied (or, on dry run, matched) installation
+	// with the error of its creation, if any. It may be called concurrently.
+	OnCopy func(installation Installation, err error)
+}
+
+// CopyReport is the result of CopyInstallations.
+type CopyReport struct {
+	// Matched is the number of registrations matching the tag expression (templates excluded).
+	Matched int
+	// Copied is the number of installations created on the destination hub,
+	// on dry run the number of installations which would be created.
+	Copied int
+	// Skipped is the number of registrations which cannot be recreated as installations,
+	// e.g. legacy "gcm" registrations or registrations without a push channel.
+	Skipped int
+	// Failed is the number of installations whose creation failed.
+	Failed int
+}
+
+// CopyInstallations exports the registrations of the source hub and recreates them as installations
+// on the destination hub (with their platform, push channel and tags), e.g. to promote an environment
+// (staging to production) or to move to another namespace. Installations are created or replaced,
+// so a copy can be safely repeated.
+//
+// The installation ID is kept when the hub reports it (the $InstallationId tag), otherwise
+// the registration ID is used. Templates are not exported by the list API and are not copied.
+//
+// An error is returned if the source hub cannot be listed, or joined with the failed creations.
+//
+// Example usage:
+//
+//	report, err := azurepush.CopyInstallations(ctx, staging, production, azurepush.CopyOptions{
+//		TagExpression: "tenant:acme",
+//		DryRun:        true,
+//	})
+func CopyInstallations(ctx context.Context, src *Client, dst HubClient, opts CopyOptions) (*CopyReport, error) {
+	if src == nil || dst == nil {
+		panic("azurepush: copy requires a source and a destination client")
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+
+	registrations, err := src.matchRegistrations(ctx, opts.TagExpression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export registrations: %w", err)
+	}
+
+	var (
+		report = &CopyReport{Matched: len(registrations)}
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		errs   []error
+		sem    = make(chan struct{}, opts.Concurrency)
+	)
+	for _, r := range registrations {
+		installation, ok := registrationInstallation(r)
+		if !ok {
+			report.Skipped++
+			continue
+		}
+
+		if opts.DryRun {
+			report.Copied++
+			if opts.OnCopy != nil {
+				opts.OnCopy(installation, nil)
+			}
+			continue
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			_, err := dst.RegisterDevice(ctx, installation)
+
+			mu.Lock()
+			if err != nil {
+				report.Failed++
+				errs = append(errs, fmt.Errorf("installation: %s: %w", installation.InstallationID, err))
+			} else {
+				report.Copied++
+			}
+			mu.Unlock()
+
+			if opts.OnCopy != nil {
+				opts.OnCopy(installation, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if err = ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// registrationInstallation converts a registration to an installation,
+// it reports false if the registration cannot be recreated as an installation.
+func registrationInstallation(r Registration) (Installation, bool) {
+	installation := Installation{
+		InstallationID: r.RegistrationID,
+		Platform:       r.Platform,
+		PushChannel:    r.PushChannel,
+	}
+
+	for _, tag := range r.Tags {
+		if id, ok := Tag(tag).installationID(); ok {
+			installation.InstallationID = id
+			continue
+		}
+
+		installation.Tags = append(installation.Tags, tag)
+	}
+
+	// Legacy platforms (e.g. "gcm") and registrations without a push channel are invalid installations.
+	return installation, installation.Validate() == nil
+}
@@ -0,0 +1,80 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestCopyInstallations(t *testing.T) {
+	staging, production := azurepushtest.NewServer(), azurepushtest.NewServer()
+	defer staging.Close()
+	defer production.Close()
+
+	src := staging.NewClient()
+	ctx := context.Background()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "iphone", Platform: azurepush.InstallationApple, PushChannel: "apns-token", Tags: []string{"tenant:acme", "user:1"}},
+		{InstallationID: "pixel", Platform: azurepush.InstallationFCMV1, PushChannel: "fcm-token", Tags: []string{"tenant:acme", "beta"},
+			Templates: map[string]azurepush.Template{"generic": {Body: `{"data":{"message":"$(message)"}}`}}},
+		{InstallationID: "other", Platform: azurepush.InstallationApple, PushChannel: "other-token", Tags: []string{"tenant:globex"}},
+	} {
+		if _, err := src.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := production.NewClient()
+
+	report, err := azurepush.CopyInstallations(ctx, src, dst, azurepush.CopyOptions{TagExpression: "tenant:acme", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 2 || report.Copied != 2 || len(production.Installations()) != 0 {
+		t.Errorf("expected a dry run of 2 installations, got: %+v, installations: %d", *report, len(production.Installations()))
+	}
+
+	var (
+		mu     sync.Mutex
+		copied []string
+	)
+	report, err = azurepush.CopyInstallations(ctx, src, dst, azurepush.CopyOptions{
+		TagExpression: "tenant:acme && !beta",
+		OnCopy: func(installation azurepush.Installation, err error) {
+			mu.Lock()
+			copied = append(copied, installation.InstallationID)
+			mu.Unlock()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Copied != 1 || !slices.Equal(copied, []string{"iphone"}) {
+		t.Errorf("expected the iphone to be copied, got: %+v, %v", *report, copied)
+	}
+
+	installation, ok := production.Installation("iphone")
+	if !ok {
+		t.Fatal("expected the installation on the destination hub")
+	}
+	if installation.Platform != azurepush.InstallationApple || installation.PushChannel != "apns-token" ||
+		!slices.Equal(installation.Tags, []string{"tenant:acme", "user:1"}) {
+		t.Errorf("unexpected copied installation: %+v", installation)
+	}
+
+	production.SetError(azurepushtest.OpRegister, http.StatusInternalServerError, "outage")
+	report, err = azurepush.CopyInstallations(ctx, src, dst, azurepush.CopyOptions{})
+	if err == nil || report.Matched != 3 || report.Failed != 3 {
+		t.Errorf("expected the failed creations to be reported, got: %v, %+v", err, report)
+	}
+
+	staging.SetError(azurepushtest.OpList, http.StatusInternalServerError, "outage")
+	if _, err = azurepush.CopyInstallations(ctx, src, dst, azurepush.CopyOptions{}); err == nil {
+		t.Error("expected an error when the source hub cannot be listed")
+	}
+}
@@ -0,0 +1,47 @@
+package azurepush_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	from := time.Date(2026, time.October, 14, 10, 30, 0, 0, time.UTC) // Wednesday.
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.October, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.October, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * MON", time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, time.October, 15, 9, 0, 0, 0, time.UTC)},
+		{"30 8 1 * *", time.Date(2026, time.November, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 12 13 * FRI", time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)}, // day of month OR day of week.
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		spec, err := azurepush.ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.expr, err)
+		}
+
+		if got := spec.Next(from); !got.Equal(tt.expected) {
+			t.Errorf("%s: expected %s, got: %s", tt.expr, tt.expected, got)
+		}
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := azurepush.ParseCron(expr); err == nil {
+			t.Errorf("%q: expected error", expr)
+		}
+	}
+}
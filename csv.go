@@ -0,0 +1,297 @@
+package azurepush
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// CSV columns of installations, see ReadInstallationsCSV.
+const (
+	CSVColumnInstallationID = "installationId"
+	CSVColumnPlatform       = "platform"
+	CSVColumnPushChannel    = "pushChannel"
+	CSVColumnTags           = "tags"
+	CSVColumnTemplates      = "templates"
+)
+
+// CSVTagSeparator separates the tags of the tags column, it is not a valid tag character.
+const CSVTagSeparator = ";"
+
+// InstallationsCSVHeader is the header row written by WriteInstallationsCSV.
+var InstallationsCSVHeader = []string{
+	CSVColumnInstallationID,
+	CSVColumnPlatform,
+	CSVColumnPushChannel,
+	CSVColumnTags,
+	CSVColumnTemplates,
+}
+
+// WriteInstallationsCSV writes the installations as CSV rows, after the InstallationsCSVHeader row,
+// so device lists can be edited with spreadsheets. See ReadInstallationsCSV for the schema.
+// The fields unknown to the Installation struct (see Installation.Extra) are not written.
+//
+// Example usage:
+//
+//	err := azurepush.WriteInstallationsCSV(file, slices.Values(installations))
+func WriteInstallationsCSV(w io.Writer, installations iter.Seq[Installation]) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(InstallationsCSVHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for installation := range installations {
+		var templates string
+		if len(installation.Templates) > 0 {
+			b, err := json.Marshal(installation.Templates)
+			if err != nil {
+				return fmt.Errorf("failed to encode templates of installation: %s: %w", installation.InstallationID, err)
+			}
+			templates = string(b)
+		}
+
+		row := []string{
+			installation.InstallationID,
+			string(installation.Platform),
+			installation.PushChannel,
+			strings.Join(installation.Tags, CSVTagSeparator),
+			templates,
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row of installation: %s: %w", installation.InstallationID, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadInstallationsCSV returns an iterator over the installations of CSV rows, e.g. a device list
+// edited with a spreadsheet or written by WriteInstallationsCSV, ready for Client.ImportInstallations.
+//
+// The first row is the header, columns are matched by name (case-insensitive) and can be in any order:
+//
+//	installationId  required, the installation ID
+//	platform        required, one of apns, FCMV1, baidu, wns, mpns and adm (case-insensitive)
+//	pushChannel     required, the device token
+//	tags            optional, the tags separated by ";", e.g. "user:42;lang:en"
+//	templates       optional, a JSON object of templates by name, e.g. {"generic":{"body":"..."}}
+//
+// Unknown or duplicated columns fail the header. Each row is validated (see Installation.Validate),
+// an invalid row is yielded as a *csv.ParseError with its line number and the iteration continues
+// unless the caller breaks it. Other errors stop the iteration.
+//
+// Example usage:
+//
+//	for installation, err := range azurepush.ReadInstallationsCSV(file) {
+//		if err != nil {
+//			log.Println(err) // record on line 7: invalid platform: "ios" ...
+//			continue
+//		}
+//		installations = append(installations, installation)
+//	}
+func ReadInstallationsCSV(r io.Reader) iter.Seq2[Installation, error] {
+	return func(yield func(Installation, error) bool) {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1 // checked per row, so a short row does not stop the iteration.
+		cr.TrimLeadingSpace = true
+
+		header, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			yield(Installation{}, fmt.Errorf("failed to read CSV header: %w", err))
+			return
+		}
+
+		columns, err := parseCSVHeader(header)
+		if err != nil {
+			yield(Installation{}, err)
+			return
+		}
+
+		for {
+			row, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				if !yield(Installation{}, err) {
+					return
+				}
+				if _, ok := errors.AsType[*csv.ParseError](err); ok {
+					continue
+				}
+				return
+			}
+
+			installation, err := parseCSVRow(columns, row)
+			if err != nil {
+				line, _ := cr.FieldPos(0)
+				err = &csv.ParseError{StartLine: line, Line: line, Err: err}
+			}
+
+			if !yield(installation, err) {
+				return
+			}
+		}
+	}
+}
+
+// parseCSVHeader returns the column names by index of a CSV header.
+func parseCSVHeader(header []string) ([]string, error) {
+	var (
+		columns = make([]string, len(header))
+		seen    = make(map[string]bool, len(header))
+	)
+	for i, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")) // spreadsheets may write a BOM.
+
+		column := ""
+		for _, known := range InstallationsCSVHeader {
+			if strings.EqualFold(name, known) {
+				column = known
+				break
+			}
+		}
+
+		if column == "" {
+			return nil, fmt.Errorf("unknown CSV column: %q", name)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicated CSV column: %q", name)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	for _, required := range []string{CSVColumnInstallationID, CSVColumnPlatform, CSVColumnPushChannel} {
+		if !seen[required] {
+			return nil, fmt.Errorf("missing CSV column: %q", required)
+		}
+	}
+
+	return columns, nil
+}
+
+// parseCSVRow returns the validated installation of a CSV row.
+func parseCSVRow(columns, row []string) (Installation, error) {
+	if len(row) != len(columns) {
+		return Installation{}, fmt.Errorf("expected %d fields, got %d", len(columns), len(row))
+	}
+
+	var installation Installation
+	for i, value := range row {
+		value = strings.TrimSpace(value)
+
+		switch columns[i] {
+		case CSVColumnInstallationID:
+			installation.InstallationID = value
+		case CSVColumnPlatform:
+			installation.Platform = parsePlatform(value)
+		case CSVColumnPushChannel:
+			installation.PushChannel = value
+		case CSVColumnTags:
+			for tag := range strings.SplitSeq(value, CSVTagSeparator) {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					installation.Tags = append(installation.Tags, tag)
+				}
+			}
+		case CSVColumnTemplates:
+			if value == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(value), &installation.Templates); err != nil {
+				return Installation{}, fmt.Errorf("invalid templates: %w", err)
+			}
+		}
+	}
+
+	if err := installation.Validate(); err != nil {
+		return Installation{}, err
+	}
+
+	for _, tag := range installation.Tags {
+		if err := Tag(tag).Validate(); err != nil {
+			return Installation{}, err
+		}
+	}
+
+	return installation, nil
+}
+
+// parsePlatform returns the platform matching s case-insensitively, e.g. "fcmv1" for InstallationFCMV1,
+// or s as it is if it does not match any.
+func parsePlatform(s string) InstallationPlatform {
+	for _, platform := range []InstallationPlatform{InstallationApple, InstallationFCMV1, InstallationBaidu, InstallationWNS, InstallationMPNS, InstallationADM} {
+		if strings.EqualFold(s, string(platform)) {
+			return platform
+		}
+	}
+
+	return InstallationPlatform(s)
+}
+
+// WriteInstallationsNDJSON writes the installations as newline-delimited JSON, one installation per line,
+// the format of the import and export files of installations jobs (see Client.ImportInstallations).
+//
+// Example usage:
+//
+//	err := azurepush.WriteInstallationsNDJSON(file, slices.Values(installations))
+func WriteInstallationsNDJSON(w io.Writer, installations iter.Seq[Installation]) error {
+	enc := json.NewEncoder(w)
+	for installation := range installations {
+		if err := enc.Encode(installation); err != nil {
+			return fmt.Errorf("failed to encode installation: %s: %w", installation.InstallationID, err)
+		}
+	}
+
+	return nil
+}
+
+// ReadInstallationsNDJSON returns an iterator over the installations of newline-delimited JSON,
+// e.g. written by WriteInstallationsNDJSON or the output file of an installations export job.
+// Like ReadInstallationsCSV, each installation is validated and an invalid line is yielded
+// as an *ExportLineError, the iteration continues unless the caller breaks it.
+//
+// Example usage:
+//
+//	for installation, err := range azurepush.ReadInstallationsNDJSON(file) {
+//		if err != nil {
+//			return err
+//		}
+//		installations = append(installations, installation)
+//	}
+func ReadInstallationsNDJSON(r io.Reader) iter.Seq2[Installation, error] {
+	return func(yield func(Installation, error) bool) {
+		for record, err := range NewExportReader(r).All() {
+			if err == nil {
+				if record.Installation != nil {
+					record.Installation.Platform = parsePlatform(string(record.Installation.Platform))
+				}
+
+				if record.Installation == nil {
+					err = &ExportLineError{Line: record.Line, Err: fmt.Errorf("not a JSON installation")}
+				} else if validateErr := record.Installation.Validate(); validateErr != nil {
+					err = &ExportLineError{Line: record.Line, Err: validateErr}
+				}
+			}
+
+			if err != nil {
+				if !yield(Installation{}, err) {
+					return
+				}
+				continue
+			}
+
+			if !yield(*record.Installation, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package azurepush_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestInstallationsCSV(t *testing.T) {
+	installations := []azurepush.Installation{
+		{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a", Tags: []string{"user:1", "lang:en"}},
+		{
+			InstallationID: "b",
+			Platform:       azurepush.InstallationFCMV1,
+			PushChannel:    "token-b",
+			Templates:      map[string]azurepush.Template{"generic": {Body: `{"message":{"notification":{"body":"$(msg)"}}}`, Tags: []string{"t"}}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := azurepush.WriteInstallationsCSV(&buf, slices.Values(installations)); err != nil {
+		t.Fatal(err)
+	}
+
+	if header, _, _ := strings.Cut(buf.String(), "\n"); header != "installationId,platform,pushChannel,tags,templates" {
+		t.Fatalf("unexpected header: %s", header)
+	}
+
+	var got []azurepush.Installation
+	for installation, err := range azurepush.ReadInstallationsCSV(&buf) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, installation)
+	}
+
+	if !reflect.DeepEqual(got, installations) {
+		t.Fatalf("expected the written installations, got: %#v", got)
+	}
+}
+
+func TestReadInstallationsCSV(t *testing.T) {
+	// Columns in any order and case, the optional ones omitted.
+	input := "\ufeffPushChannel, platform ,installationID,tags\n" +
+		"token-a,APNS,a,user:1; vip\n" +
+		"token-b,ios,b,\n" +
+		"token-c,fcmv1,c\n" +
+		"token-d,fcmv1,d,bad tag\n" +
+		"token-e,wns,e,\n"
+
+	var (
+		ids  []string
+		errs []*csv.ParseError
+	)
+	for installation, err := range azurepush.ReadInstallationsCSV(strings.NewReader(input)) {
+		if err != nil {
+			parseErr, ok := errors.AsType[*csv.ParseError](err)
+			if !ok {
+				t.Fatalf("expected a *csv.ParseError, got: %v", err)
+			}
+			errs = append(errs, parseErr)
+			continue
+		}
+		ids = append(ids, installation.InstallationID)
+
+		if installation.InstallationID == "a" && (installation.Platform != azurepush.InstallationApple || !slices.Equal(installation.Tags, []string{"user:1", "vip"})) {
+			t.Errorf("unexpected installation: %#v", installation)
+		}
+	}
+
+	if !slices.Equal(ids, []string{"a", "e"}) {
+		t.Errorf("unexpected installations: %v", ids)
+	}
+
+	if len(errs) != 3 || errs[0].Line != 3 || errs[1].Line != 4 || errs[2].Line != 5 {
+		t.Fatalf("expected errors of lines 3, 4 and 5, got: %v", errs)
+	}
+
+	for _, header := range []string{"installationId,platform", "installationId,platform,pushChannel,os", "installationId,platform,pushChannel,platform"} {
+		for _, err := range azurepush.ReadInstallationsCSV(strings.NewReader(header + "\n")) {
+			if err == nil {
+				t.Errorf("expected a header error: %s", header)
+			}
+		}
+	}
+}
+
+func TestInstallationsNDJSON(t *testing.T) {
+	installations := []azurepush.Installation{
+		{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a", Tags: []string{"user:1"}},
+		{InstallationID: "b", Platform: azurepush.InstallationFCMV1, PushChannel: "token-b"},
+	}
+
+	var buf bytes.Buffer
+	if err := azurepush.WriteInstallationsNDJSON(&buf, slices.Values(installations)); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString(`{"installationId":"c","platform":"fcmv1","pushChannel":"token-c"}` + "\n")
+	buf.WriteString(`{"installationId":"d","platform":"fcmv1"}` + "\n")
+
+	var (
+		got  []azurepush.Installation
+		errs []error
+	)
+	for installation, err := range azurepush.ReadInstallationsNDJSON(&buf) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		got = append(got, installation)
+	}
+
+	if len(got) != 3 || !reflect.DeepEqual(got[:2], installations) || got[2].Platform != azurepush.InstallationFCMV1 {
+		t.Fatalf("unexpected installations: %#v", got)
+	}
+
+	if lineErr, ok := errors.AsType[*azurepush.ExportLineError](errors.Join(errs...)); len(errs) != 1 || !ok || lineErr.Line != 4 {
+		t.Errorf("expected an invalid line 4 error, got: %v", errs)
+	}
+}
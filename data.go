@@ -0,0 +1,59 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+// DataMap converts a typed data payload (e.g. a struct with json tags) to the map of Notification.Data,
+// so the keys expected by the mobile apps are checked at compile time.
+// Numbers are kept as json.Number, so they are sent as they are encoded (e.g. "42", not "4.2e+01").
+//
+// It returns an error if the data cannot be encoded or is not encoded as a JSON object.
+func DataMap[T any](data T) (map[string]any, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var m map[string]any
+	if err = dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("notification data must be encoded as a JSON object: %w", err)
+	}
+
+	return m, nil
+}
+
+// SendNotificationData sends the notification with a typed data payload, see DataMap.
+// The data fields are added to the notification's Data (if any), replacing the keys they have in common.
+//
+// Example usage:
+//
+//	type ChatMessage struct {
+//		Type     string `json:"type"`
+//		ThreadID string `json:"threadId"`
+//	}
+//
+//	err := azurepush.SendNotificationData(ctx, client, azurepush.Notification{Title: "New message"},
+//		ChatMessage{Type: "chat_message", ThreadID: "abc123"}, "user:42")
+func SendNotificationData[T any](ctx context.Context, client HubClient, notification Notification, data T, tags ...string) error {
+	m, err := DataMap(data)
+	if err != nil {
+		return err
+	}
+
+	if len(notification.Data) > 0 {
+		merged := maps.Clone(notification.Data)
+		maps.Copy(merged, m)
+		m = merged
+	}
+	notification.Data = m
+
+	return client.SendNotification(ctx, notification, tags...)
+}
@@ -0,0 +1,71 @@
+package azurepush_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+type chatMessage struct {
+	Type     string `json:"type"`
+	ThreadID string `json:"threadId"`
+	Unread   int    `json:"unread"`
+	Muted    bool   `json:"muted,omitempty"`
+}
+
+func TestSendNotificationData(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+	if _, err := client.RegisterDevice(ctx, azurepushtest.RandomInstallation(azurepush.InstallationFCMV1)); err != nil {
+		t.Fatal(err)
+	}
+
+	notification := azurepush.Notification{Title: "New message", Data: map[string]any{"type": "generic", "campaign": "spring"}}
+	err := azurepush.SendNotificationData(ctx, client, notification, chatMessage{Type: "chat_message", ThreadID: "abc123", Unread: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if notification.Data["type"] != "generic" {
+		t.Error("expected the caller's notification data to be left untouched")
+	}
+
+	var payload []byte
+	for _, sent := range srv.AllSent() {
+		if sent.Format == string(azurepush.PlatformFCMV1) {
+			payload = sent.Payload
+		}
+	}
+
+	var fcm struct {
+		Message struct {
+			Android struct {
+				Data map[string]string `json:"data"`
+			} `json:"android"`
+		} `json:"message"`
+	}
+	if err = json.Unmarshal(payload, &fcm); err != nil {
+		t.Fatalf("expected an FCM v1 send: %v", err)
+	}
+
+	expected := map[string]string{"type": "chat_message", "threadId": "abc123", "unread": "42", "campaign": "spring"}
+	got := fcm.Message.Android.Data
+	if len(got) != len(expected) {
+		t.Fatalf("expected data: %v, got: %v", expected, got)
+	}
+	for k, v := range expected {
+		if got[k] != v {
+			t.Errorf("%s: expected: %q, got: %q", k, v, got[k])
+		}
+	}
+
+	if _, err = azurepush.DataMap([]string{"not", "an", "object"}); err == nil {
+		t.Error("expected an error for data which is not a JSON object")
+	}
+}
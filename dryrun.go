@@ -0,0 +1,64 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type dryRunContextKey struct{}
+
+// withDryRun returns a context whose mutating requests are short-circuited, see SenderOptions.DryRun.
+func withDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunContextKey{}, true)
+}
+
+// dryRun reports whether the mutating requests of the context are short-circuited,
+// see Configuration.DryRun and SenderOptions.DryRun.
+func (c *Client) dryRun(ctx context.Context) bool {
+	if dryRun, _ := ctx.Value(dryRunContextKey{}).(bool); dryRun {
+		return true
+	}
+
+	cfg, _ := c.current()
+	return cfg.DryRun
+}
+
+// mutating reports whether the request modifies the hub (or its storage),
+// the reads (e.g. DeviceExists, ValidateToken and the listings) are sent in dry-run mode.
+func mutating(req *http.Request) bool {
+	return req.Method != http.MethodGet && req.Method != http.MethodHead
+}
+
+// dryRunResponse is the successful response of a short-circuited request, it echoes the request's body
+// (e.g. the submitted job). The body is read, so the streamed payloads are built as if they were sent.
+func dryRunResponse(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	statusCode := http.StatusOK
+	if req.Method == http.MethodPost {
+		statusCode = http.StatusCreated
+	}
+
+	return &http.Response{
+		Status:        strconv.Itoa(statusCode) + " " + http.StatusText(statusCode),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {req.Header.Get("Content-Type")}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
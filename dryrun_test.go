@@ -0,0 +1,90 @@
+package azurepush_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestConfiguration_DryRun(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	cfg := srv.Configuration()
+	cfg.DryRun = true
+	client := azurepush.NewClient(cfg)
+	client.HTTPClient = srv.HTTPClient()
+
+	var records []azurepush.AuditRecord
+	client.Audit = azurepush.AuditSinkFunc(func(_ context.Context, record azurepush.AuditRecord) {
+		records = append(records, record)
+	})
+	ctx := context.Background()
+
+	id, err := client.RegisterDevice(ctx, azurepush.Installation{Platform: azurepush.InstallationFCMV1, PushChannel: "token", Tags: []string{"user:42"}})
+	if err != nil || id == "" {
+		t.Fatalf("expected a successful dry-run registration, got: %q: %v", id, err)
+	}
+	if err = client.PatchInstallation(ctx, id, azurepush.AddTagPatch("topic:news")); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.DeleteDevice(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = client.SendNotification(ctx, azurepush.Notification{}, "user:42"); err == nil {
+		t.Error("expected the payload validation in dry-run mode")
+	}
+
+	if len(srv.Installations()) != 0 || len(srv.Sent("user:42")) != 0 {
+		t.Fatal("expected no request to reach the hub in dry-run mode")
+	}
+
+	// The reads still call the hub.
+	if exists, err := client.DeviceExists(ctx, id); err != nil || exists {
+		t.Errorf("expected the device not registered, got: %v: %v", exists, err)
+	}
+
+	if len(records) != 5 {
+		t.Fatalf("expected 5 audit records, got: %d", len(records))
+	}
+	for _, record := range records {
+		if !record.DryRun {
+			t.Errorf("expected a dry-run audit record, got: %+v", record)
+		}
+	}
+	if stats := client.Stats(); stats.Sends["ok"] != 1 {
+		t.Errorf("expected the dry-run send in the stats, got: %v", stats.Sends)
+	}
+}
+
+func TestSender_DryRun(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	sender := azurepush.NewSender(client, azurepush.SenderOptions{DryRun: true})
+
+	results := sender.SendBatch(context.Background(), []azurepush.TargetedNotification{
+		{ID: "1", Notification: azurepush.Notification{Title: "Hi"}, Tags: []string{"user:42"}},
+	})
+	if results[0].Err != nil {
+		t.Fatal(results[0].Err)
+	}
+	if len(srv.Sent("user:42")) != 0 {
+		t.Error("expected the dry-run sender not to send")
+	}
+
+	// The client is not in dry-run mode.
+	if err := client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Sent("user:42")) == 0 {
+		t.Error("expected the client to send")
+	}
+}
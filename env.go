@@ -0,0 +1,139 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by ConfigurationFromEnv.
+const (
+	// EnvConfig is the path of a YAML configuration file, it takes precedence over the rest of the variables.
+	EnvConfig = "AZUREPUSH_CONFIG"
+	// EnvProfile is the profile of the configuration file to load, see LoadConfigurationProfile.
+	EnvProfile = "AZUREPUSH_PROFILE"
+	// EnvHubName is the Configuration.HubName.
+	EnvHubName = "AZUREPUSH_HUB_NAME"
+	// EnvConnectionString is the Configuration.ConnectionString.
+	EnvConnectionString = "AZUREPUSH_CONNECTION_STRING"
+	// EnvNamespace is the Configuration.Namespace, when no connection string is set.
+	EnvNamespace = "AZUREPUSH_NAMESPACE"
+	// EnvKeyName is the Configuration.KeyName, when no connection string is set.
+	EnvKeyName = "AZUREPUSH_KEY_NAME"
+	// EnvKeyValue is the Configuration.KeyValue, when no connection string is set.
+	EnvKeyValue = "AZUREPUSH_KEY_VALUE"
+	// EnvSendConnectionString is the Configuration.SendConnectionString.
+	EnvSendConnectionString = "AZUREPUSH_SEND_CONNECTION_STRING"
+	// EnvSendKeyName is the Configuration.SendKeyName, when no send connection string is set.
+	EnvSendKeyName = "AZUREPUSH_SEND_KEY_NAME"
+	// EnvSendKeyValue is the Configuration.SendKeyValue, when no send connection string is set.
+	EnvSendKeyValue = "AZUREPUSH_SEND_KEY_VALUE"
+	// EnvKeyEncoding is the Configuration.KeyEncoding, "base64" or "raw".
+	EnvKeyEncoding = "AZUREPUSH_KEY_ENCODING"
+	// EnvTokenValidity is the Configuration.TokenValidity, as a time.Duration string (e.g. "2h").
+	EnvTokenValidity = "AZUREPUSH_TOKEN_VALIDITY"
+	// EnvConnectivityCheck is the Configuration.ConnectivityCheck, as a boolean string.
+	EnvConnectivityCheck = "AZUREPUSH_CONNECTIVITY_CHECK"
+	// EnvDryRun is the Configuration.DryRun, as a boolean string.
+	// It overrides the DryRun of the AZUREPUSH_CONFIG file, so staging can share the production file.
+	EnvDryRun = "AZUREPUSH_DRY_RUN"
+)
+
+// ConfigurationFromEnv loads and validates a configuration from the environment,
+// either from the YAML file at AZUREPUSH_CONFIG (its AZUREPUSH_PROFILE profile, if set) or from the AZUREPUSH_* variables.
+func ConfigurationFromEnv() (Configuration, error) {
+	if path := os.Getenv(EnvConfig); path != "" {
+		cfg, err := LoadConfiguration(path)
+		if err != nil {
+			return Configuration{}, err
+		}
+		if err = dryRunFromEnv(cfg); err != nil {
+			return Configuration{}, err
+		}
+		return *cfg, nil
+	}
+
+	cfg := Configuration{
+		HubName:          os.Getenv(EnvHubName),
+		ConnectionString: os.Getenv(EnvConnectionString),
+		Namespace:        os.Getenv(EnvNamespace),
+		KeyName:          os.Getenv(EnvKeyName),
+		KeyValue:         os.Getenv(EnvKeyValue),
+
+		SendConnectionString: os.Getenv(EnvSendConnectionString),
+		SendKeyName:          os.Getenv(EnvSendKeyName),
+		SendKeyValue:         os.Getenv(EnvSendKeyValue),
+		KeyEncoding:          KeyEncoding(os.Getenv(EnvKeyEncoding)),
+	}
+	if cfg.HubName == "" {
+		return Configuration{}, errors.New(EnvConfig + " or " + EnvHubName + " environment variable is required")
+	}
+
+	if s := os.Getenv(EnvTokenValidity); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Configuration{}, fmt.Errorf("invalid %s: %w", EnvTokenValidity, err)
+		}
+		cfg.TokenValidity = d
+	}
+
+	if s := os.Getenv(EnvConnectivityCheck); s != "" {
+		check, err := strconv.ParseBool(s)
+		if err != nil {
+			return Configuration{}, fmt.Errorf("invalid %s: %w", EnvConnectivityCheck, err)
+		}
+		cfg.ConnectivityCheck = check
+	}
+
+	if err := dryRunFromEnv(&cfg); err != nil {
+		return Configuration{}, err
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// dryRunFromEnv sets the DryRun of the configuration from the AZUREPUSH_DRY_RUN variable, if set.
+func dryRunFromEnv(cfg *Configuration) error {
+	s := os.Getenv(EnvDryRun)
+	if s == "" {
+		return nil
+	}
+
+	dryRun, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", EnvDryRun, err)
+	}
+	cfg.DryRun = dryRun
+
+	return nil
+}
+
+// NewClientFromEnv creates a new Client configured from the environment, see ConfigurationFromEnv.
+// Unlike NewClient it returns an error instead of panicking on an invalid configuration,
+// so it can be used as a dependency injection provider (e.g. google/wire or uber/fx).
+func NewClientFromEnv() (*Client, error) {
+	cfg, err := ConfigurationFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	// Report a failed connectivity check as an error instead of NewClient's panic.
+	check := cfg.ConnectivityCheck
+	cfg.ConnectivityCheck = false
+	client := NewClient(cfg)
+
+	if check {
+		ctx, cancelFunc := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancelFunc()
+
+		if err = client.ValidateToken(ctx); err != nil {
+			return nil, fmt.Errorf("connectivity check failed: %w", err)
+		}
+		client.Config.ConnectivityCheck = true
+	}
+
+	return client, nil
+}
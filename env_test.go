@@ -0,0 +1,64 @@
+package azurepush_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestConfigurationFromEnv(t *testing.T) {
+	t.Setenv(azurepush.EnvHubName, "hub")
+	t.Setenv(azurepush.EnvConnectionString, testConnectionString)
+	t.Setenv(azurepush.EnvTokenValidity, "2h")
+
+	cfg, err := azurepush.ConfigurationFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HubName != "hub" || cfg.Namespace == "" || cfg.TokenValidity != 2*time.Hour {
+		t.Errorf("unexpected configuration: %+v", cfg)
+	}
+
+	t.Setenv(azurepush.EnvTokenValidity, "forever")
+	if _, err = azurepush.ConfigurationFromEnv(); err == nil {
+		t.Error("expected error for an invalid token validity")
+	}
+
+	t.Setenv(azurepush.EnvHubName, "")
+	if _, err = azurepush.NewClientFromEnv(); err == nil {
+		t.Error("expected error for a missing hub name")
+	}
+}
+
+func TestConfigurationFromEnv_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "configuration.yml")
+	data := "HubName: filehub\nConnectionString: \"" + testConnectionString + "\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(azurepush.EnvConfig, path)
+	t.Setenv(azurepush.EnvHubName, "ignored")
+
+	client, err := azurepush.NewClientFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Config.HubName != "filehub" {
+		t.Errorf("expected the configuration file to take precedence, got hub: %s", client.Config.HubName)
+	}
+
+	t.Setenv(azurepush.EnvDryRun, "true")
+	cfg, err := azurepush.ConfigurationFromEnv()
+	if err != nil || !cfg.DryRun {
+		t.Errorf("expected %s to override the configuration file, got: %v: %v", azurepush.EnvDryRun, cfg.DryRun, err)
+	}
+
+	t.Setenv(azurepush.EnvDryRun, "maybe")
+	if _, err = azurepush.ConfigurationFromEnv(); err == nil {
+		t.Error("expected an invalid dry-run error")
+	}
+}
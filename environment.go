@@ -0,0 +1,113 @@
+package azurepush
+
+import (
+	"context"
+	"strings"
+)
+
+// Environment is the APNs environment of an app build: production (App Store) or sandbox
+// (development builds). Sandbox device tokens are only accepted by the APNs sandbox,
+// which is configured on a separate hub, see EnvironmentClient.
+type Environment string
+
+const (
+	// EnvironmentProduction is the environment of App Store (and TestFlight) builds.
+	EnvironmentProduction Environment = "production"
+	// EnvironmentSandbox is the environment of development builds, e.g. run from Xcode.
+	EnvironmentSandbox Environment = "sandbox"
+)
+
+// TagKindEnvironment is the tag kind of EnvironmentTag.
+const TagKindEnvironment = "env"
+
+// EnvironmentTag returns the tag of an environment's installations, e.g. "env:sandbox".
+func EnvironmentTag(env Environment) Tag {
+	return NewTag(TagKindEnvironment, string(env))
+}
+
+// EnvironmentClient is a HubClient over a production hub and a sandbox hub (configured with APNs sandbox credentials),
+// so the devices of development builds receive the notifications too.
+//
+// An installation is registered to the hub of its EnvironmentTag (and deleted from the other one), the installations
+// without one are tagged with the production environment. A notification is sent to the hub of the environment tag
+// it requires (e.g. "env:sandbox && user:42"), or to both hubs, e.g. for "user:42 && !env:sandbox" or
+// "env:sandbox || user:42". Use Environment to target a single environment explicitly.
+//
+// Example usage:
+//
+//	ec := azurepush.NewEnvironmentClient(azurepush.NewClient(productionCfg), azurepush.NewClient(sandboxCfg))
+//	installation.Tags = append(installation.Tags, azurepush.EnvironmentTag(azurepush.EnvironmentSandbox).String())
+//	_, err := ec.RegisterDevice(ctx, installation)
+//	err = ec.SendNotification(ctx, notification, "user:42") // to both environments.
+//	err = ec.Environment(azurepush.EnvironmentSandbox).SendNotification(ctx, notification, "user:42")
+type EnvironmentClient struct {
+	router *HubRouter
+}
+
+var _ HubClient = (*EnvironmentClient)(nil)
+
+// NewEnvironmentClient creates a new EnvironmentClient.
+func NewEnvironmentClient(production, sandbox HubClient) *EnvironmentClient {
+	if production == nil || sandbox == nil {
+		panic("azurepush: environment client requires a production and a sandbox client")
+	}
+
+	router := NewHubRouter(HubRouterOptions{
+		Hubs: map[string]HubClient{
+			string(EnvironmentProduction): production,
+			string(EnvironmentSandbox):    sandbox,
+		},
+		Routes: []HubRoute{
+			{TagPrefix: string(EnvironmentTag(EnvironmentProduction)), Hub: string(EnvironmentProduction)},
+			{TagPrefix: string(EnvironmentTag(EnvironmentSandbox)), Hub: string(EnvironmentSandbox)},
+		},
+	})
+
+	return &EnvironmentClient{router: router}
+}
+
+// Environment returns the client of an environment's hub, or nil for an unknown environment.
+func (ec *EnvironmentClient) Environment(env Environment) HubClient {
+	return ec.router.Hub(string(env))
+}
+
+// RegisterDevice registers the installation to the hub of its environment tag and deletes it from the other hub,
+// an installation without one is tagged with the production environment.
+// It returns an error if the installation has the tags of both environments.
+func (ec *EnvironmentClient) RegisterDevice(ctx context.Context, installation Installation) (string, error) {
+	tagged := false
+	for _, tag := range installation.Tags {
+		if strings.HasPrefix(tag, TagKindEnvironment+":") {
+			tagged = true
+			break
+		}
+	}
+
+	if !tagged {
+		installation.Tags = append(installation.Tags[:len(installation.Tags):len(installation.Tags)], string(EnvironmentTag(EnvironmentProduction)))
+	}
+
+	return ec.router.RegisterDevice(ctx, installation)
+}
+
+// DeviceExists reports whether the installation exists on any of the hubs.
+func (ec *EnvironmentClient) DeviceExists(ctx context.Context, installationID string) (bool, error) {
+	return ec.router.DeviceExists(ctx, installationID)
+}
+
+// DeleteDevice deletes the installation from both hubs.
+func (ec *EnvironmentClient) DeleteDevice(ctx context.Context, installationID string) error {
+	return ec.router.DeleteDevice(ctx, installationID)
+}
+
+// ValidateToken validates the SAS tokens of both hubs.
+func (ec *EnvironmentClient) ValidateToken(ctx context.Context) error {
+	return ec.router.ValidateToken(ctx)
+}
+
+// SendNotification sends the notification to the hub of the environment tag AND-ed at the top level of its tags,
+// or to both hubs (e.g. for a negated environment tag).
+// A hub without a matching device is not an error, unless neither has one.
+func (ec *EnvironmentClient) SendNotification(ctx context.Context, notification Notification, tags ...string) error {
+	return ec.router.SendNotification(ctx, notification, tags...)
+}
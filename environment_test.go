@@ -0,0 +1,93 @@
+package azurepush_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestEnvironmentClient(t *testing.T) {
+	production, sandbox := azurepushtest.NewServer(), azurepushtest.NewServer()
+	defer production.Close()
+	defer sandbox.Close()
+
+	ec := azurepush.NewEnvironmentClient(production.NewClient(), sandbox.NewClient())
+	sandboxTag := azurepush.EnvironmentTag(azurepush.EnvironmentSandbox).String()
+
+	ctx := context.Background()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "store", Platform: azurepush.InstallationApple, PushChannel: "a", Tags: []string{"user:42"}},
+		{InstallationID: "xcode", Platform: azurepush.InstallationApple, PushChannel: "b", Tags: []string{"user:42", sandboxTag}},
+	} {
+		if _, err := ec.RegisterDevice(ctx, installation); err != nil {
+			t.Fatalf("%s: %v", installation.InstallationID, err)
+		}
+	}
+
+	if installation, ok := production.Installation("store"); !ok || !slices.Contains(installation.Tags, "env:production") {
+		t.Errorf("expected the untagged installation on the production hub, tagged with its environment: %v", installation.Tags)
+	}
+	if _, ok := sandbox.Installation("xcode"); !ok {
+		t.Error("expected the sandbox installation on the sandbox hub")
+	}
+	if _, ok := production.Installation("xcode"); ok {
+		t.Error("expected the sandbox installation not to be registered on the production hub")
+	}
+
+	if _, err := ec.RegisterDevice(ctx, azurepush.Installation{
+		Platform: azurepush.InstallationApple, PushChannel: "c", Tags: []string{"env:production", sandboxTag},
+	}); err == nil {
+		t.Error("expected an error for an installation of both environments")
+	}
+
+	notification := azurepush.Notification{Title: "Hello"}
+	if err := ec.SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+	if len(production.Sent("user:42")) != 2 || len(sandbox.Sent("user:42")) != 2 {
+		t.Errorf("expected the send on both hubs (one per platform), got: %d and %d",
+			len(production.Sent("user:42")), len(sandbox.Sent("user:42")))
+	}
+
+	production.Reset()
+	sandbox.Reset()
+
+	if err := ec.SendNotification(ctx, notification, sandboxTag+" && user:42"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ec.Environment(azurepush.EnvironmentSandbox).SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+	if len(production.AllSent()) != 0 || len(sandbox.AllSent()) != 4 {
+		t.Errorf("expected the sends on the sandbox hub only, got: %d and %d", len(production.AllSent()), len(sandbox.AllSent()))
+	}
+
+	production.Reset()
+	sandbox.Reset()
+
+	// The untagged production installations match a negated environment, the expression goes to both hubs.
+	if err := ec.SendNotification(ctx, notification, "user:42 && !"+sandboxTag); err != nil {
+		t.Fatal(err)
+	}
+	if len(production.AllSent()) == 0 || len(sandbox.AllSent()) == 0 {
+		t.Errorf("expected a negated environment to be sent on both hubs, got: %d and %d", len(production.AllSent()), len(sandbox.AllSent()))
+	}
+
+	// A development device installed from the App Store leaves the sandbox hub.
+	for _, tags := range [][]string{{"user:7", sandboxTag}, {"user:7"}} {
+		if _, err := ec.RegisterDevice(ctx, azurepush.Installation{
+			InstallationID: "upgraded", Platform: azurepush.InstallationApple, PushChannel: "d", Tags: tags,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := sandbox.Installation("upgraded"); ok {
+		t.Error("expected the installation to be deleted from the sandbox hub")
+	}
+	if _, ok := production.Installation("upgraded"); !ok {
+		t.Error("expected the installation on the production hub")
+	}
+}
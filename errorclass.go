@@ -0,0 +1,149 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// SendError is returned when the hub, or a direct provider (e.g. FCMProvider), rejects a notification
+// with a non-successful status code.
+type SendError struct {
+	Platform   Platform
+	StatusCode int
+	// Body holds the response body (if any).
+	Body string
+}
+
+// Error implements the error interface.
+func (e *SendError) Error() string {
+	return fmt.Sprintf("failed to send %s notification with status: %d and body: %s", e.Platform, e.StatusCode, e.Body)
+}
+
+// errUnsupportedPlatform is returned by the direct providers when registering an installation of another platform.
+var errUnsupportedPlatform = errors.New("invalid platform")
+
+// ErrorClass is a set of error classes, see ClassifyError.
+// Classes can be combined with the bitwise OR operator, e.g. ErrorClassNetwork|ErrorClassServer.
+type ErrorClass uint16
+
+// Error classes.
+const (
+	// ErrorClassNetwork is a connection, DNS, TLS or request timeout failure.
+	ErrorClassNetwork ErrorClass = 1 << iota
+	// ErrorClassServer is a 5xx response.
+	ErrorClassServer
+	// ErrorClassThrottled is a 429 response or an exceeded tier quota.
+	ErrorClassThrottled
+	// ErrorClassAuth is a 401 or 403 response, e.g. an invalid or revoked key.
+	ErrorClassAuth
+	// ErrorClassRequest is any other 4xx response, e.g. an invalid payload, a tier restriction
+	// or a notification rejected by Notification.Validate.
+	ErrorClassRequest
+	// ErrorClassNoDevice is reported when no device matched the tags.
+	ErrorClassNoDevice
+	// ErrorClassCanceled is a canceled or expired context, or a closed client (see ErrClientClosed).
+	ErrorClassCanceled
+	// ErrorClassOther is any other error.
+	ErrorClassOther
+)
+
+// ErrorClassOutage holds the classes of errors which indicate that the provider is unavailable:
+// network, server, throttling and authentication failures.
+const ErrorClassOutage = ErrorClassNetwork | ErrorClassServer | ErrorClassThrottled | ErrorClassAuth
+
+// Has reports whether c contains any of the classes of other.
+func (c ErrorClass) Has(other ErrorClass) bool {
+	return c&other != 0
+}
+
+// String returns the names of the classes, separated by "|".
+func (c ErrorClass) String() string {
+	names := [...]string{"network", "server", "throttled", "auth", "request", "no device", "canceled", "other"}
+
+	var s string
+	for i, name := range names {
+		if c&(1<<i) != 0 {
+			if s != "" {
+				s += "|"
+			}
+			s += name
+		}
+	}
+
+	if s == "" {
+		return "none"
+	}
+
+	return s
+}
+
+// ClassifyError returns the class of an error returned by a Client, a direct provider
+// or a ManagementClient. It returns zero for a nil error.
+//
+// Example usage:
+//
+//	if err := client.SendNotification(ctx, notification, "user:42"); azurepush.ClassifyError(err).Has(azurepush.ErrorClassOutage) {
+//		// retry later or on another provider.
+//	}
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return 0
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrClientClosed) {
+		return ErrorClassCanceled
+	}
+
+	if errors.Is(err, errDeviceNotFound) {
+		return ErrorClassNoDevice
+	}
+
+	if errors.Is(err, ErrQuotaExceeded) {
+		return ErrorClassThrottled
+	}
+
+	var tierErr *TierError
+	if errors.As(err, &tierErr) || errors.Is(err, ErrInvalidNotification) || errors.As(err, new(*PreconditionFailedError)) {
+		return ErrorClassRequest
+	}
+
+	var sendErr *SendError
+	if errors.As(err, &sendErr) {
+		return classifyStatus(sendErr.StatusCode)
+	}
+
+	var unauthorizedErr *UnauthorizedError
+	if errors.As(err, &unauthorizedErr) {
+		return ErrorClassAuth
+	}
+
+	var managementErr *ManagementError
+	if errors.As(err, &managementErr) {
+		return classifyStatus(managementErr.StatusCode)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassNetwork
+	}
+
+	return ErrorClassOther
+}
+
+func classifyStatus(statusCode int) ErrorClass {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrorClassThrottled
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrorClassAuth
+	case statusCode >= 500:
+		return ErrorClassServer
+	case statusCode >= 400:
+		return ErrorClassRequest
+	default:
+		return ErrorClassOther
+	}
+}
@@ -0,0 +1,42 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected azurepush.ErrorClass
+	}{
+		{nil, 0},
+		{&azurepush.SendError{Platform: azurepush.PlatformApple, StatusCode: http.StatusServiceUnavailable}, azurepush.ErrorClassServer},
+		{fmt.Errorf("wrapped: %w", &azurepush.SendError{StatusCode: http.StatusTooManyRequests}), azurepush.ErrorClassThrottled},
+		{&azurepush.SendError{StatusCode: http.StatusUnauthorized}, azurepush.ErrorClassAuth},
+		{&azurepush.SendError{StatusCode: http.StatusBadRequest}, azurepush.ErrorClassRequest},
+		{&azurepush.ManagementError{StatusCode: http.StatusForbidden}, azurepush.ErrorClassAuth},
+		{fmt.Errorf("failed to send: %w", azurepush.ErrQuotaExceeded), azurepush.ErrorClassThrottled},
+		{&azurepush.TierError{Feature: azurepush.FeatureScheduledSend}, azurepush.ErrorClassRequest},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, azurepush.ErrorClassNetwork},
+		{fmt.Errorf("failed: %w", context.Canceled), azurepush.ErrorClassCanceled},
+		{fmt.Errorf("failed: %w", azurepush.ErrClientClosed), azurepush.ErrorClassCanceled},
+		{errors.New("unknown"), azurepush.ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		if got := azurepush.ClassifyError(tt.err); got != tt.expected {
+			t.Errorf("%v: expected: %s, got: %s", tt.err, tt.expected, got)
+		}
+	}
+
+	if got := azurepush.ErrorClassOutage.String(); got != "network|server|throttled|auth" {
+		t.Errorf("unexpected outage classes: %s", got)
+	}
+}
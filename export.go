@@ -0,0 +1,151 @@
+package azurepush
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// ExportRecord is a record of the output file of an export job, see ExportReader.
+// Exactly one of Installation and Registration is set.
+type ExportRecord struct {
+	// Installation is set for the JSON lines of an installations export.
+	Installation *Installation
+	// Registration is set for the XML registration descriptions of a registrations export.
+	Registration *Registration
+	// Line is the line number of the record in the file, starting at 1.
+	Line int
+}
+
+// ExportReader reads the output file of an export job (see JobExportInstallations and JobExportRegistrations)
+// one record at a time, so multi-gigabyte exports are never loaded into memory.
+// Each line holds a JSON installation or an XML registration description, the format is detected per line.
+//
+// Example usage:
+//
+//	output, err := client.OpenJobOutput(ctx, job)
+//	defer output.Close()
+//
+//	for record, err := range azurepush.NewExportReader(output).All() {
+//		if err != nil {
+//			return err
+//		}
+//		count[record.Registration.Platform]++
+//	}
+type ExportReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewExportReader returns a new ExportReader which reads from r.
+func NewExportReader(r io.Reader) *ExportReader {
+	return &ExportReader{r: bufio.NewReaderSize(r, 64<<10)}
+}
+
+// Read returns the next record, or io.EOF at the end of the file. Empty lines are skipped.
+// A malformed line is reported with its line number (see ExportLineError),
+// the next Read call continues with the following line.
+func (er *ExportReader) Read() (ExportRecord, error) {
+	for {
+		line, err := er.r.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			return ExportRecord{}, err
+		}
+		er.line++
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			if err != nil {
+				return ExportRecord{}, err
+			}
+			continue
+		}
+
+		record, parseErr := parseExportLine(line)
+		if parseErr != nil {
+			return ExportRecord{}, &ExportLineError{Line: er.line, Err: parseErr}
+		}
+
+		record.Line = er.line
+		return record, nil
+	}
+}
+
+// All returns an iterator over the records of the file, it stops at the end of the file or a read error.
+// The errors of malformed lines are yielded too, the iteration continues unless the caller breaks it.
+func (er *ExportReader) All() iter.Seq2[ExportRecord, error] {
+	return func(yield func(ExportRecord, error) bool) {
+		for {
+			record, err := er.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if !yield(record, err) {
+				return
+			}
+
+			if err != nil && !errors.As(err, new(*ExportLineError)) {
+				return
+			}
+		}
+	}
+}
+
+// ExportLineError is returned by ExportReader for a malformed line.
+type ExportLineError struct {
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *ExportLineError) Error() string {
+	return fmt.Sprintf("malformed export line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the parse error.
+func (e *ExportLineError) Unwrap() error {
+	return e.Err
+}
+
+func parseExportLine(line []byte) (ExportRecord, error) {
+	switch line[0] {
+	case '{':
+		var installation Installation
+		if err := json.Unmarshal(line, &installation); err != nil {
+			return ExportRecord{}, err
+		}
+		return ExportRecord{Installation: &installation}, nil
+	case '<':
+		var description registrationDescription
+		if err := xml.Unmarshal(line, &description); err != nil {
+			return ExportRecord{}, err
+		}
+		registration := description.registration()
+		return ExportRecord{Registration: &registration}, nil
+	default:
+		return ExportRecord{}, fmt.Errorf("neither a JSON installation nor an XML registration")
+	}
+}
+
+// OpenJobOutput opens the output file of a completed job (see JobOutputFilePath) for reading,
+// e.g. for an ExportReader. The caller must close the returned reader.
+func (c *Client) OpenJobOutput(ctx context.Context, job *Job) (io.ReadCloser, error) {
+	path := job.OutputProperties[JobOutputFilePath]
+	if path == "" {
+		return nil, fmt.Errorf("job %s has no output file", job.JobID)
+	}
+
+	uri, err := BlobURI(job.OutputContainerURI, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.OpenBlob(ctx, uri)
+}
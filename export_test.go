@@ -0,0 +1,97 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestExportReader(t *testing.T) {
+	input := `{"installationId":"a","platform":"apns","pushChannel":"token-a","tags":["user:1"]}
+
+<FcmV1RegistrationDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><RegistrationId>r1</RegistrationId><Tags>user:2,vip</Tags><FcmV1RegistrationId>token-b</FcmV1RegistrationId></FcmV1RegistrationDescription>
+garbage
+{"installationId":"c","platform":"fcmv1","pushChannel":"token-c"}`
+
+	var (
+		records []azurepush.ExportRecord
+		errs    []error
+	)
+	for record, err := range azurepush.NewExportReader(strings.NewReader(input)).All() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got: %d", len(records))
+	}
+	if records[0].Installation == nil || records[0].Installation.InstallationID != "a" || records[0].Line != 1 {
+		t.Errorf("unexpected installation record: %#v", records[0])
+	}
+	if r := records[1].Registration; r == nil || r.Platform != azurepush.InstallationFCMV1 || r.PushChannel != "token-b" || len(r.Tags) != 2 || records[1].Line != 3 {
+		t.Errorf("unexpected registration record: %#v", records[1])
+	}
+	if records[2].Installation == nil || records[2].Line != 5 {
+		t.Errorf("unexpected last record: %#v", records[2])
+	}
+
+	if lineErr, ok := errors.AsType[*azurepush.ExportLineError](errors.Join(errs...)); len(errs) != 1 || !ok || lineErr.Line != 4 {
+		t.Errorf("expected a malformed line 4 error, got: %v", errs)
+	}
+
+	if _, err := azurepush.NewExportReader(strings.NewReader("")).Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got: %v", err)
+	}
+}
+
+func TestClient_OpenJobOutput(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := client.RegisterDevice(ctx, azurepush.Installation{InstallationID: id, Platform: azurepush.InstallationApple, PushChannel: "token-" + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, jobType := range []azurepush.JobType{azurepush.JobExportInstallations, azurepush.JobExportRegistrations} {
+		job, err := client.SubmitJob(ctx, azurepush.Job{Type: jobType, OutputContainerURI: "https://account.blob.core.windows.net/export?sig=abc"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output, err := client.OpenJobOutput(ctx, job)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var channels []string
+		for record, err := range azurepush.NewExportReader(output).All() {
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if record.Installation != nil {
+				channels = append(channels, record.Installation.PushChannel)
+			} else {
+				channels = append(channels, record.Registration.PushChannel)
+			}
+		}
+		output.Close()
+
+		if strings.Join(channels, ",") != "token-a,token-b" {
+			t.Errorf("%s: unexpected records: %v", jobType, channels)
+		}
+	}
+}
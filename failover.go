@@ -0,0 +1,220 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FailoverOptions holds the settings of a FailoverClient.
+type FailoverOptions struct {
+	// FailureThreshold is the number of consecutive failed sends on the primary hub
+	// before sends are failed over to the secondary hub.
+	//
+	// Defaults to 3.
+	FailureThreshold int
+
+	// ProbeInterval is the minimum duration between health probes of the primary hub
+	// while sends are failed over. Once a probe succeeds sends go back to the primary hub.
+	//
+	// Defaults to 30 seconds.
+	ProbeInterval time.Duration
+
+	// ShouldFailover reports whether a send error counts as a primary hub failure.
+	// Defaults to the outage errors (see ErrorClassOutage): network, server, throttling and authentication failures.
+	// Request errors, e.g. an invalid notification, would fail on the secondary hub as well.
+	ShouldFailover func(err error) bool
+
+	// OnFailover is called (if not nil) when sends switch hubs.
+	// The failedOver argument is true when switching to the secondary hub and false on fail-back.
+	OnFailover func(failedOver bool, cause error)
+}
+
+// FailoverClient wraps two clients configured with hubs in different namespaces (and regions).
+// Registrations and deletions are mirrored to both hubs, sends go to the primary hub
+// and automatically fail over to the secondary one when the primary returns sustained errors.
+// Sends fail back to the primary hub once a health probe succeeds.
+//
+// Example usage:
+//
+//	fc := azurepush.NewFailoverClient(
+//		azurepush.NewClient(westEuropeCfg),
+//		azurepush.NewClient(northEuropeCfg),
+//		azurepush.FailoverOptions{},
+//	)
+//	err := fc.SendNotification(ctx, notification, "user:42")
+type FailoverClient struct {
+	Primary   *Client
+	Secondary *Client
+
+	opts FailoverOptions
+
+	mu         sync.Mutex
+	failures   int
+	failedOver bool
+	lastProbe  time.Time
+}
+
+// NewFailoverClient creates a new FailoverClient.
+func NewFailoverClient(primary, secondary *Client, opts FailoverOptions) *FailoverClient {
+	if primary == nil || secondary == nil {
+		panic("azurepush: failover requires a primary and a secondary client")
+	}
+
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 3
+	}
+
+	if opts.ProbeInterval <= 0 {
+		opts.ProbeInterval = 30 * time.Second
+	}
+
+	if opts.ShouldFailover == nil {
+		opts.ShouldFailover = defaultShouldFailover
+	}
+
+	return &FailoverClient{
+		Primary:   primary,
+		Secondary: secondary,
+		opts:      opts,
+	}
+}
+
+func defaultShouldFailover(err error) bool {
+	return ClassifyError(err).Has(ErrorClassOutage)
+}
+
+// FailedOver reports whether sends currently go to the secondary hub.
+func (fc *FailoverClient) FailedOver() bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	return fc.failedOver
+}
+
+// RegisterDevice registers the installation to both hubs under the same installation ID.
+// An error is returned if any of the hubs failed, registrations are idempotent so they can be retried.
+func (fc *FailoverClient) RegisterDevice(ctx context.Context, installation Installation) (string, error) {
+	if installation.InstallationID == "" {
+		installation.InstallationID = uuid.NewString()
+	}
+
+	_, primaryErr := fc.Primary.RegisterDevice(ctx, installation)
+	if primaryErr != nil {
+		primaryErr = fmt.Errorf("primary hub: %w", primaryErr)
+	}
+
+	_, secondaryErr := fc.Secondary.RegisterDevice(ctx, installation)
+	if secondaryErr != nil {
+		secondaryErr = fmt.Errorf("secondary hub: %w", secondaryErr)
+	}
+
+	return installation.InstallationID, errors.Join(primaryErr, secondaryErr)
+}
+
+// DeleteDevice deletes the installation from both hubs.
+func (fc *FailoverClient) DeleteDevice(ctx context.Context, installationID string) error {
+	primaryErr := fc.Primary.DeleteDevice(ctx, installationID)
+	if primaryErr != nil {
+		primaryErr = fmt.Errorf("primary hub: %w", primaryErr)
+	}
+
+	secondaryErr := fc.Secondary.DeleteDevice(ctx, installationID)
+	if secondaryErr != nil {
+		secondaryErr = fmt.Errorf("secondary hub: %w", secondaryErr)
+	}
+
+	return errors.Join(primaryErr, secondaryErr)
+}
+
+// ValidateToken validates the SAS token of the currently active hub.
+func (fc *FailoverClient) ValidateToken(ctx context.Context) error {
+	return fc.active(ctx).ValidateToken(ctx)
+}
+
+// DeviceExists checks the installation on the currently active hub.
+func (fc *FailoverClient) DeviceExists(ctx context.Context, installationID string) (bool, error) {
+	return fc.active(ctx).DeviceExists(ctx, installationID)
+}
+
+// SendNotification sends the notification through the currently active hub.
+// When the primary hub reaches the failure threshold, the same notification
+// is retried on the secondary hub.
+func (fc *FailoverClient) SendNotification(ctx context.Context, notification Notification, tags ...string) error {
+	client := fc.active(ctx)
+	err := client.SendNotification(ctx, notification, tags...)
+	if client == fc.Secondary {
+		return err
+	}
+
+	if !fc.recordPrimary(err) {
+		return err
+	}
+
+	return fc.Secondary.SendNotification(ctx, notification, tags...)
+}
+
+// active returns the client to send through, probing the primary hub for fail-back when it is due.
+func (fc *FailoverClient) active(ctx context.Context) *Client {
+	fc.mu.Lock()
+	if !fc.failedOver {
+		fc.mu.Unlock()
+		return fc.Primary
+	}
+
+	if time.Since(fc.lastProbe) < fc.opts.ProbeInterval {
+		fc.mu.Unlock()
+		return fc.Secondary
+	}
+	fc.lastProbe = time.Now()
+	fc.mu.Unlock()
+
+	if err := fc.Primary.ValidateToken(ctx); err != nil {
+		return fc.Secondary
+	}
+
+	fc.mu.Lock()
+	switched := fc.failedOver
+	fc.failedOver = false
+	fc.failures = 0
+	fc.mu.Unlock()
+
+	if switched && fc.opts.OnFailover != nil {
+		fc.opts.OnFailover(false, nil)
+	}
+
+	return fc.Primary
+}
+
+// recordPrimary records the outcome of a primary hub send
+// and reports whether this failure switched sends to the secondary hub.
+func (fc *FailoverClient) recordPrimary(err error) bool {
+	fc.mu.Lock()
+	if err == nil || !fc.opts.ShouldFailover(err) {
+		if err == nil {
+			fc.failures = 0
+		}
+		fc.mu.Unlock()
+		return false
+	}
+
+	fc.failures++
+	if fc.failedOver || fc.failures < fc.opts.FailureThreshold {
+		fc.mu.Unlock()
+		return false
+	}
+
+	fc.failedOver = true
+	fc.lastProbe = time.Now()
+	fc.mu.Unlock()
+
+	if fc.opts.OnFailover != nil {
+		fc.opts.OnFailover(true, err)
+	}
+
+	return true
+}
@@ -0,0 +1,126 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestFailoverClient(t *testing.T) {
+	var (
+		primaryHealthy  atomic.Bool
+		primarySends    atomic.Int32
+		secondarySends  atomic.Int32
+		registrations   atomic.Int32
+		failoverChanges []bool
+	)
+
+	newClient := func(handler func(r *http.Request) *http.Response) *azurepush.Client {
+		client := azurepush.NewClient(azurepush.Configuration{
+			HubName:          "hub",
+			ConnectionString: testConnectionString,
+		})
+		client.HTTPClient = mockHTTPClient(handler)
+		return client
+	}
+
+	primary := newClient(func(r *http.Request) *http.Response {
+		switch r.Method {
+		case http.MethodPut:
+			registrations.Add(1)
+			return jsonResponse(http.StatusOK, "{}")
+		case http.MethodGet: // health probe.
+			if primaryHealthy.Load() {
+				return jsonResponse(http.StatusNotFound, "")
+			}
+			return jsonResponse(http.StatusServiceUnavailable, "")
+		default:
+			primarySends.Add(1)
+			if primaryHealthy.Load() {
+				return jsonResponse(http.StatusCreated, "")
+			}
+			return jsonResponse(http.StatusInternalServerError, "outage")
+		}
+	})
+	secondary := newClient(func(r *http.Request) *http.Response {
+		if r.Method == http.MethodPut {
+			registrations.Add(1)
+		} else {
+			secondarySends.Add(1)
+		}
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	fc := azurepush.NewFailoverClient(primary, secondary, azurepush.FailoverOptions{
+		FailureThreshold: 2,
+		ProbeInterval:    time.Millisecond,
+		OnFailover: func(failedOver bool, cause error) {
+			failoverChanges = append(failoverChanges, failedOver)
+		},
+	})
+
+	ctx := context.Background()
+	if _, err := fc.RegisterDevice(ctx, azurepush.Installation{Platform: azurepush.InstallationApple, PushChannel: "token"}); err != nil {
+		t.Fatalf("unexpected registration error: %v", err)
+	}
+	if registrations.Load() != 2 {
+		t.Errorf("expected registration to be mirrored to both hubs, got: %d", registrations.Load())
+	}
+
+	notification := azurepush.Notification{Title: "Hi", Body: "Hello"}
+	if err := fc.SendNotification(ctx, notification, "user:42"); err == nil {
+		t.Fatal("expected first primary failure to be returned")
+	}
+	if err := fc.SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatalf("expected send to fail over to the secondary hub, got: %v", err)
+	}
+	if !fc.FailedOver() || secondarySends.Load() != 2 {
+		t.Fatalf("expected failed over state with 2 secondary sends, got: %v, %d", fc.FailedOver(), secondarySends.Load())
+	}
+
+	primaryHealthy.Store(true)
+	time.Sleep(2 * time.Millisecond)
+	if err := fc.SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatalf("unexpected error after fail-back: %v", err)
+	}
+	if fc.FailedOver() {
+		t.Error("expected sends to fail back to the primary hub")
+	}
+
+	if len(failoverChanges) != 2 || !failoverChanges[0] || failoverChanges[1] {
+		t.Errorf("unexpected failover events: %v", failoverChanges)
+	}
+}
+
+func TestFailoverClient_RequestErrors(t *testing.T) {
+	var secondarySends atomic.Int32
+
+	primary := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	primary.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusBadRequest, "invalid payload")
+	})
+	secondary := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	secondary.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		secondarySends.Add(1)
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	fc := azurepush.NewFailoverClient(primary, secondary, azurepush.FailoverOptions{FailureThreshold: 1})
+
+	ctx := context.Background()
+	for _, notification := range []azurepush.Notification{{Title: "Hi"}, {}} {
+		for range 3 {
+			if err := fc.SendNotification(ctx, notification, "user:42"); err == nil {
+				t.Fatal("expected the request error to be returned")
+			}
+		}
+	}
+
+	if fc.FailedOver() || secondarySends.Load() != 0 {
+		t.Errorf("expected request errors to not fail over, got: %v, %d secondary sends", fc.FailedOver(), secondarySends.Load())
+	}
+}
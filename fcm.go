@@ -0,0 +1,204 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FCMOptions holds the settings of an FCMProvider.
+type FCMOptions struct {
+	// ServiceAccountJSON is the content of a Google service account key file
+	// with the Firebase Cloud Messaging API permission.
+	ServiceAccountJSON []byte
+	// ProjectID is the Firebase project ID.
+	//
+	// Defaults to the project_id of the service account.
+	ProjectID string
+
+	// Registry keeps the installations and their tags.
+	//
+	// Defaults to an in-memory registry, set a registry backed by a persistent Store in production.
+	Registry *DeviceRegistry
+
+	// HTTPClient is the client used for the token and send requests.
+	//
+	// Defaults to a client with a 30 seconds timeout.
+	HTTPClient *http.Client
+
+	// Endpoint is the base URL of the FCM API, e.g. to use an emulator in tests.
+	//
+	// Defaults to "https://fcm.googleapis.com".
+	Endpoint string
+
+	// Concurrency is the maximum number of devices sent in parallel by Send.
+	//
+	// Defaults to 8.
+	Concurrency int
+}
+
+// FCMProvider is a PushProvider which sends directly to Firebase Cloud Messaging (HTTP v1 API),
+// bypassing the Notification Hub, e.g. to fail over or migrate Android pushes.
+// It uses the same Notification model and FCM v1 payload as the hub.
+//
+// FCM has no tags, so the installations and their tags are kept in a DeviceRegistry:
+// Register and Unregister maintain it (only Android "FCMV1" installations are accepted)
+// and Send resolves the tags to device tokens. Tokens reported as unregistered by FCM
+// are removed from the registry.
+//
+// Example usage:
+//
+//	fcm, err := azurepush.NewFCMProvider(azurepush.FCMOptions{
+//		ServiceAccountJSON: serviceAccount,
+//		Registry:           azurepush.NewDeviceRegistry(store),
+//	})
+//	err = fcm.Send(ctx, notification, "user:42")
+type FCMProvider struct {
+	opts   FCMOptions
+	tokens *googleTokenSource
+}
+
+var _ PushProvider = (*FCMProvider)(nil)
+
+// NewFCMProvider creates a new FCMProvider.
+// It returns an error if the service account is invalid.
+func NewFCMProvider(opts FCMOptions) (*FCMProvider, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	tokens, err := newGoogleTokenSource(opts.ServiceAccountJSON, firebaseMessagingScope, opts.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.ProjectID == "" {
+		opts.ProjectID = tokens.account.ProjectID
+		if opts.ProjectID == "" {
+			return nil, errors.New("FCM project ID is required")
+		}
+	}
+
+	if opts.Registry == nil {
+		opts.Registry = NewDeviceRegistry(NewMemoryStore())
+	}
+
+	if opts.Endpoint == "" {
+		opts.Endpoint = "https://fcm.googleapis.com"
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+	}
+
+	return &FCMProvider{opts: opts, tokens: tokens}, nil
+}
+
+// Registry returns the registry of the provider's installations.
+func (p *FCMProvider) Registry() *DeviceRegistry {
+	return p.opts.Registry
+}
+
+// Register implements the PushProvider interface.
+// Only Android installations (InstallationFCMV1) are accepted, their push channel is the FCM registration token.
+func (p *FCMProvider) Register(ctx context.Context, installation Installation) (string, error) {
+	if installation.Platform != InstallationFCMV1 {
+		return "", fmt.Errorf("%w: %q (FCM supports %q only)", errUnsupportedPlatform, installation.Platform, InstallationFCMV1)
+	}
+
+	if installation.InstallationID == "" {
+		installation.InstallationID = uuid.NewString()
+	}
+
+	if err := p.opts.Registry.Put(ctx, installation); err != nil {
+		return "", err
+	}
+
+	return installation.InstallationID, nil
+}
+
+// Unregister implements the PushProvider interface.
+func (p *FCMProvider) Unregister(ctx context.Context, installationID string) error {
+	if installationID == "" {
+		return fmt.Errorf("installation ID cannot be empty")
+	}
+
+	return p.opts.Registry.Delete(ctx, installationID)
+}
+
+// Send implements the PushProvider interface.
+// The notification is sent to each Android installation with any of the tags, see DeviceRegistry.Match.
+func (p *FCMProvider) Send(ctx context.Context, notification Notification, tags ...string) error {
+	if err := notification.ValidateFor(PlatformFCMV1); err != nil {
+		return err
+	}
+
+	return sendToDevices(ctx, p.opts.Registry, InstallationFCMV1, tags, p.opts.Concurrency, func(installation Installation) error {
+		return p.sendTo(ctx, notification, installation.PushChannel)
+	})
+}
+
+// sendTo sends the notification to a single device token.
+func (p *FCMProvider) sendTo(ctx context.Context, notification Notification, token string) error {
+	payload := fcmV1NotificationPayload{Message: notification.fcmV1Message()}
+	payload.Message.Token = token
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal FCM payload: %w", err)
+	}
+
+	authorization, err := p.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/v1/projects/%s/messages:send", p.opts.Endpoint, p.opts.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create FCM request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := p.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send FCM request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	b, _ := io.ReadAll(resp.Body)
+
+	var fcmErr struct {
+		Error struct {
+			Details []struct {
+				ErrorCode string `json:"errorCode"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(b, &fcmErr)
+
+	for _, detail := range fcmErr.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return errDeviceUnregistered
+		}
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errDeviceUnregistered
+	}
+
+	return &SendError{Platform: PlatformFCMV1, StatusCode: resp.StatusCode, Body: string(b)}
+}
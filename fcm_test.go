@@ -0,0 +1,200 @@
+package azurepush_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+// fakeFCM is a fake Google OAuth 2.0 token endpoint and FCM HTTP v1 API.
+type fakeFCM struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	tokenRequests atomic.Int32
+
+	mu       sync.Mutex
+	tokens   []string // the sent device tokens.
+	payloads []string // the sent messages.
+}
+
+func newFakeFCM(t *testing.T) *fakeFCM {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &fakeFCM{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		f.tokenRequests.Add(1)
+
+		if r.FormValue("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || !f.validAssertion(r.FormValue("assertion")) {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"access_token":"fcm-access-token","token_type":"Bearer","expires_in":3600}`)
+	})
+	mux.HandleFunc("POST /v1/projects/my-project/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fcm-access-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var payload struct {
+			Message struct {
+				Token        string `json:"token"`
+				Notification *struct {
+					Title string `json:"title"`
+				} `json:"notification"`
+				Android json.RawMessage `json:"android"`
+			} `json:"message"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if notification := payload.Message.Notification; notification == nil && payload.Message.Android == nil || notification != nil && notification.Title == "" {
+			http.Error(w, "invalid payload: no notification title or data", http.StatusBadRequest)
+			return
+		}
+
+		if payload.Message.Token == "stale-token" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error":{"code":404,"status":"NOT_FOUND","details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`)
+			return
+		}
+
+		f.mu.Lock()
+		f.tokens = append(f.tokens, payload.Message.Token)
+		f.payloads = append(f.payloads, string(body))
+		f.mu.Unlock()
+
+		_, _ = io.WriteString(w, `{"name":"projects/my-project/messages/1"}`)
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeFCM) validAssertion(assertion string) bool {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return false
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(&f.key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+		return false
+	}
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	return err == nil && strings.Contains(string(claims), "firebase.messaging")
+}
+
+func (f *fakeFCM) serviceAccount(t *testing.T) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(f.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "my-project",
+		"private_key_id": "key-1",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"client_email":   "push@my-project.iam.gserviceaccount.com",
+		"token_uri":      f.URL + "/token",
+	})
+	return b
+}
+
+func TestFCMProvider(t *testing.T) {
+	f := newFakeFCM(t)
+
+	fcm, err := azurepush.NewFCMProvider(azurepush.FCMOptions{
+		ServiceAccountJSON: f.serviceAccount(t),
+		Endpoint:           f.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "pixel", Platform: azurepush.InstallationFCMV1, PushChannel: "pixel-token", Tags: []string{"user:42"}},
+		{InstallationID: "old-phone", Platform: azurepush.InstallationFCMV1, PushChannel: "stale-token", Tags: []string{"user:42"}},
+		{InstallationID: "galaxy", Platform: azurepush.InstallationFCMV1, PushChannel: "galaxy-token", Tags: []string{"user:43"}},
+	} {
+		if _, err = fcm.Register(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = fcm.Register(ctx, azurepush.Installation{Platform: azurepush.InstallationApple, PushChannel: "apns-token"}); err == nil {
+		t.Error("expected an error registering an Apple device")
+	}
+
+	if err = fcm.Send(ctx, azurepush.Notification{Title: "Hello", Data: map[string]any{"orderId": 42}}, "user:42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(f.tokens) != 1 || f.tokens[0] != "pixel-token" {
+		t.Errorf("expected the notification to be sent to the user's device, got: %v", f.tokens)
+	}
+	if _, err = fcm.Registry().Get(ctx, "old-phone"); err == nil {
+		t.Error("expected the unregistered token to be removed from the registry")
+	}
+
+	// A data-only notification has no notification to display and no Apple "aps" field.
+	if err = fcm.Send(ctx, azurepush.Notification{Data: map[string]any{"aps": map[string]any{"content-available": 1}, "sync": true}}, "user:42"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"message":{"token":"pixel-token","android":{"data":{"sync":"true"}}}}`; f.payloads[len(f.payloads)-1] != expected {
+		t.Errorf("unexpected data-only payload:\nexpected: %s\ngot:      %s", expected, f.payloads[len(f.payloads)-1])
+	}
+
+	if err = fcm.Send(ctx, azurepush.Notification{Title: "Hello again"}, "user:43"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.tokenRequests.Load(); got != 1 {
+		t.Errorf("expected the access token to be cached, got: %d token requests", got)
+	}
+
+	if err = fcm.Unregister(ctx, "galaxy"); err != nil {
+		t.Fatal(err)
+	}
+	if err = fcm.Send(ctx, azurepush.Notification{Title: "Anyone?"}, "user:43"); err == nil {
+		t.Error("expected an error when no device matches")
+	}
+
+	if _, err = azurepush.NewFCMProvider(azurepush.FCMOptions{ServiceAccountJSON: []byte(`{"type":"authorized_user"}`)}); err == nil {
+		t.Error("expected an error for an invalid service account")
+	}
+}
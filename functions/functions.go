@@ -0,0 +1,219 @@
+// Package functions adapts the azurepush Client to Azure Functions custom handlers,
+// so serverless push dispatchers need almost no boilerplate.
+//
+// The Functions host posts every invocation to the custom handler at /{functionName},
+// the Handler parses the trigger's payload into a notification and its tags, sends it
+// and returns a structured Outcome.
+//
+// Example usage, for a queue triggered "dispatch" function:
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("POST /dispatch", functions.Handler(client, functions.Options{Binding: "message"}))
+//	http.ListenAndServe(":"+os.Getenv("FUNCTIONS_CUSTOMHANDLER_PORT"), mux)
+//
+// Payload schema (the queue message or the HTTP request body):
+//
+//	{"notification": {"title": "Hi", "body": "Hello", "data": {"orderId": "7"}}, "tags": ["user:42"]}
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/kataras/azurepush"
+)
+
+// DefaultMaxBodySize is the default maximum size of an invocation request body.
+const DefaultMaxBodySize = 1 << 20
+
+// Invocation is the request body of the Functions host.
+type Invocation struct {
+	// Data holds the trigger and input bindings by their name.
+	Data map[string]json.RawMessage `json:"Data"`
+	// Metadata holds the trigger metadata, e.g. the message ID and dequeue count of a queue trigger.
+	Metadata map[string]json.RawMessage `json:"Metadata"`
+}
+
+// InvocationResponse is the response body to the Functions host.
+type InvocationResponse struct {
+	Outputs     map[string]any `json:"Outputs,omitempty"`
+	Logs        []string       `json:"Logs,omitempty"`
+	ReturnValue any            `json:"ReturnValue,omitempty"`
+}
+
+// Payload is the JSON schema of the trigger's payload.
+type Payload struct {
+	Notification Notification `json:"notification"`
+	// Tags targets the devices, a tag can be a tag expression. Empty tags broadcast the notification.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Notification is the JSON schema of a Payload's notification.
+type Notification struct {
+	Title string         `json:"title"`
+	Body  string         `json:"body"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+// Outcome is the structured result of an invocation,
+// set as the ReturnValue and as the body of the HTTP output binding (if any).
+type Outcome struct {
+	Sent  bool     `json:"sent"`
+	Tags  []string `json:"tags,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// Options holds the settings of the Handler.
+type Options struct {
+	// Binding is the name of the trigger binding which holds the payload.
+	// If empty, the invocation must have a single binding.
+	Binding string
+
+	// HTTPOutput is the name of the HTTP output binding (e.g. "res") of HTTP triggered functions.
+	// If not empty, the Outcome is written to it, with status 200, 400 or 502.
+	HTTPOutput string
+
+	// MaxBodySize is the maximum size of the invocation request body.
+	//
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+// Handler returns the custom handler endpoint of a push dispatcher function.
+//
+// Invalid payloads and notifications (see azurepush.ErrInvalidNotification) respond with 400 Bad Request,
+// as they would fail on every retry, and failed sends with 500 Internal Server Error, so the Functions host reports the invocation as failed and applies the trigger's retry policy
+// (e.g. queue messages are moved to the poison queue after the max dequeue count).
+func Handler(client azurepush.HubClient, opts Options) http.Handler {
+	if client == nil {
+		panic("azurepush/functions: nil client")
+	}
+
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var invocation Invocation
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, opts.MaxBodySize)).Decode(&invocation); err != nil {
+			respond(w, opts, http.StatusBadRequest, Outcome{Error: fmt.Sprintf("invalid invocation: %v", err)})
+			return
+		}
+
+		payload, err := invocation.payload(opts.Binding)
+		if err != nil {
+			respond(w, opts, http.StatusBadRequest, Outcome{Error: err.Error()})
+			return
+		}
+
+		n := azurepush.Notification{
+			Title: payload.Notification.Title,
+			Body:  payload.Notification.Body,
+			Data:  payload.Notification.Data,
+		}
+
+		outcome := Outcome{Tags: payload.Tags}
+		if err = client.SendNotification(r.Context(), n, payload.Tags...); err != nil {
+			outcome.Error = err.Error()
+			statusCode := http.StatusInternalServerError
+			if errors.Is(err, azurepush.ErrInvalidNotification) {
+				statusCode = http.StatusBadRequest
+			}
+			respond(w, opts, statusCode, outcome)
+			return
+		}
+
+		outcome.Sent = true
+		respond(w, opts, http.StatusOK, outcome)
+	})
+}
+
+// payload parses the payload of the binding. The binding's value can be the payload object itself,
+// a JSON string holding it (e.g. queue and Service Bus triggers) or an HTTP trigger request with it as its body.
+func (i Invocation) payload(binding string) (Payload, error) {
+	var payload Payload
+
+	if binding == "" {
+		if len(i.Data) != 1 {
+			return payload, fmt.Errorf("expected a single binding, got: %d", len(i.Data))
+		}
+		for name := range i.Data {
+			binding = name
+		}
+	}
+
+	raw, ok := i.Data[binding]
+	if !ok {
+		return payload, fmt.Errorf("binding %q not found", binding)
+	}
+
+	raw, err := unwrap(raw)
+	if err != nil {
+		return payload, err
+	}
+
+	if err = json.Unmarshal(raw, &payload); err != nil {
+		return payload, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	if payload.Notification.Title == "" && payload.Notification.Body == "" {
+		return payload, errors.New("invalid payload: notification title or body is required")
+	}
+
+	return payload, nil
+}
+
+// unwrap returns the payload object of a binding's value.
+func unwrap(raw json.RawMessage) (json.RawMessage, error) {
+	// JSON string, e.g. a queue message.
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return unwrap(json.RawMessage(s))
+	}
+
+	// HTTP trigger request.
+	var req struct {
+		Method string          `json:"Method"`
+		Body   json.RawMessage `json:"Body"`
+	}
+	if err := json.Unmarshal(raw, &req); err == nil && req.Method != "" {
+		if len(req.Body) == 0 || slices.Equal(req.Body, []byte("null")) {
+			return nil, errors.New("invalid payload: empty request body")
+		}
+		return unwrap(req.Body)
+	}
+
+	return raw, nil
+}
+
+func respond(w http.ResponseWriter, opts Options, statusCode int, outcome Outcome) {
+	resp := InvocationResponse{ReturnValue: outcome}
+	if outcome.Error != "" {
+		resp.Logs = []string{"azurepush: " + outcome.Error}
+	}
+
+	if opts.HTTPOutput != "" {
+		body, _ := json.Marshal(outcome)
+		httpStatus := statusCode
+		if statusCode == http.StatusInternalServerError {
+			httpStatus = http.StatusBadGateway
+		}
+
+		resp.Outputs = map[string]any{
+			opts.HTTPOutput: map[string]any{
+				"statusCode": httpStatus,
+				"body":       string(body),
+				"headers":    map[string]string{"Content-Type": "application/json"},
+			},
+		}
+		// The HTTP output carries the outcome, the invocation itself succeeded.
+		statusCode = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(resp)
+}
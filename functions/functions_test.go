@@ -0,0 +1,101 @@
+package functions_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush/azurepushtest"
+	"github.com/kataras/azurepush/functions"
+)
+
+func invoke(h http.Handler, body string) (int, functions.InvocationResponse) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/dispatch", strings.NewReader(body)))
+
+	var resp functions.InvocationResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	return rec.Code, resp
+}
+
+func TestHandler_QueueTrigger(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	h := functions.Handler(srv.NewClient(), functions.Options{Binding: "message"})
+
+	code, resp := invoke(h, `{"Data":{"message":"{\"notification\":{\"title\":\"Hi\",\"body\":\"Hello\"},\"tags\":[\"user:42\"]}"},"Metadata":{"DequeueCount":1}}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d: %+v", code, resp)
+	}
+	if outcome, _ := resp.ReturnValue.(map[string]any); outcome["sent"] != true {
+		t.Errorf("expected a sent outcome, got: %+v", resp.ReturnValue)
+	}
+	if sent := srv.Sent("user:42"); len(sent) != 2 {
+		t.Errorf("expected 2 sent notifications, got: %d", len(sent))
+	}
+
+	if code, _ = invoke(h, `{"Data":{"message":"not json"}}`); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid payload, got: %d", code)
+	}
+	if code, _ = invoke(h, `{"Data":{"other":"{}"}}`); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a missing binding, got: %d", code)
+	}
+	if code, _ = invoke(h, `{"Data":{"message":{"notification":{"title":"Hi"},"tags":["user+42"]}}}`); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid notification, got: %d", code)
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "internal error")
+	code, resp = invoke(h, `{"Data":{"message":{"notification":{"title":"Hi"}}}}`)
+	if code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 for a failed send, got: %d", code)
+	}
+	if len(resp.Logs) != 1 {
+		t.Errorf("expected the error to be logged, got: %v", resp.Logs)
+	}
+}
+
+func TestHandler_MaxBodySize(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	h := functions.Handler(srv.NewClient(), functions.Options{Binding: "message", MaxBodySize: 64})
+
+	body := `{"Data":{"message":{"notification":{"title":"` + strings.Repeat("a", 64) + `"}}}}`
+	if code, _ := invoke(h, body); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an oversized body, got: %d", code)
+	}
+	if len(srv.AllSent()) != 0 {
+		t.Errorf("expected no sends, got: %d", len(srv.AllSent()))
+	}
+}
+
+func TestHandler_HTTPTrigger(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	h := functions.Handler(srv.NewClient(), functions.Options{HTTPOutput: "res"})
+
+	code, resp := invoke(h, `{"Data":{"req":{"Method":"POST","Url":"http://localhost/api/dispatch","Body":"{\"notification\":{\"title\":\"Hi\"}}"}}}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d", code)
+	}
+
+	res, _ := resp.Outputs["res"].(map[string]any)
+	if res["statusCode"] != float64(http.StatusOK) || !strings.Contains(res["body"].(string), `"sent":true`) {
+		t.Errorf("unexpected HTTP output: %+v", res)
+	}
+	if len(srv.AllSent()) != 2 {
+		t.Errorf("expected a broadcast to both platforms, got: %d", len(srv.AllSent()))
+	}
+
+	code, resp = invoke(h, `{"Data":{"req":{"Method":"POST","Body":null}}}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected the HTTP output to carry the error, got status: %d", code)
+	}
+	if res, _ = resp.Outputs["res"].(map[string]any); res["statusCode"] != float64(http.StatusBadRequest) {
+		t.Errorf("expected HTTP output status 400, got: %+v", res)
+	}
+}
@@ -0,0 +1,42 @@
+// Package fx provides the uber/fx module of the azurepush package.
+//
+// Example usage:
+//
+//	fx.New(
+//		azurepushfx.Module,
+//		fx.Invoke(func(client azurepush.HubClient) {
+//			// use client...
+//		}),
+//	).Run()
+//
+// The client is configured from the environment, see azurepush.ConfigurationFromEnv.
+package fx
+
+import (
+	"github.com/kataras/azurepush"
+
+	"go.uber.org/fx"
+)
+
+// Module provides a *azurepush.Client configured from the environment,
+// also as the azurepush.HubClient interface.
+// The client is closed on the application's stop, draining its in-flight sends (see azurepush.Client.Close).
+var Module = fx.Module("azurepush",
+	fx.Provide(
+		fx.Annotate(
+			newClient,
+			fx.As(fx.Self()),
+			fx.As(new(azurepush.HubClient)),
+		),
+	),
+)
+
+func newClient(lc fx.Lifecycle) (*azurepush.Client, error) {
+	client, err := azurepush.NewClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	lc.Append(fx.Hook{OnStop: client.Close})
+	return client, nil
+}
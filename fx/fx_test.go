@@ -0,0 +1,50 @@
+package fx_test
+
+import (
+	"testing"
+
+	"github.com/kataras/azurepush"
+	azurepushfx "github.com/kataras/azurepush/fx"
+
+	"go.uber.org/fx"
+)
+
+func TestModule(t *testing.T) {
+	t.Setenv(azurepush.EnvHubName, "hub")
+	t.Setenv(azurepush.EnvConnectionString, "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=secret")
+
+	var (
+		client    *azurepush.Client
+		hubClient azurepush.HubClient
+	)
+	app := fx.New(azurepushfx.Module, fx.Populate(&client, &hubClient), fx.NopLogger)
+	if err := app.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if client == nil || hubClient != client {
+		t.Errorf("expected the same client to be provided as *Client and HubClient")
+	}
+
+	if err := app.Start(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Stop(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !client.Stats().Closed {
+		t.Error("expected the client to be closed on stop")
+	}
+}
+
+func TestModule_InvalidEnvironment(t *testing.T) {
+	t.Setenv(azurepush.EnvHubName, "")
+	t.Setenv(azurepush.EnvConfig, "")
+
+	var client azurepush.HubClient
+	app := fx.New(azurepushfx.Module, fx.Populate(&client), fx.NopLogger)
+	if app.Err() == nil {
+		t.Error("expected error for a missing configuration")
+	}
+}
@@ -0,0 +1,167 @@
+package azurepush
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// firebaseMessagingScope is the OAuth 2.0 scope of the FCM HTTP v1 API.
+const firebaseMessagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// googleServiceAccount is a Google service account key file,
+// see https://cloud.google.com/iam/docs/keys-create-delete.
+type googleServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// googleTokenSource issues OAuth 2.0 access tokens for a service account (JWT bearer grant)
+// and caches them until shortly before they expire.
+type googleTokenSource struct {
+	account    googleServiceAccount
+	key        *rsa.PrivateKey
+	scope      string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newGoogleTokenSource(serviceAccountJSON []byte, scope string, httpClient *http.Client) (*googleTokenSource, error) {
+	var account googleServiceAccount
+	if err := json.Unmarshal(serviceAccountJSON, &account); err != nil {
+		return nil, fmt.Errorf("invalid service account: %w", err)
+	}
+
+	if account.Type != "service_account" {
+		return nil, fmt.Errorf("invalid service account: type %q is not service_account", account.Type)
+	}
+
+	if account.ClientEmail == "" {
+		return nil, errors.New("invalid service account: client_email is required")
+	}
+
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	block, _ := pem.Decode([]byte(account.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid service account: private_key is not PEM encoded")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account private key: %w", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("invalid service account private key: not an RSA key")
+	}
+
+	return &googleTokenSource{account: account, key: key, scope: scope, httpClient: httpClient}, nil
+}
+
+// Token returns a valid access token, as an Authorization header value.
+func (s *googleTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Until(s.expires) > time.Minute {
+		return s.token, nil
+	}
+
+	assertion, err := s.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := neturl.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.account.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("failed to get access token: %s: %s", resp.Status, string(b))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode access token: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", errors.New("failed to get access token: empty token")
+	}
+
+	s.token = "Bearer " + token.AccessToken
+	s.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// assertion returns the signed JWT (RS256) exchanged for an access token.
+func (s *googleTokenSource) assertion(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": s.account.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"iss":   s.account.ClientEmail,
+		"scope": s.scope,
+		"aud":   s.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token assertion: %w", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
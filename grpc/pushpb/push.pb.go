@@ -0,0 +1,726 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v31.1.0
+// source: azurepush/v1/push.proto
+
+// Package azurepush.v1 is the push notification service backed by Azure Notification Hubs.
+
+package pushpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Platform is the push platform of a device.
+type Platform int32
+
+const (
+	Platform_PLATFORM_UNSPECIFIED Platform = 0
+	Platform_PLATFORM_APNS        Platform = 1
+	Platform_PLATFORM_FCMV1       Platform = 2
+	Platform_PLATFORM_BAIDU       Platform = 3
+	Platform_PLATFORM_WNS         Platform = 4
+	Platform_PLATFORM_MPNS        Platform = 5
+)
+
+// Enum value maps for Platform.
+var (
+	Platform_name = map[int32]string{
+		0: "PLATFORM_UNSPECIFIED",
+		1: "PLATFORM_APNS",
+		2: "PLATFORM_FCMV1",
+		3: "PLATFORM_BAIDU",
+		4: "PLATFORM_WNS",
+		5: "PLATFORM_MPNS",
+	}
+	Platform_value = map[string]int32{
+		"PLATFORM_UNSPECIFIED": 0,
+		"PLATFORM_APNS":        1,
+		"PLATFORM_FCMV1":       2,
+		"PLATFORM_BAIDU":       3,
+		"PLATFORM_WNS":         4,
+		"PLATFORM_MPNS":        5,
+	}
+)
+
+func (x Platform) Enum() *Platform {
+	p := new(Platform)
+	*p = x
+	return p
+}
+
+func (x Platform) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Platform) Descriptor() protoreflect.EnumDescriptor {
+	return file_azurepush_v1_push_proto_enumTypes[0].Descriptor()
+}
+
+func (Platform) Type() protoreflect.EnumType {
+	return &file_azurepush_v1_push_proto_enumTypes[0]
+}
+
+func (x Platform) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Platform.Descriptor instead.
+func (Platform) EnumDescriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{0}
+}
+
+type RegisterRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional, a new ID is generated if empty.
+	InstallationId string   `protobuf:"bytes,1,opt,name=installation_id,json=installationId,proto3" json:"installation_id,omitempty"`
+	Platform       Platform `protobuf:"varint,2,opt,name=platform,proto3,enum=azurepush.v1.Platform" json:"platform,omitempty"`
+	// The device token of the platform (APNs device token, FCM registration token, ...).
+	PushChannel   string   `protobuf:"bytes,3,opt,name=push_channel,json=pushChannel,proto3" json:"push_channel,omitempty"`
+	Tags          []string `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterRequest) GetInstallationId() string {
+	if x != nil {
+		return x.InstallationId
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetPlatform() Platform {
+	if x != nil {
+		return x.Platform
+	}
+	return Platform_PLATFORM_UNSPECIFIED
+}
+
+func (x *RegisterRequest) GetPushChannel() string {
+	if x != nil {
+		return x.PushChannel
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type RegisterResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	InstallationId string                 `protobuf:"bytes,1,opt,name=installation_id,json=installationId,proto3" json:"installation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RegisterResponse) GetInstallationId() string {
+	if x != nil {
+		return x.InstallationId
+	}
+	return ""
+}
+
+type UnregisterRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	InstallationId string                 `protobuf:"bytes,1,opt,name=installation_id,json=installationId,proto3" json:"installation_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *UnregisterRequest) Reset() {
+	*x = UnregisterRequest{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnregisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnregisterRequest) ProtoMessage() {}
+
+func (x *UnregisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnregisterRequest.ProtoReflect.Descriptor instead.
+func (*UnregisterRequest) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *UnregisterRequest) GetInstallationId() string {
+	if x != nil {
+		return x.InstallationId
+	}
+	return ""
+}
+
+type UnregisterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UnregisterResponse) Reset() {
+	*x = UnregisterResponse{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UnregisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UnregisterResponse) ProtoMessage() {}
+
+func (x *UnregisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UnregisterResponse.ProtoReflect.Descriptor instead.
+func (*UnregisterResponse) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{3}
+}
+
+type Notification struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Title string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Body  string                 `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+	// Custom data, sent as-is to APNs and as string values to FCM.
+	Data          map[string]string `protobuf:"bytes,3,rep,name=data,proto3" json:"data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notification) Reset() {
+	*x = Notification{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notification) ProtoMessage() {}
+
+func (x *Notification) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notification.ProtoReflect.Descriptor instead.
+func (*Notification) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Notification) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Notification) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Notification) GetData() map[string]string {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SendRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notification  *Notification          `protobuf:"bytes,1,opt,name=notification,proto3" json:"notification,omitempty"`
+	Tags          []string               `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendRequest) Reset() {
+	*x = SendRequest{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendRequest) ProtoMessage() {}
+
+func (x *SendRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendRequest.ProtoReflect.Descriptor instead.
+func (*SendRequest) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SendRequest) GetNotification() *Notification {
+	if x != nil {
+		return x.Notification
+	}
+	return nil
+}
+
+func (x *SendRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type SendToUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notification  *Notification          `protobuf:"bytes,1,opt,name=notification,proto3" json:"notification,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendToUserRequest) Reset() {
+	*x = SendToUserRequest{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendToUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendToUserRequest) ProtoMessage() {}
+
+func (x *SendToUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendToUserRequest.ProtoReflect.Descriptor instead.
+func (*SendToUserRequest) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SendToUserRequest) GetNotification() *Notification {
+	if x != nil {
+		return x.Notification
+	}
+	return nil
+}
+
+func (x *SendToUserRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type SendResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The IDs of the sent notifications, one per platform, as reported by the hub (Standard tier only).
+	// They are the notification IDs of GetTelemetry.
+	NotificationIds []string `protobuf:"bytes,1,rep,name=notification_ids,json=notificationIds,proto3" json:"notification_ids,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SendResponse) Reset() {
+	*x = SendResponse{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendResponse) ProtoMessage() {}
+
+func (x *SendResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendResponse.ProtoReflect.Descriptor instead.
+func (*SendResponse) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SendResponse) GetNotificationIds() []string {
+	if x != nil {
+		return x.NotificationIds
+	}
+	return nil
+}
+
+type GetTelemetryRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NotificationId string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetTelemetryRequest) Reset() {
+	*x = GetTelemetryRequest{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTelemetryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTelemetryRequest) ProtoMessage() {}
+
+func (x *GetTelemetryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTelemetryRequest.ProtoReflect.Descriptor instead.
+func (*GetTelemetryRequest) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetTelemetryRequest) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+type GetTelemetryResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NotificationId string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	// The overall state of the notification, e.g. "Completed".
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// Per platform outcome counts, e.g. "apns.Success" -> 10.
+	OutcomeCounts map[string]int64 `protobuf:"bytes,3,rep,name=outcome_counts,json=outcomeCounts,proto3" json:"outcome_counts,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTelemetryResponse) Reset() {
+	*x = GetTelemetryResponse{}
+	mi := &file_azurepush_v1_push_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTelemetryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTelemetryResponse) ProtoMessage() {}
+
+func (x *GetTelemetryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_azurepush_v1_push_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTelemetryResponse.ProtoReflect.Descriptor instead.
+func (*GetTelemetryResponse) Descriptor() ([]byte, []int) {
+	return file_azurepush_v1_push_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetTelemetryResponse) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+func (x *GetTelemetryResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *GetTelemetryResponse) GetOutcomeCounts() map[string]int64 {
+	if x != nil {
+		return x.OutcomeCounts
+	}
+	return nil
+}
+
+var File_azurepush_v1_push_proto protoreflect.FileDescriptor
+
+const file_azurepush_v1_push_proto_rawDesc = "" +
+	"\n" +
+	"\x17azurepush/v1/push.proto\x12\fazurepush.v1\"\xa5\x01\n" +
+	"\x0fRegisterRequest\x12'\n" +
+	"\x0finstallation_id\x18\x01 \x01(\tR\x0einstallationId\x122\n" +
+	"\bplatform\x18\x02 \x01(\x0e2\x16.azurepush.v1.PlatformR\bplatform\x12!\n" +
+	"\fpush_channel\x18\x03 \x01(\tR\vpushChannel\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\";\n" +
+	"\x10RegisterResponse\x12'\n" +
+	"\x0finstallation_id\x18\x01 \x01(\tR\x0einstallationId\"<\n" +
+	"\x11UnregisterRequest\x12'\n" +
+	"\x0finstallation_id\x18\x01 \x01(\tR\x0einstallationId\"\x14\n" +
+	"\x12UnregisterResponse\"\xab\x01\n" +
+	"\fNotification\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x12\n" +
+	"\x04body\x18\x02 \x01(\tR\x04body\x128\n" +
+	"\x04data\x18\x03 \x03(\v2$.azurepush.v1.Notification.DataEntryR\x04data\x1a7\n" +
+	"\tDataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"a\n" +
+	"\vSendRequest\x12>\n" +
+	"\fnotification\x18\x01 \x01(\v2\x1a.azurepush.v1.NotificationR\fnotification\x12\x12\n" +
+	"\x04tags\x18\x02 \x03(\tR\x04tags\"l\n" +
+	"\x11SendToUserRequest\x12>\n" +
+	"\fnotification\x18\x01 \x01(\v2\x1a.azurepush.v1.NotificationR\fnotification\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"9\n" +
+	"\fSendResponse\x12)\n" +
+	"\x10notification_ids\x18\x01 \x03(\tR\x0fnotificationIds\">\n" +
+	"\x13GetTelemetryRequest\x12'\n" +
+	"\x0fnotification_id\x18\x01 \x01(\tR\x0enotificationId\"\xf5\x01\n" +
+	"\x14GetTelemetryResponse\x12'\n" +
+	"\x0fnotification_id\x18\x01 \x01(\tR\x0enotificationId\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\\\n" +
+	"\x0eoutcome_counts\x18\x03 \x03(\v25.azurepush.v1.GetTelemetryResponse.OutcomeCountsEntryR\routcomeCounts\x1a@\n" +
+	"\x12OutcomeCountsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01*\x84\x01\n" +
+	"\bPlatform\x12\x18\n" +
+	"\x14PLATFORM_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rPLATFORM_APNS\x10\x01\x12\x12\n" +
+	"\x0ePLATFORM_FCMV1\x10\x02\x12\x12\n" +
+	"\x0ePLATFORM_BAIDU\x10\x03\x12\x10\n" +
+	"\fPLATFORM_WNS\x10\x04\x12\x11\n" +
+	"\rPLATFORM_MPNS\x10\x052\x8a\x03\n" +
+	"\vPushService\x12I\n" +
+	"\bRegister\x12\x1d.azurepush.v1.RegisterRequest\x1a\x1e.azurepush.v1.RegisterResponse\x12O\n" +
+	"\n" +
+	"Unregister\x12\x1f.azurepush.v1.UnregisterRequest\x1a .azurepush.v1.UnregisterResponse\x12=\n" +
+	"\x04Send\x12\x19.azurepush.v1.SendRequest\x1a\x1a.azurepush.v1.SendResponse\x12I\n" +
+	"\n" +
+	"SendToUser\x12\x1f.azurepush.v1.SendToUserRequest\x1a\x1a.azurepush.v1.SendResponse\x12U\n" +
+	"\fGetTelemetry\x12!.azurepush.v1.GetTelemetryRequest\x1a\".azurepush.v1.GetTelemetryResponseB*Z(github.com/kataras/azurepush/grpc/pushpbb\x06proto3"
+
+var (
+	file_azurepush_v1_push_proto_rawDescOnce sync.Once
+	file_azurepush_v1_push_proto_rawDescData []byte
+)
+
+func file_azurepush_v1_push_proto_rawDescGZIP() []byte {
+	file_azurepush_v1_push_proto_rawDescOnce.Do(func() {
+		file_azurepush_v1_push_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_azurepush_v1_push_proto_rawDesc), len(file_azurepush_v1_push_proto_rawDesc)))
+	})
+	return file_azurepush_v1_push_proto_rawDescData
+}
+
+var file_azurepush_v1_push_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_azurepush_v1_push_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_azurepush_v1_push_proto_goTypes = []any{
+	(Platform)(0),                // 0: azurepush.v1.Platform
+	(*RegisterRequest)(nil),      // 1: azurepush.v1.RegisterRequest
+	(*RegisterResponse)(nil),     // 2: azurepush.v1.RegisterResponse
+	(*UnregisterRequest)(nil),    // 3: azurepush.v1.UnregisterRequest
+	(*UnregisterResponse)(nil),   // 4: azurepush.v1.UnregisterResponse
+	(*Notification)(nil),         // 5: azurepush.v1.Notification
+	(*SendRequest)(nil),          // 6: azurepush.v1.SendRequest
+	(*SendToUserRequest)(nil),    // 7: azurepush.v1.SendToUserRequest
+	(*SendResponse)(nil),         // 8: azurepush.v1.SendResponse
+	(*GetTelemetryRequest)(nil),  // 9: azurepush.v1.GetTelemetryRequest
+	(*GetTelemetryResponse)(nil), // 10: azurepush.v1.GetTelemetryResponse
+	nil,                          // 11: azurepush.v1.Notification.DataEntry
+	nil,                          // 12: azurepush.v1.GetTelemetryResponse.OutcomeCountsEntry
+}
+var file_azurepush_v1_push_proto_depIdxs = []int32{
+	0,  // 0: azurepush.v1.RegisterRequest.platform:type_name -> azurepush.v1.Platform
+	11, // 1: azurepush.v1.Notification.data:type_name -> azurepush.v1.Notification.DataEntry
+	5,  // 2: azurepush.v1.SendRequest.notification:type_name -> azurepush.v1.Notification
+	5,  // 3: azurepush.v1.SendToUserRequest.notification:type_name -> azurepush.v1.Notification
+	12, // 4: azurepush.v1.GetTelemetryResponse.outcome_counts:type_name -> azurepush.v1.GetTelemetryResponse.OutcomeCountsEntry
+	1,  // 5: azurepush.v1.PushService.Register:input_type -> azurepush.v1.RegisterRequest
+	3,  // 6: azurepush.v1.PushService.Unregister:input_type -> azurepush.v1.UnregisterRequest
+	6,  // 7: azurepush.v1.PushService.Send:input_type -> azurepush.v1.SendRequest
+	7,  // 8: azurepush.v1.PushService.SendToUser:input_type -> azurepush.v1.SendToUserRequest
+	9,  // 9: azurepush.v1.PushService.GetTelemetry:input_type -> azurepush.v1.GetTelemetryRequest
+	2,  // 10: azurepush.v1.PushService.Register:output_type -> azurepush.v1.RegisterResponse
+	4,  // 11: azurepush.v1.PushService.Unregister:output_type -> azurepush.v1.UnregisterResponse
+	8,  // 12: azurepush.v1.PushService.Send:output_type -> azurepush.v1.SendResponse
+	8,  // 13: azurepush.v1.PushService.SendToUser:output_type -> azurepush.v1.SendResponse
+	10, // 14: azurepush.v1.PushService.GetTelemetry:output_type -> azurepush.v1.GetTelemetryResponse
+	10, // [10:15] is the sub-list for method output_type
+	5,  // [5:10] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_azurepush_v1_push_proto_init() }
+func file_azurepush_v1_push_proto_init() {
+	if File_azurepush_v1_push_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_azurepush_v1_push_proto_rawDesc), len(file_azurepush_v1_push_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_azurepush_v1_push_proto_goTypes,
+		DependencyIndexes: file_azurepush_v1_push_proto_depIdxs,
+		EnumInfos:         file_azurepush_v1_push_proto_enumTypes,
+		MessageInfos:      file_azurepush_v1_push_proto_msgTypes,
+	}.Build()
+	File_azurepush_v1_push_proto = out.File
+	file_azurepush_v1_push_proto_goTypes = nil
+	file_azurepush_v1_push_proto_depIdxs = nil
+}
@@ -0,0 +1,291 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             v31.1.0
+// source: azurepush/v1/push.proto
+
+// Package azurepush.v1 is the push notification service backed by Azure Notification Hubs.
+
+package pushpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PushService_Register_FullMethodName     = "/azurepush.v1.PushService/Register"
+	PushService_Unregister_FullMethodName   = "/azurepush.v1.PushService/Unregister"
+	PushService_Send_FullMethodName         = "/azurepush.v1.PushService/Send"
+	PushService_SendToUser_FullMethodName   = "/azurepush.v1.PushService/SendToUser"
+	PushService_GetTelemetry_FullMethodName = "/azurepush.v1.PushService/GetTelemetry"
+)
+
+// PushServiceClient is the client API for PushService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PushService registers devices and sends push notifications
+// through a single service which holds the hub's credentials.
+type PushServiceClient interface {
+	// Register creates or replaces a device installation.
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	// Unregister deletes a device installation.
+	Unregister(ctx context.Context, in *UnregisterRequest, opts ...grpc.CallOption) (*UnregisterResponse, error)
+	// Send sends a notification to the devices matching the tags, all devices if no tags are given.
+	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error)
+	// SendToUser sends a notification to all devices of a user.
+	SendToUser(ctx context.Context, in *SendToUserRequest, opts ...grpc.CallOption) (*SendResponse, error)
+	// GetTelemetry returns the delivery telemetry of a sent notification.
+	GetTelemetry(ctx context.Context, in *GetTelemetryRequest, opts ...grpc.CallOption) (*GetTelemetryResponse, error)
+}
+
+type pushServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPushServiceClient(cc grpc.ClientConnInterface) PushServiceClient {
+	return &pushServiceClient{cc}
+}
+
+func (c *pushServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, PushService_Register_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pushServiceClient) Unregister(ctx context.Context, in *UnregisterRequest, opts ...grpc.CallOption) (*UnregisterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnregisterResponse)
+	err := c.cc.Invoke(ctx, PushService_Unregister_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pushServiceClient) Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendResponse)
+	err := c.cc.Invoke(ctx, PushService_Send_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pushServiceClient) SendToUser(ctx context.Context, in *SendToUserRequest, opts ...grpc.CallOption) (*SendResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendResponse)
+	err := c.cc.Invoke(ctx, PushService_SendToUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pushServiceClient) GetTelemetry(ctx context.Context, in *GetTelemetryRequest, opts ...grpc.CallOption) (*GetTelemetryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTelemetryResponse)
+	err := c.cc.Invoke(ctx, PushService_GetTelemetry_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PushServiceServer is the server API for PushService service.
+// All implementations must embed UnimplementedPushServiceServer
+// for forward compatibility.
+//
+// PushService registers devices and sends push notifications
+// through a single service which holds the hub's credentials.
+type PushServiceServer interface {
+	// Register creates or replaces a device installation.
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	// Unregister deletes a device installation.
+	Unregister(context.Context, *UnregisterRequest) (*UnregisterResponse, error)
+	// Send sends a notification to the devices matching the tags, all devices if no tags are given.
+	Send(context.Context, *SendRequest) (*SendResponse, error)
+	// SendToUser sends a notification to all devices of a user.
+	SendToUser(context.Context, *SendToUserRequest) (*SendResponse, error)
+	// GetTelemetry returns the delivery telemetry of a sent notification.
+	GetTelemetry(context.Context, *GetTelemetryRequest) (*GetTelemetryResponse, error)
+	mustEmbedUnimplementedPushServiceServer()
+}
+
+// UnimplementedPushServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPushServiceServer struct{}
+
+func (UnimplementedPushServiceServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedPushServiceServer) Unregister(context.Context, *UnregisterRequest) (*UnregisterResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Unregister not implemented")
+}
+func (UnimplementedPushServiceServer) Send(context.Context, *SendRequest) (*SendResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedPushServiceServer) SendToUser(context.Context, *SendToUserRequest) (*SendResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SendToUser not implemented")
+}
+func (UnimplementedPushServiceServer) GetTelemetry(context.Context, *GetTelemetryRequest) (*GetTelemetryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTelemetry not implemented")
+}
+func (UnimplementedPushServiceServer) mustEmbedUnimplementedPushServiceServer() {}
+func (UnimplementedPushServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafePushServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PushServiceServer will
+// result in compilation errors.
+type UnsafePushServiceServer interface {
+	mustEmbedUnimplementedPushServiceServer()
+}
+
+func RegisterPushServiceServer(s grpc.ServiceRegistrar, srv PushServiceServer) {
+	// If the following call panics, it indicates UnimplementedPushServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PushService_ServiceDesc, srv)
+}
+
+func _PushService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PushServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PushService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PushServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PushService_Unregister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnregisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PushServiceServer).Unregister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PushService_Unregister_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PushServiceServer).Unregister(ctx, req.(*UnregisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PushService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PushServiceServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PushService_Send_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PushServiceServer).Send(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PushService_SendToUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendToUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PushServiceServer).SendToUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PushService_SendToUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PushServiceServer).SendToUser(ctx, req.(*SendToUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PushService_GetTelemetry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTelemetryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PushServiceServer).GetTelemetry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PushService_GetTelemetry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PushServiceServer).GetTelemetry(ctx, req.(*GetTelemetryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PushService_ServiceDesc is the grpc.ServiceDesc for PushService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PushService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "azurepush.v1.PushService",
+	HandlerType: (*PushServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _PushService_Register_Handler,
+		},
+		{
+			MethodName: "Unregister",
+			Handler:    _PushService_Unregister_Handler,
+		},
+		{
+			MethodName: "Send",
+			Handler:    _PushService_Send_Handler,
+		},
+		{
+			MethodName: "SendToUser",
+			Handler:    _PushService_SendToUser_Handler,
+		},
+		{
+			MethodName: "GetTelemetry",
+			Handler:    _PushService_GetTelemetry_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "azurepush/v1/push.proto",
+}
@@ -0,0 +1,211 @@
+// Package grpc exposes the azurepush Client as a gRPC PushService,
+// so internal services can register devices and send notifications
+// without linking the Azure client and holding the hub's keys.
+//
+// The service is defined at proto/azurepush/v1/push.proto,
+// the generated messages and stubs live in the pushpb package.
+//
+// Example usage:
+//
+//	s := grpc.NewServer()
+//	pushpb.RegisterPushServiceServer(s, azurepushgrpc.NewServer(client, azurepushgrpc.Options{}))
+//	s.Serve(lis)
+package grpc
+
+//go:generate protoc -I proto --go_out=. --go_opt=module=github.com/kataras/azurepush/grpc --go-grpc_out=. --go-grpc_opt=module=github.com/kataras/azurepush/grpc azurepush/v1/push.proto
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/grpc/pushpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options holds the settings of the Server.
+type Options struct {
+	// UserTag returns the tag of a user's devices, used by SendToUser.
+	//
+	// Defaults to "user:" + userID.
+	UserTag func(userID string) string
+}
+
+// Server implements the pushpb.PushServiceServer backed by a Client.
+//
+// The notification IDs of the sends and GetTelemetry require a client which reports them,
+// e.g. *azurepush.Client (see TelemetryClient), GetTelemetry responds with codes.Unimplemented otherwise.
+type Server struct {
+	pushpb.UnimplementedPushServiceServer
+
+	client azurepush.HubClient
+	opts   Options
+}
+
+var _ pushpb.PushServiceServer = (*Server)(nil)
+
+// TelemetryClient is implemented by the clients which report the IDs and the telemetry of the sent notifications.
+type TelemetryClient interface {
+	SendNotificationWithIDs(ctx context.Context, notification azurepush.Notification, opts azurepush.SendOptions, tags ...string) ([]string, error)
+	GetNotificationTelemetry(ctx context.Context, notificationID string) (*azurepush.NotificationTelemetry, error)
+}
+
+var _ TelemetryClient = (*azurepush.Client)(nil)
+
+// NewServer creates a new gRPC PushService server.
+func NewServer(client azurepush.HubClient, opts Options) *Server {
+	if client == nil {
+		panic("azurepush/grpc: nil client")
+	}
+
+	if opts.UserTag == nil {
+		opts.UserTag = func(userID string) string {
+			return azurepush.UserTag(userID).String()
+		}
+	}
+
+	return &Server{client: client, opts: opts}
+}
+
+// platforms maps the protobuf platforms to the installation platforms.
+var platforms = map[pushpb.Platform]azurepush.InstallationPlatform{
+	pushpb.Platform_PLATFORM_APNS:  azurepush.InstallationApple,
+	pushpb.Platform_PLATFORM_FCMV1: azurepush.InstallationFCMV1,
+	pushpb.Platform_PLATFORM_BAIDU: azurepush.InstallationBaidu,
+	pushpb.Platform_PLATFORM_WNS:   azurepush.InstallationWNS,
+	pushpb.Platform_PLATFORM_MPNS:  azurepush.InstallationMPNS,
+}
+
+// Register implements the pushpb.PushServiceServer interface.
+func (s *Server) Register(ctx context.Context, req *pushpb.RegisterRequest) (*pushpb.RegisterResponse, error) {
+	platform, ok := platforms[req.GetPlatform()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid platform: %s", req.GetPlatform())
+	}
+
+	if req.GetPushChannel() == "" {
+		return nil, status.Error(codes.InvalidArgument, "push channel is required")
+	}
+
+	id, err := s.client.RegisterDevice(ctx, azurepush.Installation{
+		InstallationID: req.GetInstallationId(),
+		Platform:       platform,
+		PushChannel:    req.GetPushChannel(),
+		Tags:           req.GetTags(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pushpb.RegisterResponse{InstallationId: id}, nil
+}
+
+// Unregister implements the pushpb.PushServiceServer interface.
+func (s *Server) Unregister(ctx context.Context, req *pushpb.UnregisterRequest) (*pushpb.UnregisterResponse, error) {
+	if req.GetInstallationId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "installation ID is required")
+	}
+
+	if err := s.client.DeleteDevice(ctx, req.GetInstallationId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pushpb.UnregisterResponse{}, nil
+}
+
+// Send implements the pushpb.PushServiceServer interface.
+func (s *Server) Send(ctx context.Context, req *pushpb.SendRequest) (*pushpb.SendResponse, error) {
+	return s.send(ctx, req.GetNotification(), req.GetTags()...)
+}
+
+// SendToUser implements the pushpb.PushServiceServer interface.
+func (s *Server) SendToUser(ctx context.Context, req *pushpb.SendToUserRequest) (*pushpb.SendResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	return s.send(ctx, req.GetNotification(), s.opts.UserTag(req.GetUserId()))
+}
+
+func (s *Server) send(ctx context.Context, n *pushpb.Notification, tags ...string) (*pushpb.SendResponse, error) {
+	if n == nil {
+		return nil, status.Error(codes.InvalidArgument, "notification is required")
+	}
+
+	notification := azurepush.Notification{
+		Title: n.GetTitle(),
+		Body:  n.GetBody(),
+	}
+	if len(n.GetData()) > 0 {
+		notification.Data = make(map[string]any, len(n.GetData()))
+		for key, value := range n.GetData() {
+			notification.Data[key] = value
+		}
+	}
+
+	if client, ok := s.client.(TelemetryClient); ok {
+		ids, err := client.SendNotificationWithIDs(ctx, notification, azurepush.SendOptions{}, tags...)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		return &pushpb.SendResponse{NotificationIds: ids}, nil
+	}
+
+	if err := s.client.SendNotification(ctx, notification, tags...); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pushpb.SendResponse{}, nil
+}
+
+// GetTelemetry implements the pushpb.PushServiceServer interface.
+func (s *Server) GetTelemetry(ctx context.Context, req *pushpb.GetTelemetryRequest) (*pushpb.GetTelemetryResponse, error) {
+	client, ok := s.client.(TelemetryClient)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "the client does not report telemetry")
+	}
+
+	if req.GetNotificationId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "notification ID is required")
+	}
+
+	telemetry, err := client.GetNotificationTelemetry(ctx, req.GetNotificationId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pushpb.GetTelemetryResponse{
+		NotificationId: telemetry.NotificationID,
+		State:          telemetry.State,
+		OutcomeCounts:  make(map[string]int64, len(telemetry.OutcomeCounts)),
+	}
+	for outcome, count := range telemetry.OutcomeCounts {
+		resp.OutcomeCounts[outcome] = int64(count)
+	}
+
+	return resp, nil
+}
+
+// toStatus converts a client error to a gRPC status error.
+func toStatus(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	if errors.Is(err, azurepush.ErrQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	if errors.Is(err, azurepush.ErrInvalidNotification) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if _, ok := errors.AsType[*azurepush.TierError](err); ok {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
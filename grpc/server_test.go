@@ -0,0 +1,129 @@
+package grpc_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush/azurepushtest"
+	azurepushgrpc "github.com/kataras/azurepush/grpc"
+	"github.com/kataras/azurepush/grpc/pushpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestService(t *testing.T, srv *azurepushtest.Server) pushpb.PushServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pushpb.RegisterPushServiceServer(s, azurepushgrpc.NewServer(srv.NewClient(), azurepushgrpc.Options{}))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return pushpb.NewPushServiceClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := newTestService(t, srv)
+	ctx := context.Background()
+
+	resp, err := client.Register(ctx, &pushpb.RegisterRequest{
+		Platform:    pushpb.Platform_PLATFORM_FCMV1,
+		PushChannel: "fcm-token",
+		Tags:        []string{"user:42"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected register error: %v", err)
+	}
+	if _, ok := srv.Installation(resp.GetInstallationId()); !ok {
+		t.Fatalf("expected installation %s to be registered", resp.GetInstallationId())
+	}
+
+	sendResp, err := client.SendToUser(ctx, &pushpb.SendToUserRequest{
+		Notification: &pushpb.Notification{Title: "Hi", Body: "Hello", Data: map[string]string{"orderId": "7"}},
+		UserId:       "42",
+	})
+	if err != nil {
+		t.Fatalf("unexpected send error: %v", err)
+	}
+	sent := srv.Sent("user:42")
+	if len(sent) != 2 { // apple and fcmV1.
+		t.Fatalf("expected 2 sent notifications, got: %d", len(sent))
+	}
+	if sent[1].Format != "fcmV1" || len(sent[1].InstallationIDs) != 1 {
+		t.Errorf("unexpected fcmV1 notification: %+v", sent[1])
+	}
+	if ids := sendResp.GetNotificationIds(); len(ids) != 2 || ids[1] != sent[1].ID {
+		t.Fatalf("expected the IDs of the sent notifications, got: %v", ids)
+	}
+
+	telemetry, err := client.GetTelemetry(ctx, &pushpb.GetTelemetryRequest{NotificationId: sendResp.GetNotificationIds()[1]})
+	if err != nil {
+		t.Fatalf("unexpected telemetry error: %v", err)
+	}
+	if telemetry.GetState() != "Completed" || telemetry.GetOutcomeCounts()["fcmV1.Success"] != 1 {
+		t.Errorf("unexpected telemetry: %v", telemetry)
+	}
+
+	if _, err = client.Unregister(ctx, &pushpb.UnregisterRequest{InstallationId: resp.GetInstallationId()}); err != nil {
+		t.Fatalf("unexpected unregister error: %v", err)
+	}
+	if _, ok := srv.Installation(resp.GetInstallationId()); ok {
+		t.Error("expected installation to be deleted")
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := newTestService(t, srv)
+	ctx := context.Background()
+
+	_, err := client.Register(ctx, &pushpb.RegisterRequest{PushChannel: "token"})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a missing platform, got: %v", err)
+	}
+
+	_, err = client.Send(ctx, &pushpb.SendRequest{})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a missing notification, got: %v", err)
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusForbidden, "The monthly push quota has been exceeded")
+	_, err = client.Send(ctx, &pushpb.SendRequest{Notification: &pushpb.Notification{Title: "Hi"}})
+	if code := status.Code(err); code != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted for an exceeded quota, got: %v", err)
+	}
+
+	_, err = client.GetTelemetry(ctx, &pushpb.GetTelemetryRequest{})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a missing notification ID, got: %v", err)
+	}
+
+	srv.SetError(azurepushtest.OpTelemetry, http.StatusForbidden, "Per message telemetry requires the Standard tier")
+	_, err = client.GetTelemetry(ctx, &pushpb.GetTelemetryRequest{NotificationId: "1"})
+	if code := status.Code(err); code != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for the telemetry of a lower tier, got: %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package azurepush
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultMaxRegistrationBodySize is the default maximum size of a registration request body.
+const DefaultMaxRegistrationBodySize = 64 << 10
+
+// RegistrationRequest is the JSON body accepted by the RegistrationHandler.
+type RegistrationRequest struct {
+	// InstallationID is optional, a new one is generated if empty.
+	// Mobile apps should store the returned ID and send it on subsequent registrations
+	// (e.g. when the push channel is refreshed) to update the same installation.
+	InstallationID string               `json:"installationId,omitempty"`
+	Platform       InstallationPlatform `json:"platform"`
+	PushChannel    string               `json:"pushChannel"`
+	Tags           []string             `json:"tags,omitempty"`
+}
+
+// Validate checks the installation of the request and its tags, its ID is optional.
+func (req RegistrationRequest) Validate() error {
+	for _, tag := range req.Tags {
+		if err := Tag(tag).Validate(); err != nil {
+			return err
+		}
+	}
+
+	installation := Installation{
+		InstallationID: req.InstallationID,
+		Platform:       req.Platform,
+		PushChannel:    req.PushChannel,
+		Tags:           req.Tags,
+	}
+	if installation.InstallationID == "" {
+		installation.InstallationID = "pending" // generated by RegisterDevice.
+	}
+
+	return installation.Validate()
+}
+
+// RegistrationResponse is the JSON body written by the RegistrationHandler on success.
+type RegistrationResponse struct {
+	InstallationID string `json:"installationId"`
+}
+
+// RegistrationHandlerOptions holds the settings of the RegistrationHandler.
+type RegistrationHandlerOptions struct {
+	// Authorize is called (if not nil) before the body is read,
+	// a non-nil error responds with 401 Unauthorized.
+	Authorize func(r *http.Request) error
+
+	// BeforeRegister is called (if not nil) with the installation decoded from the request,
+	// right before it is registered. It can modify it, e.g. to replace client-provided tags
+	// with server-side ones like "user:<id>" of the authenticated user.
+	// A non-nil error responds with 400 Bad Request, and so do the invalid tags it sets (see Tag.Validate).
+	BeforeRegister func(r *http.Request, installation *Installation) error
+
+	// MaxBodySize is the maximum size of the request body.
+	//
+	// Defaults to DefaultMaxRegistrationBodySize.
+	MaxBodySize int64
+
+	// OnError is called (if not nil) when the registration fails on the hub, e.g. to log the error.
+	// The caller receives a generic 502 Bad Gateway response.
+	OnError func(r *http.Request, err error)
+}
+
+// RegistrationHandler returns a ready-made device registration endpoint.
+// It accepts POST requests with a JSON RegistrationRequest body, validates the installation,
+// registers it through the client and responds with a JSON RegistrationResponse.
+//
+// Example usage:
+//
+//	http.Handle("POST /devices", azurepush.RegistrationHandler(client, azurepush.RegistrationHandlerOptions{
+//		Authorize: func(r *http.Request) error {
+//			_, err := auth.UserFromRequest(r)
+//			return err
+//		},
+//		BeforeRegister: func(r *http.Request, installation *azurepush.Installation) error {
+//			user, _ := auth.UserFromRequest(r)
+//			installation.Tags = azurepush.Tags(azurepush.UserTag(user.ID))
+//			return nil
+//		},
+//	}))
+func RegistrationHandler(client HubClient, opts RegistrationHandlerOptions) http.Handler {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxRegistrationBodySize
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeHandlerError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		if opts.Authorize != nil {
+			if err := opts.Authorize(r); err != nil {
+				writeHandlerError(w, http.StatusUnauthorized, err)
+				return
+			}
+		}
+
+		var req RegistrationRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, opts.MaxBodySize))
+		if err := dec.Decode(&req); err != nil {
+			if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+				writeHandlerError(w, http.StatusRequestEntityTooLarge, err)
+				return
+			}
+			writeHandlerError(w, http.StatusBadRequest, fmt.Errorf("invalid registration body: %w", err))
+			return
+		}
+
+		installation := Installation{
+			InstallationID: req.InstallationID,
+			Platform:       req.Platform,
+			PushChannel:    req.PushChannel,
+			Tags:           req.Tags,
+		}
+
+		if opts.BeforeRegister != nil {
+			if err := opts.BeforeRegister(r, &installation); err != nil {
+				writeHandlerError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		// The tags are validated after BeforeRegister, so server-side tags are checked as well.
+		for _, tag := range installation.Tags {
+			if err := Tag(tag).Validate(); err != nil {
+				writeHandlerError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		// The ID is generated by RegisterDevice, validate everything else upfront.
+		validate := installation
+		if validate.InstallationID == "" {
+			validate.InstallationID = "pending"
+		}
+		if err := validate.Validate(); err != nil {
+			writeHandlerError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := client.RegisterDevice(r.Context(), installation)
+		if err != nil {
+			if opts.OnError != nil {
+				opts.OnError(r, err)
+			}
+			// Azure's response is not exposed to the caller.
+			writeHandlerError(w, http.StatusBadGateway, errors.New("registration failed"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(RegistrationResponse{InstallationID: id})
+	})
+}
+
+// TokenEndpointResponse is the JSON body written by the TokenEndpointHandler on success.
+type TokenEndpointResponse struct {
+	InstallationToken
+	// HubName is the name of the hub.
+	HubName string `json:"hubName"`
+	// ConnectionString is the "Endpoint=sb://{namespace}.servicebus.windows.net/;SharedAccessSignature={token}"
+	// connection string of the token, the form accepted by the Azure Notification Hubs mobile SDKs
+	// along with the hub name.
+	ConnectionString string `json:"connectionString"`
+}
+
+// TokenEndpointHandlerOptions holds the settings of the TokenEndpointHandler.
+type TokenEndpointHandlerOptions struct {
+	// Authorize authenticates the caller and returns the installation ID it may register.
+	// It receives the installation ID requested by the caller (the "installationId" query parameter, empty if missing)
+	// and returns it after checking its ownership, or an installation ID derived from the authenticated user.
+	// A non-nil error, or an empty installation ID, responds with 401 Unauthorized.
+	//
+	// Required.
+	Authorize func(r *http.Request, installationID string) (string, error)
+
+	// Validity is the validity of the issued tokens.
+	//
+	// Defaults to DefaultInstallationTokenValidity.
+	Validity time.Duration
+
+	// OnError is called (if not nil) when a token cannot be issued, e.g. to log the error.
+	// The caller receives a generic 500 Internal Server Error response.
+	OnError func(r *http.Request, err error)
+}
+
+// TokenEndpointHandler returns a ready-made endpoint which issues installation tokens
+// (see Client.InstallationToken), so the devices register themselves directly on the hub.
+// It accepts POST requests, authorizes the caller and responds with a JSON TokenEndpointResponse.
+//
+// It panics if Authorize is nil: a token endpoint must never issue tokens for any installation.
+//
+// Example usage:
+//
+//	http.Handle("POST /push/token", azurepush.TokenEndpointHandler(client, azurepush.TokenEndpointHandlerOptions{
+//		Authorize: func(r *http.Request, installationID string) (string, error) {
+//			user, err := auth.UserFromRequest(r)
+//			if err != nil {
+//				return "", err
+//			}
+//			return user.ID + "-" + installationID, nil // each user registers under its own IDs.
+//		},
+//	}))
+func TokenEndpointHandler(client *Client, opts TokenEndpointHandlerOptions) http.Handler {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if opts.Authorize == nil {
+		panic("azurepush: token endpoint requires an Authorize function")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeHandlerError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		installationID, err := opts.Authorize(r, r.URL.Query().Get("installationId"))
+		if err == nil && installationID == "" {
+			err = errors.New("no installation to authorize")
+		}
+		if err != nil {
+			writeHandlerError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		token, err := client.InstallationToken(installationID, opts.Validity)
+		if err != nil {
+			if opts.OnError != nil {
+				opts.OnError(r, err)
+			}
+			writeHandlerError(w, http.StatusInternalServerError, errors.New("token issuance failed"))
+			return
+		}
+
+		cfg, _ := client.current()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(TokenEndpointResponse{
+			InstallationToken: *token,
+			HubName:           cfg.HubName,
+			ConnectionString:  fmt.Sprintf("Endpoint=sb://%s.servicebus.windows.net/;SharedAccessSignature=%s", cfg.Namespace, token.Token),
+		})
+	})
+}
+
+// handlerError is the JSON body written by the handlers of this package on failure.
+type handlerError struct {
+	Error string `json:"error"`
+}
+
+func writeHandlerError(w http.ResponseWriter, statusCode int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(handlerError{Error: err.Error()})
+}
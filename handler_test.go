@@ -0,0 +1,191 @@
+package azurepush_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestRegistrationHandler(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	handler := azurepush.RegistrationHandler(srv.NewClient(), azurepush.RegistrationHandlerOptions{
+		Authorize: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer user-42" {
+				return errors.New("invalid credentials")
+			}
+			return nil
+		},
+		BeforeRegister: func(r *http.Request, installation *azurepush.Installation) error {
+			installation.Tags = []string{"user:42"}
+			return nil
+		},
+	})
+
+	do := func(method, authorization, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/devices", strings.NewReader(body))
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "Bearer user-42", `{"platform":"FCMV1","pushChannel":"fcm-token","tags":["admin"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp azurepush.RegistrationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("unexpected response body: %v", err)
+	}
+
+	installation, ok := srv.Installation(resp.InstallationID)
+	if !ok {
+		t.Fatalf("expected installation %s to be registered", resp.InstallationID)
+	}
+	if !slices.Equal(installation.Tags, []string{"user:42"}) {
+		t.Errorf("expected server-side tags, got: %v", installation.Tags)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		authorization string
+		body          string
+		expected      int
+	}{
+		{"method", http.MethodGet, "Bearer user-42", "", http.StatusMethodNotAllowed},
+		{"unauthorized", http.MethodPost, "", `{"platform":"FCMV1","pushChannel":"fcm-token"}`, http.StatusUnauthorized},
+		{"malformed", http.MethodPost, "Bearer user-42", `{"platform":`, http.StatusBadRequest},
+		{"invalid platform", http.MethodPost, "Bearer user-42", `{"platform":"symbian","pushChannel":"token"}`, http.StatusBadRequest},
+		{"missing push channel", http.MethodPost, "Bearer user-42", `{"platform":"apns"}`, http.StatusBadRequest},
+		{"too large", http.MethodPost, "Bearer user-42", `{"platform":"apns","pushChannel":"` + strings.Repeat("a", azurepush.DefaultMaxRegistrationBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := do(tt.method, tt.authorization, tt.body); rec.Code != tt.expected {
+				t.Errorf("expected status %d, got: %d: %s", tt.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+}
+
+func TestRegistrationHandler_InvalidTags(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	handler := azurepush.RegistrationHandler(srv.NewClient(), azurepush.RegistrationHandlerOptions{
+		BeforeRegister: func(r *http.Request, installation *azurepush.Installation) error {
+			installation.Tags = append(installation.Tags, "user:"+r.Header.Get("X-User"))
+			return nil
+		},
+	})
+
+	tests := []struct {
+		name     string
+		user     string
+		body     string
+		expected int
+	}{
+		{"valid", "42", `{"platform":"apns","pushChannel":"token","tags":["lang:en"]}`, http.StatusOK},
+		{"client tag", "42", `{"platform":"apns","pushChannel":"token","tags":["lang en"]}`, http.StatusBadRequest},
+		{"server tag", "John Doe", `{"platform":"apns","pushChannel":"token"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(tt.body))
+			req.Header.Set("X-User", tt.user)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("expected status %d, got: %d: %s", tt.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	if installations := srv.Installations(); len(installations) != 1 {
+		t.Errorf("expected only the valid installation to be registered, got: %d", len(installations))
+	}
+}
+
+func TestRegistrationHandler_HubError(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpRegister, http.StatusInternalServerError, "secret details")
+
+	var hubErr error
+	handler := azurepush.RegistrationHandler(srv.NewClient(), azurepush.RegistrationHandlerOptions{
+		OnError: func(r *http.Request, err error) { hubErr = err },
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(`{"platform":"apns","pushChannel":"token"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadGateway {
+		t.Fatalf("expected status 502, got: %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret details") {
+		t.Errorf("expected hub response to be hidden, got: %s", rec.Body)
+	}
+	if hubErr == nil || !strings.Contains(hubErr.Error(), "secret details") {
+		t.Errorf("expected OnError to receive the hub error, got: %v", hubErr)
+	}
+}
+
+func TestTokenEndpointHandler(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	handler := azurepush.TokenEndpointHandler(srv.NewClient(), azurepush.TokenEndpointHandlerOptions{
+		Authorize: func(r *http.Request, installationID string) (string, error) {
+			if r.Header.Get("Authorization") != "Bearer user-42" {
+				return "", errors.New("invalid credentials")
+			}
+			return "user-42-" + installationID, nil
+		},
+	})
+
+	do := func(method, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/push/token?installationId=phone", nil)
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "Bearer user-42")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp azurepush.TokenEndpointResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.InstallationID != "user-42-phone" || resp.HubName != srv.Configuration().HubName ||
+		!strings.HasSuffix(resp.Endpoint, "/installations/user-42-phone?api-version=2020-06") ||
+		!strings.HasPrefix(resp.ConnectionString, "Endpoint=sb://") || !strings.HasSuffix(resp.ConnectionString, ";SharedAccessSignature="+resp.Token) {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	if rec = do(http.MethodPost, "Bearer someone"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got: %d", rec.Code)
+	}
+	if rec = do(http.MethodGet, "Bearer user-42"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got: %d", rec.Code)
+	}
+}
@@ -0,0 +1,162 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReadinessWindow is the maximum age of the last successful hub call for the client to be ready
+// without probing the hub, see Client.ReadinessHandler.
+const ReadinessWindow = time.Minute
+
+// HealthResponse is the JSON body written by the ReadinessHandler and the LivenessHandler.
+type HealthResponse struct {
+	// Status is "ok", or "unavailable" with a 503 Service Unavailable response.
+	Status string `json:"status"`
+	// Error is the reason of an "unavailable" status.
+	Error string `json:"error,omitempty"`
+}
+
+// clientHealth tracks the hub calls and the background goroutines of a client for its probes.
+type clientHealth struct {
+	lastSuccess atomic.Int64 // unix nanoseconds of the last successful hub call, zero after a failed one.
+
+	mu     sync.Mutex
+	failed []error // of the background goroutines.
+}
+
+// do sends a hub request through the request pipeline, see roundTrip.
+// It fails with ErrClientClosed once the client is closed, see Close.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	done, err := c.begin()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	return c.roundTrip(req)
+}
+
+// attempt sends a hub request with the client's HTTPClient and records its outcome for the readiness probe and Stats.
+// A response other than 5xx, 401 and 403 is a successful hub call: the hub is reachable and accepts the token.
+// It waits for a slot of the client's concurrency limit first, see Configuration.MaxConcurrentRequests.
+// In dry-run mode the mutating requests are responded locally, see Configuration.DryRun.
+func (c *Client) attempt(req *http.Request) (*http.Response, error) {
+	if mutating(req) && c.dryRun(req.Context()) {
+		return dryRunResponse(req)
+	}
+
+	c.stats.queued.Add(1)
+	release, err := c.concurrencyLimit().acquire(req.Context())
+	c.stats.queued.Add(-1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for a request slot: %w", err)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		release()
+	} else {
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	}
+
+	switch {
+	case err != nil:
+		if req.Context().Err() == nil { // not canceled by the caller.
+			c.health.lastSuccess.Store(0)
+		}
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		c.health.lastSuccess.Store(0)
+	default:
+		c.health.lastSuccess.Store(c.now().UnixNano())
+	}
+	c.recordAttempt(req, resp, err)
+
+	return resp, err
+}
+
+// backgroundFailed records the unexpected stop of a background goroutine of the client
+// (e.g. a recovered panic of the Maintenance runner), which fails the liveness probe.
+func (c *Client) backgroundFailed(err error) {
+	c.health.mu.Lock()
+	c.health.failed = append(c.health.failed, err)
+	c.health.mu.Unlock()
+}
+
+// ready reports whether the client's token is valid and the hub was called successfully
+// within the ReadinessWindow, probing the hub with ValidateToken otherwise.
+func (c *Client) ready(ctx context.Context) error {
+	_, tm := c.current()
+	if _, err := tm.GetToken(); err != nil {
+		return fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	if last := c.health.lastSuccess.Load(); last != 0 && c.now().Sub(time.Unix(0, last)) < ReadinessWindow {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := c.ValidateToken(ctx); err != nil {
+		return fmt.Errorf("hub unavailable: %w", err)
+	}
+
+	return nil
+}
+
+// alive reports whether the background goroutines of the client are healthy.
+func (c *Client) alive() error {
+	c.current() // a deadlocked client blocks the probe, which times out.
+
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	return errors.Join(c.health.failed...)
+}
+
+// ReadinessHandler returns a Kubernetes readiness probe handler of the client.
+// The client is ready when its SAS token is valid and a hub call succeeded within the ReadinessWindow;
+// an idle client, or one whose last hub call failed, probes the hub with ValidateToken.
+// It responds with 200 OK or 503 Service Unavailable and a JSON HealthResponse.
+//
+// Example usage:
+//
+//	http.Handle("GET /readyz", client.ReadinessHandler())
+//	http.Handle("GET /livez", client.LivenessHandler())
+func (c *Client) ReadinessHandler() http.Handler {
+	return healthHandler(c.ready)
+}
+
+// LivenessHandler returns a Kubernetes liveness probe handler of the client.
+// The client is alive unless one of its background goroutines stopped unexpectedly, e.g. a Maintenance runner panicked,
+// so a restart is required. The hub is not called: an unavailable hub must not restart the process.
+// It responds with 200 OK or 503 Service Unavailable and a JSON HealthResponse.
+func (c *Client) LivenessHandler() http.Handler {
+	return healthHandler(func(context.Context) error { return c.alive() })
+}
+
+func healthHandler(check func(ctx context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeHandlerError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+			return
+		}
+
+		statusCode, response := http.StatusOK, HealthResponse{Status: "ok"}
+		if err := check(r.Context()); err != nil {
+			statusCode, response = http.StatusServiceUnavailable, HealthResponse{Status: "unavailable", Error: err.Error()}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(statusCode)
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
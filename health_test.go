@@ -0,0 +1,65 @@
+package azurepush_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_ReadinessHandler(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	probe := func(h http.Handler) (int, azurepush.HealthResponse) {
+		t.Helper()
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var resp azurepush.HealthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp
+	}
+
+	// An idle client probes the hub.
+	if code, resp := probe(client.ReadinessHandler()); code != http.StatusOK || resp.Status != "ok" {
+		t.Fatalf("expected ready, got: %d: %+v", code, resp)
+	}
+
+	base := client.HTTPClient.Transport
+	client.HTTPClient.Transport = &azurepushtest.FaultTransport{Base: base, Faults: []azurepushtest.Fault{{StatusCode: http.StatusServiceUnavailable}}}
+
+	// The last hub call succeeded recently, the hub is not probed.
+	if code, _ := probe(client.ReadinessHandler()); code != http.StatusOK {
+		t.Fatalf("expected ready after a recent hub call, got: %d", code)
+	}
+
+	if _, err := client.DeviceExists(t.Context(), "device"); err == nil {
+		t.Fatal("expected a hub error")
+	}
+	if code, resp := probe(client.ReadinessHandler()); code != http.StatusServiceUnavailable || resp.Status != "unavailable" || resp.Error == "" {
+		t.Fatalf("expected not ready after a failed hub call, got: %d: %+v", code, resp)
+	}
+
+	client.HTTPClient.Transport = base
+	if code, _ := probe(client.ReadinessHandler()); code != http.StatusOK {
+		t.Fatalf("expected ready once the hub recovered, got: %d", code)
+	}
+
+	if code, resp := probe(client.LivenessHandler()); code != http.StatusOK || resp.Status != "ok" {
+		t.Fatalf("expected alive, got: %d: %+v", code, resp)
+	}
+
+	rec := httptest.NewRecorder()
+	client.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/livez", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got: %d", rec.Code)
+	}
+}
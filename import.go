@@ -0,0 +1,148 @@
+package azurepush
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"time"
+)
+
+// ImportOptions are the options of Client.ImportInstallations.
+type ImportOptions struct {
+	// Type is the type of the import job, one of the installations import types.
+	//
+	// Defaults to JobImportUpsertInstallations.
+	Type JobType
+	// BlobName is the name of the import file in the container.
+	//
+	// Defaults to "import-{unix nano}.jsonl".
+	BlobName string
+	// Poll configures the job status requests, see Client.WaitForJob.
+	// Its OnPoll hook is called before OnProgress.
+	Poll PollOptions
+	// OnProgress, if not nil, is called with the submitted job and its progress after each status request,
+	// the last call reports the completed (or failed) job.
+	OnProgress func(ImportProgress)
+}
+
+// ImportProgress is the progress of an import job, see ImportOptions.OnProgress.
+type ImportProgress struct {
+	Job *Job
+	// Percent is the percentage of completion of the job, from 0 to 100.
+	Percent float64
+	// FailedFileURI is the SAS URI of the file of the failed lines, if reported by the hub.
+	// Read it with Client.OpenBlob.
+	FailedFileURI string
+}
+
+// ImportInstallations imports installations in bulk through an import job: it writes the import file
+// (one JSON installation per line) to the container of a SAS URI (with create, write and read permissions),
+// submits the job and waits for it (see Client.WaitForJob), reporting its progress. It returns the completed job,
+// the failed lines (if any) are reported by the JobFailedFilePath output property.
+// A failed job is returned with a *JobFailedError.
+// Jobs require the Standard tier.
+//
+// The installations are streamed to the blob storage, so large imports are not loaded into memory.
+// Each installation must have an ID.
+//
+// Example usage:
+//
+//	job, err := client.ImportInstallations(ctx, containerSASURI, slices.Values(installations), azurepush.ImportOptions{
+//		OnProgress: func(p azurepush.ImportProgress) {
+//			log.Printf("import %s: %.0f%%", p.Job.JobID, p.Percent)
+//		},
+//	})
+func (c *Client) ImportInstallations(ctx context.Context, containerSASURI string, installations iter.Seq[Installation], opts ImportOptions) (*Job, error) {
+	switch opts.Type {
+	case "":
+		opts.Type = JobImportUpsertInstallations
+	case JobImportCreateInstallations, JobImportUpdateInstallations, JobImportDeleteInstallations, JobImportUpsertInstallations:
+	default:
+		return nil, fmt.Errorf("not an installations import job type: %s", opts.Type)
+	}
+
+	if opts.BlobName == "" {
+		opts.BlobName = "import-" + strconv.FormatInt(time.Now().UnixNano(), 10) + ".jsonl"
+	}
+
+	if err := c.RequireTier(FeatureBulkJobs, TierStandard); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeImportFile(pw, installations))
+	}()
+
+	importFileURI, err := c.UploadBlob(ctx, containerSASURI, opts.BlobName, pr)
+	pr.Close() // stops the writer on upload errors.
+	if err != nil {
+		return nil, fmt.Errorf("failed to write import file: %w", err)
+	}
+
+	job, err := c.SubmitJob(ctx, Job{
+		Type:               opts.Type,
+		OutputContainerURI: containerSASURI,
+		ImportFileURI:      importFileURI,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := func(job *Job) {
+		if opts.OnProgress == nil {
+			return
+		}
+
+		progress := ImportProgress{Job: job, Percent: job.Progress}
+		if path := job.OutputProperties[JobFailedFilePath]; path != "" {
+			progress.FailedFileURI, _ = BlobURI(containerSASURI, path)
+		}
+		opts.OnProgress(progress)
+	}
+
+	report(job)
+	if job.Done() {
+		if job.Status == JobStatusFailed {
+			return job, &JobFailedError{Job: job}
+		}
+		return job, nil
+	}
+
+	onPoll := opts.Poll.OnPoll
+	opts.Poll.OnPoll = func(job *Job) {
+		if onPoll != nil {
+			onPoll(job)
+		}
+		report(job)
+	}
+
+	return c.WaitForJob(ctx, job.JobID, opts.Poll)
+}
+
+// writeImportFile writes the validated installations as JSON lines, see WriteInstallationsNDJSON.
+func writeImportFile(w io.Writer, installations iter.Seq[Installation]) error {
+	var invalid error
+	validated := func(yield func(Installation) bool) {
+		i := 0
+		for installation := range installations {
+			if err := installation.Validate(); err != nil {
+				invalid = fmt.Errorf("invalid installation at index %d: %w", i, err)
+				return
+			}
+
+			if !yield(installation) {
+				return
+			}
+			i++
+		}
+	}
+
+	if err := WriteInstallationsNDJSON(w, validated); err != nil {
+		return err
+	}
+
+	return invalid
+}
@@ -0,0 +1,85 @@
+package azurepush_test
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_ImportInstallations(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	installations := []azurepush.Installation{
+		{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a", Tags: []string{"user:1"}},
+		{InstallationID: "b", Platform: azurepush.InstallationFCMV1, PushChannel: "token-b", Tags: []string{"user:2"}},
+	}
+
+	const container = "https://account.blob.core.windows.net/import?sig=abc"
+
+	var progress []azurepush.ImportProgress
+	job, err := client.ImportInstallations(ctx, container, slices.Values(installations), azurepush.ImportOptions{
+		BlobName: "devices.jsonl",
+		Poll:     azurepush.PollOptions{InitialInterval: time.Millisecond},
+		OnProgress: func(p azurepush.ImportProgress) {
+			progress = append(progress, p)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Type != azurepush.JobImportUpsertInstallations || job.Status != azurepush.JobStatusCompleted {
+		t.Fatalf("unexpected job: %#v", job)
+	}
+	if got := srv.Installations(); len(got) != 2 || got[1].PushChannel != "token-b" {
+		t.Fatalf("expected the imported installations, got: %#v", got)
+	}
+
+	if data, _ := srv.Blob("import/devices.jsonl"); strings.Count(string(data), "\n") != 2 {
+		t.Errorf("unexpected import file: %s", data)
+	}
+
+	if len(progress) != 1 || progress[0].Percent != 100 || !strings.HasSuffix(progress[0].FailedFileURI, "/Failed.txt?sig=abc") {
+		t.Fatalf("unexpected progress: %#v", progress)
+	}
+
+	// The create import fails the existing installations.
+	installations = append(installations, azurepush.Installation{InstallationID: "c", Platform: azurepush.InstallationApple, PushChannel: "token-c"})
+	if _, err = client.ImportInstallations(ctx, container, slices.Values(installations), azurepush.ImportOptions{
+		Type: azurepush.JobImportCreateInstallations,
+		Poll: azurepush.PollOptions{InitialInterval: time.Millisecond},
+		OnProgress: func(p azurepush.ImportProgress) {
+			progress = append(progress, p)
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := client.OpenBlob(ctx, progress[1].FailedFileURI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, _ := io.ReadAll(r)
+	r.Close()
+
+	if strings.Count(string(failed), "\n") != 2 || len(srv.Installations()) != 3 {
+		t.Errorf("expected the existing installations to fail, got: %s", failed)
+	}
+
+	if _, err = client.ImportInstallations(ctx, container, slices.Values([]azurepush.Installation{{PushChannel: "token"}}), azurepush.ImportOptions{}); err == nil {
+		t.Error("expected an installation without ID error")
+	}
+	if _, err = client.ImportInstallations(ctx, container, slices.Values(installations), azurepush.ImportOptions{Type: azurepush.JobExportInstallations}); err == nil {
+		t.Error("expected an invalid job type error")
+	}
+}
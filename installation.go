@@ -0,0 +1,281 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// installationFields are the JSON fields of the Installation struct.
+var installationFields = []string{"installationId", "platform", "pushChannel", "tags", "templates"}
+
+// installationJSON has the fields of Installation without its methods.
+type installationJSON Installation
+
+// MarshalJSON implements the json.Marshaler interface.
+// The fields unknown to the Installation struct, read by UnmarshalJSON (e.g. from GetInstallation),
+// are written back so a read-modify-write doesn't erase properties of newer API versions.
+func (i Installation) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(installationJSON(i))
+	if err != nil || len(i.extra) == 0 {
+		return b, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+
+	for key, value := range i.extra {
+		if _, ok := fields[key]; !ok {
+			fields[key] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// The fields unknown to the Installation struct (e.g. "pushVariables" or "expirationTime") are kept as they are.
+func (i *Installation) UnmarshalJSON(data []byte) error {
+	var decoded installationJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for _, key := range installationFields {
+		delete(fields, key)
+	}
+
+	decoded.extra = nil
+	if len(fields) > 0 {
+		decoded.extra = fields
+	}
+
+	*i = Installation(decoded)
+	return nil
+}
+
+// Extra returns a copy of the JSON fields of the installation unknown to the Installation struct,
+// as read by GetInstallation, or nil.
+func (i Installation) Extra() map[string]json.RawMessage {
+	return maps.Clone(i.extra)
+}
+
+// ETag returns the version of the installation as read by GetInstallation, or an empty string.
+// RegisterDevice sends it as If-Match, so the installation is not replaced if it was modified meanwhile.
+func (i Installation) ETag() string {
+	return i.etag
+}
+
+// SetETag sets the version of the installation sent as If-Match by RegisterDevice,
+// e.g. an ETag kept from a previous read. An empty ETag replaces the installation unconditionally.
+func (i *Installation) SetETag(etag string) {
+	i.etag = etag
+}
+
+// PreconditionFailedError is returned by RegisterDevice when the installation was modified
+// since its ETag was read (e.g. by another backend instance).
+// The installation should be read again, modified and registered again, see UpdateInstallation.
+type PreconditionFailedError struct {
+	InstallationID string
+	ETag           string
+}
+
+// Error implements the error interface.
+func (e *PreconditionFailedError) Error() string {
+	return fmt.Sprintf("installation: %s: modified since its ETag: %s was read", e.InstallationID, e.ETag)
+}
+
+// updateInstallationAttempts is the maximum number of read-modify-write attempts of UpdateInstallation.
+const updateInstallationAttempts = 3
+
+// GetInstallation returns a device installation by its ID.
+// It returns an error wrapping ErrNotFound if the installation does not exist.
+//
+// The fields of the installation unknown to the Installation struct are preserved,
+// so registering the (modified) installation back keeps them, see UpdateInstallation.
+func (c *Client) GetInstallation(ctx context.Context, installationID string) (*Installation, error) {
+	cfg, tm := c.current()
+
+	if installationID == "" {
+		return nil, fmt.Errorf("installation ID cannot be empty")
+	}
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	url := cfg.hubURL(hubPath("installations", installationID), "2020-06")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("installation: %s: %w", installationID, ErrNotFound)
+	default:
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get installation: %s: %s: %s", installationID, resp.Status, string(b))
+	}
+
+	var installation Installation
+	if err = json.NewDecoder(resp.Body).Decode(&installation); err != nil {
+		return nil, fmt.Errorf("failed to decode installation: %s: %w", installationID, err)
+	}
+
+	if installation.InstallationID == "" {
+		installation.InstallationID = installationID
+	}
+	installation.etag = resp.Header.Get("ETag")
+
+	return &installation, nil
+}
+
+// UpdateInstallation reads an installation, calls update to modify it and registers it back
+// (read-modify-write). The fields unknown to the Installation struct are preserved.
+// A non-nil error of update aborts the update and is returned.
+//
+// The installation is registered with its ETag: if it was modified meanwhile (e.g. by another backend instance),
+// it is read and update is called again, up to 3 attempts, so concurrent updates are not lost.
+// update should be idempotent.
+//
+// Example usage:
+//
+//	err := client.UpdateInstallation(ctx, id, func(installation *azurepush.Installation) error {
+//		if !slices.Contains(installation.Tags, "topic:news") {
+//			installation.Tags = append(installation.Tags, "topic:news")
+//		}
+//		return nil
+//	})
+func (c *Client) UpdateInstallation(ctx context.Context, installationID string, update func(installation *Installation) error) error {
+	var err error
+	for range updateInstallationAttempts {
+		var installation *Installation
+		if installation, err = c.GetInstallation(ctx, installationID); err != nil {
+			return err
+		}
+
+		if err = update(installation); err != nil {
+			return err
+		}
+
+		installation.InstallationID = installationID
+		if _, err = c.RegisterDevice(ctx, *installation); !errors.As(err, new(*PreconditionFailedError)) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// Patch operations, see PatchOperation.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+)
+
+// PatchOperation is a JSON Patch (RFC 6902) operation of an installation, see Client.PatchInstallation.
+type PatchOperation struct {
+	// Op is the operation, one of PatchAdd, PatchRemove and PatchReplace.
+	Op string `json:"op"`
+	// Path is the path of the installation field, e.g. "/tags", "/tags/user:42" or "/pushChannel".
+	Path string `json:"path"`
+	// Value is the value of an add or replace operation.
+	Value string `json:"value,omitempty"`
+}
+
+// AddTagPatch returns the operation which adds a tag to an installation.
+func AddTagPatch(tag string) PatchOperation {
+	return PatchOperation{Op: PatchAdd, Path: "/tags", Value: tag}
+}
+
+// RemoveTagPatch returns the operation which removes a tag from an installation.
+func RemoveTagPatch(tag string) PatchOperation {
+	return PatchOperation{Op: PatchRemove, Path: "/tags/" + jsonPointerEscaper.Replace(tag)}
+}
+
+// jsonPointerEscaper escapes a JSON Pointer (RFC 6901) reference token.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// PatchInstallation applies JSON Patch operations to an installation in a single request,
+// without reading it first (see UpdateInstallation for read-modify-write updates).
+// It returns an error wrapping ErrNotFound if the installation does not exist.
+//
+// Example usage:
+//
+//	err := client.PatchInstallation(ctx, id, azurepush.AddTagPatch("topic:news"), azurepush.RemoveTagPatch("topic:old"))
+func (c *Client) PatchInstallation(ctx context.Context, installationID string, ops ...PatchOperation) (err error) {
+	cfg, tm := c.current()
+
+	if installationID == "" {
+		return fmt.Errorf("installation ID cannot be empty")
+	}
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	defer func() {
+		c.audit(ctx, AuditRecord{Operation: AuditPatch, InstallationID: installationID, Patch: slices.Clone(ops)}, err)
+	}()
+
+	body, err := json.Marshal(ops)
+	if err != nil {
+		return fmt.Errorf("failed to encode patch operations: %w", err)
+	}
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	url := cfg.hubURL(hubPath("installations", installationID), "2020-06")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", "application/json-patch+json")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to patch installation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("installation: %s: %w", installationID, ErrNotFound)
+	default:
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to patch installation: %s: %s: %s", installationID, resp.Status, string(b))
+	}
+}
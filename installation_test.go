@@ -0,0 +1,177 @@
+package azurepush_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_UpdateInstallation_PreservesUnknownFields(t *testing.T) {
+	const stored = `{
+		"installationId": "device-1",
+		"platform": "apns",
+		"pushChannel": "token",
+		"tags": ["user:42"],
+		"pushVariables": {"firstName": "John"},
+		"expirationTime": "2027-01-01T00:00:00Z"
+	}`
+
+	var put map[string]json.RawMessage
+	client := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		switch r.Method {
+		case http.MethodGet:
+			if r.URL.Path == "/hub/installations/missing" {
+				return jsonResponse(http.StatusNotFound, "")
+			}
+			return jsonResponse(http.StatusOK, stored)
+		case http.MethodPut:
+			b, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(b, &put); err != nil {
+				t.Errorf("invalid body: %s: %v", b, err)
+			}
+			return jsonResponse(http.StatusOK, "")
+		default:
+			t.Errorf("unexpected request: %s", r.Method)
+			return jsonResponse(http.StatusMethodNotAllowed, "")
+		}
+	})
+
+	ctx := context.Background()
+	installation, err := client.GetInstallation(ctx, "device-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if installation.Platform != azurepush.InstallationApple || !slices.Equal(installation.Tags, []string{"user:42"}) {
+		t.Errorf("unexpected installation: %+v", installation)
+	}
+	if extra := installation.Extra(); len(extra) != 2 || string(extra["pushVariables"]) != `{"firstName": "John"}` {
+		t.Errorf("expected the unknown fields, got: %s", extra)
+	}
+
+	err = client.UpdateInstallation(ctx, "device-1", func(installation *azurepush.Installation) error {
+		installation.Tags = append(installation.Tags, "topic:news")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(put["tags"]) != `["user:42","topic:news"]` {
+		t.Errorf("expected the updated tags, got: %s", put["tags"])
+	}
+	if string(put["pushVariables"]) != `{"firstName":"John"}` || string(put["expirationTime"]) != `"2027-01-01T00:00:00Z"` {
+		t.Errorf("expected the unknown fields to be preserved, got: %v", put)
+	}
+
+	if _, err = client.GetInstallation(ctx, "missing"); !errors.Is(err, azurepush.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+
+	abort := errors.New("abort")
+	if err = client.UpdateInstallation(ctx, "device-1", func(*azurepush.Installation) error { return abort }); !errors.Is(err, abort) {
+		t.Errorf("expected the update error, got: %v", err)
+	}
+}
+
+func TestClient_UpdateInstallation_ETag(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	ctx := context.Background()
+	backend1, backend2 := srv.NewClient(), srv.NewClient()
+
+	if _, err := backend1.RegisterDevice(ctx, azurepush.Installation{
+		InstallationID: "device-1",
+		Platform:       azurepush.InstallationApple,
+		PushChannel:    "token",
+		Tags:           []string{"user:42"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	read1, err := backend1.GetInstallation(ctx, "device-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	read2, err := backend2.GetInstallation(ctx, "device-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read1.ETag() == "" || read1.ETag() != read2.ETag() {
+		t.Fatalf("expected the same ETag, got: %q and %q", read1.ETag(), read2.ETag())
+	}
+
+	read1.Tags = append(read1.Tags, "topic:news")
+	if _, err = backend1.RegisterDevice(ctx, *read1); err != nil {
+		t.Fatal(err)
+	}
+
+	read2.Tags = append(read2.Tags, "topic:sports")
+	_, err = backend2.RegisterDevice(ctx, *read2)
+	if preconditionErr, ok := errors.AsType[*azurepush.PreconditionFailedError](err); !ok || preconditionErr.InstallationID != "device-1" {
+		t.Fatalf("expected a precondition failed error, got: %v", err)
+	}
+
+	// A concurrent write during the update: it is retried on the new version.
+	calls := 0
+	err = backend2.UpdateInstallation(ctx, "device-1", func(installation *azurepush.Installation) error {
+		calls++
+		if calls == 1 {
+			if err := backend1.UpdateInstallation(ctx, "device-1", func(installation *azurepush.Installation) error {
+				installation.Tags = append(installation.Tags, "topic:weather")
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		installation.Tags = append(installation.Tags, "topic:sports")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation, _ := srv.Installation("device-1")
+	if calls != 2 || !slices.Equal(installation.Tags, []string{"user:42", "topic:news", "topic:weather", "topic:sports"}) {
+		t.Errorf("expected no lost update, got: %v after %d calls", installation.Tags, calls)
+	}
+}
+
+func TestClient_PatchInstallation(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	if _, err := client.RegisterDevice(ctx, azurepush.Installation{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token", Tags: []string{"topic:old", "user:1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := client.PatchInstallation(ctx, "a",
+		azurepush.AddTagPatch("topic:news"),
+		azurepush.RemoveTagPatch("topic:old"),
+		azurepush.PatchOperation{Op: azurepush.PatchReplace, Path: "/pushChannel", Value: "new-token"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	installation, _ := srv.Installation("a")
+	if !slices.Equal(installation.Tags, []string{"user:1", "topic:news"}) || installation.PushChannel != "new-token" {
+		t.Fatalf("unexpected patched installation: %#v", installation)
+	}
+
+	if err = client.PatchInstallation(ctx, "missing", azurepush.AddTagPatch("x")); !errors.Is(err, azurepush.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package azurepush
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultInstallationTokenValidity is the default validity of the tokens of Client.InstallationToken.
+const DefaultInstallationTokenValidity = 15 * time.Minute
+
+// MaxInstallationTokenValidity is the maximum validity of the tokens of Client.InstallationToken.
+const MaxInstallationTokenValidity = 24 * time.Hour
+
+// InstallationToken is a short-lived SAS token scoped to a single installation,
+// so a device can create, update (PUT or PATCH) and delete its own installation directly on the hub.
+type InstallationToken struct {
+	InstallationID string `json:"installationId"`
+	// Token is the value of the Authorization header.
+	Token string `json:"token"`
+	// Endpoint is the URL of the installation on the hub (with its api-version), the only resource the token authorizes.
+	Endpoint string `json:"endpoint"`
+	// ExpiresAt is the expiration time of the token.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// InstallationToken returns a short-lived SAS token scoped to an installation, so the mobile backend
+// can hand it to the device for a self-registration, instead of proxying every registration
+// (see RegistrationHandler). The token cannot be used for sends or for other installations.
+//
+// The token is signed with the KeyName access policy, the one of the registration and management calls
+// (Manage rights, see Configuration.SendConnectionString), never with the send policy: its rights are
+// those of that policy, narrowed by the resource URI to the single installation.
+//
+// The validity defaults to DefaultInstallationTokenValidity when zero,
+// it cannot exceed MaxInstallationTokenValidity.
+//
+// Example usage:
+//
+//	token, err := client.InstallationToken(installationID, 10*time.Minute)
+//	// the device sends: PUT token.Endpoint with the "Authorization: token.Token" header.
+func (c *Client) InstallationToken(installationID string, validity time.Duration) (*InstallationToken, error) {
+	if installationID == "" {
+		return nil, fmt.Errorf("installation ID cannot be empty")
+	}
+
+	switch {
+	case validity == 0:
+		validity = DefaultInstallationTokenValidity
+	case validity < 0 || validity > MaxInstallationTokenValidity:
+		return nil, fmt.Errorf("invalid installation token validity: %s (must be up to %s)", validity, MaxInstallationTokenValidity)
+	}
+
+	cfg, _ := c.current()
+	now := c.now()
+
+	resourceURI, err := NormalizeResourceURI(cfg.hubURL(hubPath("installations", installationID), ""))
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateSASToken(now, resourceURI, cfg.KeyName, cfg.signingKey(), validity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate installation token: %w", err)
+	}
+
+	return &InstallationToken{
+		InstallationID: installationID,
+		Token:          token,
+		Endpoint:       cfg.hubURL(hubPath("installations", installationID), "2020-06"),
+		ExpiresAt:      time.Unix(now.Add(validity).Unix(), 0),
+	}, nil
+}
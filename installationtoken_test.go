@@ -0,0 +1,78 @@
+package azurepush_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_InstallationToken(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	token, err := client.InstallationToken("device-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Until(token.ExpiresAt) > azurepush.DefaultInstallationTokenValidity || time.Until(token.ExpiresAt) < azurepush.DefaultInstallationTokenValidity-time.Minute {
+		t.Errorf("unexpected expiration: %s", token.ExpiresAt)
+	}
+
+	params, _ := url.ParseQuery(strings.TrimPrefix(token.Token, "SharedAccessSignature "))
+	if sr := params.Get("sr"); !strings.HasSuffix(sr, "/installations/device-1") {
+		t.Errorf("expected a token scoped to the installation, got: %s", sr)
+	}
+
+	// The device registers itself with the token.
+	req, _ := http.NewRequest(http.MethodPut, token.Endpoint,
+		strings.NewReader(`{"installationId":"device-1","platform":"apns","pushChannel":"token"}`))
+	req.Header.Set("Authorization", token.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := srv.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", resp.Status)
+	}
+
+	if _, ok := srv.Installation("device-1"); !ok {
+		t.Error("expected the self-registered installation")
+	}
+
+	if _, err = client.InstallationToken("", 0); err == nil {
+		t.Error("expected an empty installation ID error")
+	}
+	if _, err = client.InstallationToken("device-1", 48*time.Hour); err == nil {
+		t.Error("expected a validity error")
+	}
+}
+
+func TestClient_InstallationToken_SendPolicy(t *testing.T) {
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+		SendKeyName:      "DefaultSendSharedAccessSignature",
+		SendKeyValue:     "c2VjcmV0",
+	})
+
+	token, err := client.InstallationToken("device-1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := url.ParseQuery(strings.TrimPrefix(token.Token, "SharedAccessSignature "))
+	if skn := params.Get("skn"); skn != "DefaultFullSharedAccessSignature" {
+		t.Errorf("expected the token to be signed with the registration policy, got: %s", skn)
+	}
+}
@@ -0,0 +1,190 @@
+// Package iris integrates the azurepush package with the Iris web framework.
+//
+// Example usage:
+//
+//	app := iris.New()
+//	client := azurepush.NewClient(cfg)
+//	azurepushiris.Register(app.Party("/push"), client, azurepushiris.Options{})
+//
+// Registers the following routes:
+//
+//	POST   /push/devices           registers a device, responds with its installation ID
+//	DELETE /push/devices/{id}      deletes a device
+//	POST   /push/devices/{id}/test sends a test notification to a device
+package iris
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kataras/azurepush"
+
+	"github.com/kataras/iris/v12/context"
+	"github.com/kataras/iris/v12/core/router"
+)
+
+// Options holds the settings of the registered routes.
+type Options struct {
+	// Authorize is called (if not nil) before any route handler,
+	// a non-nil error responds with 401 Unauthorized.
+	Authorize func(ctx *context.Context) error
+
+	// BeforeRegister is called (if not nil) with the installation decoded from the request,
+	// right before it is registered. It can modify it, e.g. to set the tags of the authenticated user.
+	// A non-nil error responds with 400 Bad Request.
+	BeforeRegister func(ctx *context.Context, installation *azurepush.Installation) error
+
+	// TestNotification is the notification sent by the send-test route.
+	//
+	// Defaults to DefaultTestNotification.
+	TestNotification azurepush.Notification
+
+	// OnError is called (if not nil) when a hub operation fails, e.g. to log the error.
+	// The caller receives a generic 502 Bad Gateway response.
+	OnError func(ctx *context.Context, err error)
+}
+
+// DefaultTestNotification is the default notification sent by the send-test route.
+var DefaultTestNotification = azurepush.Notification{
+	Title: "Test notification",
+	Body:  "Push notifications are working.",
+}
+
+// clientContextKey is the context value key of the client.
+const clientContextKey = "azurepush.client"
+
+// Register registers the device routes on the party.
+// The client is registered as a dependency of the party
+// and stored to the request context, see Client.
+func Register(p router.Party, client azurepush.HubClient, opts Options) {
+	if client == nil {
+		panic("azurepush/iris: nil client")
+	}
+
+	if opts.TestNotification.Title == "" && opts.TestNotification.Body == "" {
+		opts.TestNotification = DefaultTestNotification
+	}
+
+	h := &handler{client: client, opts: opts}
+
+	p.RegisterDependency(client)
+	p.Use(h.serve)
+	p.Post("/devices", h.register)
+	p.Delete("/devices/{id}", h.unregister)
+	p.Post("/devices/{id}/test", h.sendTest)
+}
+
+// Client returns the client registered through Register, or nil.
+// It can be used by custom handlers of the party.
+func Client(ctx *context.Context) azurepush.HubClient {
+	client, _ := ctx.Values().Get(clientContextKey).(azurepush.HubClient)
+	return client
+}
+
+// ErrorResponse is the JSON body written on failure.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	client azurepush.HubClient
+	opts   Options
+}
+
+func (h *handler) serve(ctx *context.Context) {
+	ctx.Values().Set(clientContextKey, h.client)
+
+	if h.opts.Authorize != nil {
+		if err := h.opts.Authorize(ctx); err != nil {
+			h.stop(ctx, http.StatusUnauthorized, err)
+			return
+		}
+	}
+
+	ctx.Next()
+}
+
+func (h *handler) register(ctx *context.Context) {
+	var req azurepush.RegistrationRequest
+	if err := ctx.ReadJSON(&req); err != nil {
+		h.stop(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	installation := azurepush.Installation{
+		InstallationID: req.InstallationID,
+		Platform:       req.Platform,
+		PushChannel:    req.PushChannel,
+		Tags:           req.Tags,
+	}
+
+	if h.opts.BeforeRegister != nil {
+		if err := h.opts.BeforeRegister(ctx, &installation); err != nil {
+			h.stop(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// The ID is generated by RegisterDevice, validate everything else upfront.
+	validate := installation
+	if validate.InstallationID == "" {
+		validate.InstallationID = "pending"
+	}
+	if err := validate.Validate(); err != nil {
+		h.stop(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.client.RegisterDevice(ctx.Request().Context(), installation)
+	if err != nil {
+		h.hubError(ctx, err)
+		return
+	}
+
+	ctx.JSON(azurepush.RegistrationResponse{InstallationID: id})
+}
+
+func (h *handler) unregister(ctx *context.Context) {
+	if err := h.client.DeleteDevice(ctx.Request().Context(), ctx.Params().Get("id")); err != nil {
+		h.hubError(ctx, err)
+		return
+	}
+
+	ctx.StatusCode(http.StatusNoContent)
+}
+
+func (h *handler) sendTest(ctx *context.Context) {
+	id := ctx.Params().Get("id")
+	reqCtx := ctx.Request().Context()
+
+	exists, err := h.client.DeviceExists(reqCtx, id)
+	if err != nil {
+		h.hubError(ctx, err)
+		return
+	}
+	if !exists {
+		h.stop(ctx, http.StatusNotFound, errors.New("device not found"))
+		return
+	}
+
+	// The built-in $InstallationId tag targets a single installation.
+	if err = h.client.SendNotification(reqCtx, h.opts.TestNotification, azurepush.InstallationTag(id).String()); err != nil {
+		h.hubError(ctx, err)
+		return
+	}
+
+	ctx.StatusCode(http.StatusAccepted)
+}
+
+func (h *handler) hubError(ctx *context.Context, err error) {
+	if h.opts.OnError != nil {
+		h.opts.OnError(ctx, err)
+	}
+
+	// Azure's response is not exposed to the caller.
+	h.stop(ctx, http.StatusBadGateway, errors.New("push service unavailable"))
+}
+
+func (h *handler) stop(ctx *context.Context, statusCode int, err error) {
+	ctx.StopWithJSON(statusCode, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,109 @@
+package iris_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+	azurepushiris "github.com/kataras/azurepush/iris"
+
+	"github.com/kataras/iris/v12"
+)
+
+func TestRegister(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	app := iris.New()
+	push := app.Party("/push")
+	azurepushiris.Register(push, srv.NewClient(), azurepushiris.Options{
+		BeforeRegister: func(ctx iris.Context, installation *azurepush.Installation) error {
+			installation.Tags = []string{"user:42"}
+			return nil
+		},
+	})
+	push.Get("/client", func(ctx iris.Context) {
+		if azurepushiris.Client(ctx) == nil {
+			ctx.StatusCode(http.StatusInternalServerError)
+		}
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		app.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/push/devices", `{"platform":"apns","pushChannel":"apns-token"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got: %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp azurepush.RegistrationResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("unexpected response body: %v", err)
+	}
+	if installation, ok := srv.Installation(resp.InstallationID); !ok || installation.Tags[0] != "user:42" {
+		t.Fatalf("expected installation to be registered with the user tag, got: %+v", installation)
+	}
+
+	if rec = do(http.MethodPost, "/push/devices", `{"platform":"symbian","pushChannel":"token"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid platform, got: %d", rec.Code)
+	}
+
+	if rec = do(http.MethodPost, "/push/devices/"+resp.InstallationID+"/test", ""); rec.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got: %d: %s", rec.Code, rec.Body)
+	}
+	sent := srv.Sent("$InstallationId:{" + resp.InstallationID + "}")
+	if len(sent) == 0 || len(sent[0].InstallationIDs) != 1 || sent[0].InstallationIDs[0] != resp.InstallationID {
+		t.Errorf("expected the test notification to target the installation, got: %+v", sent)
+	}
+
+	if rec = do(http.MethodGet, "/push/client", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the client to be available to the party's handlers, got status: %d", rec.Code)
+	}
+
+	if rec = do(http.MethodDelete, "/push/devices/"+resp.InstallationID, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got: %d: %s", rec.Code, rec.Body)
+	}
+	if _, ok := srv.Installation(resp.InstallationID); ok {
+		t.Error("expected installation to be deleted")
+	}
+
+	if rec = do(http.MethodPost, "/push/devices/"+resp.InstallationID+"/test", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for a deleted device, got: %d", rec.Code)
+	}
+}
+
+func TestRegister_Authorize(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	app := iris.New()
+	azurepushiris.Register(app.Party("/push"), srv.NewClient(), azurepushiris.Options{
+		Authorize: func(ctx iris.Context) error {
+			return iris.ErrNotFound // any error.
+		},
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/push/devices", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got: %d", rec.Code)
+	}
+	if len(srv.Installations()) != 0 {
+		t.Error("expected no installations")
+	}
+}
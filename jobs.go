@@ -0,0 +1,366 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JobType is the type of a Notification Hub job, see Client.SubmitJob.
+type JobType string
+
+// Job types.
+const (
+	JobExportRegistrations       JobType = "ExportRegistrations"
+	JobImportCreateRegistrations JobType = "ImportCreateRegistrations"
+	JobImportUpdateRegistrations JobType = "ImportUpdateRegistrations"
+	JobImportDeleteRegistrations JobType = "ImportDeleteRegistrations"
+	JobImportUpsertRegistrations JobType = "ImportUpsertRegistrations"
+	JobExportInstallations       JobType = "ExportInstallations"
+	JobImportCreateInstallations JobType = "ImportCreateInstallations"
+	JobImportUpdateInstallations JobType = "ImportUpdateInstallations"
+	JobImportDeleteInstallations JobType = "ImportDeleteInstallations"
+	JobImportUpsertInstallations JobType = "ImportUpsertInstallations"
+)
+
+// Import reports whether the job type imports a file, which requires the Job.ImportFileURI.
+func (t JobType) Import() bool {
+	return strings.HasPrefix(string(t), "Import")
+}
+
+// JobStatus is the status of a Notification Hub job.
+type JobStatus string
+
+// Job statuses.
+const (
+	JobStatusStarted   JobStatus = "Started"
+	JobStatusRunning   JobStatus = "Running"
+	JobStatusCompleted JobStatus = "Completed"
+	JobStatusFailed    JobStatus = "Failed"
+)
+
+// Output properties of the jobs, see Job.OutputProperties.
+const (
+	// JobOutputFilePath is the blob path of the job's output file,
+	// e.g. the exported registrations or the results of an import.
+	JobOutputFilePath = "OutputFilePath"
+	// JobFailedFilePath is the blob path of the failed lines of an import job.
+	JobFailedFilePath = "FailedFilePath"
+)
+
+// Job is a Notification Hub job: a bulk export or import of the registrations (or installations)
+// through an Azure Storage blob container. Jobs require the Standard tier.
+//
+// See https://learn.microsoft.com/en-us/azure/notification-hubs/export-modify-registrations-bulk.
+type Job struct {
+	JobID string
+	Type  JobType
+	// OutputContainerURI is the SAS URI of the blob container of the output files (with write permissions), required.
+	OutputContainerURI string
+	// ImportFileURI is the SAS URI of the blob of the import file (with read permissions), required by the import jobs.
+	ImportFileURI string
+
+	// The following fields are reported by the hub.
+
+	Status JobStatus
+	// Progress is the percentage of completion, from 0 to 100.
+	Progress float64
+	// Failure holds the reason of a failed job.
+	Failure string
+	// OutputProperties holds the output of the job, e.g. JobOutputFilePath and JobFailedFilePath.
+	OutputProperties map[string]string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Done reports whether the job has completed or failed.
+func (j *Job) Done() bool {
+	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed
+}
+
+// validate checks the fields required to submit the job.
+func (j *Job) validate() error {
+	if j.Type == "" {
+		return fmt.Errorf("job type is required")
+	}
+
+	if j.OutputContainerURI == "" {
+		return fmt.Errorf("job output container URI is required")
+	}
+
+	if j.Type.Import() && j.ImportFileURI == "" {
+		return fmt.Errorf("%s job requires an import file URI", j.Type)
+	}
+
+	return nil
+}
+
+// SubmitJob submits an export or import job to the hub and returns it as created, with its ID.
+// Use GetJob to follow its progress.
+//
+// Example usage:
+//
+//	job, err := client.SubmitJob(ctx, azurepush.Job{
+//		Type:               azurepush.JobExportRegistrations,
+//		OutputContainerURI: containerSASURI,
+//	})
+func (c *Client) SubmitJob(ctx context.Context, job Job) (*Job, error) {
+	if err := job.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := c.RequireTier(FeatureBulkJobs, TierStandard); err != nil {
+		return nil, err
+	}
+
+	body, err := xml.Marshal(jobEntry{
+		Content: jobContent{
+			Type: "application/xml",
+			Job: jobDescription{
+				XMLNS:              serviceBusConnectNamespace,
+				Type:               job.Type,
+				OutputContainerURI: job.OutputContainerURI,
+				ImportFileURI:      job.ImportFileURI,
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %w", err)
+	}
+
+	var created jobEntry
+	if err = c.doJobRequest(ctx, http.MethodPost, "jobs", body, &created); err != nil {
+		return nil, fmt.Errorf("failed to submit job: %w", err)
+	}
+
+	return created.Content.Job.job(), nil
+}
+
+// GetJob returns a job of the hub by its ID, with its current status and progress.
+func (c *Client) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("job ID cannot be empty")
+	}
+
+	var entry jobEntry
+	if err := c.doJobRequest(ctx, http.MethodGet, hubPath("jobs", jobID), nil, &entry); err != nil {
+		return nil, fmt.Errorf("failed to get job: %s: %w", jobID, err)
+	}
+
+	return entry.Content.Job.job(), nil
+}
+
+// ListJobs returns the jobs of the hub, with their status and output properties.
+func (c *Client) ListJobs(ctx context.Context) ([]Job, error) {
+	var feed struct {
+		Entries []jobEntry `xml:"entry"`
+	}
+	if err := c.doJobRequest(ctx, http.MethodGet, "jobs", nil, &feed); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	jobs := make([]Job, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		jobs = append(jobs, *entry.Content.Job.job())
+	}
+
+	return jobs, nil
+}
+
+// PollOptions are the options of Client.WaitForJob.
+type PollOptions struct {
+	// InitialInterval is the interval before the first status request.
+	//
+	// Defaults to 2 seconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the interval between the status requests.
+	//
+	// Defaults to 1 minute.
+	MaxInterval time.Duration
+	// Multiplier increases the interval after each status request.
+	//
+	// Defaults to 2.
+	Multiplier float64
+	// OnPoll, if not nil, is called with the job after each status request, e.g. to report its progress.
+	OnPoll func(job *Job)
+}
+
+// JobFailedError is returned by Client.WaitForJob for a failed job.
+type JobFailedError struct {
+	Job *Job
+}
+
+// Error implements the error interface.
+func (e *JobFailedError) Error() string {
+	return fmt.Sprintf("%s job %s failed: %s", e.Job.Type, e.Job.JobID, e.Job.Failure)
+}
+
+// WaitForJob polls the status of a job, with exponential backoff, until it completes or fails, and returns it.
+// A failed job is returned with a *JobFailedError. Network errors of the status requests are retried,
+// the rest of the errors and the cancellation of the context stop the polling.
+//
+// Example usage:
+//
+//	job, err := client.WaitForJob(ctx, job.JobID, azurepush.PollOptions{MaxInterval: 30 * time.Second})
+//	if jobErr, ok := errors.AsType[*azurepush.JobFailedError](err); ok {
+//		log.Printf("export failed: %s", jobErr.Job.Failure)
+//	}
+func (c *Client) WaitForJob(ctx context.Context, jobID string, opts PollOptions) (*Job, error) {
+	if jobID == "" {
+		return nil, fmt.Errorf("job ID cannot be empty")
+	}
+
+	if opts.InitialInterval <= 0 {
+		opts.InitialInterval = 2 * time.Second
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = time.Minute
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = 2
+	}
+
+	timer := time.NewTimer(opts.InitialInterval)
+	defer timer.Stop()
+
+	interval := opts.InitialInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		job, err := c.GetJob(ctx, jobID)
+		switch {
+		case err == nil:
+			if opts.OnPoll != nil {
+				opts.OnPoll(job)
+			}
+
+			switch job.Status {
+			case JobStatusCompleted:
+				return job, nil
+			case JobStatusFailed:
+				return job, &JobFailedError{Job: job}
+			}
+		case ClassifyError(err).Has(ErrorClassNetwork) && ctx.Err() == nil:
+			// retry.
+		default:
+			return nil, err
+		}
+
+		interval = min(time.Duration(float64(interval)*opts.Multiplier), opts.MaxInterval)
+		timer.Reset(interval)
+	}
+}
+
+// doJobRequest sends a request to a jobs resource of the hub and decodes its Atom response.
+func (c *Client) doJobRequest(ctx context.Context, method, resource string, body []byte, v any) error {
+	cfg, tm := c.current()
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	url := cfg.hubURL(resource, "2015-01")
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/atom+xml;type=entry;charset=utf-8")
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		if err = tierErrorFromResponse(FeatureBulkJobs, TierStandard, c.Tier(), resp.StatusCode, string(b)); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s: %s", resp.Status, string(b))
+	}
+
+	if err = xml.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// serviceBusConnectNamespace is the XML namespace of the hub's resource descriptions.
+const serviceBusConnectNamespace = "http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"
+
+// jobEntry is the Atom entry of a job.
+type jobEntry struct {
+	XMLName xml.Name   `xml:"http://www.w3.org/2005/Atom entry"`
+	Content jobContent `xml:"content"`
+}
+
+type jobContent struct {
+	Type string         `xml:"type,attr"`
+	Job  jobDescription `xml:"NotificationHubJob"`
+}
+
+// jobDescription is the <NotificationHubJob> description of a job.
+type jobDescription struct {
+	XMLNS              string  `xml:"xmlns,attr,omitempty"`
+	JobID              string  `xml:"JobId,omitempty"`
+	Progress           string  `xml:"Progress,omitempty"`
+	Type               JobType `xml:"Type"`
+	Status             string  `xml:"Status,omitempty"`
+	OutputContainerURI string  `xml:"OutputContainerUri,omitempty"`
+	ImportFileURI      string  `xml:"ImportFileUri,omitempty"`
+	Failure            string  `xml:"Failure,omitempty"`
+	OutputProperties   *struct {
+		Items []struct {
+			Key   string `xml:"Key"`
+			Value string `xml:"Value"`
+		} `xml:"KeyValueOfstringstring"`
+	} `xml:"OutputProperties,omitempty"`
+	CreatedAt string `xml:"CreatedAt,omitempty"`
+	UpdatedAt string `xml:"UpdatedAt,omitempty"`
+}
+
+func (d jobDescription) job() *Job {
+	job := &Job{
+		JobID:              d.JobID,
+		Type:               d.Type,
+		OutputContainerURI: d.OutputContainerURI,
+		ImportFileURI:      d.ImportFileURI,
+		Status:             JobStatus(d.Status),
+		Failure:            d.Failure,
+	}
+
+	job.Progress, _ = strconv.ParseFloat(d.Progress, 64)
+	job.CreatedAt, _ = time.Parse(time.RFC3339, d.CreatedAt)
+	job.UpdatedAt, _ = time.Parse(time.RFC3339, d.UpdatedAt)
+
+	if d.OutputProperties != nil && len(d.OutputProperties.Items) > 0 {
+		job.OutputProperties = make(map[string]string, len(d.OutputProperties.Items))
+		for _, item := range d.OutputProperties.Items {
+			job.OutputProperties[item.Key] = item.Value
+		}
+	}
+
+	return job
+}
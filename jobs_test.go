@@ -0,0 +1,146 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_Jobs(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	const container = "https://account.blob.core.windows.net/export?sv=2020-08-04&sig=abc"
+	job, err := client.SubmitJob(ctx, azurepush.Job{Type: azurepush.JobExportRegistrations, OutputContainerURI: container})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.JobID == "" || job.Type != azurepush.JobExportRegistrations || job.OutputContainerURI != container || job.CreatedAt.IsZero() {
+		t.Fatalf("unexpected submitted job: %#v", job)
+	}
+
+	got, err := client.GetJob(ctx, job.JobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Done() || got.Status != azurepush.JobStatusCompleted || got.Progress != 100 {
+		t.Errorf("expected a completed job, got: %#v", got)
+	}
+	if path := got.OutputProperties[azurepush.JobOutputFilePath]; path != "https://account.blob.core.windows.net/export/"+job.JobID+"/Output.txt" {
+		t.Errorf("unexpected output file path: %s", path)
+	}
+
+	if _, err = client.SubmitJob(ctx, azurepush.Job{
+		Type:               azurepush.JobImportUpsertInstallations,
+		OutputContainerURI: container,
+		ImportFileURI:      "https://account.blob.core.windows.net/import/devices.txt?sig=def",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := client.ListJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[1].ImportFileURI == "" || jobs[1].OutputProperties[azurepush.JobFailedFilePath] == "" {
+		t.Fatalf("unexpected jobs: %#v", jobs)
+	}
+
+	if _, err = client.GetJob(ctx, "missing"); err == nil {
+		t.Error("expected a job not found error")
+	}
+
+	for _, invalid := range []azurepush.Job{
+		{OutputContainerURI: container},
+		{Type: azurepush.JobExportInstallations},
+		{Type: azurepush.JobImportCreateRegistrations, OutputContainerURI: container},
+	} {
+		if _, err = client.SubmitJob(ctx, invalid); err == nil {
+			t.Errorf("expected an invalid job error: %#v", invalid)
+		}
+	}
+
+	srv.SetError(azurepushtest.OpJob, http.StatusForbidden, "Bulk jobs are not supported by the Free tier.")
+	_, err = client.SubmitJob(ctx, azurepush.Job{Type: azurepush.JobExportRegistrations, OutputContainerURI: container})
+	if _, ok := errors.AsType[*azurepush.TierError](err); !ok {
+		t.Errorf("expected a tier error, got: %v", err)
+	}
+
+	client.SetTier(azurepush.TierBasic)
+	srv.ClearErrors()
+	_, err = client.SubmitJob(ctx, azurepush.Job{Type: azurepush.JobExportRegistrations, OutputContainerURI: container})
+	if _, ok := errors.AsType[*azurepush.TierError](err); !ok || len(srv.Jobs()) != 2 {
+		t.Errorf("expected a tier error without a request, got: %v", err)
+	}
+}
+
+func TestClient_WaitForJob(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	srv.SetJobPolls(3)
+	job, err := client.SubmitJob(ctx, azurepush.Job{Type: azurepush.JobExportInstallations, OutputContainerURI: "https://account.blob.core.windows.net/export?sig=abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != azurepush.JobStatusStarted || job.Done() {
+		t.Fatalf("expected a started job, got: %s", job.Status)
+	}
+
+	var progress []float64
+	job, err = client.WaitForJob(ctx, job.JobID, azurepush.PollOptions{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     4 * time.Millisecond,
+		OnPoll: func(job *azurepush.Job) {
+			progress = append(progress, job.Progress)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != azurepush.JobStatusCompleted || !slices.Equal(progress, []float64{25, 50, 75, 100}) {
+		t.Errorf("unexpected job: %s, progress: %v", job.Status, progress)
+	}
+
+	// An import job of a missing file fails.
+	job, err = client.SubmitJob(ctx, azurepush.Job{
+		Type:               azurepush.JobImportCreateInstallations,
+		OutputContainerURI: "https://account.blob.core.windows.net/import?sig=abc",
+		ImportFileURI:      "https://account.blob.core.windows.net/import/missing.jsonl?sig=abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.WaitForJob(ctx, job.JobID, azurepush.PollOptions{InitialInterval: time.Millisecond})
+	if jobErr, ok := errors.AsType[*azurepush.JobFailedError](err); !ok || jobErr.Job.Failure == "" {
+		t.Errorf("expected a job failed error, got: %v", err)
+	}
+
+	// The context stops the polling.
+	srv.SetJobPolls(1000)
+	job, err = client.SubmitJob(ctx, azurepush.Job{Type: azurepush.JobExportInstallations, OutputContainerURI: "https://account.blob.core.windows.net/export?sig=abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err = client.WaitForJob(timeoutCtx, job.JobID, azurepush.PollOptions{InitialInterval: time.Millisecond}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline exceeded error, got: %v", err)
+	}
+}
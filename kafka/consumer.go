@@ -0,0 +1,229 @@
+// Package kafka consumes notification events from a Kafka topic and sends them
+// through the azurepush batch Sender, with at-least-once delivery:
+// the offsets of a batch are committed only after all of its notifications were handled.
+//
+// Each message value is a JSON Event:
+//
+//	{
+//	  "id": "order-7-shipped",
+//	  "notification": {"title": "Order shipped", "body": "Your order is on its way", "data": {"orderId": "7"}},
+//	  "tags": ["user:42"]
+//	}
+//
+// Example usage:
+//
+//	reader := kafka.NewReader(kafka.ReaderConfig{
+//		Brokers: []string{"localhost:9092"},
+//		GroupID: "push",
+//		Topic:   "notifications",
+//	})
+//	defer reader.Close()
+//
+//	consumer := azurepushkafka.NewConsumer(client, reader, azurepushkafka.Options{})
+//	err := consumer.Run(ctx)
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kataras/azurepush"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Event is the JSON schema of the consumed messages.
+type Event struct {
+	// ID is an optional identifier of the event, reported in the SendResult.
+	ID           string       `json:"id,omitempty"`
+	Notification Notification `json:"notification"`
+	// Tags targets the devices, a tag can be a tag expression. Empty tags broadcast the notification.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Notification is the JSON schema of an Event's notification.
+type Notification struct {
+	Title string         `json:"title"`
+	Body  string         `json:"body"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+// ErrInvalidEvent is reported (wrapped) for messages whose value is not a valid Event.
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Reader fetches and commits Kafka messages of a consumer group.
+// It is implemented by *kafka.Reader.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+var _ Reader = (*kafka.Reader)(nil)
+
+// Options holds the settings of the Consumer.
+type Options struct {
+	// BatchSize is the maximum number of messages sent as a single batch.
+	//
+	// Defaults to 100.
+	BatchSize int
+
+	// BatchTimeout is the maximum duration to wait for a batch to fill up
+	// after its first message, before it is sent.
+	//
+	// Defaults to 1 second.
+	BatchTimeout time.Duration
+
+	// Sender holds the settings of the underlying batch Sender.
+	Sender azurepush.SenderOptions
+
+	// OnFailure is called for every message which is not a valid Event (the error wraps ErrInvalidEvent)
+	// or failed to be sent. Returning nil commits the message, e.g. after publishing it to a dead-letter topic.
+	// Returning an error stops the consumer without committing the batch, so its messages are redelivered.
+	//
+	// Defaults to skipping the invalid events and the permanent failures, which would fail on every redelivery:
+	// rejected requests, e.g. invalid notifications (see azurepush.ErrInvalidNotification),
+	// and sends which matched no device (see azurepush.ErrorClassRequest and azurepush.ErrorClassNoDevice).
+	// It stops on the rest of the failed sends, e.g. network and server failures.
+	OnFailure func(ctx context.Context, message kafka.Message, err error) error
+
+	// OnResult is called (if not nil) with the result of every sent event.
+	OnResult func(message kafka.Message, result azurepush.SendResult)
+}
+
+// Consumer consumes notification events and sends them in batches.
+type Consumer struct {
+	reader Reader
+	sender *azurepush.Sender
+	opts   Options
+}
+
+// NewConsumer creates a new Consumer.
+func NewConsumer(client *azurepush.Client, reader Reader, opts Options) *Consumer {
+	if reader == nil {
+		panic("azurepush/kafka: nil reader")
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.BatchTimeout <= 0 {
+		opts.BatchTimeout = time.Second
+	}
+
+	if opts.OnFailure == nil {
+		opts.OnFailure = defaultOnFailure
+	}
+
+	return &Consumer{
+		reader: reader,
+		sender: azurepush.NewSender(client, opts.Sender),
+		opts:   opts,
+	}
+}
+
+func defaultOnFailure(_ context.Context, _ kafka.Message, err error) error {
+	if errors.Is(err, ErrInvalidEvent) || azurepush.ClassifyError(err).Has(azurepush.ErrorClassRequest|azurepush.ErrorClassNoDevice) {
+		return nil
+	}
+
+	return err
+}
+
+// Run consumes messages until the context is canceled or a batch fails.
+// It returns nil when the context is canceled.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		messages, err := c.fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to fetch messages: %w", err)
+		}
+
+		if err = c.handle(ctx, messages); err != nil {
+			if ctx.Err() != nil {
+				return nil // the uncommitted batch is redelivered.
+			}
+			return err
+		}
+	}
+}
+
+// fetch blocks until the first message of a batch is available,
+// then fetches more until the batch is full or the batch timeout elapses.
+func (c *Consumer) fetch(ctx context.Context) ([]kafka.Message, error) {
+	first, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]kafka.Message, 1, c.opts.BatchSize)
+	messages[0] = first
+
+	batchCtx, cancel := context.WithTimeout(ctx, c.opts.BatchTimeout)
+	defer cancel()
+
+	for len(messages) < c.opts.BatchSize {
+		msg, err := c.reader.FetchMessage(batchCtx)
+		if err != nil {
+			if batchCtx.Err() != nil && ctx.Err() == nil {
+				break // batch timeout.
+			}
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// handle sends the batch and commits its offsets.
+func (c *Consumer) handle(ctx context.Context, messages []kafka.Message) error {
+	notifications := make([]azurepush.TargetedNotification, 0, len(messages))
+	sent := make([]kafka.Message, 0, len(messages))
+
+	for _, msg := range messages {
+		var event Event
+		if err := json.Unmarshal(msg.Value, &event); err != nil {
+			if err = c.opts.OnFailure(ctx, msg, fmt.Errorf("%w: %w", ErrInvalidEvent, err)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		notifications = append(notifications, azurepush.TargetedNotification{
+			ID: event.ID,
+			Notification: azurepush.Notification{
+				Title: event.Notification.Title,
+				Body:  event.Notification.Body,
+				Data:  event.Notification.Data,
+			},
+			Tags: event.Tags,
+		})
+		sent = append(sent, msg)
+	}
+
+	for i, result := range c.sender.SendBatch(ctx, notifications) {
+		if c.opts.OnResult != nil {
+			c.opts.OnResult(sent[i], result)
+		}
+
+		if result.Err != nil {
+			if err := c.opts.OnFailure(ctx, sent[i], result.Err); err != nil {
+				return fmt.Errorf("failed to send event %s/%d/%d: %w", sent[i].Topic, sent[i].Partition, sent[i].Offset, err)
+			}
+		}
+	}
+
+	// Commit even if the context was canceled meanwhile, all messages were handled.
+	if err := c.reader.CommitMessages(context.WithoutCancel(ctx), messages...); err != nil {
+		return fmt.Errorf("failed to commit messages: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package kafka_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+	azurepushkafka "github.com/kataras/azurepush/kafka"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader serves its messages, then blocks until the context is canceled.
+type fakeReader struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []int64
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.messages) > 0 {
+		msg := r.messages[0]
+		r.messages = r.messages[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+func newMessages(values ...string) []kafka.Message {
+	messages := make([]kafka.Message, len(values))
+	for i, value := range values {
+		messages[i] = kafka.Message{Topic: "notifications", Offset: int64(i), Value: []byte(value)}
+	}
+	return messages
+}
+
+func TestConsumer(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	reader := &fakeReader{messages: newMessages(
+		`{"id":"a","notification":{"title":"Hi"},"tags":["user:1"]}`,
+		`not json`,
+		`{"id":"b","notification":{"title":"Hi"},"tags":["user:2"]}`,
+	)}
+
+	var (
+		mu      sync.Mutex
+		results []string
+	)
+	consumer := azurepushkafka.NewConsumer(srv.NewClient(), reader, azurepushkafka.Options{
+		BatchSize:    2,
+		BatchTimeout: 20 * time.Millisecond,
+		OnResult: func(message kafka.Message, result azurepush.SendResult) {
+			mu.Lock()
+			results = append(results, result.ID)
+			mu.Unlock()
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := consumer.Run(ctx); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	if len(reader.committed) != 3 {
+		t.Errorf("expected all 3 messages to be committed, got offsets: %v", reader.committed)
+	}
+	if len(results) != 2 || results[0] != "a" || results[1] != "b" {
+		t.Errorf("unexpected results: %v", results)
+	}
+	if len(srv.Sent("user:1")) != 2 || len(srv.Sent("user:2")) != 2 {
+		t.Errorf("expected both events to be sent to both platforms, got: %d", len(srv.AllSent()))
+	}
+}
+
+func TestConsumer_FailedSendIsNotCommitted(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "internal error")
+
+	reader := &fakeReader{messages: newMessages(`{"notification":{"title":"Hi"}}`)}
+	consumer := azurepushkafka.NewConsumer(srv.NewClient(), reader, azurepushkafka.Options{BatchTimeout: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := consumer.Run(ctx); err == nil {
+		t.Fatal("expected the failed send to stop the consumer")
+	}
+	if len(reader.committed) != 0 {
+		t.Errorf("expected no commits, got offsets: %v", reader.committed)
+	}
+}
+
+func TestConsumer_InvalidNotificationIsSkipped(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	reader := &fakeReader{messages: newMessages(
+		`{"id":"empty","notification":{},"tags":["user:1"]}`,
+		`{"id":"a","notification":{"title":"Hi"},"tags":["user:2"]}`,
+	)}
+	consumer := azurepushkafka.NewConsumer(srv.NewClient(), reader, azurepushkafka.Options{BatchTimeout: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := consumer.Run(ctx); err != nil {
+		t.Fatalf("expected an invalid notification not to stop the consumer, got: %v", err)
+	}
+	if len(reader.committed) != 2 {
+		t.Errorf("expected both messages to be committed, got offsets: %v", reader.committed)
+	}
+	if len(srv.Sent("user:2")) == 0 {
+		t.Error("expected the valid event to be sent")
+	}
+}
+
+func TestConsumer_NoDeviceIsSkipped(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpSend, http.StatusNotFound, "no device")
+
+	reader := &fakeReader{messages: newMessages(
+		`{"id":"a","notification":{"title":"Hi"},"tags":["user:1"]}`,
+		`{"id":"b","notification":{"title":"Hi"},"tags":["user:2"]}`,
+	)}
+	consumer := azurepushkafka.NewConsumer(srv.NewClient(), reader, azurepushkafka.Options{BatchTimeout: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := consumer.Run(ctx); err != nil {
+		t.Fatalf("expected a send with no devices not to stop the consumer, got: %v", err)
+	}
+	if len(reader.committed) != 2 {
+		t.Errorf("expected both messages to be committed, got offsets: %v", reader.committed)
+	}
+}
+
+func TestConsumer_OnFailure(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "internal error")
+
+	var deadLetters []int64
+	reader := &fakeReader{messages: newMessages(`{"notification":{"title":"Hi"}}`, `{}`)}
+	consumer := azurepushkafka.NewConsumer(srv.NewClient(), reader, azurepushkafka.Options{
+		BatchTimeout: 10 * time.Millisecond,
+		OnFailure: func(ctx context.Context, message kafka.Message, err error) error {
+			if errors.Is(err, azurepushkafka.ErrInvalidEvent) {
+				t.Errorf("unexpected invalid event: %v", err)
+			}
+			deadLetters = append(deadLetters, message.Offset)
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := consumer.Run(ctx); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+
+	if len(deadLetters) != 2 || len(reader.committed) != 2 {
+		t.Errorf("expected both failed messages to be dead-lettered and committed, got: %v, %v", deadLetters, reader.committed)
+	}
+}
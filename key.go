@@ -0,0 +1,131 @@
+package azurepush
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// KeyEncoding is the encoding of the configured shared access keys, see Configuration.KeyEncoding.
+//
+// The hub signs the SAS tokens with the key exactly as the Azure Portal shows it (a base64 string),
+// a key stored base64-decoded or with surrounding whitespace produces 401 Unauthorized responses.
+type KeyEncoding string
+
+const (
+	// KeyEncodingAuto trims the surrounding whitespace of the keys and detects the base64-decoded ones
+	// (binary keys of 32 bytes), which are encoded back before signing.
+	KeyEncodingAuto KeyEncoding = ""
+	// KeyEncodingBase64 expects the keys as the Azure Portal shows them, their surrounding whitespace is trimmed.
+	// Validate reports the keys that fail VerifyKeyFormat.
+	KeyEncodingBase64 KeyEncoding = "base64"
+	// KeyEncodingRaw expects base64-decoded keys, they are encoded before signing.
+	KeyEncodingRaw KeyEncoding = "raw"
+)
+
+// ErrMalformedKey is reported by VerifyKeyFormat and Configuration.Validate for a malformed shared access key.
+var ErrMalformedKey = errors.New("malformed shared access key")
+
+// keySize is the size in bytes of the shared access keys generated by Azure (256 bits).
+const keySize = 32
+
+// VerifyKeyFormat reports whether a shared access key looks like one generated by Azure:
+// a base64 string of (at least) 256 bits, without whitespace or quotes.
+// The errors wrap ErrMalformedKey.
+//
+// Example usage:
+//
+//	if err := azurepush.VerifyKeyFormat(cfg.KeyValue); err != nil {
+//		log.Printf("check the AZUREPUSH_KEY_VALUE: %v", err)
+//	}
+func VerifyKeyFormat(key string) error {
+	if key == "" {
+		return fmt.Errorf("%w: empty key", ErrMalformedKey)
+	}
+
+	if strings.TrimSpace(key) != key {
+		return fmt.Errorf("%w: surrounding whitespace", ErrMalformedKey)
+	}
+
+	if strings.IndexFunc(key, unicode.IsSpace) != -1 {
+		return fmt.Errorf("%w: whitespace", ErrMalformedKey)
+	}
+
+	if strings.ContainsAny(key, `"'`) {
+		return fmt.Errorf("%w: quotes", ErrMalformedKey)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		if isBinaryKey(key) {
+			return fmt.Errorf("%w: the key seems base64-decoded, use the KeyEncodingRaw", ErrMalformedKey)
+		}
+		return fmt.Errorf("%w: not a base64 string", ErrMalformedKey)
+	}
+
+	if len(decoded) < keySize {
+		return fmt.Errorf("%w: %d bits, expected at least %d", ErrMalformedKey, len(decoded)*8, keySize*8)
+	}
+
+	return nil
+}
+
+// validateKey reports the obviously malformed keys of an encoding,
+// the keys of KeyEncodingAuto are not required to be base64 strings.
+func validateKey(key string, encoding KeyEncoding) error {
+	switch encoding {
+	case KeyEncodingRaw:
+		return nil
+	case KeyEncodingBase64:
+		return VerifyKeyFormat(strings.TrimSpace(key))
+	case KeyEncodingAuto:
+		if isBinaryKey(key) {
+			return nil
+		}
+
+		key = strings.TrimSpace(key)
+
+		if strings.IndexFunc(key, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) }) != -1 {
+			return fmt.Errorf("%w: whitespace or control characters", ErrMalformedKey)
+		}
+
+		if strings.HasPrefix(key, `"`) || strings.HasPrefix(key, "'") {
+			return fmt.Errorf("%w: quotes", ErrMalformedKey)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unsupported key encoding: %q", encoding)
+	}
+}
+
+// signingKey returns the key the SAS tokens are signed with.
+func signingKey(key string, encoding KeyEncoding) string {
+	switch encoding {
+	case KeyEncodingRaw:
+		return base64.StdEncoding.EncodeToString([]byte(key))
+	case KeyEncodingAuto:
+		if isBinaryKey(key) {
+			return base64.StdEncoding.EncodeToString([]byte(key))
+		}
+	}
+
+	return strings.TrimSpace(key)
+}
+
+// isBinaryKey reports whether the key is a base64-decoded key generated by Azure.
+func isBinaryKey(key string) bool {
+	if len(key) != keySize {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return true
+		}
+	}
+
+	return false
+}
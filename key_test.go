@@ -0,0 +1,91 @@
+package azurepush_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestVerifyKeyFormat(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	if err := azurepush.VerifyKeyFormat(key); err != nil {
+		t.Fatalf("expected a valid key, got: %v", err)
+	}
+
+	for _, malformed := range []string{
+		"",
+		key + "\n",
+		`"` + key + `"`,
+		"c2VjcmV0",                             // 48 bits.
+		"not a key",                            // whitespace.
+		"0123456789abcdef0123456789abcdef\x00", // not base64.
+	} {
+		if err := azurepush.VerifyKeyFormat(malformed); !errors.Is(err, azurepush.ErrMalformedKey) {
+			t.Errorf("%q: expected a malformed key error, got: %v", malformed, err)
+		}
+	}
+}
+
+func TestConfiguration_KeyEncoding(t *testing.T) {
+	raw := "\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f\x20"
+	key := base64.StdEncoding.EncodeToString([]byte(raw))
+	clock := azurepushtest.NewFakeClock(time.Unix(1700000000, 0))
+
+	signature := func(keyValue string, encoding azurepush.KeyEncoding) string {
+		t.Helper()
+
+		cfg := azurepush.Configuration{
+			HubName:     "hub",
+			Namespace:   "namespace",
+			KeyName:     "DefaultFullSharedAccessSignature",
+			KeyValue:    keyValue,
+			KeyEncoding: encoding,
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		tm := azurepush.NewTokenManager(cfg)
+		tm.SetClock(clock)
+		token, err := tm.GetToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		params, _ := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+		return params.Get("sig")
+	}
+
+	expected := signature(key, azurepush.KeyEncodingBase64)
+
+	if got := signature(" "+key+"\n", azurepush.KeyEncodingAuto); got != expected {
+		t.Error("expected the surrounding whitespace of the key to be ignored")
+	}
+	if got := signature(raw, azurepush.KeyEncodingRaw); got != expected {
+		t.Error("expected the raw key to be encoded")
+	}
+	if got := signature(raw, azurepush.KeyEncodingAuto); got != expected {
+		t.Error("expected the raw key to be detected")
+	}
+
+	for _, cfg := range []azurepush.Configuration{
+		{HubName: "hub", Namespace: "namespace", KeyName: "key", KeyValue: `"` + key + `"`},
+		{HubName: "hub", Namespace: "namespace", KeyName: "key", KeyValue: "sec ret"},
+		{HubName: "hub", Namespace: "namespace", KeyName: "key", KeyValue: "secret", KeyEncoding: azurepush.KeyEncodingBase64},
+	} {
+		if err := cfg.Validate(); !errors.Is(err, azurepush.ErrMalformedKey) {
+			t.Errorf("%q: expected a malformed key error, got: %v", cfg.KeyValue, err)
+		}
+	}
+
+	cfg := azurepush.Configuration{HubName: "hub", Namespace: "namespace", KeyName: "key", KeyValue: key, KeyEncoding: "hex"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected an unsupported key encoding error")
+	}
+}
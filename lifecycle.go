@@ -0,0 +1,137 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClientClosed is returned by the hub calls of a closed client, see Client.Close.
+var ErrClientClosed = errors.New("client closed")
+
+// clientLifecycle tracks the in-flight hub calls and the background goroutines of a client, see Client.Close.
+type clientLifecycle struct {
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{} // closed by Close, created lazily.
+
+	inflight   sync.WaitGroup
+	background sync.WaitGroup
+}
+
+// doneChan returns the channel closed by Close, the caller must hold the lock.
+func (l *clientLifecycle) doneChan() chan struct{} {
+	if l.done == nil {
+		l.done = make(chan struct{})
+	}
+
+	return l.done
+}
+
+// isClosed reports whether the client is closed.
+func (c *Client) isClosed() bool {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	return c.lifecycle.closed
+}
+
+// begin tracks a hub call until the returned function is called,
+// it fails with ErrClientClosed once the client is closed.
+func (c *Client) begin() (func(), error) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	if c.lifecycle.closed {
+		return nil, ErrClientClosed
+	}
+
+	c.lifecycle.inflight.Add(1)
+	return c.lifecycle.inflight.Done, nil
+}
+
+// startBackground tracks a background goroutine of the client (e.g. Maintenance.Run) until the returned function is called.
+// The returned context is canceled when the client is closed, it is already canceled if the client is closed.
+func (c *Client) startBackground(ctx context.Context) (context.Context, func()) {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	if c.lifecycle.closed {
+		cancel()
+		return ctx, cancel
+	}
+
+	c.lifecycle.background.Add(1)
+	done := c.lifecycle.doneChan()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		cancel()
+		c.lifecycle.background.Done()
+	}
+}
+
+// startBackgroundOf tracks a background goroutine of a wrapper of the client (e.g. QuietHoursClient.Run),
+// see Client.startBackground. Clients other than *Client are not tracked.
+func startBackgroundOf(client HubClient, ctx context.Context) (context.Context, func()) {
+	if c, ok := client.(*Client); ok {
+		return c.startBackground(ctx)
+	}
+
+	return ctx, func() {}
+}
+
+// Close closes the client gracefully, e.g. on the SIGTERM of a rolling deploy:
+//   - new hub calls fail with ErrClientClosed, so senders and workers stop taking new work
+//   - the background goroutines of the client (Maintenance.Run, QuietHoursClient.Run and Scheduler.Run) are stopped,
+//     the deferred notifications and the schedules not sent yet are kept for their next run
+//   - the in-flight hub calls, e.g. sends, are drained until the context is done
+//   - the idle connections of the HTTPClient are closed, unless it is shared by the clients of a ClientPool.
+//
+// It returns an error if the context is done before the in-flight calls are drained.
+// Calling Close again returns nil immediately.
+//
+// Example usage:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := client.Close(ctx); err != nil {
+//		log.Printf("push client: %v", err)
+//	}
+func (c *Client) Close(ctx context.Context) error {
+	c.lifecycle.mu.Lock()
+	if c.lifecycle.closed {
+		c.lifecycle.mu.Unlock()
+		return nil
+	}
+	c.lifecycle.closed = true
+	close(c.lifecycle.doneChan())
+	c.lifecycle.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.lifecycle.inflight.Wait()
+		c.lifecycle.background.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = fmt.Errorf("failed to drain in-flight requests: %w", ctx.Err())
+	}
+
+	if c.HTTPClient != nil && !c.sharedHTTPClient {
+		c.HTTPClient.CloseIdleConnections()
+	}
+
+	return err
+}
@@ -0,0 +1,142 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+// blockingTransport holds the requests until release is closed.
+type blockingTransport struct {
+	base    http.RoundTripper
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.started <- struct{}{}
+	<-t.release
+	return t.base.RoundTrip(r)
+}
+
+func TestClient_Close(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	if _, err := client.RegisterDevice(t.Context(), azurepush.Installation{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a", Tags: []string{"user:1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	maintenance, err := azurepush.NewMaintenance(client, azurepush.MaintenanceOptions{
+		Policies: []azurepush.PrunePolicy{{Name: "expired", Schedule: "@weekly", ExpiredChannels: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopped := make(chan error)
+	go func() { stopped <- maintenance.Run(context.Background()) }()
+
+	transport := &blockingTransport{base: client.HTTPClient.Transport, started: make(chan struct{}, 2), release: make(chan struct{})}
+	client.HTTPClient.Transport = transport
+
+	sent := make(chan error)
+	go func() {
+		sent <- client.SendNotification(context.Background(), azurepush.Notification{Title: "Hi"}, "user:1")
+	}()
+	<-transport.started
+
+	// The in-flight send is not drained before the deadline.
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err = client.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a drain deadline error, got: %v", err)
+	}
+
+	if err = client.SendNotification(t.Context(), azurepush.Notification{Title: "Hi"}, "user:1"); !errors.Is(err, azurepush.ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got: %v", err)
+	}
+
+	close(transport.release)
+	if err = <-sent; err != nil {
+		t.Fatalf("expected the in-flight send to complete, got: %v", err)
+	}
+
+	if err = <-stopped; err != nil {
+		t.Fatalf("expected the maintenance runner stopped, got: %v", err)
+	}
+
+	if err = maintenance.Run(t.Context()); err != nil {
+		t.Fatalf("expected Run to return on a closed client, got: %v", err)
+	}
+
+	if err = client.Close(t.Context()); err != nil {
+		t.Fatalf("expected a repeated Close to return nil, got: %v", err)
+	}
+}
+
+func TestClient_Close_Runners(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	store := azurepush.NewMemoryStore()
+	clock := azurepushtest.NewFakeClock(time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC))
+
+	qc := azurepush.NewQuietHoursClient(client, azurepush.QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour}, azurepush.QuietHoursOptions{
+		Clock: clock,
+		Store: store,
+		OnRelease: func(_ azurepush.DeferredNotification, err error) {
+			t.Errorf("unexpected release: %v", err)
+		},
+	})
+	if err := qc.SendNotification(t.Context(), azurepush.Notification{Title: "Good morning"}, "user:1"); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler := azurepush.NewScheduler(client, azurepush.SchedulerOptions{
+		Store: store,
+		OnRun: func(_ azurepush.Schedule, err error) {
+			t.Errorf("unexpected run: %v", err)
+		},
+	})
+	next := time.Now().Add(-time.Minute) // due.
+	if err := scheduler.Add(t.Context(), azurepush.Schedule{ID: "digest", Cron: "@weekly", Notification: azurepush.Notification{Title: "Digest"}, Next: next}); err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := make(chan error)
+	go func() { stopped <- qc.Run(context.Background()) }()
+	time.Sleep(10 * time.Millisecond) // the notification is not due, Run waits.
+
+	if err := client.Close(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-stopped; err != nil {
+		t.Fatalf("expected the quiet hours runner to stop on Close, got: %v", err)
+	}
+
+	// The runners of a closed client keep their work for later.
+	clock.Advance(9 * time.Hour)
+	if err := qc.Run(context.Background()); err != nil {
+		t.Fatalf("expected Run to return on a closed client, got: %v", err)
+	}
+	if deferred := qc.Deferred(); len(deferred) != 1 {
+		t.Errorf("expected the deferred notification to be kept in memory, got: %d", len(deferred))
+	}
+	if entries, _ := store.List(t.Context(), "quiethours/"); len(entries) != 1 {
+		t.Errorf("expected the deferred notification to be kept in the store, got: %d", len(entries))
+	}
+
+	if err := scheduler.Run(context.Background()); err != nil {
+		t.Fatalf("expected Run to return on a closed client, got: %v", err)
+	}
+	if schedules := scheduler.Schedules(); len(schedules) != 1 || !schedules[0].Next.Equal(next) {
+		t.Errorf("expected the schedule to be kept due, got: %+v", schedules)
+	}
+}
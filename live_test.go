@@ -0,0 +1,119 @@
+//go:build live
+
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kataras/azurepush"
+)
+
+// TestLive_Lifecycle runs the register, send and delete lifecycle against a real hub.
+// It is excluded from the default test run, run it with:
+//
+//	AZUREPUSH_HUB_NAME=myhub \
+//	AZUREPUSH_CONNECTION_STRING="Endpoint=sb://..." \
+//	AZUREPUSH_FCM_TOKEN=optional-real-device-token \
+//	go test -tags=live -run TestLive -v ./...
+//
+// The per message telemetry step is skipped below the Standard tier.
+func TestLive_Lifecycle(t *testing.T) {
+	hubName, connectionString := os.Getenv("AZUREPUSH_HUB_NAME"), os.Getenv("AZUREPUSH_CONNECTION_STRING")
+	if hubName == "" || connectionString == "" {
+		t.Skip("AZUREPUSH_HUB_NAME and AZUREPUSH_CONNECTION_STRING are required for live tests")
+	}
+
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:           hubName,
+		ConnectionString:  connectionString,
+		TokenValidity:     time.Hour,
+		ConnectivityCheck: true,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	pushChannel := os.Getenv("AZUREPUSH_FCM_TOKEN")
+	if pushChannel == "" {
+		// A structurally valid but unknown token, Azure accepts the installation.
+		pushChannel = "live-test-" + uuid.NewString()
+	}
+
+	tag := "azurepush-live:" + uuid.NewString()
+	id, err := client.RegisterDevice(ctx, azurepush.Installation{
+		Platform:    azurepush.InstallationFCMV1,
+		PushChannel: pushChannel,
+		Tags:        []string{tag},
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = client.DeleteDevice(context.Background(), id)
+	})
+
+	// Installations are eventually consistent.
+	var exists bool
+	for range 10 {
+		if exists, err = client.DeviceExists(ctx, id); err != nil {
+			t.Fatalf("exists: %v", err)
+		}
+		if exists {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if !exists {
+		t.Fatalf("expected installation %s to exist", id)
+	}
+
+	ids, err := client.SendNotificationWithIDs(ctx, azurepush.Notification{
+		Title: "azurepush live test",
+		Body:  "Hello from the live contract test suite",
+		Data:  map[string]any{"test": true},
+	}, azurepush.SendOptions{}, tag)
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	t.Run("telemetry", func(t *testing.T) {
+		if len(ids) == 0 {
+			t.Skip("the hub reported no notification IDs, per message telemetry requires the Standard tier")
+		}
+
+		// Telemetry is eventually consistent as well.
+		var (
+			telemetry *azurepush.NotificationTelemetry
+			err       error
+		)
+		for range 10 {
+			if telemetry, err = client.GetNotificationTelemetry(ctx, ids[0]); err == nil {
+				break
+			}
+			if _, ok := errors.AsType[*azurepush.TierError](err); ok {
+				t.Skipf("per message telemetry is not available: %v", err)
+			}
+			time.Sleep(time.Second)
+		}
+		if err != nil {
+			t.Fatalf("telemetry: %v", err)
+		}
+		if telemetry.NotificationID != ids[0] || telemetry.State == "" {
+			t.Errorf("unexpected telemetry: %+v", telemetry)
+		}
+	})
+
+	if err = client.DeleteDevice(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// Idempotent delete.
+	if err = client.DeleteDevice(ctx, id); err != nil {
+		t.Fatalf("second delete: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// PrunePolicy is a pruning policy of the registrations of the hub, e.g. "delete the installations
+// not updated in 180 days" or "delete the expired channels weekly". Policies are declared in the
+// Configuration's Pruning field and executed on their schedule by a Maintenance runner.
+//
+// Example configuration:
+//
+//	Pruning:
+//	  - Name: stale
+//	    Schedule: "0 3 * * *"
+//	    NotUpdatedDays: 180
+//	  - Name: expired
+//	    Schedule: "@weekly"
+//	    ExpiredChannels: true
+type PrunePolicy struct {
+	// Name identifies the policy in the reports, it must be unique.
+	Name string `yaml:"Name" json:"Name"`
+	// Schedule is the cron expression of the runs (in UTC), e.g. "0 3 * * SUN" or "@weekly", see ParseCron.
+	Schedule string `yaml:"Schedule" json:"Schedule"`
+
+	// TagExpression and Platforms limit the pruned registrations, see TagFilter.
+	TagExpression string                 `yaml:"TagExpression" json:"TagExpression,omitempty"`
+	Platforms     []InstallationPlatform `yaml:"Platforms" json:"Platforms,omitempty"`
+
+	// NotUpdatedDays, if positive, prunes the registrations not updated for this number of days.
+	NotUpdatedDays int `yaml:"NotUpdatedDays" json:"NotUpdatedDays,omitempty"`
+	// ExpiredChannels prunes the registrations whose expiration time has passed.
+	ExpiredChannels bool `yaml:"ExpiredChannels" json:"ExpiredChannels,omitempty"`
+
+	// DryRun only reports the registrations which would be pruned.
+	DryRun bool `yaml:"DryRun" json:"DryRun,omitempty"`
+}
+
+// validate checks the policy, a policy must prune by age or expiration
+// so a misconfigured policy never deletes all the registrations of a tag.
+func (p PrunePolicy) validate() error {
+	if p.Name == "" {
+		return errors.New("pruning policy name is required")
+	}
+
+	if _, err := ParseCron(p.Schedule); err != nil {
+		return fmt.Errorf("pruning policy: %s: %w", p.Name, err)
+	}
+
+	if p.NotUpdatedDays < 0 {
+		return fmt.Errorf("pruning policy: %s: negative NotUpdatedDays", p.Name)
+	}
+
+	if p.NotUpdatedDays == 0 && !p.ExpiredChannels {
+		return fmt.Errorf("pruning policy: %s: NotUpdatedDays or ExpiredChannels is required", p.Name)
+	}
+
+	if p.TagExpression != "" {
+		if _, err := ParseTagExpression(p.TagExpression); err != nil {
+			return fmt.Errorf("pruning policy: %s: %w", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// filter returns the delete filter of the policy at the given time.
+func (p PrunePolicy) filter(now time.Time) DeleteFilter {
+	filter := DeleteFilter{
+		TagFilter:       TagFilter{TagExpression: p.TagExpression, Platforms: p.Platforms},
+		ExpiredChannels: p.ExpiredChannels,
+	}
+	if p.NotUpdatedDays > 0 {
+		filter.UpdatedBefore = now.AddDate(0, 0, -p.NotUpdatedDays)
+	}
+
+	return filter
+}
+
+func validatePrunePolicies(policies []PrunePolicy) error {
+	names := make(map[string]struct{}, len(policies))
+	for _, policy := range policies {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+
+		if _, ok := names[policy.Name]; ok {
+			return fmt.Errorf("duplicated pruning policy: %s", policy.Name)
+		}
+		names[policy.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// PruneReport is the report of a run of a pruning policy, see MaintenanceOptions.OnReport.
+type PruneReport struct {
+	Policy PrunePolicy
+	// Started and Finished are the start and end times of the run.
+	Started, Finished time.Time
+	// Matched, Deleted and Failed are the counts of the run, see BulkDeleteReport.
+	BulkDeleteReport
+	// Err is the error of the run, if any.
+	Err error
+}
+
+// MaintenanceOptions holds the settings of a Maintenance runner.
+type MaintenanceOptions struct {
+	// Policies are the pruning policies to run.
+	//
+	// Defaults to the client's Config.Pruning.
+	Policies []PrunePolicy
+
+	// Concurrency is the maximum number of registrations deleted in parallel by a run.
+	//
+	// Defaults to 8.
+	Concurrency int
+
+	// OnReport is called (if not nil) after each run of a policy.
+	OnReport func(PruneReport)
+}
+
+// Maintenance runs the pruning policies of a hub on their schedule in a background goroutine,
+// so hubs do not accumulate millions of dead registrations. Each run deletes the matching registrations
+// with Client.BulkDelete and is reported, the last report of each policy is kept (see Reports).
+//
+// Example usage:
+//
+//	maintenance, err := azurepush.NewMaintenance(client, azurepush.MaintenanceOptions{
+//		OnReport: func(r azurepush.PruneReport) {
+//			log.Printf("pruning %s: %d deleted, %d failed, err: %v", r.Policy.Name, r.Deleted, r.Failed, r.Err)
+//		},
+//	})
+//	go maintenance.Run(ctx)
+type Maintenance struct {
+	client    *Client
+	opts      MaintenanceOptions
+	schedules []*CronSchedule // of the policies.
+
+	mu      sync.Mutex
+	reports map[string]PruneReport // last by policy name.
+}
+
+// NewMaintenance creates a new Maintenance runner of the client's pruning policies.
+// It returns an error if a policy is invalid.
+func NewMaintenance(client *Client, opts MaintenanceOptions) (*Maintenance, error) {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if opts.Policies == nil {
+		cfg, _ := client.current()
+		opts.Policies = cfg.Pruning
+	}
+
+	if err := validatePrunePolicies(opts.Policies); err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*CronSchedule, len(opts.Policies))
+	for i, policy := range opts.Policies {
+		schedules[i], _ = ParseCron(policy.Schedule) // validated.
+	}
+
+	return &Maintenance{
+		client:    client,
+		opts:      opts,
+		schedules: schedules,
+		reports:   make(map[string]PruneReport),
+	}, nil
+}
+
+// Run runs the policies on their schedule until the context is canceled or the client is closed (see Client.Close).
+// Runs of different policies due at the same time run one after the other.
+//
+// A panic of a run (e.g. of OnReport) stops Run with an error and fails the client's liveness probe,
+// see Client.LivenessHandler.
+func (m *Maintenance) Run(ctx context.Context) (err error) {
+	ctx, stop := m.client.startBackground(ctx)
+	defer stop()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("maintenance: panic: %v", r)
+			m.client.backgroundFailed(err)
+		}
+	}()
+
+	now := time.Now().UTC()
+	next := make([]time.Time, len(m.schedules))
+	for i, schedule := range m.schedules {
+		next[i] = schedule.Next(now)
+	}
+
+	for {
+		var wake time.Time
+		for i, policy := range m.opts.Policies {
+			if next[i].IsZero() {
+				continue
+			}
+
+			if !next[i].After(time.Now()) {
+				m.run(ctx, policy)
+				next[i] = m.schedules[i].Next(time.Now().UTC())
+			}
+
+			if !next[i].IsZero() && (wake.IsZero() || next[i].Before(wake)) {
+				wake = next[i]
+			}
+		}
+
+		if wake.IsZero() {
+			<-ctx.Done()
+			return nil
+		}
+
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// RunPolicy runs a policy by its name now, e.g. from an admin endpoint, and returns its report.
+func (m *Maintenance) RunPolicy(ctx context.Context, name string) (PruneReport, error) {
+	i := slices.IndexFunc(m.opts.Policies, func(p PrunePolicy) bool { return p.Name == name })
+	if i == -1 {
+		return PruneReport{}, fmt.Errorf("pruning policy: %s: not found", name)
+	}
+
+	report := m.run(ctx, m.opts.Policies[i])
+	return report, report.Err
+}
+
+// Reports returns the last report of each policy which has run, in the order of the policies.
+func (m *Maintenance) Reports() []PruneReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reports []PruneReport
+	for _, policy := range m.opts.Policies {
+		if report, ok := m.reports[policy.Name]; ok {
+			reports = append(reports, report)
+		}
+	}
+
+	return reports
+}
+
+func (m *Maintenance) run(ctx context.Context, policy PrunePolicy) PruneReport {
+	report := PruneReport{Policy: policy, Started: time.Now()}
+
+	deleted, err := m.client.BulkDelete(ctx, policy.filter(m.client.now()), BulkDeleteOptions{
+		DryRun:      policy.DryRun,
+		Concurrency: m.opts.Concurrency,
+	})
+	if deleted != nil {
+		report.BulkDeleteReport = *deleted
+	}
+	if err != nil {
+		report.Err = fmt.Errorf("pruning policy: %s: %w", policy.Name, err)
+	}
+	report.Finished = time.Now()
+
+	m.mu.Lock()
+	m.reports[policy.Name] = report
+	m.mu.Unlock()
+
+	if m.opts.OnReport != nil {
+		m.opts.OnReport(report)
+	}
+
+	return report
+}
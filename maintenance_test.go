@@ -0,0 +1,90 @@
+package azurepush_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestMaintenance(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	clock := azurepushtest.NewFakeClock(time.Now().Truncate(time.Second))
+	srv.SetClock(clock)
+
+	cfg := srv.Configuration()
+	cfg.Pruning = []azurepush.PrunePolicy{
+		{Name: "stale", Schedule: "0 3 * * *", NotUpdatedDays: 180},
+		{Name: "expired", Schedule: "@weekly", ExpiredChannels: true, Platforms: []azurepush.InstallationPlatform{azurepush.InstallationFCMV1}, DryRun: true},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := azurepush.NewClient(cfg)
+	client.HTTPClient = srv.HTTPClient()
+	client.SetClock(clock)
+	ctx := context.Background()
+
+	for _, id := range []string{"old", "new"} {
+		if _, err := client.RegisterDevice(ctx, azurepush.Installation{InstallationID: id, Platform: azurepush.InstallationFCMV1, PushChannel: "token-" + id}); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(100 * 24 * time.Hour)
+	}
+	srv.SetExpiration("new", clock.Now().Add(-time.Hour))
+
+	var reports []azurepush.PruneReport
+	maintenance, err := azurepush.NewMaintenance(client, azurepush.MaintenanceOptions{
+		OnReport: func(r azurepush.PruneReport) { reports = append(reports, r) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := maintenance.RunPolicy(ctx, "stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 1 || report.Deleted != 1 || report.Finished.Before(report.Started) {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if _, ok := srv.Installation("old"); ok {
+		t.Fatal("expected the stale installation pruned")
+	}
+
+	if report, err = maintenance.RunPolicy(ctx, "expired"); err != nil || report.Deleted != 1 {
+		t.Fatalf("unexpected dry run report: %+v: %v", report, err)
+	}
+	if _, ok := srv.Installation("new"); !ok {
+		t.Fatal("expected no deletion on dry run")
+	}
+
+	if got := maintenance.Reports(); len(got) != 2 || got[0].Policy.Name != "stale" || len(reports) != 2 {
+		t.Fatalf("unexpected reports: %+v", got)
+	}
+
+	if _, err = maintenance.RunPolicy(ctx, "missing"); err == nil {
+		t.Fatal("expected a policy not found error")
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err = maintenance.Run(runCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, policies := range [][]azurepush.PrunePolicy{
+		{{Name: "no criteria", Schedule: "@daily"}},
+		{{Name: "bad schedule", Schedule: "daily", ExpiredChannels: true}},
+		{{Name: "a", Schedule: "@daily", ExpiredChannels: true}, {Name: "a", Schedule: "@weekly", ExpiredChannels: true}},
+	} {
+		if _, err = azurepush.NewMaintenance(client, azurepush.MaintenanceOptions{Policies: policies}); err == nil {
+			t.Errorf("expected an invalid policy error: %+v", policies)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultManagementEndpoint is the Azure Resource Manager endpoint used by the ManagementClient.
+var DefaultManagementEndpoint = "https://management.azure.com"
+
+// managementAPIVersion is the Microsoft.NotificationHubs resource provider API version.
+const managementAPIVersion = "2023-09-01"
+
+// AccessTokenProvider returns OAuth2 bearer tokens for Azure Resource Manager calls.
+//
+// The data-plane Client signs its requests with SAS tokens,
+// the management plane (namespaces, hubs, access policies) requires an Azure AD token instead.
+type AccessTokenProvider interface {
+	AccessToken(ctx context.Context) (string, error)
+}
+
+// AccessTokenProviderFunc is a function adapter for the AccessTokenProvider interface.
+type AccessTokenProviderFunc func(ctx context.Context) (string, error)
+
+// AccessToken implements the AccessTokenProvider interface.
+func (f AccessTokenProviderFunc) AccessToken(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// ManagementClient provides access to the Azure Resource Manager operations
+// of a Notification Hubs namespace, e.g. namespace information and access policies.
+//
+// It is meant for provisioning tooling, the data-plane operations (registrations, sends)
+// live on the Client.
+//
+// Example usage:
+//
+//	mc := azurepush.NewManagementClient(cfg, tokens)
+//	ns, err := mc.GetNamespace(context.Background())
+type ManagementClient struct {
+	Config Configuration
+	Tokens AccessTokenProvider
+
+	// Endpoint is the Azure Resource Manager endpoint.
+	// Defaults to DefaultManagementEndpoint.
+	Endpoint string
+
+	// HTTPClient is the client used for HTTP requests.
+	// It can be overridden for testing.
+	HTTPClient *http.Client
+}
+
+// NewManagementClient creates a new Azure Resource Manager client for the configured namespace.
+// The configuration's SubscriptionID, ResourceGroup and Namespace fields are required.
+func NewManagementClient(cfg Configuration, tokens AccessTokenProvider) *ManagementClient {
+	if err := cfg.validateManagement(); err != nil {
+		panic(err)
+	}
+
+	if tokens == nil {
+		panic("azurepush: nil access token provider")
+	}
+
+	return &ManagementClient{
+		Config:     cfg,
+		Tokens:     tokens,
+		Endpoint:   DefaultManagementEndpoint,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type (
+	// Sku is the pricing tier of a namespace or hub.
+	Sku struct {
+		// Name is the tier name: "Free", "Basic" or "Standard".
+		Name     string `json:"name"`
+		Tier     string `json:"tier,omitempty"`
+		Size     string `json:"size,omitempty"`
+		Family   string `json:"family,omitempty"`
+		Capacity int    `json:"capacity,omitempty"`
+	}
+
+	// Namespace describes a Notification Hubs namespace as returned by Azure Resource Manager.
+	Namespace struct {
+		ID         string              `json:"id"`
+		Name       string              `json:"name"`
+		Location   string              `json:"location"`
+		Tags       map[string]string   `json:"tags,omitempty"`
+		Sku        Sku                 `json:"sku"`
+		Properties NamespaceProperties `json:"properties"`
+	}
+
+	// NamespaceProperties holds the state of a namespace.
+	NamespaceProperties struct {
+		ProvisioningState  string    `json:"provisioningState"`
+		Status             string    `json:"status"`
+		Enabled            bool      `json:"enabled"`
+		Critical           bool      `json:"critical"`
+		NamespaceType      string    `json:"namespaceType"`
+		ServiceBusEndpoint string    `json:"serviceBusEndpoint"`
+		MetricID           string    `json:"metricId"`
+		CreatedAt          time.Time `json:"createdAt"`
+		UpdatedAt          time.Time `json:"updatedAt"`
+	}
+
+	// NamespaceAvailability is the result of a namespace name availability check.
+	NamespaceAvailability struct {
+		Name string `json:"name"`
+		// Available reports whether the name can be used for a new namespace.
+		// Note that Azure spells the JSON property "isAvailiable".
+		Available bool `json:"isAvailiable"`
+	}
+
+	// AuthorizationRule is a shared access policy of a namespace or hub.
+	AuthorizationRule struct {
+		ID         string                      `json:"id"`
+		Name       string                      `json:"name"`
+		Properties AuthorizationRuleProperties `json:"properties"`
+	}
+
+	// AuthorizationRuleProperties holds the rights and metadata of a shared access policy.
+	AuthorizationRuleProperties struct {
+		// Rights is a combination of "Listen", "Send" and "Manage".
+		Rights       []string  `json:"rights"`
+		KeyName      string    `json:"keyName,omitempty"`
+		ClaimType    string    `json:"claimType,omitempty"`
+		ClaimValue   string    `json:"claimValue,omitempty"`
+		Revision     int       `json:"revision,omitempty"`
+		CreatedTime  time.Time `json:"createdTime,omitzero"`
+		ModifiedTime time.Time `json:"modifiedTime,omitzero"`
+	}
+)
+
+// Access rights of a shared access policy.
+const (
+	// AccessRightListen allows registering devices (installations and registrations).
+	AccessRightListen = "Listen"
+	// AccessRightSend allows sending notifications.
+	AccessRightSend = "Send"
+	// AccessRightManage allows managing the hub, it requires Listen and Send to be set as well.
+	AccessRightManage = "Manage"
+)
+
+// AuthorizationRuleKeys holds the keys and connection strings of a shared access policy.
+type AuthorizationRuleKeys struct {
+	KeyName                   string `json:"keyName"`
+	PrimaryKey                string `json:"primaryKey"`
+	SecondaryKey              string `json:"secondaryKey"`
+	PrimaryConnectionString   string `json:"primaryConnectionString"`
+	SecondaryConnectionString string `json:"secondaryConnectionString"`
+}
+
+// ConnectionString returns the primary or secondary connection string.
+func (k AuthorizationRuleKeys) ConnectionString(which KeyKind) string {
+	if which == SecondaryKey {
+		return k.SecondaryConnectionString
+	}
+
+	return k.PrimaryConnectionString
+}
+
+// KeyKind selects the primary or secondary key of a shared access policy.
+type KeyKind string
+
+const (
+	// PrimaryKey is the primary key of a shared access policy.
+	PrimaryKey KeyKind = "PrimaryKey"
+	// SecondaryKey is the secondary key of a shared access policy.
+	SecondaryKey KeyKind = "SecondaryKey"
+)
+
+// ManagementError is returned when Azure Resource Manager responds with a non-successful status code.
+type ManagementError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *ManagementError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("management request failed with status: %d: %s", e.StatusCode, e.Message)
+	}
+
+	return fmt.Sprintf("management request failed with status: %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// GetNamespace returns the configured namespace's information.
+func (mc *ManagementClient) GetNamespace(ctx context.Context) (*Namespace, error) {
+	var ns Namespace
+	if err := mc.do(ctx, http.MethodGet, mc.namespacePath(), nil, &ns); err != nil {
+		return nil, err
+	}
+
+	return &ns, nil
+}
+
+// CheckNamespaceAvailability reports whether the given namespace name is available for creation.
+func (mc *ManagementClient) CheckNamespaceAvailability(ctx context.Context, name string) (bool, error) {
+	if name == "" {
+		return false, errors.New("namespace name cannot be empty")
+	}
+
+	path := fmt.Sprintf("/subscriptions/%s/providers/Microsoft.NotificationHubs/checkNamespaceAvailability",
+		url.PathEscape(mc.Config.SubscriptionID))
+
+	var result NamespaceAvailability
+	if err := mc.do(ctx, http.MethodPost, path, map[string]string{"name": name}, &result); err != nil {
+		return false, err
+	}
+
+	return result.Available, nil
+}
+
+// ListNamespaceAuthorizationRules returns the shared access policies defined on the namespace.
+func (mc *ManagementClient) ListNamespaceAuthorizationRules(ctx context.Context) ([]AuthorizationRule, error) {
+	return listAll[AuthorizationRule](ctx, mc, mc.namespacePath()+"/authorizationRules")
+}
+
+// ListAuthorizationRules returns the shared access policies defined on the configured hub.
+func (mc *ManagementClient) ListAuthorizationRules(ctx context.Context) ([]AuthorizationRule, error) {
+	path, err := mc.hubPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return listAll[AuthorizationRule](ctx, mc, path+"/authorizationRules")
+}
+
+// GetAuthorizationRule returns a shared access policy of the configured hub by its name.
+func (mc *ManagementClient) GetAuthorizationRule(ctx context.Context, ruleName string) (*AuthorizationRule, error) {
+	path, err := mc.authorizationRulePath(ruleName)
+	if err != nil {
+		return nil, err
+	}
+
+	var rule AuthorizationRule
+	if err := mc.do(ctx, http.MethodGet, path, nil, &rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// CreateOrUpdateAuthorizationRule creates (or replaces the rights of) a shared access policy on the configured hub.
+//
+// Example of a send-only policy for a consuming service:
+//
+//	rule, err := mc.CreateOrUpdateAuthorizationRule(ctx, "orders-service", azurepush.AccessRightSend)
+func (mc *ManagementClient) CreateOrUpdateAuthorizationRule(ctx context.Context, ruleName string, rights ...string) (*AuthorizationRule, error) {
+	path, err := mc.authorizationRulePath(ruleName)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rights) == 0 {
+		return nil, errors.New("at least one access right is required")
+	}
+
+	for _, right := range rights {
+		switch right {
+		case AccessRightListen, AccessRightSend, AccessRightManage:
+		default:
+			return nil, fmt.Errorf("invalid access right: %q (must be 'Listen', 'Send' or 'Manage')", right)
+		}
+	}
+
+	body := map[string]any{
+		"properties": map[string]any{
+			"rights": rights,
+		},
+	}
+
+	var rule AuthorizationRule
+	if err := mc.do(ctx, http.MethodPut, path, body, &rule); err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// DeleteAuthorizationRule deletes a shared access policy from the configured hub.
+//
+// Like DeleteDevice, this operation is idempotent: a missing policy is not reported as an error.
+func (mc *ManagementClient) DeleteAuthorizationRule(ctx context.Context, ruleName string) error {
+	path, err := mc.authorizationRulePath(ruleName)
+	if err != nil {
+		return err
+	}
+
+	err = mc.do(ctx, http.MethodDelete, path, nil, nil)
+	if mErr, ok := errors.AsType[*ManagementError](err); ok && mErr.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
+	return err
+}
+
+// ListKeys returns the keys and connection strings of a shared access policy of the configured hub.
+func (mc *ManagementClient) ListKeys(ctx context.Context, ruleName string) (*AuthorizationRuleKeys, error) {
+	path, err := mc.authorizationRulePath(ruleName)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys AuthorizationRuleKeys
+	if err := mc.do(ctx, http.MethodPost, path+"/listKeys", nil, &keys); err != nil {
+		return nil, err
+	}
+
+	return &keys, nil
+}
+
+// RegenerateKey regenerates the primary or secondary key of a shared access policy of the configured hub
+// and returns the policy's new keys.
+//
+// Rotate one key at a time and feed the new connection string into the data-plane client:
+//
+//	keys, err := mc.RegenerateKey(ctx, "DefaultFullSharedAccessSignature", azurepush.SecondaryKey)
+//	if err != nil {
+//		return err
+//	}
+//	cfg := client.Config
+//	cfg.ConnectionString = keys.ConnectionString(azurepush.SecondaryKey)
+//	err = client.RotateCredentials(ctx, cfg) // verifies the new key before switching, see Client.RotateCredentials.
+func (mc *ManagementClient) RegenerateKey(ctx context.Context, ruleName string, which KeyKind) (*AuthorizationRuleKeys, error) {
+	switch which {
+	case PrimaryKey, SecondaryKey:
+	default:
+		return nil, fmt.Errorf("invalid key kind: %q (must be 'PrimaryKey' or 'SecondaryKey')", which)
+	}
+
+	path, err := mc.authorizationRulePath(ruleName)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys AuthorizationRuleKeys
+	if err := mc.do(ctx, http.MethodPost, path+"/regenerateKeys", map[string]KeyKind{"policyKey": which}, &keys); err != nil {
+		return nil, err
+	}
+
+	return &keys, nil
+}
+
+func (mc *ManagementClient) authorizationRulePath(ruleName string) (string, error) {
+	if ruleName == "" {
+		return "", errors.New("authorization rule name cannot be empty")
+	}
+
+	path, err := mc.hubPath()
+	if err != nil {
+		return "", err
+	}
+
+	return path + "/authorizationRules/" + url.PathEscape(ruleName), nil
+}
+
+func (mc *ManagementClient) hubPath() (string, error) {
+	if mc.Config.HubName == "" {
+		return "", errors.New("missing Azure hub name")
+	}
+
+	return mc.namespacePath() + "/notificationHubs/" + url.PathEscape(mc.Config.HubName), nil
+}
+
+func (mc *ManagementClient) namespacePath() string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.NotificationHubs/namespaces/%s",
+		url.PathEscape(mc.Config.SubscriptionID),
+		url.PathEscape(mc.Config.ResourceGroup),
+		url.PathEscape(mc.Config.Namespace),
+	)
+}
+
+type listResult[T any] struct {
+	Value    []T    `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+// listAll follows the nextLink of an Azure Resource Manager list operation until all pages are read.
+func listAll[T any](ctx context.Context, mc *ManagementClient, path string) ([]T, error) {
+	var all []T
+	for path != "" {
+		var page listResult[T]
+		if err := mc.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Value...)
+		path = page.NextLink
+	}
+
+	return all, nil
+}
+
+// do sends a request to Azure Resource Manager and decodes the JSON response into dest (if not nil).
+// The path may be relative to the Endpoint or an absolute URL (e.g. a nextLink).
+func (mc *ManagementClient) do(ctx context.Context, method, path string, body, dest any) error {
+	token, err := mc.Tokens.AccessToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	u, err := url.Parse(path)
+	if err != nil {
+		return fmt.Errorf("invalid management path: %w", err)
+	}
+	if !u.IsAbs() {
+		endpoint := mc.Endpoint
+		if endpoint == "" {
+			endpoint = DefaultManagementEndpoint
+		}
+		if u, err = url.Parse(endpoint + path); err != nil {
+			return fmt.Errorf("invalid management endpoint: %w", err)
+		}
+	}
+	if u.Query().Get("api-version") == "" {
+		q := u.Query()
+		q.Set("api-version", managementAPIVersion)
+		u.RawQuery = q.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := mc.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return newManagementError(resp)
+	}
+
+	if dest == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil && err != io.EOF {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+func newManagementError(resp *http.Response) error {
+	b, _ := io.ReadAll(resp.Body)
+
+	var errResp struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(b, &errResp) == nil && errResp.Error.Code != "" {
+		return &ManagementError{StatusCode: resp.StatusCode, Code: errResp.Error.Code, Message: errResp.Error.Message}
+	}
+
+	return &ManagementError{StatusCode: resp.StatusCode, Message: string(b)}
+}
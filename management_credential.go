@@ -0,0 +1,83 @@
+package azurepush
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// CredentialTokenProvider is an AccessTokenProvider backed by an azcore.TokenCredential,
+// e.g. a service principal or a managed identity created through the azidentity package.
+// Tokens are cached and refreshed 5 minutes before they expire.
+//
+// The data-plane Client is not affected, it keeps signing its requests with SAS tokens.
+type CredentialTokenProvider struct {
+	credential azcore.TokenCredential
+	scopes     []string
+
+	token azcore.AccessToken
+	mutex sync.Mutex
+}
+
+var _ AccessTokenProvider = (*CredentialTokenProvider)(nil)
+
+// NewCredentialTokenProvider creates a new AccessTokenProvider for the given credential.
+// The scope defaults to the "/.default" scope of the DefaultManagementEndpoint.
+func NewCredentialTokenProvider(credential azcore.TokenCredential, scopes ...string) *CredentialTokenProvider {
+	if credential == nil {
+		panic("azurepush: nil token credential")
+	}
+
+	if len(scopes) == 0 {
+		scopes = []string{managementScope(DefaultManagementEndpoint)}
+	}
+
+	return &CredentialTokenProvider{credential: credential, scopes: scopes}
+}
+
+// AccessToken implements the AccessTokenProvider interface.
+func (p *CredentialTokenProvider) AccessToken(ctx context.Context) (string, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.token.Token == "" || time.Now().After(p.token.ExpiresOn.Add(-5*time.Minute)) {
+		token, err := p.credential.GetToken(ctx, policy.TokenRequestOptions{Scopes: p.scopes})
+		if err != nil {
+			return "", fmt.Errorf("failed to acquire Azure AD token: %w", err)
+		}
+		p.token = token
+	}
+
+	return p.token.Token, nil
+}
+
+// NewManagementClientWithCredential creates a new ManagementClient which authenticates
+// against Azure Resource Manager with the given credential.
+// The configuration's SubscriptionID, ResourceGroup and Namespace fields are required.
+//
+// Example:
+//
+//	cred, err := azidentity.NewDefaultAzureCredential(nil)
+//	if err != nil {
+//		panic(err)
+//	}
+//	mc := azurepush.NewManagementClientWithCredential(cfg, cred)
+func NewManagementClientWithCredential(cfg Configuration, credential azcore.TokenCredential) *ManagementClient {
+	endpoint := DefaultManagementEndpoint
+	if cfg.ManagementEndpoint != "" {
+		endpoint = cfg.ManagementEndpoint
+	}
+
+	mc := NewManagementClient(cfg, NewCredentialTokenProvider(credential, managementScope(endpoint)))
+	mc.Endpoint = endpoint
+	return mc
+}
+
+func managementScope(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/") + "/.default"
+}
@@ -0,0 +1,46 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/kataras/azurepush"
+)
+
+type testCredential struct {
+	calls int
+}
+
+func (c *testCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	c.calls++
+	if len(opts.Scopes) != 1 || opts.Scopes[0] != "https://management.azure.com/.default" {
+		return azcore.AccessToken{}, context.Canceled
+	}
+
+	return azcore.AccessToken{Token: "aad-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestNewManagementClientWithCredential(t *testing.T) {
+	cred := new(testCredential)
+	mc := azurepush.NewManagementClientWithCredential(testManagementConfiguration, cred)
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		if got := r.Header.Get("Authorization"); got != "Bearer aad-token" {
+			t.Errorf("expected bearer authorization, got: %s", got)
+		}
+		return jsonResponse(http.StatusOK, `{"name":"namespace"}`)
+	})
+
+	for range 2 {
+		if _, err := mc.GetNamespace(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if cred.calls != 1 {
+		t.Errorf("expected the access token to be cached, got %d credential calls", cred.calls)
+	}
+}
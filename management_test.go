@@ -0,0 +1,206 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+var testManagementConfiguration = azurepush.Configuration{
+	HubName:        "hub",
+	Namespace:      "namespace",
+	SubscriptionID: "sub",
+	ResourceGroup:  "rg",
+}
+
+func testAccessTokens() azurepush.AccessTokenProvider {
+	return azurepush.AccessTokenProviderFunc(func(ctx context.Context) (string, error) {
+		return "arm-token", nil
+	})
+}
+
+func jsonResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+	}
+}
+
+func TestManagementClient_GetNamespace_Mocked(t *testing.T) {
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		if got := r.Header.Get("Authorization"); got != "Bearer arm-token" {
+			t.Errorf("expected bearer authorization, got: %s", got)
+		}
+		expectedPath := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.NotificationHubs/namespaces/namespace"
+		if r.URL.Path != expectedPath {
+			t.Errorf("expected path %s, got: %s", expectedPath, r.URL.Path)
+		}
+		if r.URL.Query().Get("api-version") == "" {
+			t.Error("expected api-version query parameter")
+		}
+		return jsonResponse(http.StatusOK, `{"name":"namespace","location":"West Europe","sku":{"name":"Standard"},"properties":{"status":"Active","enabled":true}}`)
+	})
+
+	ns, err := mc.GetNamespace(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ns.Sku.Name != "Standard" || ns.Properties.Status != "Active" || !ns.Properties.Enabled {
+		t.Errorf("unexpected namespace: %+v", ns)
+	}
+}
+
+func TestManagementClient_CheckNamespaceAvailability_Mocked(t *testing.T) {
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/checkNamespaceAvailability") {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		return jsonResponse(http.StatusOK, `{"name":"newnamespace","isAvailiable":true}`)
+	})
+
+	available, err := mc.CheckNamespaceAvailability(context.Background(), "newnamespace")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !available {
+		t.Error("expected namespace to be available")
+	}
+}
+
+func TestManagementClient_ListNamespaceAuthorizationRules_Paged(t *testing.T) {
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		if r.URL.Query().Get("page") == "2" {
+			return jsonResponse(http.StatusOK, `{"value":[{"name":"send","properties":{"rights":["Send"]}}]}`)
+		}
+		return jsonResponse(http.StatusOK, `{"value":[{"name":"RootManageSharedAccessKey","properties":{"rights":["Listen","Manage","Send"]}}],"nextLink":"https://management.azure.com/next?page=2&api-version=2023-09-01"}`)
+	})
+
+	rules, err := mc.ListNamespaceAuthorizationRules(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got: %d", len(rules))
+	}
+	if rules[1].Name != "send" || rules[1].Properties.Rights[0] != "Send" {
+		t.Errorf("unexpected second rule: %+v", rules[1])
+	}
+}
+
+func TestManagementClient_Error(t *testing.T) {
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusNotFound, `{"error":{"code":"ResourceNotFound","message":"namespace not found"}}`)
+	})
+
+	_, err := mc.GetNamespace(context.Background())
+	var mErr *azurepush.ManagementError
+	if !errors.As(err, &mErr) {
+		t.Fatalf("expected a management error, got: %v", err)
+	}
+	if mErr.StatusCode != http.StatusNotFound || mErr.Code != "ResourceNotFound" {
+		t.Errorf("unexpected management error: %+v", mErr)
+	}
+}
+
+func TestManagementClient_AuthorizationRules_Mocked(t *testing.T) {
+	var requests []string
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		requests = append(requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.NotificationHubs/namespaces/namespace"))
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != `{"properties":{"rights":["Send"]}}` {
+				t.Errorf("unexpected create body: %s", body)
+			}
+			return jsonResponse(http.StatusOK, `{"name":"orders","properties":{"rights":["Send"]}}`)
+		case http.MethodDelete:
+			return jsonResponse(http.StatusNotFound, `{"error":{"code":"NotFound","message":"not found"}}`)
+		default:
+			return jsonResponse(http.StatusOK, `{"value":[{"name":"orders","properties":{"rights":["Send"]}}]}`)
+		}
+	})
+
+	ctx := context.Background()
+	rule, err := mc.CreateOrUpdateAuthorizationRule(ctx, "orders", azurepush.AccessRightSend)
+	if err != nil {
+		t.Fatalf("unexpected error creating rule: %v", err)
+	}
+	if rule.Name != "orders" {
+		t.Errorf("expected rule name 'orders', got: %s", rule.Name)
+	}
+
+	rules, err := mc.ListAuthorizationRules(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error listing rules: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Properties.Rights[0] != azurepush.AccessRightSend {
+		t.Errorf("unexpected rules: %+v", rules)
+	}
+
+	if err = mc.DeleteAuthorizationRule(ctx, "orders"); err != nil {
+		t.Fatalf("expected missing rule deletion to succeed, got: %v", err)
+	}
+
+	expected := []string{
+		"PUT /notificationHubs/hub/authorizationRules/orders",
+		"GET /notificationHubs/hub/authorizationRules",
+		"DELETE /notificationHubs/hub/authorizationRules/orders",
+	}
+	if strings.Join(requests, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected requests:\n%s", strings.Join(requests, "\n"))
+	}
+
+	if _, err = mc.CreateOrUpdateAuthorizationRule(ctx, "orders", "Write"); err == nil {
+		t.Error("expected error for invalid access right")
+	}
+}
+
+func TestManagementClient_RegenerateKey_Reload(t *testing.T) {
+	const newConnectionString = "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=rotated"
+
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.HasSuffix(r.URL.Path, "/authorizationRules/DefaultFullSharedAccessSignature/regenerateKeys") {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if string(body) != `{"policyKey":"SecondaryKey"}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+		return jsonResponse(http.StatusOK, `{"keyName":"DefaultFullSharedAccessSignature","secondaryKey":"rotated","secondaryConnectionString":"`+newConnectionString+`"}`)
+	})
+
+	keys, err := mc.RegenerateKey(context.Background(), "DefaultFullSharedAccessSignature", azurepush.SecondaryKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+
+	cfg := client.Config
+	cfg.ConnectionString = keys.ConnectionString(azurepush.SecondaryKey)
+	if err = client.Reload(cfg); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+	if client.Config.KeyValue != "rotated" {
+		t.Errorf("expected reloaded key value 'rotated', got: %s", client.Config.KeyValue)
+	}
+
+	if _, err = mc.RegenerateKey(context.Background(), "DefaultFullSharedAccessSignature", "TertiaryKey"); err == nil {
+		t.Error("expected error for invalid key kind")
+	}
+}
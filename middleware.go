@@ -0,0 +1,292 @@
+package azurepush
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps an http.Handler, e.g. the RegistrationHandler, see Chain.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps the handler with the middlewares, the first one is the outermost.
+// The middlewares of this package protect endpoints exposed to mobile apps:
+// authentication (APIKeyAuth, BearerAuth), per-client rate limiting (RateLimit)
+// and request validation (ValidateJSON, ValidateRegistrationRequest).
+//
+// Example usage:
+//
+//	http.Handle("POST /devices", azurepush.Chain(
+//		azurepush.RegistrationHandler(client, azurepush.RegistrationHandlerOptions{}),
+//		azurepush.BearerAuth(verifyJWT),
+//		azurepush.RateLimit(azurepush.RateLimitOptions{RequestsPerSecond: 1, Burst: 5}),
+//		azurepush.ValidateRegistrationRequest(0),
+//	))
+func Chain(h http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
+	return h
+}
+
+type subjectContextKey struct{}
+
+// Subject returns the subject of the request authenticated by BearerAuth or APIKeyAuth, if any.
+func Subject(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectContextKey{}).(string)
+	return subject
+}
+
+// BearerAuth returns a middleware which authenticates the "Authorization: Bearer <token>" header of the requests
+// with the verify hook, e.g. the signature and claims validation of a JWT by the application's identity library.
+// The returned subject (e.g. the user ID of the token) is stored in the request's context, see Subject.
+// A missing token or a verify error responds with 401 Unauthorized.
+//
+// Example usage:
+//
+//	auth := azurepush.BearerAuth(func(ctx context.Context, token string) (string, error) {
+//		claims, err := jwtVerifier.Verify(ctx, token)
+//		if err != nil {
+//			return "", err
+//		}
+//		return claims.Subject, nil
+//	})
+func BearerAuth(verify func(ctx context.Context, token string) (subject string, err error)) Middleware {
+	if verify == nil {
+		panic("azurepush: nil verify function")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				writeHandlerError(w, http.StatusUnauthorized, errors.New("missing bearer token"))
+				return
+			}
+
+			subject, err := verify(r.Context(), token)
+			if err != nil {
+				writeHandlerError(w, http.StatusUnauthorized, errors.New("invalid bearer token"))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), subjectContextKey{}, subject)))
+		})
+	}
+}
+
+// APIKeyAuth returns a BearerAuth middleware which accepts the given API keys, compared in constant time.
+// The subject of a request is "apikey:" followed by a short hash of its key,
+// so the clients can be rate limited apart without exposing their keys.
+// It panics without keys.
+func APIKeyAuth(keys ...string) Middleware {
+	if len(keys) == 0 {
+		panic("azurepush: no API keys")
+	}
+
+	return BearerAuth(func(_ context.Context, token string) (string, error) {
+		valid := false
+		for _, key := range keys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1 {
+				valid = true
+			}
+		}
+
+		if !valid {
+			return "", errors.New("invalid API key")
+		}
+
+		sum := sha256.Sum256([]byte(token))
+		return "apikey:" + hex.EncodeToString(sum[:6]), nil
+	})
+}
+
+// RateLimitOptions holds the settings of the RateLimit middleware.
+type RateLimitOptions struct {
+	// RequestsPerSecond is the sustained rate of the requests of each client.
+	//
+	// Required.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests a client can send at once after being idle.
+	//
+	// Defaults to the rounded up RequestsPerSecond.
+	Burst int
+
+	// Key returns the client of a request.
+	//
+	// Defaults to the authenticated Subject, or the remote IP address of unauthenticated requests
+	// (the forwarding headers are not trusted, set Key to use them behind a proxy).
+	Key func(r *http.Request) string
+}
+
+// RateLimit returns a middleware which limits the requests of each client with a token bucket,
+// the requests over the limit are responded with 429 Too Many Requests and a Retry-After header.
+// Place it after the authentication middleware, so the clients are told apart by their Subject.
+// It panics if RequestsPerSecond is not positive.
+func RateLimit(opts RateLimitOptions) Middleware {
+	if opts.RequestsPerSecond <= 0 {
+		panic("azurepush: rate limit requires a positive RequestsPerSecond")
+	}
+
+	if opts.Burst <= 0 {
+		opts.Burst = int(math.Ceil(opts.RequestsPerSecond))
+	}
+
+	if opts.Key == nil {
+		opts.Key = rateLimitKey
+	}
+
+	limiter := &clientRateLimiter{opts: opts, buckets: make(map[string]*tokenBucket)}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait, ok := limiter.allow(opts.Key(r), time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeHandlerError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitKey(r *http.Request) string {
+	if subject := Subject(r.Context()); subject != "" {
+		return subject
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// clientRateLimiter holds the token buckets of the clients of the RateLimit middleware.
+type clientRateLimiter struct {
+	opts RateLimitOptions
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// allow takes a token of the client's bucket, or returns the wait until the next one.
+func (l *clientRateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.opts.Burst), last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = min(float64(l.opts.Burst), bucket.tokens+now.Sub(bucket.last).Seconds()*l.opts.RequestsPerSecond)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return time.Duration((1 - bucket.tokens) / l.opts.RequestsPerSecond * float64(time.Second)), false
+	}
+
+	bucket.tokens--
+	return 0, true
+}
+
+// sweep removes the buckets refilled since, once a minute, so the idle clients do not hold memory.
+func (l *clientRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(float64(l.opts.Burst) / l.opts.RequestsPerSecond * float64(time.Second))
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// ValidateJSON returns a middleware which validates the JSON body of the requests before the handler reads it:
+// the body must be at most maxBodySize bytes (defaults to DefaultMaxRegistrationBodySize),
+// hold a single JSON value of T without unknown fields and pass the validate function (if not nil).
+// Invalid requests are responded with 400 Bad Request, or 413 Request Entity Too Large,
+// and the next handler receives the validated body.
+//
+// Example usage:
+//
+//	azurepush.ValidateJSON(4<<10, func(req *api.SendRequest) error {
+//		if req.Notification.Title == nil && req.Notification.Body == nil {
+//			return errors.New("notification title or body is required")
+//		}
+//		return nil
+//	})
+func ValidateJSON[T any](maxBodySize int64, validate func(*T) error) Middleware {
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxRegistrationBodySize
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if err != nil {
+				if _, ok := errors.AsType[*http.MaxBytesError](err); ok {
+					writeHandlerError(w, http.StatusRequestEntityTooLarge, err)
+					return
+				}
+				writeHandlerError(w, http.StatusBadRequest, fmt.Errorf("failed to read body: %w", err))
+				return
+			}
+
+			var v T
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.DisallowUnknownFields()
+			if err = dec.Decode(&v); err == nil && dec.More() {
+				err = errors.New("unexpected data after the JSON value")
+			}
+			if err == nil && validate != nil {
+				err = validate(&v)
+			}
+			if err != nil {
+				writeHandlerError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %w", err))
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ValidateRegistrationRequest returns a ValidateJSON middleware of the RegistrationRequest bodies,
+// see RegistrationRequest.Validate.
+func ValidateRegistrationRequest(maxBodySize int64) Middleware {
+	return ValidateJSON(maxBodySize, func(req *RegistrationRequest) error {
+		return req.Validate()
+	})
+}
@@ -0,0 +1,115 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestChain(t *testing.T) {
+	var order []string
+	middleware := func(name string) azurepush.Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	h := azurepush.Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { order = append(order, "handler") }), middleware("a"), middleware("b"))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Join(order, ",") != "a,b,handler" {
+		t.Fatalf("unexpected order: %v", order)
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	h := azurepush.BearerAuth(func(_ context.Context, token string) (string, error) {
+		if token != "jwt" {
+			return "", errors.New("invalid signature")
+		}
+		return "user-42", nil
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, azurepush.Subject(r.Context()))
+	}))
+
+	for token, expected := range map[string]int{"": http.StatusUnauthorized, "forged": http.StatusUnauthorized, "jwt": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != expected {
+			t.Errorf("token %q: expected status %d, got: %d", token, expected, rec.Code)
+		}
+		if expected == http.StatusOK && rec.Body.String() != "user-42" {
+			t.Errorf("expected the subject, got: %s", rec.Body)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := azurepush.Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		azurepush.APIKeyAuth("a", "b"),
+		azurepush.RateLimit(azurepush.RateLimitOptions{RequestsPerSecond: 0.1, Burst: 2}),
+	)
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, expected := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if rec := do("a"); rec.Code != expected {
+			t.Fatalf("request %d: expected status %d, got: %d", i, expected, rec.Code)
+		}
+	}
+
+	if rec := do("a"); rec.Header().Get("Retry-After") != "10" {
+		t.Errorf("expected a 10s Retry-After, got: %q", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := do("b"); rec.Code != http.StatusOK {
+		t.Errorf("expected the clients limited apart, got: %d", rec.Code)
+	}
+}
+
+func TestValidateRegistrationRequest(t *testing.T) {
+	var body string
+	h := azurepush.ValidateRegistrationRequest(128)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+
+	for input, expected := range map[string]int{
+		`{"platform":"apns","pushChannel":"token","tags":["user:1"]}`:          http.StatusOK,
+		`{"platform":"apns","pushChannel":"token","os":"ios"}`:                 http.StatusBadRequest,
+		`{"platform":"apns","pushChannel":"token"} {}`:                         http.StatusBadRequest,
+		`{"platform":"symbian","pushChannel":"token"}`:                         http.StatusBadRequest,
+		`{"platform":"apns","pushChannel":"token","tags":["bad tag"]}`:         http.StatusBadRequest,
+		`{"platform":"apns","pushChannel":"` + strings.Repeat("x", 128) + `"}`: http.StatusRequestEntityTooLarge,
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(input)))
+
+		if rec.Code != expected {
+			t.Errorf("%s: expected status %d, got: %d: %s", input, expected, rec.Code, rec.Body)
+		}
+		if expected == http.StatusOK && body != input {
+			t.Errorf("expected the validated body passed to the handler, got: %s", body)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package azurepush_test
+
+// raceEnabled reports whether the tests run with the race detector,
+// which adds allocations.
+const raceEnabled = false
@@ -0,0 +1,767 @@
+package azurepush
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Notification holds the title, body and custom data for a notification sent to both iOS and Android.
+type Notification struct {
+	Title string
+	Body  string
+	Data  map[string]any // any custom data.
+
+	// ImageURL is the URL of an image displayed with the notification (big picture), it should be HTTPS.
+	// It is sent as the FCM v1 notification image and the hero image of Windows toasts.
+	// Apple devices receive it as the AppleImageDataKey custom data field with "mutable-content",
+	// so the app's Notification Service Extension can download and attach it.
+	ImageURL string `json:",omitempty"`
+
+	// Actions are the action buttons of the notification (e.g. "Accept" and "Decline"),
+	// see NotificationAction for how each platform receives them.
+	Actions []NotificationAction `json:",omitempty"`
+
+	// Group groups the notifications on the devices, e.g. the messages of a chat conversation ("chat:42").
+	// It is sent as the APNs "thread-id" and as the GroupDataKey custom data field to Android devices,
+	// whose app builds the notification group (NotificationCompat.Builder.setGroup).
+	// FCM v1 notifications also carry it as their android.notification.tag, so a newer notification
+	// of the group replaces the previous one in the drawer when the app is in the background.
+	Group string `json:",omitempty"`
+	// Summary describes the group (e.g. "Maria"), it requires a Group.
+	// It is sent as the APNs alert "summary-arg" (e.g. "3 more messages from Maria")
+	// and as the GroupSummaryDataKey custom data field to Android devices, for the group summary notification.
+	Summary string `json:",omitempty"`
+
+	// Apple holds the optional fields of the APNs alert dictionary (e.g. the subtitle),
+	// sent along with the Title and Body to Apple devices only.
+	Apple *AppleAlert `json:",omitempty"`
+	// Android holds the optional FCM v1 settings (e.g. the analytics label),
+	// sent to Android devices only.
+	Android *AndroidOptions `json:",omitempty"`
+}
+
+// AppleAlert holds the fields of the APNs alert dictionary besides its title and body.
+// See https://developer.apple.com/documentation/usernotifications/generating-a-remote-notification.
+//
+// Example usage:
+//
+//	notification := azurepush.Notification{
+//		Title: "Order shipped",
+//		Body:  "Your order is on its way",
+//		Apple: &azurepush.AppleAlert{Subtitle: "Order #1234"},
+//	}
+type AppleAlert struct {
+	// Subtitle is displayed below the title.
+	Subtitle string `json:"subtitle,omitempty"`
+	// LaunchImage is the file name of the image displayed while the app launches from the notification.
+	LaunchImage string `json:"launch-image,omitempty"`
+
+	// TitleLocKey is the key of the localized title in the app's Localizable.strings,
+	// formatted with the TitleLocArgs. The Title is displayed on devices without the key.
+	TitleLocKey  string   `json:"title-loc-key,omitempty"`
+	TitleLocArgs []string `json:"title-loc-args,omitempty"`
+	// SubtitleLocKey is the key of the localized subtitle, formatted with the SubtitleLocArgs.
+	SubtitleLocKey  string   `json:"subtitle-loc-key,omitempty"`
+	SubtitleLocArgs []string `json:"subtitle-loc-args,omitempty"`
+	// LocKey is the key of the localized body, formatted with the LocArgs.
+	LocKey  string   `json:"loc-key,omitempty"`
+	LocArgs []string `json:"loc-args,omitempty"`
+}
+
+// AndroidOptions holds the optional FCM v1 settings of a notification.
+//
+// Example usage:
+//
+//	notification := azurepush.Notification{
+//		Title:   "Spring sale",
+//		Body:    "Everything is 20% off today",
+//		Android: &azurepush.AndroidOptions{AnalyticsLabel: "spring_sale"},
+//	}
+type AndroidOptions struct {
+	// AnalyticsLabel segments the notification in the Firebase analytics (fcm_options.analytics_label).
+	// It has at most 50 letters, digits and the '-', '_', '.', '~', '%' characters.
+	AnalyticsLabel string `json:"analyticsLabel,omitempty"`
+}
+
+// maxAnalyticsLabelLength is the maximum length of the FCM analytics label.
+const maxAnalyticsLabelLength = 50
+
+// localized reports whether the alert has a localized title or body.
+func (a *AppleAlert) localized() bool {
+	return a != nil && (a.TitleLocKey != "" || a.LocKey != "")
+}
+
+// Platform is a notification format of Azure Notification Hubs,
+// sent as the ServiceBusNotification-Format header.
+// See InstallationPlatform for the platforms of the device installations.
+type Platform string
+
+const (
+	// PlatformApple is the notification format for Apple devices (APNs).
+	PlatformApple Platform = "apple"
+	// PlatformFCMV1 is the notification format for Android devices (Firebase Cloud Messaging v1).
+	PlatformFCMV1 Platform = "fcmV1"
+	// PlatformBaidu is the notification format for Baidu Push devices.
+	PlatformBaidu Platform = "baidu"
+	// PlatformWindows is the notification format for Windows Notification Service devices.
+	PlatformWindows Platform = "windows"
+	// PlatformWindowsPhone is the notification format for Microsoft Push Notification Service devices.
+	PlatformWindowsPhone Platform = "windowsphone"
+	// PlatformADM is the notification format for Amazon Device Messaging (Fire OS) devices.
+	PlatformADM Platform = "adm"
+)
+
+// InstallationPlatform returns the installation platform of the format's devices,
+// e.g. InstallationApple for PlatformApple, or an empty InstallationPlatform for an unknown format.
+//
+// Notifications are encoded for PlatformApple and PlatformFCMV1 only.
+func (p Platform) InstallationPlatform() InstallationPlatform {
+	switch p {
+	case PlatformApple:
+		return InstallationApple
+	case PlatformFCMV1:
+		return InstallationFCMV1
+	case PlatformBaidu:
+		return InstallationBaidu
+	case PlatformWindows:
+		return InstallationWNS
+	case PlatformWindowsPhone:
+		return InstallationMPNS
+	case PlatformADM:
+		return InstallationADM
+	default:
+		return ""
+	}
+}
+
+// availablePlatforms are the platforms a notification is sent to by SendNotification by default,
+// see Configuration.SendPlatforms.
+var availablePlatforms = []Platform{PlatformApple, PlatformFCMV1}
+
+// sendablePlatforms are the platforms a notification can be encoded for.
+var sendablePlatforms = []Platform{PlatformApple, PlatformFCMV1, PlatformADM, PlatformWindows}
+
+// MarshalFor returns exactly the payload bytes and headers that are sent to the hub
+// for the given platform, without sending anything.
+// The Authorization and ServiceBusNotification-Tags headers are not included.
+//
+// It is useful for golden-file tests, payload size checks and debugging:
+//
+//	payload, headers, err := notification.MarshalFor(azurepush.PlatformApple)
+//
+// PlatformWindows returns the WNS toast XML of the notification (title, body, image and actions),
+// sent by SendNotification when enabled by Configuration.SendPlatforms.
+func (n Notification) MarshalFor(platform Platform) ([]byte, map[string]string, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 512))
+	if err := n.encode(platform, buf); err != nil {
+		return nil, nil, err
+	}
+
+	headers := map[string]string{"ServiceBusNotification-Format": string(platform)}
+	setPlatformHeaders(func(key, value string) { headers[key] = value }, platform)
+
+	return buf.Bytes(), headers, nil
+}
+
+// ErrInvalidNotification is returned (wrapped) by Notification.Validate and the sends of invalid notifications.
+var ErrInvalidNotification = errors.New("invalid notification")
+
+// fcmReservedDataKeys are the data keys rejected by FCM, along with the "google." and "gcm." prefixes.
+var fcmReservedDataKeys = []string{"from", "notification", "message_type"}
+
+// Validate checks the notification against the rules of all the platforms it is sent to by SendNotification
+// by default (Apple and FCM v1), see ValidateFor. It is called by the send methods before any request,
+// so invalid notifications fail early. The Client validates against its Configuration.SendPlatforms.
+func (n Notification) Validate() error {
+	return n.validateFor(availablePlatforms)
+}
+
+// validateFor checks the notification against the rules of the platforms, see ValidateFor.
+func (n Notification) validateFor(platforms []Platform) error {
+	var errs []error
+	for _, platform := range platforms {
+		if err := n.ValidateFor(platform); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ValidateFor checks the notification against the rules of a platform:
+//
+//   - a notification without title and body is only valid as a silent one: with a custom "aps" data field
+//     (e.g. {"content-available": 1}) for Apple, with data for FCM v1. An Apple alert with a localized
+//     title or body (TitleLocKey or LocKey) needs no title and body;
+//   - the custom "aps" data field of Apple must be an object and it replaces the alert,
+//     so it cannot be combined with a title, a body or the Apple alert fields;
+//   - FCM v1 data keys cannot be reserved ("from", "notification", "message_type", "google." and "gcm." prefixes)
+//     and data values must be strings, numbers or booleans, nested values should be encoded as JSON strings
+//     (the Apple "aps" field is not checked, it is not sent to FCM);
+//   - the FCM v1 analytics label has at most 50 letters, digits and '-', '_', '.', '~', '%' characters;
+//   - the image URL is an absolute http or https URL;
+//   - the actions have an ID (without dots) and a title, their IDs are unique,
+//     and the FCM v1 data cannot hold the ActionsDataKey field as well;
+//   - a summary requires a group, the FCM v1 data cannot hold the GroupDataKey and GroupSummaryDataKey fields
+//     of a group;
+//   - a Windows toast needs a title or a body.
+//
+// The returned errors wrap ErrInvalidNotification.
+func (n Notification) ValidateFor(platform Platform) error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidNotification, platform, fmt.Sprintf(format, args...))
+	}
+
+	empty := n.Title == "" && n.Body == ""
+
+	if n.ImageURL != "" {
+		if u, err := url.Parse(n.ImageURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return invalid("image URL %q must be an absolute http or https URL", n.ImageURL)
+		}
+	}
+
+	if err := validateActions(n.Actions); err != nil {
+		return invalid("%v", err)
+	}
+
+	if n.Summary != "" && n.Group == "" {
+		return invalid("a summary requires a group")
+	}
+
+	switch platform {
+	case PlatformApple:
+		aps, ok := n.Data["aps"]
+		if !ok {
+			if empty && !n.Apple.localized() {
+				return invalid("title and body are empty: set one, or send a silent notification with a custom \"aps\" data field")
+			}
+			return nil
+		}
+
+		if !empty || n.Apple != nil {
+			return invalid("the custom \"aps\" data field replaces the alert of the title and body: clear them, or remove \"aps\"")
+		}
+
+		b, err := json.Marshal(aps)
+		if err != nil || len(b) == 0 || b[0] != '{' {
+			return invalid("the custom \"aps\" data field must be an object, got: %T", aps)
+		}
+	case PlatformFCMV1:
+		if empty && len(n.Data) == 0 {
+			return invalid("title and body are empty: set one, or send a data-only notification")
+		}
+
+		for key := range n.androidData() {
+			if _, ok := n.Data[key]; ok {
+				return invalid("data key %q is reserved for the notification fields", key)
+			}
+		}
+
+		if n.Android != nil && !validAnalyticsLabel(n.Android.AnalyticsLabel) {
+			return invalid("analytics label %q must have at most %d letters, digits and '-', '_', '.', '~', '%%' characters",
+				n.Android.AnalyticsLabel, maxAnalyticsLabelLength)
+		}
+
+		for key, value := range n.Data {
+			if key == "aps" {
+				continue // Apple's.
+			}
+
+			if key == "" || slices.Contains(fcmReservedDataKeys, key) || strings.HasPrefix(key, "google.") || strings.HasPrefix(key, "gcm.") {
+				return invalid("data key %q is reserved", key)
+			}
+
+			if !scalarDataValue(value) {
+				return invalid("data value of key %q is a %T: use a string, number or boolean (encode nested values as JSON strings)", key, value)
+			}
+		}
+	case PlatformADM:
+		if empty && len(n.Data) == 0 {
+			return invalid("title and body are empty: set one, or send a data-only notification")
+		}
+
+		for key := range n.admFields() {
+			if _, ok := n.Data[key]; ok {
+				return invalid("data key %q is reserved for the notification fields", key)
+			}
+		}
+
+		for key, value := range n.Data {
+			if key != "aps" && !scalarDataValue(value) {
+				return invalid("data value of key %q is a %T: use a string, number or boolean (encode nested values as JSON strings)", key, value)
+			}
+		}
+	case PlatformWindows:
+		if empty {
+			return invalid("title and body are empty: set one")
+		}
+	default:
+		return fmt.Errorf("%w: unsupported platform: %s", ErrInvalidNotification, platform)
+	}
+
+	return nil
+}
+
+// validAnalyticsLabel reports whether label is empty or a valid FCM analytics label.
+func validAnalyticsLabel(label string) bool {
+	if len(label) > maxAnalyticsLabelLength {
+		return false
+	}
+
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.~%", r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encode writes the platform-specific payload to buf.
+func (n Notification) encode(platform Platform, buf *bytes.Buffer) error {
+	enc := json.NewEncoder(buf)
+
+	var payload any
+	switch platform {
+	case PlatformApple:
+		// APNs supports custom fields alongside "aps",
+		// they are written directly instead of being copied to an intermediate map.
+		if err := n.encodeApple(enc, buf); err != nil {
+			return fmt.Errorf("failed to marshal payload for %s: %w", platform, err)
+		}
+		return nil
+	case PlatformFCMV1:
+		// FCMv1 requires message wrapper and string-only data values.
+		payload = &fcmV1NotificationPayload{Message: n.fcmV1Message()}
+	case PlatformWindows:
+		n.encodeWindowsToast(buf)
+		return nil
+	case PlatformADM:
+		// ADM has no notification object, the app displays the data.
+		payload = &admPayload{Data: n.admData()}
+	default:
+		return fmt.Errorf("unsupported platform: %s", platform)
+	}
+
+	if err := encodeJSON(enc, buf, payload); err != nil {
+		return fmt.Errorf("failed to marshal payload for %s: %w", platform, err)
+	}
+
+	return nil
+}
+
+// AppleImageDataKey is the custom data key of the image URL of Apple notifications, see Notification.ImageURL.
+const AppleImageDataKey = "image"
+
+// encodeApple writes the APNs payload: the "aps" dictionary followed by the custom data fields in key order.
+// A custom "aps" data field replaces the generated one.
+func (n Notification) encodeApple(enc *json.Encoder, buf *bytes.Buffer) error {
+	buf.WriteByte('{')
+
+	comma := false
+	if _, ok := n.Data["aps"]; !ok {
+		aps := appleAPS{
+			Alert: appleAlert{
+				notificationMessage: notificationMessage{Title: n.Title, Body: n.Body},
+				AppleAlert:          n.Apple,
+				SummaryArg:          n.Summary,
+			},
+			Sound:    "default",
+			Category: ActionCategory(n.Actions...),
+			ThreadID: n.Group,
+		}
+		if n.ImageURL != "" {
+			aps.MutableContent = 1 // lets the Notification Service Extension download the image.
+		}
+
+		buf.WriteString(`"aps":`)
+		if err := encodeJSON(enc, buf, &aps); err != nil {
+			return err
+		}
+		comma = true
+	}
+
+	keys := make([]string, 0, len(n.Data)+1)
+	for key := range n.Data {
+		keys = append(keys, key)
+	}
+	_, hasImageKey := n.Data[AppleImageDataKey]
+	if n.ImageURL != "" && !hasImageKey {
+		keys = append(keys, AppleImageDataKey)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if comma {
+			buf.WriteByte(',')
+		}
+		comma = true
+
+		value, ok := n.Data[key]
+		if !ok { // the image key.
+			value = n.ImageURL
+		}
+
+		if err := encodeJSON(enc, buf, key); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := encodeJSON(enc, buf, value); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteByte('}')
+	return nil
+}
+
+// fcmV1Message returns the FCM v1 message of the notification, without a target device token.
+func (n Notification) fcmV1Message() fcmV1Message {
+	var msg fcmV1Message
+	if n.Title != "" || n.Body != "" { // a data-only or silent notification has no notification to display.
+		msg.Notification = &fcmV1Notification{
+			notificationMessage: notificationMessage{Title: n.Title, Body: n.Body},
+			Image:               n.ImageURL,
+		}
+	}
+	data := toStringMap(n.Data)
+	for key, value := range n.androidData() {
+		if data == nil {
+			data = make(map[string]string, 3)
+		}
+		data[key] = value
+	}
+	if len(data) > 0 {
+		msg.Android = &fcmV1Android{
+			Data: data,
+		}
+		if n.Group != "" {
+			msg.Android.Notification.Tag = n.Group
+		}
+	}
+	if n.Android != nil && n.Android.AnalyticsLabel != "" {
+		msg.FCMOptions = &fcmV1Options{AnalyticsLabel: n.Android.AnalyticsLabel}
+	}
+
+	return msg
+}
+
+// Custom data keys of the group of Android notifications, see Notification.Group and Notification.Summary.
+const (
+	GroupDataKey        = "group"
+	GroupSummaryDataKey = "groupSummary"
+)
+
+// androidData returns the custom data fields of the notification fields sent to Android devices by convention:
+// the actions and the group.
+func (n Notification) androidData() map[string]string {
+	if len(n.Actions) == 0 && n.Group == "" {
+		return nil
+	}
+
+	data := make(map[string]string, 3)
+	if len(n.Actions) > 0 {
+		data[ActionsDataKey] = actionsData(n.Actions)
+	}
+	if n.Group != "" {
+		data[GroupDataKey] = n.Group
+	}
+	if n.Summary != "" {
+		data[GroupSummaryDataKey] = n.Summary
+	}
+
+	return data
+}
+
+// Custom data keys of the title and body of ADM notifications, see PlatformADM.
+const (
+	ADMTitleDataKey = "title"
+	ADMBodyDataKey  = "body"
+)
+
+// admData returns the data of the ADM payload: the custom data and the notification fields, see admFields.
+func (n Notification) admData() map[string]string {
+	data := toStringMap(n.Data)
+	if data == nil {
+		data = make(map[string]string, 2)
+	}
+
+	maps.Copy(data, n.admFields())
+	return data
+}
+
+// admFields returns the custom data fields of the notification fields sent to ADM devices by convention:
+// the title, the body and the Android conventions (see androidData).
+func (n Notification) admFields() map[string]string {
+	fields := n.androidData()
+	if fields == nil {
+		fields = make(map[string]string, 2)
+	}
+
+	if n.Title != "" {
+		fields[ADMTitleDataKey] = n.Title
+	}
+	if n.Body != "" {
+		fields[ADMBodyDataKey] = n.Body
+	}
+
+	return fields
+}
+
+// scalarDataValue reports whether a custom data value is a string, a number or a boolean.
+func scalarDataValue(value any) bool {
+	switch value.(type) {
+	case string, bool, json.Number,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// encodeWindowsToast writes the WNS toast XML payload: the title and body texts, the image as a hero image
+// and the actions.
+// The custom data is not sent to Windows devices.
+func (n Notification) encodeWindowsToast(buf *bytes.Buffer) {
+	buf.WriteString(`<toast><visual><binding template="ToastGeneric">`)
+	for _, text := range []string{n.Title, n.Body} {
+		if text != "" {
+			buf.WriteString("<text>")
+			xml.EscapeText(buf, []byte(text))
+			buf.WriteString("</text>")
+		}
+	}
+	if n.ImageURL != "" {
+		buf.WriteString(`<image placement="hero" src="`)
+		xml.EscapeText(buf, []byte(n.ImageURL))
+		buf.WriteString(`"/>`)
+	}
+	buf.WriteString(`</binding></visual>`)
+
+	if len(n.Actions) > 0 {
+		buf.WriteString(`<actions>`)
+		for _, action := range n.Actions {
+			if action.Input {
+				buf.WriteString(`<input id="`)
+				xml.EscapeText(buf, []byte(action.ID))
+				buf.WriteString(`" type="text" placeHolderContent="`)
+				xml.EscapeText(buf, []byte(action.InputPlaceholder))
+				buf.WriteString(`"/>`)
+			}
+		}
+		for _, action := range n.Actions {
+			buf.WriteString(`<action content="`)
+			xml.EscapeText(buf, []byte(action.Title))
+			buf.WriteString(`" arguments="`)
+			xml.EscapeText(buf, []byte(action.ID))
+			if action.Input {
+				buf.WriteString(`" hint-inputId="`)
+				xml.EscapeText(buf, []byte(action.ID))
+			}
+			buf.WriteString(`"/>`)
+		}
+		buf.WriteString(`</actions>`)
+	}
+
+	buf.WriteString(`</toast>`)
+}
+
+// setPlatformHeaders sets the content headers of the platform's payloads.
+func setPlatformHeaders(set func(key, value string), platform Platform) {
+	if platform == PlatformWindows {
+		set("Content-Type", "application/xml")
+		set("X-WNS-Type", "wns/toast")
+		return
+	}
+
+	set("Content-Type", "application/json")
+}
+
+// encodeJSON writes the JSON encoding of v to buf through its encoder, without the trailing newline.
+func encodeJSON(enc *json.Encoder, buf *bytes.Buffer, v any) error {
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode's trailing newline.
+
+	return nil
+}
+
+// maxPooledBufferSize is the maximum capacity of a buffer returned to the bufferPool,
+// larger buffers (e.g. big installations) are left to the garbage collector.
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool holds the buffers used to encode request bodies on the hot send path.
+var bufferPool = sync.Pool{
+	New: func() any {
+		return bytes.NewBuffer(make([]byte, 0, 1024))
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// pooledPayload is a request body backed by a pooled buffer.
+// It can be read multiple times (GetBody for retries, redirects and HTTP/2 replays),
+// the buffer is returned to the pool once the request is done and all its readers are closed.
+type pooledPayload struct {
+	buf *bytes.Buffer
+
+	mu      sync.Mutex
+	readers int
+	done    bool
+}
+
+func newPooledPayload(buf *bytes.Buffer) *pooledPayload {
+	return &pooledPayload{buf: buf}
+}
+
+// setBody sets the request's Body, GetBody and ContentLength.
+func (p *pooledPayload) setBody(req *http.Request) {
+	req.Body, _ = p.getBody()
+	req.GetBody = p.getBody
+	req.ContentLength = int64(p.buf.Len())
+}
+
+func (p *pooledPayload) getBody() (io.ReadCloser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.done {
+		return nil, errors.New("request body already released")
+	}
+
+	p.readers++
+	return &pooledPayloadReader{Reader: bytes.NewReader(p.buf.Bytes()), payload: p}, nil
+}
+
+// release marks the request as done.
+func (p *pooledPayload) release() {
+	p.mu.Lock()
+	p.done = true
+	if p.readers == 0 {
+		putBuffer(p.buf)
+	}
+	p.mu.Unlock()
+}
+
+func (p *pooledPayload) closeReader() {
+	p.mu.Lock()
+	p.readers--
+	if p.done && p.readers == 0 {
+		putBuffer(p.buf)
+	}
+	p.mu.Unlock()
+}
+
+type pooledPayloadReader struct {
+	*bytes.Reader
+	payload *pooledPayload
+	once    sync.Once
+}
+
+// Close implements the io.Closer interface.
+func (r *pooledPayloadReader) Close() error {
+	r.once.Do(r.payload.closeReader)
+	return nil
+}
+
+type notificationMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// appleAPS is the APNs "aps" dictionary.
+type appleAPS struct {
+	Alert          appleAlert `json:"alert"`
+	Sound          string     `json:"sound"`
+	MutableContent int        `json:"mutable-content,omitempty"`
+	Category       string     `json:"category,omitempty"`
+	ThreadID       string     `json:"thread-id,omitempty"`
+}
+
+// appleAlert is the APNs alert dictionary, the AppleAlert fields are written when it is not nil.
+type appleAlert struct {
+	notificationMessage
+	*AppleAlert
+	SummaryArg string `json:"summary-arg,omitempty"`
+}
+
+// fcmV1NotificationPayload is the Azure NH wrapper for FCMv1.
+// FCMv1 requires the payload under a "message" object with string-only data values.
+// See: https://learn.microsoft.com/en-us/azure/notification-hubs/firebase-migration-rest
+type fcmV1NotificationPayload struct {
+	Message fcmV1Message `json:"message"`
+}
+
+type fcmV1Message struct {
+	// Token is the target device of a direct FCM send (see FCMProvider), the hub sets it on its own.
+	Token        string             `json:"token,omitempty"`
+	Notification *fcmV1Notification `json:"notification,omitempty"`
+	Android      *fcmV1Android      `json:"android,omitempty"`
+	FCMOptions   *fcmV1Options      `json:"fcm_options,omitempty"`
+}
+
+type fcmV1Notification struct {
+	notificationMessage
+	Image string `json:"image,omitempty"`
+}
+
+// admPayload is the Amazon Device Messaging payload, its data values are strings.
+type admPayload struct {
+	Data map[string]string `json:"data"`
+}
+
+type fcmV1Options struct {
+	AnalyticsLabel string `json:"analytics_label"`
+}
+
+type fcmV1Android struct {
+	Data         map[string]string        `json:"data,omitempty"`
+	Notification fcmV1AndroidNotification `json:"notification,omitzero"`
+}
+
+type fcmV1AndroidNotification struct {
+	// Tag replaces the previous notification with the same tag in the notification drawer.
+	Tag string `json:"tag,omitempty"`
+}
+
+// toStringMap converts map[string]any to map[string]string for FCMv1 compatibility.
+// The Apple "aps" field is skipped, it is not sent to the other platforms.
+func toStringMap(m map[string]any) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		if k == "aps" {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			result[k] = s
+			continue
+		}
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result
+}
@@ -0,0 +1,389 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestNotification_MarshalFor(t *testing.T) {
+	notification := azurepush.Notification{
+		Title: "Hi",
+		Body:  "Hello",
+		Data:  map[string]any{"threadId": "abc123", "count": 2},
+	}
+
+	tests := []struct {
+		platform azurepush.Platform
+		expected string
+	}{
+		{
+			platform: azurepush.PlatformApple,
+			expected: `{"aps":{"alert":{"title":"Hi","body":"Hello"},"sound":"default"},"count":2,"threadId":"abc123"}`,
+		},
+		{
+			platform: azurepush.PlatformFCMV1,
+			expected: `{"message":{"notification":{"title":"Hi","body":"Hello"},"android":{"data":{"count":"2","threadId":"abc123"}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.platform), func(t *testing.T) {
+			payload, headers, err := notification.MarshalFor(tt.platform)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(payload) != tt.expected {
+				t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", tt.expected, payload)
+			}
+
+			if headers["ServiceBusNotification-Format"] != string(tt.platform) {
+				t.Errorf("unexpected format header: %s", headers["ServiceBusNotification-Format"])
+			}
+			if headers["Content-Type"] != "application/json" {
+				t.Errorf("unexpected content type header: %s", headers["Content-Type"])
+			}
+		})
+	}
+
+	if _, _, err := notification.MarshalFor("unknown"); err == nil {
+		t.Error("expected error for an unsupported platform")
+	}
+}
+
+func TestNotification_MarshalFor_AppleCustomAPS(t *testing.T) {
+	notification := azurepush.Notification{
+		Title: "Hi",
+		Body:  "Hello",
+		Data: map[string]any{
+			"aps":   map[string]any{"content-available": 1},
+			"alpha": true,
+		},
+	}
+
+	payload, _, err := notification.MarshalFor(azurepush.PlatformApple)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"alpha":true,"aps":{"content-available":1}}`
+	if string(payload) != expected {
+		t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", expected, payload)
+	}
+}
+
+func TestNotification_MarshalFor_FCMDataOnly(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification azurepush.Notification
+		expected     string
+	}{
+		{
+			name:         "data only",
+			notification: azurepush.Notification{Data: map[string]any{"sync": true}},
+			expected:     `{"message":{"android":{"data":{"sync":"true"}}}}`,
+		},
+		{
+			name:         "silent",
+			notification: azurepush.Notification{Data: map[string]any{"aps": map[string]any{"content-available": 1}, "sync": true}},
+			expected:     `{"message":{"android":{"data":{"sync":"true"}}}}`,
+		},
+		{
+			name:         "apple silent only",
+			notification: azurepush.Notification{Data: map[string]any{"aps": map[string]any{"content-available": 1}}},
+			expected:     `{"message":{}}`,
+		},
+	}
+
+	// Neither the empty notification nor the Apple "aps" field is sent to FCM.
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _, err := tt.notification.MarshalFor(azurepush.PlatformFCMV1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(payload) != tt.expected {
+				t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", tt.expected, payload)
+			}
+		})
+	}
+}
+
+func TestNotification_MarshalFor_AppleAlert(t *testing.T) {
+	notification := azurepush.Notification{
+		Title: "Order shipped",
+		Body:  "Your order is on its way",
+		Apple: &azurepush.AppleAlert{
+			Subtitle:    "Order #1234",
+			LaunchImage: "shipped.png",
+			LocKey:      "ORDER_SHIPPED",
+			LocArgs:     []string{"1234"},
+		},
+	}
+
+	payload, _, err := notification.MarshalFor(azurepush.PlatformApple)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"aps":{"alert":{"title":"Order shipped","body":"Your order is on its way","subtitle":"Order #1234",` +
+		`"launch-image":"shipped.png","loc-key":"ORDER_SHIPPED","loc-args":["1234"]},"sound":"default"}}`
+	if string(payload) != expected {
+		t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", expected, payload)
+	}
+
+	// The Apple alert is not sent to FCM.
+	payload, _, err = notification.MarshalFor(azurepush.PlatformFCMV1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = `{"message":{"notification":{"title":"Order shipped","body":"Your order is on its way"}}}`
+	if string(payload) != expected {
+		t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", expected, payload)
+	}
+}
+
+func TestNotification_MarshalFor_AnalyticsLabel(t *testing.T) {
+	notification := azurepush.Notification{
+		Title:   "Spring sale",
+		Android: &azurepush.AndroidOptions{AnalyticsLabel: "spring_sale"},
+	}
+
+	payload, _, err := notification.MarshalFor(azurepush.PlatformFCMV1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"message":{"notification":{"title":"Spring sale","body":""},"fcm_options":{"analytics_label":"spring_sale"}}}`
+	if string(payload) != expected {
+		t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", expected, payload)
+	}
+
+	// The Android options are not sent to Apple.
+	payload, _, err = notification.MarshalFor(azurepush.PlatformApple)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected = `{"aps":{"alert":{"title":"Spring sale","body":""},"sound":"default"}}`
+	if string(payload) != expected {
+		t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", expected, payload)
+	}
+}
+
+func TestNotification_MarshalFor_Image(t *testing.T) {
+	notification := azurepush.Notification{
+		Title:    "New arrivals",
+		Body:     "Shoes & bags",
+		ImageURL: "https://cdn.example.com/arrivals.png?w=1024&h=512",
+		Data:     map[string]any{"id": "42"},
+	}
+
+	tests := []struct {
+		platform azurepush.Platform
+		expected string
+	}{
+		{
+			platform: azurepush.PlatformApple,
+			expected: `{"aps":{"alert":{"title":"New arrivals","body":"Shoes \u0026 bags"},"sound":"default","mutable-content":1},` +
+				`"id":"42","image":"https://cdn.example.com/arrivals.png?w=1024\u0026h=512"}`,
+		},
+		{
+			platform: azurepush.PlatformFCMV1,
+			expected: `{"message":{"notification":{"title":"New arrivals","body":"Shoes \u0026 bags",` +
+				`"image":"https://cdn.example.com/arrivals.png?w=1024\u0026h=512"},"android":{"data":{"id":"42"}}}}`,
+		},
+		{
+			platform: azurepush.PlatformWindows,
+			expected: `<toast><visual><binding template="ToastGeneric"><text>New arrivals</text><text>Shoes &amp; bags</text>` +
+				`<image placement="hero" src="https://cdn.example.com/arrivals.png?w=1024&amp;h=512"/></binding></visual></toast>`,
+		},
+	}
+
+	for _, tt := range tests {
+		payload, headers, err := notification.MarshalFor(tt.platform)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.platform, err)
+		}
+
+		if string(payload) != tt.expected {
+			t.Errorf("%s: unexpected payload:\nexpected: %s\ngot:      %s", tt.platform, tt.expected, payload)
+		}
+
+		if err = notification.ValidateFor(tt.platform); err != nil {
+			t.Errorf("%s: unexpected validation error: %v", tt.platform, err)
+		}
+
+		if tt.platform == azurepush.PlatformWindows && (headers["Content-Type"] != "application/xml" || headers["X-WNS-Type"] != "wns/toast") {
+			t.Errorf("unexpected Windows headers: %v", headers)
+		}
+	}
+
+	notification.ImageURL = "arrivals.png"
+	if err := notification.Validate(); !errors.Is(err, azurepush.ErrInvalidNotification) {
+		t.Errorf("expected an invalid image URL error, got: %v", err)
+	}
+}
+
+func TestNotification_MarshalFor_Group(t *testing.T) {
+	notification := azurepush.Notification{
+		Title:   "Maria",
+		Body:    "See you at 8",
+		Group:   "chat:42",
+		Summary: "Maria",
+	}
+
+	tests := []struct {
+		platform azurepush.Platform
+		expected string
+	}{
+		{
+			platform: azurepush.PlatformApple,
+			expected: `{"aps":{"alert":{"title":"Maria","body":"See you at 8","summary-arg":"Maria"},"sound":"default","thread-id":"chat:42"}}`,
+		},
+		{
+			platform: azurepush.PlatformFCMV1,
+			expected: `{"message":{"notification":{"title":"Maria","body":"See you at 8"},"android":{"data":{"group":"chat:42","groupSummary":"Maria"},"notification":{"tag":"chat:42"}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		payload, _, err := notification.MarshalFor(tt.platform)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.platform, err)
+		}
+
+		if string(payload) != tt.expected {
+			t.Errorf("%s: unexpected payload:\nexpected: %s\ngot:      %s", tt.platform, tt.expected, payload)
+		}
+	}
+
+	if err := notification.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	notification.Group = ""
+	if err := notification.Validate(); !errors.Is(err, azurepush.ErrInvalidNotification) {
+		t.Errorf("expected a summary without group error, got: %v", err)
+	}
+
+	notification = azurepush.Notification{Title: "Hi", Group: "chat:42", Data: map[string]any{azurepush.GroupDataKey: "other"}}
+	if err := notification.ValidateFor(azurepush.PlatformFCMV1); !errors.Is(err, azurepush.ErrInvalidNotification) {
+		t.Errorf("expected a reserved data key error, got: %v", err)
+	}
+}
+
+func TestNotification_MarshalFor_ADM(t *testing.T) {
+	notification := azurepush.Notification{
+		Title: "Hi",
+		Body:  "Hello",
+		Group: "chat:42",
+		Data:  map[string]any{"threadId": "abc123", "count": 2},
+	}
+
+	if err := notification.ValidateFor(azurepush.PlatformADM); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	payload, headers, err := notification.MarshalFor(azurepush.PlatformADM)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"data":{"body":"Hello","count":"2","group":"chat:42","threadId":"abc123","title":"Hi"}}`
+	if string(payload) != expected {
+		t.Errorf("unexpected payload:\nexpected: %s\ngot:      %s", expected, payload)
+	}
+	if headers["ServiceBusNotification-Format"] != "adm" {
+		t.Errorf("unexpected format header: %s", headers["ServiceBusNotification-Format"])
+	}
+
+	notification.Data[azurepush.ADMTitleDataKey] = "Other"
+	if err = notification.ValidateFor(azurepush.PlatformADM); !errors.Is(err, azurepush.ErrInvalidNotification) {
+		t.Errorf("expected a reserved data key error, got: %v", err)
+	}
+}
+
+func TestPlatformConversion(t *testing.T) {
+	tests := []struct {
+		installation azurepush.InstallationPlatform
+		format       azurepush.Platform
+	}{
+		{azurepush.InstallationApple, azurepush.PlatformApple},
+		{azurepush.InstallationFCMV1, azurepush.PlatformFCMV1},
+		{azurepush.InstallationBaidu, azurepush.PlatformBaidu},
+		{azurepush.InstallationWNS, azurepush.PlatformWindows},
+		{azurepush.InstallationMPNS, azurepush.PlatformWindowsPhone},
+		{azurepush.InstallationADM, azurepush.PlatformADM},
+	}
+
+	for _, tt := range tests {
+		if got := tt.installation.Format(); got != tt.format {
+			t.Errorf("%s: expected format: %s, got: %s", tt.installation, tt.format, got)
+		}
+
+		if got := tt.format.InstallationPlatform(); got != tt.installation {
+			t.Errorf("%s: expected installation platform: %s, got: %s", tt.format, tt.installation, got)
+		}
+	}
+
+	if got := azurepush.InstallationPlatform("gcm").Format(); got != "" {
+		t.Errorf("expected no format for an unknown platform, got: %s", got)
+	}
+}
+
+func TestNotification_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification azurepush.Notification
+		invalid      []azurepush.Platform
+	}{
+		{"title", azurepush.Notification{Title: "Hi"}, nil},
+		{"empty", azurepush.Notification{}, []azurepush.Platform{azurepush.PlatformApple, azurepush.PlatformFCMV1}},
+		{"silent", azurepush.Notification{Data: map[string]any{"aps": map[string]any{"content-available": 1}}}, nil},
+		{"data only", azurepush.Notification{Data: map[string]any{"sync": true}}, []azurepush.Platform{azurepush.PlatformApple}},
+		{"aps with alert", azurepush.Notification{Title: "Hi", Data: map[string]any{"aps": map[string]any{"badge": 1}}}, []azurepush.Platform{azurepush.PlatformApple}},
+		{"aps not an object", azurepush.Notification{Data: map[string]any{"aps": "silent"}}, []azurepush.Platform{azurepush.PlatformApple}},
+		{"reserved key", azurepush.Notification{Title: "Hi", Data: map[string]any{"google.c.a.e": "1"}}, []azurepush.Platform{azurepush.PlatformFCMV1}},
+		{"nested value", azurepush.Notification{Title: "Hi", Data: map[string]any{"order": map[string]any{"id": 42}}}, []azurepush.Platform{azurepush.PlatformFCMV1}},
+		{"localized alert", azurepush.Notification{Apple: &azurepush.AppleAlert{LocKey: "WELCOME"}}, []azurepush.Platform{azurepush.PlatformFCMV1}},
+		{"subtitle only", azurepush.Notification{Apple: &azurepush.AppleAlert{Subtitle: "Hi"}}, []azurepush.Platform{azurepush.PlatformApple, azurepush.PlatformFCMV1}},
+		{"aps with apple alert", azurepush.Notification{Apple: &azurepush.AppleAlert{Subtitle: "Hi"}, Data: map[string]any{"aps": map[string]any{"badge": 1}, "sync": true}}, []azurepush.Platform{azurepush.PlatformApple}},
+		{"analytics label", azurepush.Notification{Title: "Hi", Android: &azurepush.AndroidOptions{AnalyticsLabel: "spring_sale-2026"}}, nil},
+		{"invalid analytics label", azurepush.Notification{Title: "Hi", Android: &azurepush.AndroidOptions{AnalyticsLabel: "spring sale"}}, []azurepush.Platform{azurepush.PlatformFCMV1}},
+		{"scalar values", azurepush.Notification{Title: "Hi", Data: map[string]any{"id": 42, "price": 9.99, "paid": true}}, nil},
+	}
+
+	for _, tt := range tests {
+		for _, platform := range []azurepush.Platform{azurepush.PlatformApple, azurepush.PlatformFCMV1} {
+			err := tt.notification.ValidateFor(platform)
+			if expected := slices.Contains(tt.invalid, platform); expected != (err != nil) {
+				t.Errorf("%s: %s: expected invalid: %t, got: %v", tt.name, platform, expected, err)
+			}
+			if err != nil && !errors.Is(err, azurepush.ErrInvalidNotification) {
+				t.Errorf("%s: %s: expected an ErrInvalidNotification, got: %v", tt.name, platform, err)
+			}
+		}
+
+		if err := tt.notification.Validate(); (err != nil) != (len(tt.invalid) > 0) {
+			t.Errorf("%s: unexpected Validate result: %v", tt.name, err)
+		}
+	}
+
+	// Sends fail before any request.
+	client := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		t.Error("unexpected request of an invalid notification")
+		return jsonResponse(http.StatusCreated, "")
+	})
+	if err := client.SendNotification(context.Background(), azurepush.Notification{}, "user:42"); !errors.Is(err, azurepush.ErrInvalidNotification) {
+		t.Errorf("expected an ErrInvalidNotification, got: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package azurepush_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+var benchNotification = azurepush.Notification{
+	Title: "New message",
+	Body:  "You have a new message from Alice",
+	Data: map[string]any{
+		"type":     "chat_message",
+		"threadId": "abc123",
+		"unread":   3,
+	},
+}
+
+func BenchmarkNotification_MarshalFor(b *testing.B) {
+	for _, platform := range []azurepush.Platform{azurepush.PlatformApple, azurepush.PlatformFCMV1} {
+		b.Run(string(platform), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, _, err := benchNotification.MarshalFor(platform); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateSASToken(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		_, err := azurepush.GenerateSASToken("https://mynamespace.servicebus.windows.net/myhub", "DefaultFullSharedAccessSignature", "secret", time.Hour)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInstallation_Marshal(b *testing.B) {
+	installation := azurepush.Installation{
+		InstallationID: "device-uuid-123",
+		Platform:       azurepush.InstallationFCMV1,
+		PushChannel:    "fcm-registration-token",
+		Tags:           []string{"user:42", "topic:news", "lang:en"},
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := json.Marshal(installation); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestNotification_MarshalFor_AllocationBudget guards the payload construction against allocation regressions.
+func TestNotification_MarshalFor_AllocationBudget(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful with the race detector")
+	}
+
+	budgets := map[azurepush.Platform]float64{
+		azurepush.PlatformApple: 18,
+		azurepush.PlatformFCMV1: 16,
+	}
+
+	for platform, budget := range budgets {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _, _ = benchNotification.MarshalFor(platform)
+		})
+		if allocs > budget {
+			t.Errorf("%s: expected at most %.0f allocations, got: %.0f", platform, budget, allocs)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package azurepush
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// NotificationTemplate is a notification whose title, body and string data values
+// are text/template templates, rendered per recipient. The rest of its fields
+// (e.g. the ImageURL, Actions, Group and the platform options) are sent as they are.
+//
+// Example usage:
+//
+//	tmpl, err := azurepush.ParseNotificationTemplate(azurepush.Notification{
+//		Title: "Hi {{.Name}}",
+//		Body:  "Your order {{.OrderID}} shipped",
+//		Data:  map[string]any{"orderId": "{{.OrderID}}"},
+//	})
+type NotificationTemplate struct {
+	base        Notification // the fields which are not templates.
+	title, body *template.Template
+	data        map[string]*template.Template
+	static      map[string]any // non-string data values, copied as they are.
+}
+
+// ParseNotificationTemplate parses the title, body and string data values of the notification as templates.
+// Missing keys of the rendered data are errors, so a recipient is never sent a "<no value>".
+func ParseNotificationTemplate(n Notification) (*NotificationTemplate, error) {
+	var (
+		t   = &NotificationTemplate{base: n, data: make(map[string]*template.Template), static: make(map[string]any)}
+		err error
+	)
+	t.base.Title, t.base.Body, t.base.Data = "", "", nil
+
+	if t.title, err = parseTemplateField("title", n.Title); err != nil {
+		return nil, err
+	}
+
+	if t.body, err = parseTemplateField("body", n.Body); err != nil {
+		return nil, err
+	}
+
+	for key, value := range n.Data {
+		s, ok := value.(string)
+		if !ok {
+			t.static[key] = value
+			continue
+		}
+
+		if t.data[key], err = parseTemplateField("data."+key, s); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func parseTemplateField(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// Execute renders the notification for a recipient's data.
+func (t *NotificationTemplate) Execute(data any) (Notification, error) {
+	var (
+		n   = t.base
+		err error
+	)
+
+	// The rendered notifications do not share the template's actions and platform options.
+	n.Actions = slices.Clone(n.Actions)
+	if n.Apple != nil {
+		apple := *n.Apple
+		n.Apple = &apple
+	}
+	if n.Android != nil {
+		android := *n.Android
+		n.Android = &android
+	}
+
+	if n.Title, err = executeTemplate(t.title, data); err != nil {
+		return Notification{}, err
+	}
+
+	if n.Body, err = executeTemplate(t.body, data); err != nil {
+		return Notification{}, err
+	}
+
+	if len(t.data)+len(t.static) > 0 {
+		n.Data = make(map[string]any, len(t.data)+len(t.static))
+		for key, value := range t.static {
+			n.Data[key] = value
+		}
+
+		for key, tmpl := range t.data {
+			if n.Data[key], err = executeTemplate(tmpl, data); err != nil {
+				return Notification{}, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func executeTemplate(tmpl *template.Template, data any) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render notification template: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// RecipientDataFunc returns the template data of a recipient tag, e.g. a user record
+// for a "user:<id>" tag.
+type RecipientDataFunc func(ctx context.Context, tag string) (any, error)
+
+// SendPersonalized renders the template for each tag with the data returned by the provider
+// and sends each rendered notification to its tag. It returns the results in the same order as the tags,
+// the ID of each result is its tag. Recipients whose data or rendering fails are not sent,
+// their result reports the error.
+func (s *Sender) SendPersonalized(ctx context.Context, tmpl *NotificationTemplate, tags []string, provider RecipientDataFunc) []SendResult {
+	results := make([]SendResult, len(tags))
+
+	var (
+		notifications = make([]TargetedNotification, 0, len(tags))
+		indices       = make([]int, 0, len(tags)) // indices of the rendered tags.
+	)
+	for i, tag := range tags {
+		n, err := renderFor(ctx, tmpl, tag, provider)
+		if err != nil {
+			results[i] = SendResult{ID: tag, Tags: []string{tag}, Err: err}
+			continue
+		}
+
+		notifications = append(notifications, TargetedNotification{ID: tag, Notification: n, Tags: []string{tag}})
+		indices = append(indices, i)
+	}
+
+	for i, result := range s.SendBatch(ctx, notifications) {
+		results[indices[i]] = result
+	}
+
+	return results
+}
+
+func renderFor(ctx context.Context, tmpl *NotificationTemplate, tag string, provider RecipientDataFunc) (Notification, error) {
+	data, err := provider(ctx, tag)
+	if err != nil {
+		return Notification{}, fmt.Errorf("failed to get recipient data: %s: %w", tag, err)
+	}
+
+	n, err := tmpl.Execute(data)
+	if err != nil {
+		return Notification{}, fmt.Errorf("recipient: %s: %w", tag, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,135 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+type recipient struct {
+	Name    string
+	OrderID string
+}
+
+func TestNotificationTemplate_Execute(t *testing.T) {
+	tmpl, err := azurepush.ParseNotificationTemplate(azurepush.Notification{
+		Title: "Hi {{.Name}}",
+		Body:  "Your order {{.OrderID}} shipped",
+		Data:  map[string]any{"orderId": "{{.OrderID}}", "badge": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := tmpl.Execute(recipient{Name: "Gerasimos", OrderID: "A-42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Title != "Hi Gerasimos" || n.Body != "Your order A-42 shipped" {
+		t.Errorf("unexpected rendered notification: %+v", n)
+	}
+	if n.Data["orderId"] != "A-42" || n.Data["badge"] != 1 {
+		t.Errorf("unexpected rendered data: %v", n.Data)
+	}
+
+	if _, err = tmpl.Execute(map[string]any{"Name": "Gerasimos"}); err == nil {
+		t.Error("expected an error for missing template data")
+	}
+
+	if _, err = azurepush.ParseNotificationTemplate(azurepush.Notification{Title: "Hi {{.Name"}); err == nil {
+		t.Error("expected an error for an invalid template")
+	}
+}
+
+func TestNotificationTemplate_Execute_Rich(t *testing.T) {
+	tmpl, err := azurepush.ParseNotificationTemplate(azurepush.Notification{
+		Title:    "{{.Name}} shared a photo",
+		ImageURL: "https://example.com/photo.jpg",
+		Actions:  []azurepush.NotificationAction{{ID: "like", Title: "Like"}, {ID: "reply", Title: "Reply", Input: true}},
+		Group:    "album:7",
+		Summary:  "Album",
+		Apple:    &azurepush.AppleAlert{Subtitle: "Album"},
+		Android:  &azurepush.AndroidOptions{AnalyticsLabel: "shared-photo"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := tmpl.Execute(recipient{Name: "Maria"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n.Title != "Maria shared a photo" || n.ImageURL != "https://example.com/photo.jpg" || n.Group != "album:7" || n.Summary != "Album" {
+		t.Errorf("expected the template's fields to be kept, got: %+v", n)
+	}
+	if len(n.Actions) != 2 || n.Actions[1].ID != "reply" || !n.Actions[1].Input {
+		t.Errorf("expected the template's actions, got: %+v", n.Actions)
+	}
+	if n.Apple == nil || n.Apple.Subtitle != "Album" || n.Android == nil || n.Android.AnalyticsLabel != "shared-photo" {
+		t.Errorf("expected the template's platform options, got: %+v, %+v", n.Apple, n.Android)
+	}
+
+	// A rendered notification does not share the template's fields.
+	n.Actions[0].Title = "Love"
+	n.Apple.Subtitle = "Changed"
+	if other, _ := tmpl.Execute(recipient{Name: "Nikos"}); other.Actions[0].Title != "Like" || other.Apple.Subtitle != "Album" {
+		t.Errorf("expected the template not to be modified by a rendered notification, got: %+v", other)
+	}
+}
+
+func TestSender_SendPersonalized(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	tmpl, err := azurepush.ParseNotificationTemplate(azurepush.Notification{
+		Title: "Hi {{.Name}}",
+		Body:  "Your order {{.OrderID}} shipped",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipients := map[string]recipient{
+		"user:1": {Name: "Alice", OrderID: "A-1"},
+		"user:2": {Name: "Bob", OrderID: "B-2"},
+	}
+	provider := func(ctx context.Context, tag string) (any, error) {
+		r, ok := recipients[tag]
+		if !ok {
+			return nil, errors.New("unknown user")
+		}
+		return r, nil
+	}
+
+	sender := azurepush.NewSender(srv.NewClient(), azurepush.SenderOptions{})
+	results := sender.SendPersonalized(context.Background(), tmpl, []string{"user:1", "user:unknown", "user:2"}, provider)
+
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got: %d", len(results))
+	}
+	if results[0].ID != "user:1" || results[0].Err != nil || results[2].ID != "user:2" || results[2].Err != nil {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if results[1].ID != "user:unknown" || results[1].Err == nil {
+		t.Errorf("expected the unknown recipient to fail, got: %+v", results[1])
+	}
+	if len(srv.Sent("user:unknown")) != 0 {
+		t.Error("expected the failed recipient not to be sent")
+	}
+
+	for tag, r := range recipients {
+		sent := srv.Sent(tag)
+		if len(sent) == 0 {
+			t.Fatalf("expected tag: %s to be sent", tag)
+		}
+		if !strings.Contains(string(sent[0].Payload), "Hi "+r.Name) || !strings.Contains(string(sent[0].Payload), r.OrderID) {
+			t.Errorf("expected a personalized payload for tag: %s, got: %s", tag, sent[0].Payload)
+		}
+	}
+}
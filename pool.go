@@ -0,0 +1,225 @@
+package azurepush
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ClientPoolOptions holds the settings of a ClientPool.
+type ClientPoolOptions struct {
+	// Config returns the configuration of a tenant's hub, e.g. from a database or a secret store.
+	// It is called once per tenant, when its client is first requested (or requested again after eviction).
+	//
+	// Required.
+	Config func(ctx context.Context, tenantID string) (Configuration, error)
+
+	// MaxSize is the maximum number of cached clients,
+	// the least recently used client is evicted when a new one would exceed it.
+	//
+	// Defaults to 1000.
+	MaxSize int
+
+	// HTTPClient is shared by all the clients of the pool, so they share its transport and connections.
+	//
+	// Defaults to NewHTTPClient with the default transport settings.
+	HTTPClient *http.Client
+
+	// Audit, if not nil, is the AuditSink of all the clients of the pool,
+	// the records tell the tenants apart by their hub.
+	Audit AuditSink
+
+	// OnEvict is called (if not nil) when a client is evicted or removed from the pool,
+	// right before the client is closed.
+	OnEvict func(tenantID string, client *Client)
+
+	// CloseTimeout is the time the evicted and removed clients are given to drain their in-flight calls,
+	// see Client.Close.
+	//
+	// Defaults to 30 seconds.
+	CloseTimeout time.Duration
+}
+
+// ClientPool is a bounded cache of the clients of multi-tenant backends with one hub per tenant.
+// Clients are created lazily from the Config callback, each with its own TokenManager,
+// and share a single HTTP client (and transport) so tenants don't multiply connections.
+//
+// Example usage:
+//
+//	pool := azurepush.NewClientPool(azurepush.ClientPoolOptions{
+//		Config: func(ctx context.Context, tenantID string) (azurepush.Configuration, error) {
+//			return tenants.HubConfiguration(ctx, tenantID)
+//		},
+//	})
+//	client, err := pool.Get(ctx, tenantID)
+//	if err != nil { /* ... */ }
+//	err = client.SendNotification(ctx, notification, "user:42")
+type ClientPool struct {
+	opts ClientPoolOptions
+
+	mu      sync.Mutex
+	lru     *list.List // of *poolEntry, most recently used first.
+	entries map[string]*list.Element
+}
+
+type poolEntry struct {
+	tenantID string
+	ready    chan struct{} // closed once client or err is set.
+	client   *Client
+	err      error
+}
+
+// NewClientPool creates a new ClientPool.
+func NewClientPool(opts ClientPoolOptions) *ClientPool {
+	if opts.Config == nil {
+		panic("azurepush: nil client pool config func")
+	}
+
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 1000
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = NewHTTPClient(Configuration{})
+	}
+
+	if opts.CloseTimeout <= 0 {
+		opts.CloseTimeout = 30 * time.Second
+	}
+
+	return &ClientPool{
+		opts:    opts,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the client of a tenant, creating it on first use.
+// Concurrent calls for the same tenant share a single creation.
+// Failed creations are not cached, the next call retries.
+func (p *ClientPool) Get(ctx context.Context, tenantID string) (*Client, error) {
+	p.mu.Lock()
+	if elem, ok := p.entries[tenantID]; ok {
+		p.lru.MoveToFront(elem)
+		p.mu.Unlock()
+
+		entry := elem.Value.(*poolEntry)
+		select {
+		case <-entry.ready:
+			return entry.client, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry := &poolEntry{tenantID: tenantID, ready: make(chan struct{})}
+	elem := p.lru.PushFront(entry)
+	p.entries[tenantID] = elem
+	p.mu.Unlock()
+
+	entry.client, entry.err = p.newClient(ctx, tenantID)
+	close(entry.ready)
+
+	p.mu.Lock()
+	var evicted []*poolEntry
+	if entry.err != nil {
+		if p.entries[tenantID] == elem {
+			p.lru.Remove(elem)
+			delete(p.entries, tenantID)
+		}
+	} else {
+		// Evicted only once created, so failed creations don't evict working clients.
+		evicted = p.evict()
+	}
+	p.mu.Unlock()
+
+	p.notifyEvicted(evicted)
+	return entry.client, entry.err
+}
+
+// Remove removes the client of a tenant, e.g. when the tenant is deleted or its hub changed.
+// The next Get creates a new client.
+func (p *ClientPool) Remove(tenantID string) {
+	p.mu.Lock()
+	elem, ok := p.entries[tenantID]
+	if ok {
+		p.lru.Remove(elem)
+		delete(p.entries, tenantID)
+	}
+	p.mu.Unlock()
+
+	if ok {
+		p.notifyEvicted([]*poolEntry{elem.Value.(*poolEntry)})
+	}
+}
+
+// Len returns the number of cached clients.
+func (p *ClientPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.lru.Len()
+}
+
+func (p *ClientPool) newClient(ctx context.Context, tenantID string) (*Client, error) {
+	cfg, err := p.opts.Config(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configuration of tenant: %s: %w", tenantID, err)
+	}
+
+	client, err := newClient(ctx, cfg, p.opts.HTTPClient)
+	if err != nil {
+		return nil, fmt.Errorf("tenant: %s: %w", tenantID, err)
+	}
+	client.Audit = p.opts.Audit
+
+	return client, nil
+}
+
+// evict removes the least recently used entries over the maximum size, the caller must hold the lock.
+func (p *ClientPool) evict() []*poolEntry {
+	var evicted []*poolEntry
+	for p.lru.Len() > p.opts.MaxSize {
+		entry := p.lru.Remove(p.lru.Back()).(*poolEntry)
+		delete(p.entries, entry.tenantID)
+		evicted = append(evicted, entry)
+	}
+
+	return evicted
+}
+
+// notifyEvicted calls OnEvict for the successfully created clients of the evicted entries
+// and closes them in the background (see Client.Close), so their background goroutines stop
+// and their in-flight calls are drained. The entries still being created are handled in the background once created.
+func (p *ClientPool) notifyEvicted(evicted []*poolEntry) {
+	for _, entry := range evicted {
+		notify := func() {
+			if entry.err != nil {
+				return
+			}
+
+			if p.opts.OnEvict != nil {
+				p.opts.OnEvict(entry.tenantID, entry.client)
+			}
+
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), p.opts.CloseTimeout)
+				defer cancel()
+				_ = entry.client.Close(ctx)
+			}()
+		}
+
+		select {
+		case <-entry.ready:
+			notify()
+		default:
+			go func() {
+				<-entry.ready
+				notify()
+			}()
+		}
+	}
+}
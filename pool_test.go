@@ -0,0 +1,139 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClientPool(t *testing.T) {
+	var (
+		configCalls atomic.Int32
+		evicted     []string
+	)
+
+	pool := azurepush.NewClientPool(azurepush.ClientPoolOptions{
+		Config: func(ctx context.Context, tenantID string) (azurepush.Configuration, error) {
+			configCalls.Add(1)
+			if tenantID == "unknown" {
+				return azurepush.Configuration{}, errors.New("tenant not found")
+			}
+
+			return azurepush.Configuration{HubName: "hub-" + tenantID, ConnectionString: testConnectionString}, nil
+		},
+		MaxSize: 2,
+		OnEvict: func(tenantID string, client *azurepush.Client) {
+			evicted = append(evicted, tenantID)
+		},
+	})
+
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		clients = make([]*azurepush.Client, 10)
+	)
+	for i := range clients {
+		wg.Go(func() {
+			client, err := pool.Get(ctx, "acme")
+			if err != nil {
+				t.Error(err)
+			}
+			clients[i] = client
+		})
+	}
+	wg.Wait()
+
+	if configCalls.Load() != 1 {
+		t.Errorf("expected a single client creation, got: %d", configCalls.Load())
+	}
+	if slices.ContainsFunc(clients, func(c *azurepush.Client) bool { return c != clients[0] }) {
+		t.Error("expected the same client for the same tenant")
+	}
+	if clients[0].Config.HubName != "hub-acme" {
+		t.Errorf("expected the tenant's hub, got: %s", clients[0].Config.HubName)
+	}
+
+	globex, err := pool.Get(ctx, "globex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if globex.HTTPClient != clients[0].HTTPClient {
+		t.Error("expected the clients to share the HTTP client")
+	}
+	if globex.TokenManager == clients[0].TokenManager {
+		t.Error("expected a token manager per tenant")
+	}
+
+	if _, err = pool.Get(ctx, "acme"); err != nil { // acme is now the most recently used.
+		t.Fatal(err)
+	}
+	if _, err = pool.Get(ctx, "initech"); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Len() != 2 || !slices.Equal(evicted, []string{"globex"}) {
+		t.Errorf("expected the least recently used client to be evicted, got: %v (len: %d)", evicted, pool.Len())
+	}
+	for !globex.Stats().Closed {
+		time.Sleep(time.Millisecond)
+	}
+	if err = globex.SendNotification(ctx, azurepush.Notification{Title: "Hi"}); !errors.Is(err, azurepush.ErrClientClosed) {
+		t.Errorf("expected the evicted client to be closed, got: %v", err)
+	}
+
+	calls := configCalls.Load()
+	for range 2 {
+		if _, err = pool.Get(ctx, "unknown"); err == nil {
+			t.Error("expected an error for an unknown tenant")
+		}
+	}
+	if configCalls.Load() != calls+2 {
+		t.Error("expected failed creations not to be cached")
+	}
+
+	if pool.Len() != 2 || len(evicted) != 1 {
+		t.Errorf("expected failed creations not to evict clients, got: %v", evicted)
+	}
+
+	pool.Remove("acme")
+	if pool.Len() != 1 || !slices.Equal(evicted, []string{"globex", "acme"}) {
+		t.Errorf("expected the removed client to be evicted, got: %v", evicted)
+	}
+}
+
+func TestClientPool_WarmConnections(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	var validations atomic.Int32
+	httpClient := srv.NewClient().HTTPClient
+	transport := httpClient.Transport
+	httpClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		validations.Add(1)
+		return transport.RoundTrip(r)
+	})
+
+	pool := azurepush.NewClientPool(azurepush.ClientPoolOptions{
+		Config: func(ctx context.Context, tenantID string) (azurepush.Configuration, error) {
+			cfg := srv.Configuration()
+			cfg.WarmConnections = 2
+			return cfg, nil
+		},
+		HTTPClient: httpClient,
+	})
+
+	if _, err := pool.Get(context.Background(), "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if validations.Load() != 2 {
+		t.Errorf("expected the pool to warm 2 connections, got: %d requests", validations.Load())
+	}
+}
@@ -0,0 +1,67 @@
+package azurepush
+
+import "context"
+
+// PushProvider is the minimal push notification provider abstraction: it registers devices
+// and sends notifications to the devices of tags. Application code can depend on a PushProvider
+// to swap providers (e.g. a Notification Hub or direct FCM/APNs), compose them or mock them in tests.
+//
+// It is implemented by *Client, other HubClient implementations can be adapted with NewHubProvider.
+type PushProvider interface {
+	// Register creates or replaces a device installation and returns its ID
+	// (generated if the installation has none).
+	Register(ctx context.Context, installation Installation) (string, error)
+	// Unregister removes a device installation. Removing a missing installation is not an error.
+	Unregister(ctx context.Context, installationID string) error
+	// Send sends a notification to the devices with any of the tags, or to all devices if no tag is given.
+	Send(ctx context.Context, notification Notification, tags ...string) error
+}
+
+var (
+	_ PushProvider = (*Client)(nil)
+	_ PushProvider = (*hubProvider)(nil)
+)
+
+// Register implements the PushProvider interface, see RegisterDevice.
+func (c *Client) Register(ctx context.Context, installation Installation) (string, error) {
+	return c.RegisterDevice(ctx, installation)
+}
+
+// Unregister implements the PushProvider interface, see DeleteDevice.
+func (c *Client) Unregister(ctx context.Context, installationID string) error {
+	return c.DeleteDevice(ctx, installationID)
+}
+
+// Send implements the PushProvider interface, see SendNotification.
+func (c *Client) Send(ctx context.Context, notification Notification, tags ...string) error {
+	return c.SendNotification(ctx, notification, tags...)
+}
+
+// NewHubProvider adapts a HubClient (e.g. a *FailoverClient or a *QuietHoursClient) to a PushProvider.
+func NewHubProvider(client HubClient) PushProvider {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if provider, ok := client.(PushProvider); ok {
+		return provider
+	}
+
+	return &hubProvider{client: client}
+}
+
+type hubProvider struct {
+	client HubClient
+}
+
+func (p *hubProvider) Register(ctx context.Context, installation Installation) (string, error) {
+	return p.client.RegisterDevice(ctx, installation)
+}
+
+func (p *hubProvider) Unregister(ctx context.Context, installationID string) error {
+	return p.client.DeleteDevice(ctx, installationID)
+}
+
+func (p *hubProvider) Send(ctx context.Context, notification Notification, tags ...string) error {
+	return p.client.SendNotification(ctx, notification, tags...)
+}
@@ -0,0 +1,56 @@
+package azurepush_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestPushProvider(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	quiet := azurepush.NewQuietHoursClient(client, azurepush.QuietHours{}, azurepush.QuietHoursOptions{})
+
+	providers := map[string]azurepush.PushProvider{
+		"client":  client,
+		"adapted": azurepush.NewHubProvider(quiet),
+	}
+
+	ctx := context.Background()
+	for name, provider := range providers {
+		t.Run(name, func(t *testing.T) {
+			defer srv.Reset()
+
+			id, err := provider.Register(ctx, azurepush.Installation{
+				Platform:    azurepush.InstallationApple,
+				PushChannel: "token",
+				Tags:        []string{"user:42"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = provider.Send(ctx, azurepush.Notification{Title: "Hello"}, "user:42"); err != nil {
+				t.Fatal(err)
+			}
+			if sent := srv.Sent("user:42"); len(sent) == 0 || len(sent[0].InstallationIDs) == 0 || sent[0].InstallationIDs[0] != id {
+				t.Errorf("expected the notification to reach installation: %s, got: %+v", id, sent)
+			}
+
+			if err = provider.Unregister(ctx, id); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := srv.Installation(id); ok {
+				t.Error("expected the installation to be removed")
+			}
+		})
+	}
+
+	if p := azurepush.NewHubProvider(client); p != azurepush.PushProvider(client) {
+		t.Error("expected a *Client to be used as it is")
+	}
+}
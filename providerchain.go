@@ -0,0 +1,205 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ProviderChainOptions holds the settings of a ProviderChain.
+type ProviderChainOptions struct {
+	// FailoverOn holds the classes of send errors which fail over to the next provider,
+	// see ClassifyError.
+	//
+	// Defaults to ErrorClassOutage.
+	FailoverOn ErrorClass
+
+	// OnFailover is called (if not nil) each time a send fails over to the next provider.
+	OnFailover func(event FailoverEvent)
+}
+
+// FailoverEvent describes a failover of a ProviderChain send.
+type FailoverEvent struct {
+	// From and To are the indexes of the failed and the next provider,
+	// the primary provider is 0 and the fallbacks follow in order.
+	From, To int
+	// Class is the class of Err.
+	Class ErrorClass
+	// Err is the send error of the failed provider.
+	Err error
+	// Tags are the tags of the notification.
+	Tags []string
+}
+
+// ProviderChain is a PushProvider which sends through a primary provider (e.g. the hub's Client)
+// and falls back to the next provider (e.g. a second hub, or direct FCM and APNs providers combined
+// with CombineProviders) when a send fails with one of the configured error classes,
+// giving push redundancy across providers.
+//
+// Registrations and deletions are mirrored to all providers under the same installation ID,
+// so the fallbacks can reach the same devices.
+//
+// Example usage:
+//
+//	chain := azurepush.NewProviderChain(azurepush.ProviderChainOptions{
+//		OnFailover: func(event azurepush.FailoverEvent) {
+//			log.Printf("push failover %d -> %d: %s: %v", event.From, event.To, event.Class, event.Err)
+//		},
+//	}, client, azurepush.CombineProviders(fcm, apns))
+//	err := chain.Send(ctx, notification, "user:42")
+type ProviderChain struct {
+	providers []PushProvider
+	opts      ProviderChainOptions
+}
+
+var _ PushProvider = (*ProviderChain)(nil)
+
+// NewProviderChain creates a new ProviderChain of the primary provider followed by the fallbacks.
+func NewProviderChain(opts ProviderChainOptions, primary PushProvider, fallbacks ...PushProvider) *ProviderChain {
+	providers := append([]PushProvider{primary}, fallbacks...)
+	for _, provider := range providers {
+		if provider == nil {
+			panic("azurepush: nil provider")
+		}
+	}
+
+	if opts.FailoverOn == 0 {
+		opts.FailoverOn = ErrorClassOutage
+	}
+
+	return &ProviderChain{providers: providers, opts: opts}
+}
+
+// Register implements the PushProvider interface.
+// The installation is registered to all providers which support its platform.
+func (c *ProviderChain) Register(ctx context.Context, installation Installation) (string, error) {
+	return registerAll(ctx, c.providers, installation)
+}
+
+// Unregister implements the PushProvider interface.
+// The installation is removed from all providers.
+func (c *ProviderChain) Unregister(ctx context.Context, installationID string) error {
+	return unregisterAll(ctx, c.providers, installationID)
+}
+
+// Send implements the PushProvider interface.
+// The notification is sent through the primary provider and, on a failure of the FailoverOn classes,
+// through the next providers in order until one succeeds. The error of the last tried provider is returned.
+func (c *ProviderChain) Send(ctx context.Context, notification Notification, tags ...string) error {
+	var err error
+	for i, provider := range c.providers {
+		if err = provider.Send(ctx, notification, tags...); err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil || i == len(c.providers)-1 {
+			return err
+		}
+
+		class := ClassifyError(err)
+		if !c.opts.FailoverOn.Has(class) {
+			return err
+		}
+
+		if c.opts.OnFailover != nil {
+			c.opts.OnFailover(FailoverEvent{From: i, To: i + 1, Class: class, Err: err, Tags: tags})
+		}
+	}
+
+	return err
+}
+
+// CombineProviders returns a PushProvider which registers and sends to all the given providers,
+// e.g. direct FCM and APNs providers, each reaching the devices of its own platform.
+//
+// A send succeeds when every provider succeeds, providers without a matching device are ignored
+// unless none of them has one.
+func CombineProviders(providers ...PushProvider) PushProvider {
+	if len(providers) == 0 {
+		panic("azurepush: no providers to combine")
+	}
+
+	for _, provider := range providers {
+		if provider == nil {
+			panic("azurepush: nil provider")
+		}
+	}
+
+	return combinedProvider(providers)
+}
+
+type combinedProvider []PushProvider
+
+func (p combinedProvider) Register(ctx context.Context, installation Installation) (string, error) {
+	return registerAll(ctx, p, installation)
+}
+
+func (p combinedProvider) Unregister(ctx context.Context, installationID string) error {
+	return unregisterAll(ctx, p, installationID)
+}
+
+func (p combinedProvider) Send(ctx context.Context, notification Notification, tags ...string) error {
+	var (
+		errs     []error
+		notFound []error
+	)
+	for _, provider := range p {
+		err := provider.Send(ctx, notification, tags...)
+		if errors.Is(err, errDeviceNotFound) {
+			notFound = append(notFound, err)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(notFound) == len(p) {
+		return errors.Join(notFound...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// registerAll registers the installation to the providers under the same (generated if empty) ID.
+// Providers which don't support the installation's platform are skipped, unless none supports it.
+func registerAll(ctx context.Context, providers []PushProvider, installation Installation) (string, error) {
+	if installation.InstallationID == "" {
+		installation.InstallationID = uuid.NewString()
+	}
+
+	var (
+		errs        []error
+		unsupported error
+		registered  bool
+	)
+	for i, provider := range providers {
+		_, err := provider.Register(ctx, installation)
+		switch {
+		case err == nil:
+			registered = true
+		case errors.Is(err, errUnsupportedPlatform):
+			unsupported = err
+		default:
+			errs = append(errs, fmt.Errorf("provider %d: %w", i, err))
+		}
+	}
+
+	if !registered && len(errs) == 0 {
+		return "", unsupported
+	}
+
+	return installation.InstallationID, errors.Join(errs...)
+}
+
+// unregisterAll removes the installation from all providers.
+func unregisterAll(ctx context.Context, providers []PushProvider, installationID string) error {
+	var errs []error
+	for i, provider := range providers {
+		if err := provider.Unregister(ctx, installationID); err != nil {
+			errs = append(errs, fmt.Errorf("provider %d: %w", i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,93 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestProviderChain(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	f := newFakeFCM(t)
+	fcm, err := azurepush.NewFCMProvider(azurepush.FCMOptions{
+		ServiceAccountJSON: f.serviceAccount(t),
+		Endpoint:           f.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []azurepush.FailoverEvent
+	chain := azurepush.NewProviderChain(azurepush.ProviderChainOptions{
+		OnFailover: func(event azurepush.FailoverEvent) {
+			events = append(events, event)
+		},
+	}, srv.NewClient(), fcm)
+
+	ctx := context.Background()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "iphone", Platform: azurepush.InstallationApple, PushChannel: "iphone-token", Tags: []string{"user:42"}},
+		{InstallationID: "pixel", Platform: azurepush.InstallationFCMV1, PushChannel: "pixel-token", Tags: []string{"user:42"}},
+	} {
+		if _, err = chain.Register(ctx, installation); err != nil {
+			t.Fatalf("%s: %v", installation.InstallationID, err)
+		}
+	}
+
+	if len(srv.Installations()) != 2 {
+		t.Errorf("expected both installations on the hub, got: %v", srv.Installations())
+	}
+	if _, err = fcm.Registry().Get(ctx, "pixel"); err != nil {
+		t.Errorf("expected the Android installation on the FCM provider: %v", err)
+	}
+	if _, err = fcm.Registry().Get(ctx, "iphone"); err == nil {
+		t.Error("expected the Apple installation to be skipped by the FCM provider")
+	}
+
+	notification := azurepush.Notification{Title: "Hello"}
+
+	if err = chain.Send(ctx, notification, "user:42"); err != nil || len(events) != 0 || len(f.tokens) != 0 {
+		t.Fatalf("expected the hub to send, got: %v, events: %v, FCM sends: %v", err, events, f.tokens)
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusServiceUnavailable, "outage")
+	if err = chain.Send(ctx, notification, "user:42"); err != nil {
+		t.Fatalf("expected the send to fail over, got: %v", err)
+	}
+	if len(events) != 1 || events[0].From != 0 || events[0].To != 1 || events[0].Class != azurepush.ErrorClassServer {
+		t.Errorf("expected a server failover event, got: %+v", events)
+	}
+	if !slices.Equal(f.tokens, []string{"pixel-token"}) {
+		t.Errorf("expected the fallback to reach the Android device, got: %v", f.tokens)
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusBadRequest, "invalid payload")
+	if err = chain.Send(ctx, notification, "user:42"); azurepush.ClassifyError(err) != azurepush.ErrorClassRequest {
+		t.Errorf("expected the request error of the hub, got: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("expected request errors not to fail over, got: %+v", events)
+	}
+
+	if err = chain.Unregister(ctx, "pixel"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Installation("pixel"); ok {
+		t.Error("expected the installation to be removed from the hub")
+	}
+	if _, err = fcm.Registry().Get(ctx, "pixel"); err == nil {
+		t.Error("expected the installation to be removed from the FCM provider")
+	}
+
+	if _, err = azurepush.CombineProviders(fcm).Register(ctx, azurepush.Installation{
+		InstallationID: "iphone", Platform: azurepush.InstallationApple, PushChannel: "iphone-token",
+	}); err == nil {
+		t.Error("expected an error when no provider supports the platform")
+	}
+}
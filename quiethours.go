@@ -0,0 +1,436 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TimeZoneResolver resolves the time zone of the devices targeted by a tag,
+// e.g. by looking up the user of a "user:<id>" tag.
+type TimeZoneResolver interface {
+	// TimeZone returns the location of the tag, or nil if it is unknown.
+	TimeZone(ctx context.Context, tag string) (*time.Location, error)
+}
+
+// TimeZoneResolverFunc is a function adapter for the TimeZoneResolver interface.
+type TimeZoneResolverFunc func(ctx context.Context, tag string) (*time.Location, error)
+
+// TimeZone implements the TimeZoneResolver interface.
+func (f TimeZoneResolverFunc) TimeZone(ctx context.Context, tag string) (*time.Location, error) {
+	return f(ctx, tag)
+}
+
+// QuietHours is a daily local time window in which notifications are not delivered.
+// The window may cross midnight, e.g. from 22:00 to 08:00.
+type QuietHours struct {
+	// Start and End are the local times of day the window starts and ends,
+	// as durations since midnight (e.g. 22*time.Hour and 8*time.Hour).
+	Start, End time.Duration
+
+	// Resolver resolves the time zone of each tag.
+	Resolver TimeZoneResolver
+	// DefaultLocation is used for tags of unknown time zone (and broadcasts).
+	//
+	// Defaults to UTC.
+	DefaultLocation *time.Location
+}
+
+// ReleaseTime returns the time a notification sent at t in the given location can be delivered:
+// t itself outside of the quiet hours, or the end of the window.
+func (q QuietHours) ReleaseTime(t time.Time, loc *time.Location) time.Time {
+	if q.Start == q.End {
+		return t
+	}
+
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	// The wall clock time of day, the elapsed time since midnight is an hour off on DST days.
+	offset := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+
+	if q.Start < q.End { // same day window, e.g. 01:00-06:00.
+		if offset >= q.Start && offset < q.End {
+			return q.at(midnight, 0)
+		}
+		return t
+	}
+
+	switch { // window crosses midnight, e.g. 22:00-08:00.
+	case offset >= q.Start:
+		return q.at(midnight, 1)
+	case offset < q.End:
+		return q.at(midnight, 0)
+	default:
+		return t
+	}
+}
+
+// at returns the end of the window, days after the given midnight.
+// The wall clock is used (instead of adding durations) so it stays correct across DST changes.
+func (q QuietHours) at(midnight time.Time, days int) time.Time {
+	end := q.End.Round(time.Minute)
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day()+days, int(end/time.Hour), int(end%time.Hour/time.Minute), 0, 0, midnight.Location())
+}
+
+// DeferredNotification is a notification held back by quiet hours.
+type DeferredNotification struct {
+	Notification Notification `json:"notification"`
+	Tags         []string     `json:"tags,omitempty"`
+	ReleaseAt    time.Time    `json:"releaseAt"`
+
+	key string // the Store key, if persisted.
+}
+
+// QuietHoursOptions holds the settings of a QuietHoursClient.
+type QuietHoursOptions struct {
+	// Clock reports the current time. The timers of Run are the clock's ones if it is a TimerClock.
+	//
+	// Defaults to SystemClock.
+	Clock Clock
+	// RetryInterval is the delay before a deferred notification whose send failed
+	// with a transient error (see ErrorClassOutage) is sent again.
+	//
+	// Defaults to 1 minute.
+	RetryInterval time.Duration
+	// OnDefer is called (if not nil) when a notification is deferred.
+	OnDefer func(deferred DeferredNotification)
+	// OnRelease is called (if not nil) after a deferred notification is sent, with the send error (if any).
+	// A notification requeued after a transient error is reported once it is sent, or fails permanently.
+	OnRelease func(deferred DeferredNotification, err error)
+
+	// Store, if not nil, persists the deferred notifications under the "quiethours/" prefix,
+	// so they are not lost on restarts. Run restores them.
+	Store Store
+}
+
+// QuietHoursClient wraps a HubClient and defers the notifications which would land
+// in the quiet hours of their recipients' local time, until the window ends.
+// Tags are grouped by their release time, so a single send may be split into several ones.
+//
+// Deferred notifications are kept in memory (and in the Store option, if set) and sent by Run.
+//
+// Example usage:
+//
+//	qc := azurepush.NewQuietHoursClient(client, azurepush.QuietHours{
+//		Start:    22 * time.Hour,
+//		End:      8 * time.Hour,
+//		Resolver: azurepush.TimeZoneResolverFunc(users.TimeZoneOfTag),
+//	}, azurepush.QuietHoursOptions{})
+//	go qc.Run(ctx)
+//
+//	err := qc.SendNotification(ctx, notification, "user:42", "user:43")
+type QuietHoursClient struct {
+	HubClient
+
+	quiet QuietHours
+	opts  QuietHoursOptions
+
+	mu       sync.Mutex
+	deferred []DeferredNotification // sorted by release time.
+	wake     chan struct{}
+}
+
+var _ HubClient = (*QuietHoursClient)(nil)
+
+// NewQuietHoursClient creates a new QuietHoursClient.
+func NewQuietHoursClient(client HubClient, quiet QuietHours, opts QuietHoursOptions) *QuietHoursClient {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if quiet.DefaultLocation == nil {
+		quiet.DefaultLocation = time.UTC
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = time.Minute
+	}
+
+	return &QuietHoursClient{
+		HubClient: client,
+		quiet:     quiet,
+		opts:      opts,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// SendNotification sends the notification to the tags outside of their quiet hours
+// and defers it for the rest.
+func (qc *QuietHoursClient) SendNotification(ctx context.Context, notification Notification, tags ...string) error {
+	now := qc.opts.Clock.Now()
+
+	if len(tags) == 0 { // broadcast.
+		return qc.sendOrDefer(ctx, notification, nil, qc.quiet.ReleaseTime(now, qc.quiet.DefaultLocation), now)
+	}
+
+	// The release times are grouped by instant: the same instant of different locations is not == as a time.Time.
+	type group struct {
+		releaseAt time.Time
+		tags      []string
+	}
+	groups := make(map[int64]*group)
+	for _, tag := range tags {
+		loc, err := qc.location(ctx, tag)
+		if err != nil {
+			return err
+		}
+
+		releaseAt := qc.quiet.ReleaseTime(now, loc)
+		g, ok := groups[releaseAt.UnixNano()]
+		if !ok {
+			g = &group{releaseAt: releaseAt}
+			groups[releaseAt.UnixNano()] = g
+		}
+		g.tags = append(g.tags, tag)
+	}
+
+	var errs []error
+	for _, key := range slices.Sorted(maps.Keys(groups)) {
+		errs = append(errs, qc.sendOrDefer(ctx, notification, groups[key].tags, groups[key].releaseAt, now))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (qc *QuietHoursClient) location(ctx context.Context, tag string) (*time.Location, error) {
+	if qc.quiet.Resolver == nil {
+		return qc.quiet.DefaultLocation, nil
+	}
+
+	loc, err := qc.quiet.Resolver.TimeZone(ctx, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve time zone of tag: %s: %w", tag, err)
+	}
+
+	if loc == nil {
+		return qc.quiet.DefaultLocation, nil
+	}
+
+	return loc, nil
+}
+
+func (qc *QuietHoursClient) sendOrDefer(ctx context.Context, notification Notification, tags []string, releaseAt, now time.Time) error {
+	if !releaseAt.After(now) {
+		return qc.HubClient.SendNotification(ctx, notification, tags...)
+	}
+
+	deferred := DeferredNotification{Notification: notification, Tags: tags, ReleaseAt: releaseAt}
+
+	if store := qc.opts.Store; store != nil {
+		// The release time prefixes the key, so the stored notifications are listed in release order.
+		deferred.key = fmt.Sprintf("%s%020d-%s", quietHoursKeyPrefix, releaseAt.UnixNano(), uuid.NewString())
+
+		value, err := json.Marshal(deferred)
+		if err != nil {
+			return err
+		}
+
+		if err = store.Put(ctx, deferred.key, value); err != nil {
+			return fmt.Errorf("failed to store deferred notification: %w", err)
+		}
+	}
+
+	qc.insert(deferred)
+
+	if qc.opts.OnDefer != nil {
+		qc.opts.OnDefer(deferred)
+	}
+
+	return nil
+}
+
+func (qc *QuietHoursClient) insert(deferred DeferredNotification) {
+	qc.mu.Lock()
+	i, _ := slices.BinarySearchFunc(qc.deferred, deferred.ReleaseAt, func(d DeferredNotification, t time.Time) int {
+		if d.ReleaseAt.After(t) {
+			return 1
+		}
+		return -1 // after the equal ones, keeping the send order.
+	})
+	qc.deferred = slices.Insert(qc.deferred, i, deferred)
+	qc.mu.Unlock()
+
+	select {
+	case qc.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Deferred returns the deferred notifications, sorted by their release time.
+func (qc *QuietHoursClient) Deferred() []DeferredNotification {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	return slices.Clone(qc.deferred)
+}
+
+// Release sends the deferred notifications whose release time has come.
+// A send which fails with a transient error (see ErrorClassOutage) is requeued after the RetryInterval,
+// and kept in the Store meanwhile. The notifications not sent because the context is done
+// or the client is closed (e.g. Run stopping on shutdown) are kept as they are, for the next Release or Run.
+func (qc *QuietHoursClient) Release(ctx context.Context) {
+	_ = qc.release(ctx)
+}
+
+// release is Release, it returns ErrClientClosed if the client is closed.
+func (qc *QuietHoursClient) release(ctx context.Context) error {
+	now := qc.opts.Clock.Now()
+
+	qc.mu.Lock()
+	n := 0
+	for n < len(qc.deferred) && !qc.deferred[n].ReleaseAt.After(now) {
+		n++
+	}
+	due := slices.Clone(qc.deferred[:n])
+	qc.deferred = slices.Delete(qc.deferred, 0, n)
+	qc.mu.Unlock()
+
+	// The outcome of a completed send is stored even if the context is done meanwhile.
+	storeCtx := context.WithoutCancel(ctx)
+
+	for i, deferred := range due {
+		if ctx.Err() != nil { // interrupted, the rest are kept.
+			for _, deferred := range due[i:] {
+				qc.insert(deferred)
+			}
+			return nil
+		}
+
+		err := qc.HubClient.SendNotification(ctx, deferred.Notification, deferred.Tags...)
+		if errors.Is(err, ErrClientClosed) {
+			for _, deferred := range due[i:] {
+				qc.insert(deferred)
+			}
+			return err
+		}
+		if err != nil && ctx.Err() != nil {
+			qc.insert(deferred) // not a definitive outcome, its Store entry is kept.
+			continue
+		}
+
+		if ClassifyError(err).Has(ErrorClassOutage) {
+			requeueErr := qc.requeue(storeCtx, deferred, now.Add(qc.opts.RetryInterval))
+			if requeueErr == nil {
+				continue
+			}
+			err = errors.Join(err, requeueErr)
+		}
+
+		if deferred.key != "" {
+			if deleteErr := qc.opts.Store.Delete(storeCtx, deferred.key); deleteErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to delete stored deferred notification: %w", deleteErr))
+			}
+		}
+
+		if qc.opts.OnRelease != nil {
+			qc.opts.OnRelease(deferred, err)
+		}
+	}
+
+	return nil
+}
+
+// requeue defers a notification whose send failed again, until the retry time.
+func (qc *QuietHoursClient) requeue(ctx context.Context, deferred DeferredNotification, retryAt time.Time) error {
+	deferred.ReleaseAt = retryAt
+
+	if deferred.key != "" {
+		value, err := json.Marshal(deferred)
+		if err != nil {
+			return err
+		}
+
+		if err = qc.opts.Store.Put(ctx, deferred.key, value); err != nil {
+			return fmt.Errorf("failed to store deferred notification: %w", err)
+		}
+	}
+
+	qc.insert(deferred)
+	return nil
+}
+
+// Run restores the stored deferred notifications (see QuietHoursOptions.Store)
+// and sends the deferred notifications when their release time comes,
+// until the context is canceled or the wrapped client is closed (see Client.Close).
+func (qc *QuietHoursClient) Run(ctx context.Context) error {
+	ctx, stop := startBackgroundOf(qc.HubClient, ctx)
+	defer stop()
+
+	if err := qc.restore(ctx); err != nil {
+		return err
+	}
+
+	for {
+		if err := qc.release(ctx); err != nil {
+			return nil // the client is closed.
+		}
+
+		var (
+			fire <-chan time.Time
+			stop = func() {}
+		)
+		qc.mu.Lock()
+		if len(qc.deferred) > 0 {
+			fire, stop = newClockTimer(qc.opts.Clock, qc.deferred[0].ReleaseAt.Sub(qc.opts.Clock.Now()))
+		}
+		qc.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			stop()
+			return nil
+		case <-fire:
+		case <-qc.wake:
+		}
+
+		stop()
+	}
+}
+
+// quietHoursKeyPrefix is the Store key prefix of the deferred notifications.
+const quietHoursKeyPrefix = "quiethours/"
+
+func (qc *QuietHoursClient) restore(ctx context.Context) error {
+	if qc.opts.Store == nil {
+		return nil
+	}
+
+	entries, err := qc.opts.Store.List(ctx, quietHoursKeyPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to load deferred notifications: %w", err)
+	}
+
+	qc.mu.Lock()
+	known := make(map[string]struct{}, len(qc.deferred))
+	for _, deferred := range qc.deferred {
+		known[deferred.key] = struct{}{}
+	}
+	qc.mu.Unlock()
+
+	for _, entry := range entries {
+		if _, ok := known[entry.Key]; ok {
+			continue
+		}
+
+		var deferred DeferredNotification
+		if err = json.Unmarshal(entry.Value, &deferred); err != nil {
+			return fmt.Errorf("invalid stored deferred notification: %s: %w", entry.Key, err)
+		}
+		deferred.key = entry.Key
+
+		qc.insert(deferred)
+	}
+
+	return nil
+}
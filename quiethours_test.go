@@ -0,0 +1,327 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestQuietHours_ReleaseTime(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	quiet := azurepush.QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"daytime", time.Date(2026, 3, 10, 12, 0, 0, 0, athens), time.Date(2026, 3, 10, 12, 0, 0, 0, athens)},
+		{"evening", time.Date(2026, 3, 10, 23, 30, 0, 0, athens), time.Date(2026, 3, 11, 8, 0, 0, 0, athens)},
+		{"early morning", time.Date(2026, 3, 10, 3, 0, 0, 0, athens), time.Date(2026, 3, 10, 8, 0, 0, 0, athens)},
+		{"window end", time.Date(2026, 3, 10, 8, 0, 0, 0, athens), time.Date(2026, 3, 10, 8, 0, 0, 0, athens)},
+		// Daylight saving time starts on 2026-03-29 at 03:00 in Athens.
+		{"dst", time.Date(2026, 3, 28, 22, 0, 0, 0, athens), time.Date(2026, 3, 29, 8, 0, 0, 0, athens)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The current time is given in UTC, the window applies to the local time.
+			if got := quiet.ReleaseTime(tt.now.UTC(), athens); !got.Equal(tt.want) {
+				t.Fatalf("expected release at %s, got: %s", tt.want, got)
+			}
+		})
+	}
+
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	dstTests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		// Daylight saving time ends on 2026-11-01 at 02:00 in New York, the day lasts 25 hours.
+		{"dst end", time.Date(2026, 11, 1, 21, 30, 0, 0, newYork), time.Date(2026, 11, 1, 21, 30, 0, 0, newYork)},
+		{"dst end quiet", time.Date(2026, 11, 1, 22, 30, 0, 0, newYork), time.Date(2026, 11, 2, 8, 0, 0, 0, newYork)},
+		// Daylight saving time starts on 2026-03-08 at 02:00 in New York, the day lasts 23 hours.
+		{"dst start", time.Date(2026, 3, 8, 21, 30, 0, 0, newYork), time.Date(2026, 3, 8, 21, 30, 0, 0, newYork)},
+		{"dst start quiet", time.Date(2026, 3, 8, 22, 30, 0, 0, newYork), time.Date(2026, 3, 9, 8, 0, 0, 0, newYork)},
+	}
+
+	for _, tt := range dstTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quiet.ReleaseTime(tt.now.UTC(), newYork); !got.Equal(tt.want) {
+				t.Fatalf("expected release at %s, got: %s", tt.want, got)
+			}
+		})
+	}
+
+	sameDay := azurepush.QuietHours{Start: time.Hour, End: 6 * time.Hour}
+	now := time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC)
+	if got := sameDay.ReleaseTime(now, time.UTC); !got.Equal(now) {
+		t.Fatalf("expected no deferral outside of a same day window, got: %s", got)
+	}
+}
+
+func TestQuietHoursClient(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	tokyo := time.FixedZone("Tokyo", 9*60*60)
+	// 13:00 UTC, 22:00 in Tokyo.
+	clock := azurepushtest.NewFakeClock(time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC))
+
+	var released []azurepush.DeferredNotification
+	qc := azurepush.NewQuietHoursClient(srv.NewClient(), azurepush.QuietHours{
+		Start: 22 * time.Hour,
+		End:   8 * time.Hour,
+		Resolver: azurepush.TimeZoneResolverFunc(func(ctx context.Context, tag string) (*time.Location, error) {
+			if tag == "user:tokyo" {
+				return tokyo, nil
+			}
+			return nil, nil // default (UTC).
+		}),
+	}, azurepush.QuietHoursOptions{
+		Clock: clock,
+		OnRelease: func(deferred azurepush.DeferredNotification, err error) {
+			if err != nil {
+				t.Errorf("unexpected release error: %v", err)
+			}
+			released = append(released, deferred)
+		},
+	})
+
+	ctx := context.Background()
+	notification := azurepush.Notification{Title: "Hello", Body: "World"}
+	if err := qc.SendNotification(ctx, notification, "user:london", "user:tokyo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(srv.Sent("user:london")) == 0 {
+		t.Fatal("expected the notification to be sent to the tag outside of quiet hours")
+	}
+	if got := len(srv.Sent("user:tokyo")); got != 0 {
+		t.Fatalf("expected the notification to be deferred for the tag in quiet hours, got: %d sends", got)
+	}
+
+	deferred := qc.Deferred()
+	if len(deferred) != 1 {
+		t.Fatalf("expected 1 deferred notification, got: %d", len(deferred))
+	}
+	if want := time.Date(2026, 3, 11, 8, 0, 0, 0, tokyo); !deferred[0].ReleaseAt.Equal(want) {
+		t.Fatalf("expected release at %s, got: %s", want, deferred[0].ReleaseAt)
+	}
+
+	clock.Advance(9*time.Hour + 59*time.Minute) // 07:59 in Tokyo.
+	qc.Release(ctx)
+	if len(released) != 0 {
+		t.Fatalf("expected no release before the window ends, got: %d", len(released))
+	}
+
+	clock.Advance(time.Minute)
+	qc.Release(ctx)
+	if len(released) != 1 || len(srv.Sent("user:tokyo")) == 0 {
+		t.Fatalf("expected the deferred notification to be released when the window ends")
+	}
+	if got := len(qc.Deferred()); got != 0 {
+		t.Fatalf("expected no deferred notifications, got: %d", got)
+	}
+}
+
+func TestQuietHoursClient_Store(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	store := azurepush.NewMemoryStore()
+	clock := azurepushtest.NewFakeClock(time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC))
+	quiet := azurepush.QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour}
+	ctx := context.Background()
+
+	qc := azurepush.NewQuietHoursClient(srv.NewClient(), quiet, azurepush.QuietHoursOptions{Clock: clock, Store: store})
+	if err := qc.SendNotification(ctx, azurepush.Notification{Title: "Good morning"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, _ := store.List(ctx, "quiethours/"); len(entries) != 1 {
+		t.Fatalf("expected the deferred notification to be stored, got: %d", len(entries))
+	}
+
+	// A new client, e.g. after a restart, restores the stored notifications when it runs.
+	clock.Advance(9 * time.Hour)
+	released := make(chan azurepush.DeferredNotification, 1)
+	restored := azurepush.NewQuietHoursClient(srv.NewClient(), quiet, azurepush.QuietHoursOptions{
+		Clock: clock,
+		Store: store,
+		OnRelease: func(deferred azurepush.DeferredNotification, err error) {
+			if err != nil {
+				t.Errorf("unexpected release error: %v", err)
+			}
+			released <- deferred
+		},
+	})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go restored.Run(runCtx)
+
+	select {
+	case deferred := <-released:
+		if deferred.Notification.Title != "Good morning" || deferred.Tags[0] != "user:42" {
+			t.Errorf("unexpected released notification: %+v", deferred)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the restored notification to be released")
+	}
+
+	if len(srv.Sent("user:42")) == 0 {
+		t.Error("expected the restored notification to be sent")
+	}
+	if entries, _ := store.List(ctx, "quiethours/"); len(entries) != 0 {
+		t.Errorf("expected the released notification to be deleted from the store, got: %d", len(entries))
+	}
+}
+
+func TestQuietHoursClient_Retry(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	store := azurepush.NewMemoryStore()
+	clock := azurepushtest.NewFakeClock(time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC))
+	released := make(chan error, 1)
+	qc := azurepush.NewQuietHoursClient(srv.NewClient(), azurepush.QuietHours{
+		Start: 22 * time.Hour,
+		End:   8 * time.Hour,
+		Resolver: azurepush.TimeZoneResolverFunc(func(ctx context.Context, tag string) (*time.Location, error) {
+			return time.FixedZone("GMT", 0), nil // the same instants as the default UTC location.
+		}),
+	}, azurepush.QuietHoursOptions{
+		Clock:         clock,
+		Store:         store,
+		RetryInterval: 5 * time.Minute,
+		OnRelease: func(_ azurepush.DeferredNotification, err error) {
+			released <- err
+		},
+	})
+
+	ctx := context.Background()
+	if err := qc.SendNotification(ctx, azurepush.Notification{Title: "Good morning"}, "user:1", "user:2"); err != nil {
+		t.Fatal(err)
+	}
+	if deferred := qc.Deferred(); len(deferred) != 1 || len(deferred[0].Tags) != 2 {
+		t.Fatalf("expected the tags of the same release time to be deferred together, got: %+v", deferred)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go qc.Run(runCtx)
+
+	// The hub is unavailable at the release time, the notification is kept for a retry.
+	srv.SetError(azurepushtest.OpSend, http.StatusServiceUnavailable, "")
+	clock.Advance(9 * time.Hour)
+	waitFor(t, func() bool {
+		deferred := qc.Deferred()
+		return len(deferred) == 1 && deferred[0].ReleaseAt.Equal(clock.Now().Add(5*time.Minute))
+	})
+	if entries, _ := store.List(ctx, "quiethours/"); len(entries) != 1 {
+		t.Fatalf("expected the failed notification to be kept in the store, got: %d", len(entries))
+	}
+
+	srv.ClearErrors()
+	clock.Advance(5 * time.Minute) // fires the timer of Run without waiting.
+	select {
+	case err := <-released:
+		if err != nil {
+			t.Fatalf("unexpected release error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the notification to be sent on retry")
+	}
+	if len(srv.Sent("user:2")) == 0 {
+		t.Error("expected the retried notification to be sent")
+	}
+}
+
+func TestQuietHoursClient_CanceledRelease(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	// The context is canceled during the first send, e.g. by a shutdown.
+	var cancelSend context.CancelFunc
+	client := srv.NewClient()
+	transport := client.HTTPClient.Transport
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if cancelSend != nil {
+			cancelSend()
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return transport.RoundTrip(r)
+	})
+
+	store := azurepush.NewMemoryStore()
+	clock := azurepushtest.NewFakeClock(time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC))
+	var released int
+	qc := azurepush.NewQuietHoursClient(client, azurepush.QuietHours{Start: 22 * time.Hour, End: 8 * time.Hour}, azurepush.QuietHoursOptions{
+		Clock: clock,
+		Store: store,
+		OnRelease: func(_ azurepush.DeferredNotification, err error) {
+			if err != nil {
+				t.Errorf("unexpected release error: %v", err)
+			}
+			released++
+		},
+	})
+
+	ctx := context.Background()
+	for _, tag := range []string{"user:1", "user:2"} {
+		if err := qc.SendNotification(ctx, azurepush.Notification{Title: "Good morning"}, tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock.Advance(9 * time.Hour)
+	canceled, cancel := context.WithCancel(ctx)
+	cancelSend = cancel
+	qc.Release(canceled)
+	cancelSend = nil
+
+	if released != 0 || len(srv.AllSent()) != 0 {
+		t.Fatalf("expected no release on a canceled context, got: %d released, %d sent", released, len(srv.AllSent()))
+	}
+	if deferred := qc.Deferred(); len(deferred) != 2 {
+		t.Fatalf("expected the notifications to be kept in memory, got: %d", len(deferred))
+	}
+	if entries, _ := store.List(ctx, "quiethours/"); len(entries) != 2 {
+		t.Fatalf("expected the notifications to be kept in the store, got: %d", len(entries))
+	}
+
+	qc.Release(ctx)
+	if released != 2 || len(srv.Sent("user:2")) == 0 {
+		t.Errorf("expected the kept notifications to be released, got: %d", released)
+	}
+	if entries, _ := store.List(ctx, "quiethours/"); len(entries) != 0 {
+		t.Errorf("expected the released notifications to be deleted from the store, got: %d", len(entries))
+	}
+}
+
+// waitFor polls the condition until it is true or a deadline passes.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); !condition(); {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
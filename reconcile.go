@@ -0,0 +1,150 @@
+package azurepush
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// KnownDevice is a device of the caller's source of truth (e.g. the devices table of the application's database),
+// see Client.Reconcile. At least one of its fields must be set.
+type KnownDevice struct {
+	// InstallationID is the ID of the device's installation.
+	InstallationID string
+	// PushChannel is the device token, it matches the registrations of other IDs too,
+	// e.g. the legacy registrations which are not installations.
+	PushChannel string
+}
+
+// KnownDevices returns the known devices of a slice as the source of truth of Client.Reconcile.
+func KnownDevices(devices []KnownDevice) iter.Seq2[KnownDevice, error] {
+	return func(yield func(KnownDevice, error) bool) {
+		for _, device := range devices {
+			if !yield(device, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ReconcileOptions holds the settings of Client.Reconcile.
+type ReconcileOptions struct {
+	// Filter limits the compared registrations of the hub, e.g. to the tenant of the source of truth.
+	// Defaults to all registrations.
+	Filter TagFilter
+
+	// DeleteOrphans deletes the registrations present only on the hub (see BulkDeleteOptions).
+	// It is rejected with an empty source of truth, which would delete all the (filtered) registrations.
+	DeleteOrphans bool
+	// Delete holds the settings of the deletion of the orphans, its DryRun field is ignored.
+	Delete BulkDeleteOptions
+}
+
+// ReconcileReport is the result of Client.Reconcile.
+type ReconcileReport struct {
+	// Matched is the number of registrations of the hub matching a known device (templates excluded).
+	Matched int
+	// HubOnly holds the registrations of the hub which match no known device, the orphans.
+	HubOnly []Registration
+	// KnownOnly holds the known devices which match no registration of the hub, e.g. devices to register again.
+	KnownOnly []KnownDevice
+	// Deleted is the number of deleted orphans, see ReconcileOptions.DeleteOrphans.
+	Deleted int
+	// Failed is the number of orphans whose deletion failed.
+	Failed int
+}
+
+// Reconcile compares the registrations of the hub against the caller's source of truth of "known good" devices
+// and reports the devices present only on one side, optionally deleting the hub-only orphans
+// (e.g. devices of deleted user accounts which still receive notifications).
+//
+// A registration matches a known device by its installation ID (see Registration.InstallationID)
+// or else by its push channel, so legacy registrations match too. The known devices are held in memory,
+// the registrations of the hub are listed page by page.
+//
+// An error is returned if the source of truth or the hub cannot be read, before anything is deleted
+// (a partially read source would report its missing devices as orphans), or joined with the failed deletions.
+//
+// Example usage:
+//
+//	known := func(yield func(azurepush.KnownDevice, error) bool) {
+//		for rows.Next() {
+//			var device azurepush.KnownDevice
+//			err := rows.Scan(&device.InstallationID, &device.PushChannel)
+//			if !yield(device, err) {
+//				return
+//			}
+//		}
+//		if err := rows.Err(); err != nil {
+//			yield(azurepush.KnownDevice{}, err)
+//		}
+//	}
+//
+//	report, err := client.Reconcile(ctx, known, azurepush.ReconcileOptions{DeleteOrphans: true})
+func (c *Client) Reconcile(ctx context.Context, known iter.Seq2[KnownDevice, error], opts ReconcileOptions) (*ReconcileReport, error) {
+	var (
+		devices   []KnownDevice
+		byID      = make(map[string]int)
+		byChannel = make(map[string]int)
+	)
+	for device, err := range known {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read known devices: %w", err)
+		}
+
+		if device.InstallationID == "" && device.PushChannel == "" {
+			return nil, fmt.Errorf("known device %d: installation ID or push channel is required", len(devices))
+		}
+
+		if device.InstallationID != "" {
+			byID[device.InstallationID] = len(devices)
+		}
+		if device.PushChannel != "" {
+			byChannel[device.PushChannel] = len(devices)
+		}
+		devices = append(devices, device)
+	}
+
+	if opts.DeleteOrphans && len(devices) == 0 {
+		return nil, fmt.Errorf("no known devices: refusing to delete all registrations as orphans")
+	}
+
+	var (
+		report  = new(ReconcileReport)
+		matched = make([]bool, len(devices))
+	)
+	for r, err := range c.filterRegistrations(ctx, opts.Filter) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list registrations: %w", err)
+		}
+
+		i, ok := byID[r.InstallationID()]
+		if !ok && r.PushChannel != "" {
+			i, ok = byChannel[r.PushChannel]
+		}
+
+		if !ok {
+			report.HubOnly = append(report.HubOnly, r)
+			continue
+		}
+
+		report.Matched++
+		matched[i] = true
+	}
+
+	for i, device := range devices {
+		if !matched[i] {
+			report.KnownOnly = append(report.KnownOnly, device)
+		}
+	}
+
+	if !opts.DeleteOrphans || len(report.HubOnly) == 0 {
+		return report, nil
+	}
+
+	deleteOpts := opts.Delete
+	deleteOpts.DryRun = false
+	deleted, err := c.deleteRegistrations(ctx, report.HubOnly, deleteOpts)
+	report.Deleted, report.Failed = deleted.Deleted, deleted.Failed
+	return report, err
+}
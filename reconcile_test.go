@@ -0,0 +1,77 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_Reconcile(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "kept", Platform: azurepush.InstallationApple, PushChannel: "token-kept", Tags: []string{"tenant:acme"}},
+		{InstallationID: "reinstalled", Platform: azurepush.InstallationApple, PushChannel: "token-same", Tags: []string{"tenant:acme"}},
+		{InstallationID: "orphan", Platform: azurepush.InstallationFCMV1, PushChannel: "token-orphan", Tags: []string{"tenant:acme"},
+			Templates: map[string]azurepush.Template{"generic": {Body: `{}`}}},
+		{InstallationID: "other-tenant", Platform: azurepush.InstallationApple, PushChannel: "token-other", Tags: []string{"tenant:globex"}},
+	} {
+		if _, err := client.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	known := []azurepush.KnownDevice{
+		{InstallationID: "kept"},
+		{InstallationID: "new-id", PushChannel: "token-same"}, // matched by its push channel.
+		{InstallationID: "missing"},
+	}
+	opts := azurepush.ReconcileOptions{Filter: azurepush.TagFilter{TagExpression: "tenant:acme"}}
+
+	report, err := client.Reconcile(ctx, azurepush.KnownDevices(known), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Matched != 2 || len(report.HubOnly) != 1 || report.HubOnly[0].InstallationID() != "orphan" ||
+		len(report.KnownOnly) != 1 || report.KnownOnly[0].InstallationID != "missing" || report.Deleted != 0 {
+		t.Fatalf("unexpected report: %+v", *report)
+	}
+	if len(srv.Installations()) != 4 {
+		t.Fatal("expected no deletions without DeleteOrphans")
+	}
+
+	opts.DeleteOrphans = true
+	if report, err = client.Reconcile(ctx, azurepush.KnownDevices(known), opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := srv.Installation("orphan"); ok || report.Deleted != 1 || len(srv.Installations()) != 3 {
+		t.Fatalf("expected the orphan deleted, got: %+v", *report)
+	}
+
+	if _, err = client.Reconcile(ctx, azurepush.KnownDevices(nil), opts); err == nil {
+		t.Error("expected an error deleting orphans of an empty source of truth")
+	}
+	if _, err = client.Reconcile(ctx, azurepush.KnownDevices([]azurepush.KnownDevice{{}}), azurepush.ReconcileOptions{}); err == nil {
+		t.Error("expected an empty known device error")
+	}
+
+	// A source of truth which fails midway deletes nothing, its unread devices would be reported as orphans.
+	failing := func(yield func(azurepush.KnownDevice, error) bool) {
+		if yield(azurepush.KnownDevice{InstallationID: "kept"}, nil) {
+			yield(azurepush.KnownDevice{}, errors.New("connection reset"))
+		}
+	}
+	if _, err = client.Reconcile(ctx, failing, opts); err == nil {
+		t.Error("expected the error of the source of truth")
+	}
+	if len(srv.Installations()) != 3 {
+		t.Errorf("expected no deletions after a source error, got: %d installations", len(srv.Installations()))
+	}
+}
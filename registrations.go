@@ -0,0 +1,402 @@
+package azurepush
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	neturl "net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// registrationsPageSize is the maximum number of registrations per page of the list API.
+const registrationsPageSize = 100
+
+// Registration is a device registration of the hub, as returned by the list API.
+// Every installation is backed by a registration (plus one per template).
+type Registration struct {
+	RegistrationID string
+	// Platform is the platform of the device, one of the Installation* constants
+	// (or the lower-cased platform name of legacy registrations, e.g. "gcm").
+	Platform InstallationPlatform
+	// PushChannel is the device token (or channel URI) of the registration.
+	PushChannel string
+	// Template reports whether the registration is a template registration.
+	Template       bool
+	Tags           []string
+	ExpirationTime time.Time
+	// CreatedAt and UpdatedAt are the creation and last update times of the registration.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// InstallationID returns the ID of the installation backing the registration (its $InstallationId tag),
+// or an empty string for registrations created through the legacy registrations API.
+func (r Registration) InstallationID() string {
+	for _, tag := range r.Tags {
+		if id, ok := Tag(tag).installationID(); ok {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// ListRegistrations returns all registrations of the hub, following the pagination of the list API.
+// It may take a while on large hubs, use ListRegistrationsByTag when possible.
+func (c *Client) ListRegistrations(ctx context.Context) ([]Registration, error) {
+	return c.listRegistrations(ctx, "registrations")
+}
+
+// ListRegistrationsByTag returns the registrations with the given tag,
+// following the pagination of the list API.
+func (c *Client) ListRegistrationsByTag(ctx context.Context, tag string) ([]Registration, error) {
+	if tag == "" {
+		return nil, fmt.Errorf("tag cannot be empty")
+	}
+
+	return c.listRegistrations(ctx, hubPath("tags", tag, "registrations"))
+}
+
+func (c *Client) listRegistrations(ctx context.Context, resource string) ([]Registration, error) {
+	var registrations []Registration
+	for page, err := range c.registrationPages(ctx, resource) {
+		if err != nil {
+			return nil, err
+		}
+		registrations = append(registrations, page...)
+	}
+
+	return registrations, nil
+}
+
+// registrationPages returns an iterator over the pages of registrations of the list API,
+// following its pagination. It stops at the first error.
+func (c *Client) registrationPages(ctx context.Context, resource string) iter.Seq2[[]Registration, error] {
+	return func(yield func([]Registration, error) bool) {
+		var continuation string
+		for {
+			page, next, err := c.listRegistrationsPage(ctx, resource, continuation)
+			if !yield(page, err) || err != nil || next == "" {
+				return
+			}
+			continuation = next
+		}
+	}
+}
+
+// listRegistrationsPage returns a page of registrations and the continuation token of the next page, if any.
+func (c *Client) listRegistrationsPage(ctx context.Context, resource, continuation string) ([]Registration, string, error) {
+	cfg, tm := c.current()
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	query := neturl.Values{"$top": {fmt.Sprint(registrationsPageSize)}}
+	if continuation != "" {
+		query.Set("ContinuationToken", continuation)
+	}
+
+	url := cfg.hubURL(resource, "2015-01") + "&" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list registrations: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, "", fmt.Errorf("failed to list registrations: %s: %s", resp.Status, string(b))
+	}
+
+	var feed registrationFeed
+	if err = xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, "", fmt.Errorf("failed to decode registrations: %w", err)
+	}
+
+	registrations := make([]Registration, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		r := entry.Content.Description.registration()
+		r.CreatedAt, _ = time.Parse(time.RFC3339, entry.Published)
+		r.UpdatedAt, _ = time.Parse(time.RFC3339, entry.Updated)
+		registrations = append(registrations, r)
+	}
+
+	return registrations, resp.Header.Get("X-MS-ContinuationToken"), nil
+}
+
+// deleteRegistration deletes a registration by its ID, a missing registration is not an error.
+// Registrations of installations should be deleted with DeleteDevice.
+func (c *Client) deleteRegistration(ctx context.Context, registrationID string) (err error) {
+	cfg, tm := c.current()
+	defer func() { c.audit(ctx, AuditRecord{Operation: AuditDelete, RegistrationID: registrationID}, err) }()
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	url := cfg.hubURL(hubPath("registrations", registrationID), "2015-01")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("If-Match", "*")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete registration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete registration: %s: %s: %s", registrationID, resp.Status, string(b))
+	}
+}
+
+// registrationFeed is the Atom feed of the list registrations API.
+type registrationFeed struct {
+	Entries []struct {
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Content   struct {
+			Description registrationDescription `xml:",any"`
+		} `xml:"content"`
+	} `xml:"entry"`
+}
+
+// registrationDescription is a platform registration description, e.g. <AppleRegistrationDescription>.
+type registrationDescription struct {
+	XMLName        xml.Name
+	RegistrationID string `xml:"RegistrationId"`
+	Tags           string `xml:"Tags"`
+	ExpirationTime string `xml:"ExpirationTime"`
+
+	// The push channel element depends on the platform.
+	DeviceToken         string `xml:"DeviceToken"`
+	FcmV1RegistrationID string `xml:"FcmV1RegistrationId"`
+	GcmRegistrationID   string `xml:"GcmRegistrationId"`
+	BaiduChannelID      string `xml:"BaiduChannelId"`
+	ChannelURI          string `xml:"ChannelUri"`
+	ADMRegistrationID   string `xml:"AdmRegistrationId"`
+}
+
+func (d registrationDescription) registration() Registration {
+	name := strings.TrimSuffix(d.XMLName.Local, "RegistrationDescription")
+	platform, template := strings.CutSuffix(name, "Template")
+
+	r := Registration{
+		RegistrationID: d.RegistrationID,
+		Platform:       registrationPlatform(platform),
+		PushChannel:    cmp.Or(d.DeviceToken, d.FcmV1RegistrationID, d.GcmRegistrationID, d.BaiduChannelID, d.ChannelURI, d.ADMRegistrationID),
+		Template:       template,
+	}
+
+	for tag := range strings.SplitSeq(d.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			r.Tags = append(r.Tags, tag)
+		}
+	}
+
+	r.ExpirationTime, _ = time.Parse(time.RFC3339, d.ExpirationTime)
+	return r
+}
+
+func registrationPlatform(name string) InstallationPlatform {
+	switch name {
+	case "Apple":
+		return InstallationApple
+	case "FcmV1":
+		return InstallationFCMV1
+	case "Baidu":
+		return InstallationBaidu
+	case "Windows":
+		return InstallationWNS
+	case "Mpns":
+		return InstallationMPNS
+	case "Adm":
+		return InstallationADM
+	default:
+		return InstallationPlatform(strings.ToLower(name))
+	}
+}
+
+// AudienceEstimate is the estimated number of devices matching a tag expression.
+type AudienceEstimate struct {
+	// Total is the number of matching registrations.
+	Total int
+	// ByPlatform holds the number of matching registrations per platform (see Registration.Platform).
+	ByPlatform map[InstallationPlatform]int
+}
+
+// EstimateAudience counts the registrations matching a tag expression, per platform,
+// e.g. to know roughly how many devices a campaign reaches before launching it.
+// An empty expression counts all registrations (a broadcast).
+//
+// The registrations of each tag of the expression are listed and the expression is evaluated
+// against their tags; expressions with a ! (NOT) operator list all registrations instead.
+// Template registrations are not counted, so each installation counts once.
+func (c *Client) EstimateAudience(ctx context.Context, tagExpression string) (*AudienceEstimate, error) {
+	registrations, err := c.matchRegistrations(ctx, tagExpression)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &AudienceEstimate{ByPlatform: make(map[InstallationPlatform]int)}
+	for _, r := range registrations {
+		estimate.Total++
+		estimate.ByPlatform[r.Platform]++
+	}
+
+	return estimate, nil
+}
+
+// TagCount is the number of registrations of a tag, see Client.TagReport.
+type TagCount struct {
+	Tag string
+	AudienceEstimate
+}
+
+// TagReport counts the registrations of each tag, per platform, following the pagination of the list API
+// page by page, e.g. to validate audience sizes or to detect tags which unexpectedly dropped to zero
+// after an app release. The counts are in the order of the tags, a tag without registrations counts zero.
+// Template registrations are not counted, so each installation counts once.
+//
+// Example usage:
+//
+//	counts, err := client.TagReport(ctx, []string{"topic:news", "topic:sports"})
+//	for _, count := range counts {
+//		if count.Total == 0 {
+//			log.Printf("no devices subscribed to %s", count.Tag)
+//		}
+//	}
+func (c *Client) TagReport(ctx context.Context, tags []string) ([]TagCount, error) {
+	for _, tag := range tags {
+		if err := Tag(tag).Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make([]TagCount, 0, len(tags))
+	for _, tag := range tags {
+		count := TagCount{Tag: tag, AudienceEstimate: AudienceEstimate{ByPlatform: make(map[InstallationPlatform]int)}}
+		for page, err := range c.registrationPages(ctx, hubPath("tags", tag, "registrations")) {
+			if err != nil {
+				return nil, fmt.Errorf("tag: %s: %w", tag, err)
+			}
+
+			for _, r := range page {
+				if r.Template {
+					continue
+				}
+				count.Total++
+				count.ByPlatform[r.Platform]++
+			}
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// TagFilter selects registrations by their tags and platform, see Client.BulkUpdateTags.
+type TagFilter struct {
+	// TagExpression selects the registrations by their tags, e.g. "tenant:acme" or "region:eu && !beta".
+	// An empty expression matches all registrations.
+	TagExpression string
+	// Platforms, if not empty, limits the matched registrations to these platforms.
+	Platforms []InstallationPlatform
+}
+
+// matchRegistrations returns the registrations, without the template ones, matching a tag expression
+// (all if empty). See EstimateAudience.
+func (c *Client) matchRegistrations(ctx context.Context, tagExpression string) ([]Registration, error) {
+	var matched []Registration
+	for r, err := range c.filterRegistrations(ctx, TagFilter{TagExpression: tagExpression}) {
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, r)
+	}
+
+	return matched, nil
+}
+
+// filterRegistrations returns an iterator over the registrations, without the template ones, matching a filter.
+// The registrations of each tag of the expression are listed page by page and the expression is evaluated
+// against their tags; expressions with a ! (NOT) operator list all registrations instead.
+// It stops at the first error.
+func (c *Client) filterRegistrations(ctx context.Context, filter TagFilter) iter.Seq2[Registration, error] {
+	return func(yield func(Registration, error) bool) {
+		var expr *TagExpression
+		if strings.TrimSpace(filter.TagExpression) != "" {
+			var err error
+			if expr, err = ParseTagExpression(filter.TagExpression); err != nil {
+				yield(Registration{}, err)
+				return
+			}
+		}
+
+		resources := []string{"registrations"}
+		if expr != nil && !expr.HasNegation() {
+			resources = resources[:0]
+			for _, tag := range expr.Tags() {
+				resources = append(resources, hubPath("tags", tag, "registrations"))
+			}
+		}
+
+		seen := make(map[string]struct{})
+		for _, resource := range resources {
+			for page, err := range c.registrationPages(ctx, resource) {
+				if err != nil {
+					yield(Registration{}, err)
+					return
+				}
+
+				for _, r := range page {
+					if r.Template {
+						continue
+					}
+
+					if _, ok := seen[r.RegistrationID]; ok {
+						continue
+					}
+					seen[r.RegistrationID] = struct{}{}
+
+					if expr != nil && !expr.Match(r.Tags) {
+						continue
+					}
+
+					if len(filter.Platforms) > 0 && !slices.Contains(filter.Platforms, r.Platform) {
+						continue
+					}
+
+					if !yield(r, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package azurepush_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_EstimateAudience(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	// 150 fans to span two pages of the list API.
+	for i := range 150 {
+		platform, tags := azurepush.InstallationApple, []string{"follows:RedSox", "location:Boston"}
+		if i%3 == 0 {
+			platform, tags = azurepush.InstallationFCMV1, []string{"follows:RedSox", "location:NYC"}
+		}
+
+		installation := azurepush.Installation{
+			InstallationID: fmt.Sprintf("device-%d", i),
+			Platform:       platform,
+			PushChannel:    "token",
+			Tags:           tags,
+		}
+		if i == 0 {
+			installation.Templates = map[string]azurepush.Template{"greeting": {Body: `{"aps":{"alert":"$(message)"}}`}}
+		}
+
+		if _, err := client.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		expr                string
+		total, apple, fcmV1 int
+	}{
+		{"follows:RedSox", 150, 100, 50},
+		{"follows:RedSox && location:Boston", 100, 100, 0},
+		{"follows:RedSox && !location:Boston", 50, 0, 50},
+		{"location:NYC || location:Boston", 150, 100, 50},
+		{"", 150, 100, 50},
+		{"follows:Cardinals", 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		estimate, err := client.EstimateAudience(ctx, tt.expr)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.expr, err)
+		}
+
+		if estimate.Total != tt.total || estimate.ByPlatform[azurepush.InstallationApple] != tt.apple || estimate.ByPlatform[azurepush.InstallationFCMV1] != tt.fcmV1 {
+			t.Errorf("%q: unexpected estimate: %+v", tt.expr, estimate)
+		}
+	}
+
+	registrations, err := client.ListRegistrationsByTag(ctx, "location:NYC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50 installations plus the template registration of device-0.
+	if len(registrations) != 51 || registrations[0].Platform != azurepush.InstallationFCMV1 {
+		t.Errorf("unexpected registrations: %d", len(registrations))
+	}
+	if r := registrations[0]; r.InstallationID() != "device-0" || r.CreatedAt.IsZero() || r.UpdatedAt.IsZero() {
+		t.Errorf("unexpected registration: %+v", r)
+	}
+
+	if _, err = client.EstimateAudience(ctx, "a &&"); err == nil {
+		t.Error("expected an error for an invalid tag expression")
+	}
+
+	srv.SetError(azurepushtest.OpList, http.StatusUnauthorized, "unauthorized")
+	if _, err = client.EstimateAudience(ctx, "follows:RedSox"); err == nil {
+		t.Error("expected an error when the list API fails")
+	}
+}
+
+func TestClient_TagReport(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	// 120 subscribers to span two pages of the list API.
+	for i := range 120 {
+		platform := azurepush.InstallationApple
+		if i%4 == 0 {
+			platform = azurepush.InstallationFCMV1
+		}
+
+		installation := azurepush.Installation{
+			InstallationID: fmt.Sprintf("device-%d", i),
+			Platform:       platform,
+			PushChannel:    "token",
+			Tags:           []string{"topic:news"},
+		}
+		if i < 10 {
+			installation.Tags = append(installation.Tags, "topic:sports")
+			installation.Templates = map[string]azurepush.Template{"generic": {Body: `{"aps":{"alert":"$(message)"}}`}}
+		}
+
+		if _, err := client.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, err := client.TagReport(ctx, []string{"topic:news", "topic:sports", "topic:weather"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		tag                 string
+		total, apple, fcmV1 int
+	}{
+		{"topic:news", 120, 90, 30},
+		{"topic:sports", 10, 7, 3},
+		{"topic:weather", 0, 0, 0},
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("expected %d counts, got: %d", len(expected), len(counts))
+	}
+	for i, tt := range expected {
+		if c := counts[i]; c.Tag != tt.tag || c.Total != tt.total || c.ByPlatform[azurepush.InstallationApple] != tt.apple || c.ByPlatform[azurepush.InstallationFCMV1] != tt.fcmV1 {
+			t.Errorf("%s: unexpected count: %+v", tt.tag, c)
+		}
+	}
+
+	if _, err = client.TagReport(ctx, []string{"bad tag"}); err == nil {
+		t.Error("expected an invalid tag error")
+	}
+
+	srv.SetError(azurepushtest.OpList, http.StatusInternalServerError, "boom")
+	if _, err = client.TagReport(ctx, []string{"topic:news"}); err == nil {
+		t.Error("expected an error when the list API fails")
+	}
+}
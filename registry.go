@@ -0,0 +1,229 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Store key prefixes of the DeviceRegistry.
+const (
+	deviceKeyPrefix    = "devices/"
+	deviceTagKeyPrefix = "devicetags/"
+)
+
+// DeviceRegistry keeps the device installations and their tags for the direct providers
+// (e.g. FCMProvider), which, unlike a Notification Hub, have no device bookkeeping.
+// It is backed by a Store: devices are stored under the "devices/" prefix
+// and indexed by tag under the "devicetags/" prefix.
+type DeviceRegistry struct {
+	store Store
+}
+
+// NewDeviceRegistry creates a new DeviceRegistry backed by the given store.
+func NewDeviceRegistry(store Store) *DeviceRegistry {
+	if store == nil {
+		panic("azurepush: nil store")
+	}
+
+	return &DeviceRegistry{store: store}
+}
+
+// Put creates or replaces an installation.
+func (r *DeviceRegistry) Put(ctx context.Context, installation Installation) error {
+	if err := installation.Validate(); err != nil {
+		return fmt.Errorf("invalid installation data: %w", err)
+	}
+
+	previous, err := r.Get(ctx, installation.InstallationID)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+
+	value, err := json.Marshal(installation)
+	if err != nil {
+		return err
+	}
+
+	if err = r.store.Put(ctx, deviceKeyPrefix+installation.InstallationID, value); err != nil {
+		return fmt.Errorf("failed to store installation: %s: %w", installation.InstallationID, err)
+	}
+
+	for _, tag := range previous.Tags {
+		if !slices.Contains(installation.Tags, tag) {
+			if err = r.store.Delete(ctx, deviceTagKey(tag, installation.InstallationID)); err != nil {
+				return fmt.Errorf("failed to delete tag index: %s: %w", tag, err)
+			}
+		}
+	}
+
+	for _, tag := range installation.Tags {
+		if err = r.store.Put(ctx, deviceTagKey(tag, installation.InstallationID), nil); err != nil {
+			return fmt.Errorf("failed to store tag index: %s: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns an installation by its ID, or ErrNotFound.
+func (r *DeviceRegistry) Get(ctx context.Context, installationID string) (Installation, error) {
+	value, err := r.store.Get(ctx, deviceKeyPrefix+installationID)
+	if err != nil {
+		return Installation{}, err
+	}
+
+	var installation Installation
+	if err = json.Unmarshal(value, &installation); err != nil {
+		return Installation{}, fmt.Errorf("invalid stored installation: %s: %w", installationID, err)
+	}
+
+	return installation, nil
+}
+
+// Delete removes an installation. Removing a missing installation is not an error.
+func (r *DeviceRegistry) Delete(ctx context.Context, installationID string) error {
+	installation, err := r.Get(ctx, installationID)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range installation.Tags {
+		if err = r.store.Delete(ctx, deviceTagKey(tag, installationID)); err != nil {
+			return fmt.Errorf("failed to delete tag index: %s: %w", tag, err)
+		}
+	}
+
+	return r.store.Delete(ctx, deviceKeyPrefix+installationID)
+}
+
+// Match returns the installations of the given platform (all platforms if empty) which have any of the tags,
+// or all of them if no tag is given. Like a Notification Hub, a tag can be a tag expression
+// (e.g. "follows:RedSox && !location:Boston"), which is evaluated against all installations.
+func (r *DeviceRegistry) Match(ctx context.Context, platform InstallationPlatform, tags ...string) ([]Installation, error) {
+	var (
+		expressions []*TagExpression
+		all         = len(tags) == 0
+	)
+	for _, tag := range tags {
+		if strings.ContainsAny(tag, "&|!()") {
+			expr, err := ParseTagExpression(tag)
+			if err != nil {
+				return nil, err
+			}
+			expressions = append(expressions, expr)
+			all = true
+		}
+	}
+
+	var ids []string
+	for _, tag := range tags {
+		if all {
+			break // the tags are matched against all installations.
+		}
+
+		prefix := deviceTagKeyPrefix + tag + "/"
+		entries, err := r.store.List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tag: %s: %w", tag, err)
+		}
+		for _, entry := range entries {
+			ids = append(ids, strings.TrimPrefix(entry.Key, prefix))
+		}
+	}
+
+	var installations []Installation
+	if all {
+		entries, err := r.store.List(ctx, deviceKeyPrefix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list installations: %w", err)
+		}
+
+		for _, entry := range entries {
+			var installation Installation
+			if err = json.Unmarshal(entry.Value, &installation); err != nil {
+				return nil, fmt.Errorf("invalid stored installation: %s: %w", entry.Key, err)
+			}
+
+			if len(tags) == 0 || slices.ContainsFunc(installation.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) ||
+				slices.ContainsFunc(expressions, func(expr *TagExpression) bool { return expr.Match(installation.Tags) }) {
+				installations = append(installations, installation)
+			}
+		}
+	} else {
+		slices.Sort(ids)
+		for _, id := range slices.Compact(ids) {
+			installation, err := r.Get(ctx, id)
+			if errors.Is(err, ErrNotFound) {
+				continue // deleted meanwhile.
+			}
+			if err != nil {
+				return nil, err
+			}
+			installations = append(installations, installation)
+		}
+	}
+
+	if platform != "" {
+		installations = slices.DeleteFunc(installations, func(installation Installation) bool {
+			return installation.Platform != platform
+		})
+	}
+
+	return installations, nil
+}
+
+func deviceTagKey(tag, installationID string) string {
+	return deviceTagKeyPrefix + tag + "/" + installationID
+}
+
+// errDeviceUnregistered is returned by the direct providers when the push service reports
+// a device token as invalid or unregistered (e.g. the app was uninstalled).
+var errDeviceUnregistered = errors.New("device token is unregistered")
+
+// sendToDevices calls send for each installation of the platform matching the tags, with bounded concurrency.
+// The installations whose token is reported as unregistered are removed from the registry.
+func sendToDevices(ctx context.Context, registry *DeviceRegistry, platform InstallationPlatform, tags []string, concurrency int, send func(installation Installation) error) error {
+	installations, err := registry.Match(ctx, platform, tags...)
+	if err != nil {
+		return err
+	}
+
+	if len(installations) == 0 {
+		return fmt.Errorf("%w: for tag(s): %s", errDeviceNotFound, strings.Join(tags, ", "))
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, installation := range installations {
+		sem <- struct{}{}
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			err := send(installation)
+			if errors.Is(err, errDeviceUnregistered) {
+				err = registry.Delete(ctx, installation.InstallationID)
+			}
+
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("installation: %s: %w", installation.InstallationID, err))
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestDeviceRegistry(t *testing.T) {
+	registry := azurepush.NewDeviceRegistry(azurepush.NewMemoryStore())
+	ctx := context.Background()
+
+	installations := []azurepush.Installation{
+		{InstallationID: "a", Platform: azurepush.InstallationFCMV1, PushChannel: "token-a", Tags: []string{"user:1", "follows:RedSox"}},
+		{InstallationID: "b", Platform: azurepush.InstallationApple, PushChannel: "token-b", Tags: []string{"user:1"}},
+		{InstallationID: "c", Platform: azurepush.InstallationFCMV1, PushChannel: "token-c", Tags: []string{"user:2", "follows:RedSox", "location:Boston"}},
+	}
+	for _, installation := range installations {
+		if err := registry.Put(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(installations []azurepush.Installation) []string {
+		var ids []string
+		for _, installation := range installations {
+			ids = append(ids, installation.InstallationID)
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	tests := []struct {
+		platform azurepush.InstallationPlatform
+		tags     []string
+		want     []string
+	}{
+		{"", []string{"user:1"}, []string{"a", "b"}},
+		{azurepush.InstallationFCMV1, []string{"user:1"}, []string{"a"}},
+		{"", []string{"user:1", "user:2"}, []string{"a", "b", "c"}},
+		{"", []string{"follows:RedSox && !location:Boston"}, []string{"a"}},
+		{"", nil, []string{"a", "b", "c"}},
+		{"", []string{"user:3"}, nil},
+	}
+	for _, tt := range tests {
+		got, err := registry.Match(ctx, tt.platform, tt.tags...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(ids(got), tt.want) {
+			t.Errorf("%q %v: expected %v, got: %v", tt.platform, tt.tags, tt.want, ids(got))
+		}
+	}
+
+	// Replacing an installation updates its tags.
+	installations[0].Tags = []string{"user:3"}
+	if err := registry.Put(ctx, installations[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := registry.Match(ctx, "", "user:1"); !slices.Equal(ids(got), []string{"b"}) {
+		t.Errorf("expected the old tag to be removed, got: %v", ids(got))
+	}
+
+	if err := registry.Delete(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Get(ctx, "c"); !errors.Is(err, azurepush.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+	if got, _ := registry.Match(ctx, "", "user:2"); len(got) != 0 {
+		t.Errorf("expected the deleted installation not to match, got: %v", ids(got))
+	}
+	if err := registry.Delete(ctx, "missing"); err != nil {
+		t.Errorf("expected no error deleting a missing installation, got: %v", err)
+	}
+
+	if err := registry.Put(ctx, azurepush.Installation{InstallationID: "d"}); err == nil {
+		t.Error("expected an error for an invalid installation")
+	}
+}
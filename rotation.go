@@ -0,0 +1,84 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RotateCredentials switches the client to new credentials without downtime, e.g. after a key regeneration.
+// The new configuration is verified with a probe call to its hub (see ValidateSASToken) before any request uses it,
+// then the client is reloaded (see Reload, the cached tokens are invalidated) and verified again through its own pipeline.
+// If a verification fails the client keeps, or is rolled back to, its previous configuration and the error is returned.
+//
+// The send access policy of the new configuration (see Configuration.SendKeyName), if any, is not probed:
+// a send-only policy cannot be verified without sending a notification.
+//
+// With the primary and secondary keys of a shared access policy, rotate one key at a time
+// so the tokens signed by the other key stay valid meanwhile:
+//
+//	keys, err := mc.RegenerateKey(ctx, "DefaultFullSharedAccessSignature", azurepush.SecondaryKey)
+//	if err != nil {
+//		return err
+//	}
+//
+//	cfg := client.Config
+//	cfg.ConnectionString = keys.ConnectionString(azurepush.SecondaryKey)
+//	if err = client.RotateCredentials(ctx, cfg); err != nil {
+//		return err // the client still uses the primary key.
+//	}
+//	// The primary key can be regenerated safely now.
+func (c *Client) RotateCredentials(ctx context.Context, cfg Configuration) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid credentials: %w", err)
+	}
+
+	c.rotation.Lock()
+	defer c.rotation.Unlock()
+
+	if err := c.probeCredentials(ctx, cfg); err != nil {
+		return fmt.Errorf("failed to verify the new credentials: %w", err)
+	}
+
+	previous, _ := c.current()
+	if err := c.Reload(cfg); err != nil {
+		return err
+	}
+
+	if err := c.ValidateToken(ctx); err != nil {
+		if rollbackErr := c.Reload(previous); rollbackErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to roll back: %w", rollbackErr))
+		}
+		return fmt.Errorf("failed to verify the new credentials, rolled back: %w", err)
+	}
+
+	return nil
+}
+
+// probeCredentials verifies the credentials of a configuration with a token of its own,
+// without changing the client.
+func (c *Client) probeCredentials(ctx context.Context, cfg Configuration) error {
+	tm := NewTokenManager(cfg)
+	c.mu.RLock()
+	if c.clock != nil {
+		tm.SetClock(c.clock)
+	}
+	c.mu.RUnlock()
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return err
+	}
+
+	result, err := ValidateSASToken(ctx, ValidateSASTokenOptions{
+		Token:      token,
+		Namespace:  cfg.Namespace,
+		HubName:    cfg.HubName,
+		HTTPClient: c.HTTPClient,
+	})
+	if err != nil {
+		return err
+	}
+
+	return result.Err()
+}
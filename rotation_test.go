@@ -0,0 +1,78 @@
+package azurepush_test
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_RotateCredentials(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	const (
+		oldKey = "c2VjcmV0"         // the key of the server's configuration.
+		newKey = "bmV3LXNlY3JldA==" // "new-secret".
+	)
+	srv.SetKeys(oldKey)
+
+	client := srv.NewClient()
+	register := func() error {
+		_, err := client.RegisterDevice(t.Context(), azurepush.Installation{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a"})
+		return err
+	}
+	if err := register(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := srv.Configuration()
+	cfg.ConnectionString = strings.Replace(cfg.ConnectionString, oldKey, newKey, 1)
+
+	// The hub does not accept the new key yet, the client is not switched.
+	if err := client.RotateCredentials(t.Context(), cfg); err == nil {
+		t.Fatal("expected a verification error")
+	}
+	if client.Config.KeyValue != oldKey {
+		t.Fatalf("expected the old key kept, got: %s", client.Config.KeyValue)
+	}
+	if err := register(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both keys are valid during the rotation.
+	srv.SetKeys(oldKey, newKey)
+	if err := client.RotateCredentials(t.Context(), cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetKeys(newKey)
+	if err := register(); err != nil {
+		t.Fatalf("expected the new key used, got: %v", err)
+	}
+
+	// The verification through the client fails after the switch, the client is rolled back.
+	var validations atomic.Int32
+	base := client.HTTPClient.Transport
+	client.HTTPClient.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodGet && validations.Add(1) == 2 {
+			return &http.Response{StatusCode: http.StatusInternalServerError, Body: http.NoBody, Header: make(http.Header), Request: r}, nil
+		}
+		return base.RoundTrip(r)
+	})
+
+	srv.SetKeys(oldKey, newKey)
+	if err := client.RotateCredentials(t.Context(), srv.Configuration()); err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("expected a rolled back rotation, got: %v", err)
+	}
+	if client.Config.KeyValue != newKey {
+		t.Fatalf("expected the new key restored, got: %s", client.Config.KeyValue)
+	}
+
+	if err := client.RotateCredentials(t.Context(), azurepush.Configuration{HubName: "hub"}); err == nil {
+		t.Fatal("expected an invalid configuration error")
+	}
+}
@@ -0,0 +1,299 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// HubRoute routes the tags with a prefix (e.g. "region:eu") to a hub of a HubRouter.
+type HubRoute struct {
+	// TagPrefix is the prefix of the routed tags, e.g. "region:eu" matches "region:eu" and "region:eu-west".
+	TagPrefix string
+	// Hub is the name of the destination hub, see HubRouterOptions.Hubs.
+	Hub string
+}
+
+// HubRouterOptions holds the settings of a HubRouter.
+type HubRouterOptions struct {
+	// Hubs holds the clients of the hubs by name, e.g. "eu" and "us".
+	Hubs map[string]HubClient
+	// Routes are matched in order against the tags, the first matching route wins.
+	Routes []HubRoute
+	// Default is the name of the hub of the installations and tags which match no route.
+	// If empty, such installations are rejected and such tags are sent to all hubs.
+	Default string
+}
+
+// HubRouter is a HubClient which routes registrations and sends across multiple hubs
+// (e.g. one per region, for data residency) based on tag prefix rules:
+// an installation is registered to the hub of its routed tags (e.g. "region:eu" to the EU hub)
+// and a notification is sent to the hubs of its tags.
+//
+// Example usage:
+//
+//	router := azurepush.NewHubRouter(azurepush.HubRouterOptions{
+//		Hubs: map[string]azurepush.HubClient{
+//			"eu": azurepush.NewClient(euCfg),
+//			"us": azurepush.NewClient(usCfg),
+//		},
+//		Routes: []azurepush.HubRoute{
+//			{TagPrefix: "region:eu", Hub: "eu"},
+//			{TagPrefix: "region:us", Hub: "us"},
+//		},
+//	})
+//	_, err := router.RegisterDevice(ctx, installation) // with a "region:eu" tag.
+//	err = router.SendNotification(ctx, notification, "region:eu && user:42")
+type HubRouter struct {
+	opts HubRouterOptions
+}
+
+var _ HubClient = (*HubRouter)(nil)
+
+// NewHubRouter creates a new HubRouter.
+// It panics if there are no hubs or a route or the default refers to a missing hub.
+func NewHubRouter(opts HubRouterOptions) *HubRouter {
+	if len(opts.Hubs) == 0 {
+		panic("azurepush: router requires at least one hub")
+	}
+
+	for name, client := range opts.Hubs {
+		if client == nil {
+			panic("azurepush: nil client for hub: " + name)
+		}
+	}
+
+	for _, route := range opts.Routes {
+		if _, ok := opts.Hubs[route.Hub]; !ok {
+			panic("azurepush: route to unknown hub: " + route.Hub)
+		}
+	}
+
+	if _, ok := opts.Hubs[opts.Default]; opts.Default != "" && !ok {
+		panic("azurepush: unknown default hub: " + opts.Default)
+	}
+
+	return &HubRouter{opts: opts}
+}
+
+// Hub returns the client of a hub by its name, or nil.
+func (r *HubRouter) Hub(name string) HubClient {
+	return r.opts.Hubs[name]
+}
+
+// Route returns the name of the hub of a tag, or an empty string if no route matches.
+// The Default hub is not considered.
+func (r *HubRouter) Route(tag string) string {
+	for _, route := range r.opts.Routes {
+		if strings.HasPrefix(tag, route.TagPrefix) {
+			return route.Hub
+		}
+	}
+
+	return ""
+}
+
+// RegisterDevice registers the installation to the hub of its routed tags, or to the Default hub.
+// It returns an error if its tags route to different hubs, or if none matches and there is no Default hub.
+//
+// The installation is deleted from the other hubs, so a device whose routed tags changed (e.g. moved from
+// "region:eu" to "region:us") is not left registered, and notified, on its previous hub.
+func (r *HubRouter) RegisterDevice(ctx context.Context, installation Installation) (string, error) {
+	var hubs []string
+	for _, tag := range installation.Tags {
+		if hub := r.Route(tag); hub != "" && !slices.Contains(hubs, hub) {
+			hubs = append(hubs, hub)
+		}
+	}
+
+	switch {
+	case len(hubs) > 1:
+		return "", fmt.Errorf("installation tags route to multiple hubs: %s", strings.Join(hubs, ", "))
+	case len(hubs) == 0 && r.opts.Default == "":
+		return "", errors.New("installation tags match no hub route")
+	case len(hubs) == 0:
+		hubs = append(hubs, r.opts.Default)
+	}
+
+	generated := installation.InstallationID == ""
+	if generated {
+		installation.InstallationID = uuid.NewString()
+	}
+
+	if _, err := r.opts.Hubs[hubs[0]].RegisterDevice(ctx, installation); err != nil {
+		return "", fmt.Errorf("hub: %s: %w", hubs[0], err)
+	}
+
+	if generated { // a new installation, there is no previous registration.
+		return installation.InstallationID, nil
+	}
+
+	err := r.each(func(name string, client HubClient) error {
+		if name == hubs[0] {
+			return nil
+		}
+
+		return client.DeleteDevice(ctx, installation.InstallationID)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to delete the previous registration: %w", err)
+	}
+
+	return installation.InstallationID, nil
+}
+
+// DeviceExists reports whether the installation exists on any of the hubs.
+func (r *HubRouter) DeviceExists(ctx context.Context, installationID string) (bool, error) {
+	var errs []error
+	for name, client := range r.opts.Hubs {
+		exists, err := client.DeviceExists(ctx, installationID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hub: %s: %w", name, err))
+			continue
+		}
+
+		if exists {
+			return true, nil
+		}
+	}
+
+	return false, errors.Join(errs...)
+}
+
+// DeleteDevice deletes the installation from all hubs, as its hub is not known by its ID.
+func (r *HubRouter) DeleteDevice(ctx context.Context, installationID string) error {
+	return r.each(func(name string, client HubClient) error {
+		return client.DeleteDevice(ctx, installationID)
+	})
+}
+
+// ValidateToken validates the SAS tokens of all hubs.
+func (r *HubRouter) ValidateToken(ctx context.Context) error {
+	return r.each(func(name string, client HubClient) error {
+		return client.ValidateToken(ctx)
+	})
+}
+
+// SendNotification sends the notification to the hubs of the tags, in parallel.
+// A tag expression goes to the hubs of the routed tags AND-ed at its top level, an expression with a negation
+// or with a routed tag under an OR goes to all hubs. The tags which match no route go to the Default hub,
+// or to all hubs if there is no Default hub. Without tags the notification is broadcast to all hubs.
+//
+// Hubs without a matching device are not an error, unless no hub has one.
+func (r *HubRouter) SendNotification(ctx context.Context, notification Notification, tags ...string) error {
+	byHub := make(map[string][]string)
+	if len(tags) == 0 {
+		for name := range r.opts.Hubs {
+			byHub[name] = nil
+		}
+	}
+
+	for _, tag := range tags {
+		hubs, err := r.hubsOf(tag)
+		if err != nil {
+			return err
+		}
+
+		for _, hub := range hubs {
+			byHub[hub] = append(byHub[hub], tag)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		errs     []error
+		notFound []error
+	)
+	r.send(byHub, func(name string, client HubClient, tags []string) {
+		err := client.SendNotification(ctx, notification, tags...)
+		if err == nil {
+			return
+		}
+
+		err = fmt.Errorf("hub: %s: %w", name, err)
+
+		mu.Lock()
+		if errors.Is(err, errDeviceNotFound) {
+			notFound = append(notFound, err)
+		} else {
+			errs = append(errs, err)
+		}
+		mu.Unlock()
+	})
+
+	if len(notFound) == len(byHub) {
+		return errors.Join(notFound...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// hubsOf returns the names of the hubs a tag (or tag expression) is sent to.
+// An expression is narrowed to the hubs of its routed tags only if they are AND-ed at its top level,
+// as every matching device has them. An expression with a negation, or with a routed tag under an OR,
+// may match the devices of any hub and it is sent to all hubs.
+func (r *HubRouter) hubsOf(tag string) ([]string, error) {
+	required := []string{tag}
+	if strings.ContainsAny(tag, "&|!()") {
+		expr, err := ParseTagExpression(tag)
+		if err != nil {
+			return nil, err
+		}
+
+		required = expr.conjuncts()
+		if expr.HasNegation() || slices.ContainsFunc(expr.Tags(), func(t string) bool {
+			return r.Route(t) != "" && !slices.Contains(required, t)
+		}) {
+			return r.all(), nil
+		}
+	}
+
+	var hubs []string
+	for _, t := range required {
+		if hub := r.Route(t); hub != "" && !slices.Contains(hubs, hub) {
+			hubs = append(hubs, hub)
+		}
+	}
+
+	switch {
+	case len(hubs) > 0:
+		return hubs, nil
+	case r.opts.Default != "":
+		return []string{r.opts.Default}, nil
+	default:
+		return r.all(), nil
+	}
+}
+
+// all returns the names of all hubs, sorted.
+func (r *HubRouter) all() []string {
+	return slices.Sorted(maps.Keys(r.opts.Hubs))
+}
+
+func (r *HubRouter) send(byHub map[string][]string, fn func(name string, client HubClient, tags []string)) {
+	var wg sync.WaitGroup
+	for name, tags := range byHub {
+		wg.Go(func() {
+			fn(name, r.opts.Hubs[name], tags)
+		})
+	}
+	wg.Wait()
+}
+
+// each calls fn for every hub, sequentially, and joins the errors.
+func (r *HubRouter) each(fn func(name string, client HubClient) error) error {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(r.opts.Hubs)) {
+		if err := fn(name, r.opts.Hubs[name]); err != nil {
+			errs = append(errs, fmt.Errorf("hub: %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
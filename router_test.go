@@ -0,0 +1,133 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestHubRouter(t *testing.T) {
+	eu, us := azurepushtest.NewServer(), azurepushtest.NewServer()
+	defer eu.Close()
+	defer us.Close()
+
+	router := azurepush.NewHubRouter(azurepush.HubRouterOptions{
+		Hubs: map[string]azurepush.HubClient{
+			"eu": eu.NewClient(),
+			"us": us.NewClient(),
+		},
+		Routes: []azurepush.HubRoute{
+			{TagPrefix: "region:eu", Hub: "eu"},
+			{TagPrefix: "region:us", Hub: "us"},
+		},
+	})
+
+	ctx := context.Background()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "alice", Platform: azurepush.InstallationApple, PushChannel: "a", Tags: []string{"user:1", "region:eu-west"}},
+		{InstallationID: "bob", Platform: azurepush.InstallationApple, PushChannel: "b", Tags: []string{"user:2", "region:us"}},
+	} {
+		if _, err := router.RegisterDevice(ctx, installation); err != nil {
+			t.Fatalf("%s: %v", installation.InstallationID, err)
+		}
+	}
+
+	if _, ok := eu.Installation("alice"); !ok {
+		t.Error("expected alice on the EU hub")
+	}
+	if _, ok := us.Installation("alice"); ok {
+		t.Error("expected alice not to leave the EU hub")
+	}
+	if _, ok := us.Installation("bob"); !ok {
+		t.Error("expected bob on the US hub")
+	}
+
+	if _, err := router.RegisterDevice(ctx, azurepush.Installation{
+		Platform: azurepush.InstallationApple, PushChannel: "c", Tags: []string{"region:eu", "region:us"},
+	}); err == nil {
+		t.Error("expected an error for tags routing to multiple hubs")
+	}
+	if _, err := router.RegisterDevice(ctx, azurepush.Installation{
+		Platform: azurepush.InstallationApple, PushChannel: "c", Tags: []string{"user:3"},
+	}); err == nil {
+		t.Error("expected an error for unrouted tags without a default hub")
+	}
+
+	if err := router.SendNotification(ctx, azurepush.Notification{Title: "Hello"}, "region:eu-west && user:1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(eu.AllSent()) == 0 || len(us.AllSent()) != 0 {
+		t.Errorf("expected the expression to be sent to the EU hub only, got: EU: %d, US: %d", len(eu.AllSent()), len(us.AllSent()))
+	}
+
+	// A device which moved to another region is deleted from its previous hub.
+	if _, err := router.RegisterDevice(ctx, azurepush.Installation{
+		InstallationID: "alice", Platform: azurepush.InstallationApple, PushChannel: "a", Tags: []string{"user:1", "region:us"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := eu.Installation("alice"); ok {
+		t.Error("expected alice to be deleted from the EU hub")
+	}
+	if _, ok := us.Installation("alice"); !ok {
+		t.Error("expected alice on the US hub")
+	}
+
+	// A routed tag under an OR, or a negation, may match the devices of any hub.
+	for _, expr := range []string{"region:eu-west || user:2", "user:2 && !region:eu-west", "!region:us"} {
+		eu.Reset()
+		us.Reset()
+		if err := router.SendNotification(ctx, azurepush.Notification{Title: "Hello"}, expr); err != nil {
+			t.Fatalf("%s: %v", expr, err)
+		}
+		if len(eu.AllSent()) == 0 || len(us.AllSent()) == 0 {
+			t.Errorf("%s: expected the expression to be sent to all hubs, got: EU: %d, US: %d", expr, len(eu.AllSent()), len(us.AllSent()))
+		}
+	}
+
+	eu.Reset()
+	us.Reset()
+	for _, installation := range []azurepush.Installation{
+		{InstallationID: "alice", Platform: azurepush.InstallationApple, PushChannel: "a", Tags: []string{"user:1", "region:eu"}},
+		{InstallationID: "bob", Platform: azurepush.InstallationApple, PushChannel: "b", Tags: []string{"user:2", "region:us"}},
+	} {
+		if _, err := router.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// An unrouted tag goes to all hubs, the hubs without devices are not an error.
+	if err := router.SendNotification(ctx, azurepush.Notification{Title: "Hello"}, "user:2"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := us.Sent("user:2"); len(sent) == 0 || len(sent[0].InstallationIDs) == 0 {
+		t.Errorf("expected the notification to reach bob, got: %+v", sent)
+	}
+	if len(eu.Sent("user:2")) == 0 {
+		t.Error("expected the unrouted tag to be sent to the EU hub too")
+	}
+
+	eu.SetError(azurepushtest.OpSend, http.StatusNotFound, "")
+	if err := router.SendNotification(ctx, azurepush.Notification{Title: "Hello"}, "user:2"); err != nil {
+		t.Errorf("expected a hub without devices not to fail the send, got: %v", err)
+	}
+	us.SetError(azurepushtest.OpSend, http.StatusNotFound, "")
+	if err := router.SendNotification(ctx, azurepush.Notification{Title: "Hello"}, "user:2"); azurepush.ClassifyError(err) != azurepush.ErrorClassNoDevice {
+		t.Errorf("expected a no device error when no hub has a matching device, got: %v", err)
+	}
+
+	exists, err := router.DeviceExists(ctx, "bob")
+	if err != nil || !exists {
+		t.Errorf("expected bob to exist, got: %v, %v", exists, err)
+	}
+
+	if err = router.DeleteDevice(ctx, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := us.Installation("bob"); ok {
+		t.Error("expected bob to be deleted")
+	}
+}
@@ -0,0 +1,430 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule is a recurring notification.
+type Schedule struct {
+	// ID identifies the schedule, adding a schedule with an existing ID replaces it.
+	ID string
+	// Cron is a standard 5-field cron expression, e.g. "0 9 * * MON" for every Monday at 09:00,
+	// or a descriptor like "@daily".
+	Cron         string
+	Notification Notification
+	Tags         []string
+
+	// Location is the time zone of the cron expression.
+	// A schedule persisted by a Store needs a location loaded by its name (see time.LoadLocation),
+	// a time.FixedZone location is rejected.
+	//
+	// Defaults to UTC.
+	Location *time.Location
+
+	// Next is the time of the next run, maintained by the Scheduler.
+	// It is computed from the cron expression when the schedule is added, unless already set
+	// (e.g. by a persisted schedule).
+	Next time.Time
+	// LastRun is the time of the last run, maintained by the Scheduler.
+	LastRun time.Time
+}
+
+// SchedulerOptions holds the settings of a Scheduler.
+// The Load, Save and Delete persistence hooks let schedules survive restarts,
+// e.g. by storing them in a database.
+type SchedulerOptions struct {
+	// Load is called (if not nil) once by Run, to restore the persisted schedules.
+	Load func(ctx context.Context) ([]Schedule, error)
+	// Save is called (if not nil) when a schedule is added and after each of its runs.
+	Save func(ctx context.Context, schedule Schedule) error
+	// Delete is called (if not nil) when a schedule is removed.
+	Delete func(ctx context.Context, id string) error
+
+	// Store, if not nil, persists the schedules under the "schedules/" prefix.
+	// It provides the Load, Save and Delete hooks which are not set.
+	Store Store
+
+	// OnRun is called (if not nil) after each run, with the send error (if any).
+	OnRun func(schedule Schedule, err error)
+}
+
+// Scheduler runs recurring notifications in a background goroutine.
+//
+// Schedules whose next run was missed (e.g. while the process was down) run once
+// when the scheduler starts, then continue on their cron schedule.
+// A run which failed with an outage (see ErrorClassOutage) is retried with a backoff,
+// from 30 seconds up to 15 minutes, unless the next run is due first.
+//
+// Example usage:
+//
+//	scheduler := azurepush.NewScheduler(client, azurepush.SchedulerOptions{})
+//	err := scheduler.Add(ctx, azurepush.Schedule{
+//		ID:           "weekly-digest",
+//		Cron:         "0 9 * * MON",
+//		Notification: azurepush.Notification{Title: "Your weekly digest", Body: "See what you missed"},
+//		Tags:         []string{"digest:weekly"},
+//	})
+//	go scheduler.Run(ctx)
+type Scheduler struct {
+	client HubClient
+	opts   SchedulerOptions
+
+	mu        sync.Mutex
+	schedules map[string]*scheduled
+	wake      chan struct{}
+}
+
+type scheduled struct {
+	Schedule
+	spec    *CronSchedule
+	retries int // the failed attempts of the current run.
+}
+
+// NewScheduler creates a new Scheduler.
+func NewScheduler(client HubClient, opts SchedulerOptions) *Scheduler {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if opts.Store != nil {
+		opts.storeHooks()
+	}
+
+	return &Scheduler{
+		client:    client,
+		opts:      opts,
+		schedules: make(map[string]*scheduled),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Add adds or replaces a schedule and persists it.
+func (s *Scheduler) Add(ctx context.Context, schedule Schedule) error {
+	sc, err := newScheduled(schedule, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if s.opts.Save != nil {
+		if err = s.opts.Save(ctx, sc.Schedule); err != nil {
+			return fmt.Errorf("failed to save schedule: %s: %w", schedule.ID, err)
+		}
+	}
+
+	s.mu.Lock()
+	s.schedules[sc.ID] = sc
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// Remove removes a schedule.
+func (s *Scheduler) Remove(ctx context.Context, id string) error {
+	if s.opts.Delete != nil {
+		if err := s.opts.Delete(ctx, id); err != nil {
+			return fmt.Errorf("failed to delete schedule: %s: %w", id, err)
+		}
+	}
+
+	s.mu.Lock()
+	delete(s.schedules, id)
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// Schedules returns the schedules, sorted by their ID.
+func (s *Scheduler) Schedules() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := slices.Sorted(maps.Keys(s.schedules))
+	schedules := make([]Schedule, len(ids))
+	for i, id := range ids {
+		schedules[i] = s.schedules[id].Schedule
+	}
+
+	return schedules
+}
+
+// Run restores the persisted schedules and runs them until the context is canceled
+// or the client is closed (see Client.Close).
+func (s *Scheduler) Run(ctx context.Context) error {
+	ctx, stop := startBackgroundOf(s.client, ctx)
+	defer stop()
+
+	if s.opts.Load != nil {
+		schedules, err := s.opts.Load(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load schedules: %w", err)
+		}
+
+		now := time.Now()
+		s.mu.Lock()
+		for _, schedule := range schedules {
+			sc, err := newScheduled(schedule, now)
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			s.schedules[sc.ID] = sc
+		}
+		s.mu.Unlock()
+	}
+
+	for {
+		for _, sc := range s.due(time.Now()) {
+			if err := s.run(ctx, sc); err != nil {
+				return nil // the client is closed.
+			}
+		}
+
+		var (
+			timer *time.Timer
+			fire  <-chan time.Time
+		)
+		if next := s.next(); !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-fire:
+		case <-s.wake:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// due returns the schedules whose next run is not after now.
+func (s *Scheduler) due(now time.Time) []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []Schedule
+	for _, sc := range s.schedules {
+		if !sc.Next.IsZero() && !sc.Next.After(now) {
+			due = append(due, sc.Schedule)
+		}
+	}
+	slices.SortFunc(due, func(a, b Schedule) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return due
+}
+
+// next returns the earliest next run of all schedules.
+func (s *Scheduler) next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, sc := range s.schedules {
+		if !sc.Next.IsZero() && (next.IsZero() || sc.Next.Before(next)) {
+			next = sc.Next
+		}
+	}
+
+	return next
+}
+
+// run sends the schedule and advances it to its next run. A send interrupted by the context
+// or the closed client is not a run, the schedule is kept due for the next Run; ErrClientClosed is returned then.
+// A send which failed with an outage is retried after a backoff, see scheduleRetryDelay.
+func (s *Scheduler) run(ctx context.Context, schedule Schedule) error {
+	err := s.client.SendNotification(ctx, schedule.Notification, schedule.Tags...)
+	if errors.Is(err, ErrClientClosed) {
+		return err
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	sc, ok := s.schedules[schedule.ID]
+	if ok {
+		next := sc.spec.Next(now.In(sc.Location))
+		if ClassifyError(err).Has(ErrorClassOutage | ErrorClassCanceled) {
+			sc.retries++
+			if retry := now.Add(scheduleRetryDelay(sc.retries)); retry.Before(next) {
+				next = retry
+			}
+		} else {
+			sc.retries = 0
+			sc.LastRun = now
+		}
+		sc.Next = next
+		schedule = sc.Schedule
+	}
+	s.mu.Unlock()
+
+	if ok && s.opts.Save != nil {
+		if saveErr := s.opts.Save(context.WithoutCancel(ctx), schedule); saveErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to save schedule: %s: %w", schedule.ID, saveErr))
+		}
+	}
+
+	if s.opts.OnRun != nil {
+		s.opts.OnRun(schedule, err)
+	}
+
+	return nil
+}
+
+// scheduleRetryDelay returns the exponential delay of the retry of a run, from 30 seconds up to 15 minutes.
+func scheduleRetryDelay(retry int) time.Duration {
+	return min(30*time.Second<<min(retry-1, 5), 15*time.Minute)
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func newScheduled(schedule Schedule, now time.Time) (*scheduled, error) {
+	if schedule.ID == "" {
+		return nil, errors.New("schedule ID is required")
+	}
+
+	spec, err := ParseCron(schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("schedule: %s: %w", schedule.ID, err)
+	}
+
+	if schedule.Location == nil {
+		schedule.Location = time.UTC
+	}
+
+	if schedule.Next.IsZero() {
+		schedule.Next = spec.Next(now.In(schedule.Location))
+	}
+
+	return &scheduled{Schedule: schedule, spec: spec}, nil
+}
+
+// scheduleKeyPrefix is the Store key prefix of the schedules.
+const scheduleKeyPrefix = "schedules/"
+
+// scheduleRecord is the stored JSON document of a schedule,
+// the location is stored by its name, see storableLocation.
+type scheduleRecord struct {
+	ID           string       `json:"id"`
+	Cron         string       `json:"cron"`
+	Notification Notification `json:"notification"`
+	Tags         []string     `json:"tags,omitempty"`
+	Location     string       `json:"location"`
+	Next         time.Time    `json:"next"`
+	LastRun      time.Time    `json:"lastRun,omitzero"`
+}
+
+func (opts *SchedulerOptions) storeHooks() {
+	store := opts.Store
+
+	if opts.Load == nil {
+		opts.Load = func(ctx context.Context) ([]Schedule, error) {
+			entries, err := store.List(ctx, scheduleKeyPrefix)
+			if err != nil {
+				return nil, err
+			}
+
+			schedules := make([]Schedule, 0, len(entries))
+			for _, entry := range entries {
+				var record scheduleRecord
+				if err = json.Unmarshal(entry.Value, &record); err != nil {
+					return nil, fmt.Errorf("invalid stored schedule: %s: %w", entry.Key, err)
+				}
+
+				loc, err := time.LoadLocation(record.Location)
+				if err != nil {
+					return nil, fmt.Errorf("invalid stored schedule: %s: %w", entry.Key, err)
+				}
+
+				schedules = append(schedules, Schedule{
+					ID:           record.ID,
+					Cron:         record.Cron,
+					Notification: record.Notification,
+					Tags:         record.Tags,
+					Location:     loc,
+					Next:         record.Next,
+					LastRun:      record.LastRun,
+				})
+			}
+
+			return schedules, nil
+		}
+	}
+
+	if opts.Save == nil {
+		opts.Save = func(ctx context.Context, schedule Schedule) error {
+			if !storableLocation(schedule.Location) {
+				return fmt.Errorf("location: %s cannot be stored by its name, load it with time.LoadLocation", schedule.Location)
+			}
+
+			value, err := json.Marshal(scheduleRecord{
+				ID:           schedule.ID,
+				Cron:         schedule.Cron,
+				Notification: schedule.Notification,
+				Tags:         schedule.Tags,
+				Location:     schedule.Location.String(),
+				Next:         schedule.Next,
+				LastRun:      schedule.LastRun,
+			})
+			if err != nil {
+				return err
+			}
+
+			return store.Put(ctx, scheduleKeyPrefix+schedule.ID, value)
+		}
+	}
+
+	if opts.Delete == nil {
+		opts.Delete = func(ctx context.Context, id string) error {
+			return store.Delete(ctx, scheduleKeyPrefix+id)
+		}
+	}
+}
+
+// storableLocation reports whether the location is loaded back by its name (see time.LoadLocation)
+// with the same offsets, unlike e.g. a time.FixedZone location.
+func storableLocation(loc *time.Location) bool {
+	loaded, err := time.LoadLocation(loc.String())
+	if err != nil {
+		return false
+	}
+
+	if loaded == loc {
+		return true
+	}
+
+	// Compare the offsets of both halves of the year, they differ on daylight saving time.
+	year := time.Now().Year()
+	for _, month := range []time.Month{time.January, time.July} {
+		t := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+		_, offset := t.In(loc).Zone()
+		_, loadedOffset := t.In(loaded).Zone()
+		if offset != loadedOffset {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,213 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestScheduler(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	var (
+		mu    sync.Mutex
+		saved = map[string]azurepush.Schedule{
+			// Persisted schedule which missed its run while the process was down.
+			"weekly-digest": {
+				ID:           "weekly-digest",
+				Cron:         "0 9 * * MON",
+				Notification: azurepush.Notification{Title: "Your weekly digest"},
+				Tags:         []string{"digest:weekly"},
+				Next:         time.Now().Add(-time.Hour),
+			},
+		}
+		runs = make(chan azurepush.Schedule, 1)
+	)
+
+	scheduler := azurepush.NewScheduler(srv.NewClient(), azurepush.SchedulerOptions{
+		Load: func(ctx context.Context) ([]azurepush.Schedule, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return []azurepush.Schedule{saved["weekly-digest"]}, nil
+		},
+		Save: func(ctx context.Context, schedule azurepush.Schedule) error {
+			mu.Lock()
+			saved[schedule.ID] = schedule
+			mu.Unlock()
+			return nil
+		},
+		Delete: func(ctx context.Context, id string) error {
+			mu.Lock()
+			delete(saved, id)
+			mu.Unlock()
+			return nil
+		},
+		OnRun: func(schedule azurepush.Schedule, err error) {
+			if err != nil {
+				t.Errorf("unexpected run error: %v", err)
+			}
+			runs <- schedule
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Run(ctx)
+
+	select {
+	case schedule := <-runs:
+		if schedule.ID != "weekly-digest" || !schedule.Next.After(time.Now()) || schedule.Next.Weekday() != time.Monday {
+			t.Errorf("unexpected run: %+v", schedule)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the missed schedule to run")
+	}
+
+	if sent := srv.Sent("digest:weekly"); len(sent) != 2 {
+		t.Errorf("expected the digest to be sent to both platforms, got: %d", len(sent))
+	}
+
+	mu.Lock()
+	if next := saved["weekly-digest"].Next; !next.After(time.Now()) {
+		t.Errorf("expected the next run to be persisted, got: %s", next)
+	}
+	mu.Unlock()
+
+	if err := scheduler.Add(ctx, azurepush.Schedule{ID: "bad", Cron: "every monday"}); err == nil {
+		t.Error("expected error for an invalid cron expression")
+	}
+
+	if err := scheduler.Remove(ctx, "weekly-digest"); err != nil {
+		t.Fatalf("unexpected remove error: %v", err)
+	}
+	if len(scheduler.Schedules()) != 0 || len(saved) != 0 {
+		t.Error("expected the schedule to be removed")
+	}
+}
+
+func TestScheduler_Store(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	store := azurepush.NewMemoryStore()
+	ctx := context.Background()
+
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	scheduler := azurepush.NewScheduler(srv.NewClient(), azurepush.SchedulerOptions{Store: store})
+	err = scheduler.Add(ctx, azurepush.Schedule{
+		ID:           "daily-quiz",
+		Cron:         "@daily",
+		Notification: azurepush.Notification{Title: "Daily quiz", Data: map[string]any{"quizId": "42"}},
+		Tags:         []string{"quiz"},
+		Location:     athens,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fixed zone cannot be loaded back by its name.
+	err = scheduler.Add(ctx, azurepush.Schedule{ID: "fixed", Cron: "@daily", Notification: azurepush.Notification{Title: "Hi"}, Location: time.FixedZone("EET", 2*60*60)})
+	if err == nil {
+		t.Error("expected an error storing a fixed zone schedule")
+	}
+
+	entries, _ := store.List(ctx, "schedules/")
+	if len(entries) != 1 || entries[0].Key != "schedules/daily-quiz" {
+		t.Fatalf("expected the schedule to be stored, got: %+v", entries)
+	}
+
+	// A new scheduler, e.g. after a restart, restores the stored schedules.
+	restored := azurepush.NewScheduler(srv.NewClient(), azurepush.SchedulerOptions{Store: store})
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		restored.Run(runCtx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(restored.Schedules()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	schedules := restored.Schedules()
+	if len(schedules) != 1 {
+		t.Fatalf("expected the schedule to be restored, got: %+v", schedules)
+	}
+	if s := schedules[0]; s.Location.String() != "Europe/Athens" || s.Notification.Title != "Daily quiz" || s.Tags[0] != "quiz" || s.Next.IsZero() {
+		t.Errorf("unexpected restored schedule: %+v", s)
+	}
+
+	if err = restored.Remove(ctx, "daily-quiz"); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ = store.List(ctx, "schedules/"); len(entries) != 0 {
+		t.Errorf("expected the schedule to be deleted from the store, got: %+v", entries)
+	}
+}
+
+func TestScheduler_Outage(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpSend, http.StatusServiceUnavailable, "outage")
+
+	runs := make(chan error, 1)
+	scheduler := azurepush.NewScheduler(srv.NewClient(), azurepush.SchedulerOptions{
+		OnRun: func(_ azurepush.Schedule, err error) {
+			select {
+			case runs <- err:
+			default:
+			}
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	err := scheduler.Add(ctx, azurepush.Schedule{
+		ID:           "weekly-digest",
+		Cron:         "@weekly",
+		Notification: azurepush.Notification{Title: "Your weekly digest"},
+		Next:         time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case err = <-runs:
+		if err == nil {
+			t.Fatal("expected the send to fail")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the due schedule to run")
+	}
+	cancel()
+	<-done
+
+	// The failed run is retried shortly instead of next week.
+	schedule := scheduler.Schedules()[0]
+	if retry := time.Until(schedule.Next); retry <= 0 || retry > time.Minute {
+		t.Errorf("expected the run to be retried in 30s, got: %s", retry)
+	}
+	if !schedule.LastRun.IsZero() {
+		t.Errorf("expected the failed run not to count, got last run: %s", schedule.LastRun)
+	}
+}
@@ -0,0 +1,340 @@
+package azurepush
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TargetedNotification is a notification and the tags it targets.
+type TargetedNotification struct {
+	// ID is an optional caller-defined identifier, copied to the SendResult.
+	ID           string
+	Notification Notification
+	Tags         []string
+}
+
+// SendResult is the outcome of sending a TargetedNotification.
+type SendResult struct {
+	// ID is the TargetedNotification's ID.
+	ID   string
+	Tags []string
+	// NotificationIDs holds the IDs of the sent notifications reported by the hub (Standard tier only),
+	// one per platform.
+	NotificationIDs []string
+	Err             error
+	Duration        time.Duration
+}
+
+// SenderOptions holds the settings of a Sender.
+type SenderOptions struct {
+	// Concurrency is the maximum number of notifications sent in parallel.
+	//
+	// Defaults to 8, or 64 in throughput mode.
+	Concurrency int
+
+	// ThroughputMode tunes the sender for very large fan-outs (e.g. 100k+ notifications in minutes
+	// during breaking news):
+	//   - Concurrency defaults to 64, make sure the client's MaxIdleConnsPerHost is not lower.
+	//   - Connections to the hub are pre-warmed (one per worker) before the first send,
+	//     so the burst does not pay DNS and TLS latency on every new connection.
+	//   - The SAS token is refreshed up front if it would expire during the batch
+	//     (see TokenPreRefresh), instead of stalling all workers mid-batch.
+	//   - Results are collected in a preallocated slice instead of per-result channels.
+	ThroughputMode bool
+
+	// RateLimit is the maximum number of notifications sent per second, zero means no limit.
+	// Each notification counts once, whatever the number of platforms it is sent to.
+	RateLimit float64
+
+	// TokenPreRefresh is the minimum remaining validity of the SAS token before a batch starts
+	// in throughput mode.
+	//
+	// Defaults to 30 minutes.
+	TokenPreRefresh time.Duration
+
+	// DryRun builds and validates the notifications, and reports them as sent (OnResult, audit records and stats),
+	// without sending them, whether the client is in dry-run mode or not (see Configuration.DryRun).
+	DryRun bool
+
+	// OnResult is called (if not nil) after each send, as soon as it completes,
+	// e.g. to checkpoint the progress of a large batch. It is called concurrently by the workers.
+	OnResult func(result SendResult)
+}
+
+// Sender sends notifications in batches through a Client with bounded concurrency.
+//
+// Example usage:
+//
+//	sender := azurepush.NewSender(client, azurepush.SenderOptions{ThroughputMode: true})
+//	results := sender.SendBatch(ctx, notifications)
+//	for _, result := range results {
+//		if result.Err != nil {
+//			log.Printf("send %s failed: %v", result.ID, result.Err)
+//		}
+//	}
+type Sender struct {
+	client  *Client
+	opts    SenderOptions
+	limiter *rateLimiter
+}
+
+// NewSender creates a new Sender for the given client.
+func NewSender(client *Client, opts SenderOptions) *Sender {
+	if client == nil {
+		panic("azurepush: nil client")
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 8
+		if opts.ThroughputMode {
+			opts.Concurrency = 64
+		}
+	}
+
+	if opts.TokenPreRefresh <= 0 {
+		opts.TokenPreRefresh = 30 * time.Minute
+	}
+
+	return &Sender{client: client, opts: opts, limiter: newRateLimiter(opts.RateLimit)}
+}
+
+// SendBatch sends all notifications and returns their results in the same order.
+// It returns when all notifications are sent or the context is done,
+// notifications not sent because of the context report its error.
+func (s *Sender) SendBatch(ctx context.Context, notifications []TargetedNotification) []SendResult {
+	results := make([]SendResult, len(notifications))
+	if len(notifications) == 0 {
+		return results
+	}
+
+	workers := min(s.opts.Concurrency, len(notifications))
+	if s.opts.ThroughputMode {
+		s.prepare(ctx, workers)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		jobs = make(chan int, workers)
+	)
+	for range workers {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = s.send(ctx, notifications[i])
+			}
+		})
+	}
+
+	for i := range notifications {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			results[i] = SendResult{ID: notifications[i].ID, Tags: notifications[i].Tags, Err: ctx.Err()}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// SendDrip spreads the notifications evenly over the given duration,
+// e.g. 50k notifications over an hour, to avoid a traffic spike on the backends the notifications link to.
+// Up to Concurrency sends are still in flight at once, a send which takes longer than the interval
+// does not delay the next ones.
+//
+// It returns the results of the dispatched notifications, in the same order.
+// When the context is done it stops dispatching, so the results are a prefix of the notifications
+// and the rest can be resumed with notifications[len(results):].
+func (s *Sender) SendDrip(ctx context.Context, notifications []TargetedNotification, over time.Duration) []SendResult {
+	results := make([]SendResult, len(notifications))
+	if len(notifications) == 0 {
+		return results
+	}
+
+	interval := over / time.Duration(len(notifications))
+
+	var (
+		wg         sync.WaitGroup
+		jobs       = make(chan int)
+		dispatched = 0
+		start      = time.Now()
+	)
+	for range min(s.opts.Concurrency, len(notifications)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = s.send(ctx, notifications[i])
+			}
+		})
+	}
+
+dispatch:
+	for i := range notifications {
+		if wait := time.Until(start.Add(time.Duration(i) * interval)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				break dispatch
+			}
+		}
+
+		select {
+		case jobs <- i:
+			dispatched++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results[:dispatched]
+}
+
+// SendStream consumes notifications from the input channel and emits their results,
+// applying the sender's concurrency and rate limits.
+// Results are emitted in completion order, use TargetedNotification.ID to correlate them.
+//
+// The output channel is closed once the input channel is closed and drained, or the context is done.
+// It provides natural backpressure: when the results are not consumed,
+// the workers block and stop reading from the input (e.g. a queue or a database cursor).
+//
+// Example usage:
+//
+//	input := make(chan azurepush.TargetedNotification)
+//	go func() {
+//		defer close(input)
+//		for rows.Next() {
+//			input <- toNotification(rows)
+//		}
+//	}()
+//	for result := range sender.SendStream(ctx, input) {
+//		// handle result...
+//	}
+func (s *Sender) SendStream(ctx context.Context, input <-chan TargetedNotification) <-chan SendResult {
+	output := make(chan SendResult, s.opts.Concurrency)
+
+	if s.opts.ThroughputMode {
+		s.prepare(ctx, s.opts.Concurrency)
+	}
+
+	var wg sync.WaitGroup
+	for range s.opts.Concurrency {
+		wg.Go(func() {
+			for {
+				var (
+					n  TargetedNotification
+					ok bool
+				)
+				select {
+				case n, ok = <-input:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+
+				select {
+				case output <- s.send(ctx, n):
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(output)
+	}()
+
+	return output
+}
+
+func (s *Sender) send(ctx context.Context, n TargetedNotification) SendResult {
+	if s.opts.DryRun {
+		ctx = withDryRun(ctx)
+	}
+
+	result := SendResult{ID: n.ID, Tags: n.Tags}
+	if err := s.limiter.wait(ctx); err != nil {
+		result.Err = err
+		return result
+	}
+
+	start := time.Now()
+	result.NotificationIDs, result.Err = s.client.sendNotification(ctx, n.Notification, SendOptions{}, n.Tags...)
+	result.Duration = time.Since(start)
+
+	if s.opts.OnResult != nil {
+		s.opts.OnResult(result)
+	}
+
+	return result
+}
+
+// rateLimiter spaces events evenly to a maximum rate, a nil limiter never waits.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next event is allowed or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prepare refreshes the token and pre-warms the connections of the throughput mode.
+// Failures are ignored, the sends report them.
+func (s *Sender) prepare(ctx context.Context, connections int) {
+	if _, provider := s.client.currentSend(); provider != nil {
+		if tm, ok := provider.(*TokenManager); ok {
+			_, _ = tm.getTokenValidFor(tm.ResourceURI(), s.opts.TokenPreRefresh)
+		}
+	}
+
+	_ = s.client.Warm(ctx, connections)
+}
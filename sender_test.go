@@ -0,0 +1,206 @@
+package azurepush_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+func newSenderTestClient(handler func(r *http.Request) *http.Response) *azurepush.Client {
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+	client.HTTPClient = mockHTTPClient(handler)
+	return client
+}
+
+func TestSender_SendBatch(t *testing.T) {
+	var sends, probes atomic.Int32
+	client := newSenderTestClient(func(r *http.Request) *http.Response {
+		if r.Method == http.MethodGet {
+			probes.Add(1)
+			return jsonResponse(http.StatusNotFound, "")
+		}
+
+		sends.Add(1)
+		if strings.Contains(r.Header.Get("ServiceBusNotification-Tags"), "user:fail") {
+			return jsonResponse(http.StatusBadRequest, "bad request")
+		}
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	notifications := make([]azurepush.TargetedNotification, 10)
+	for i := range notifications {
+		notifications[i] = azurepush.TargetedNotification{
+			ID:           strconv.Itoa(i),
+			Notification: azurepush.Notification{Title: "Hi"},
+			Tags:         []string{"user:" + strconv.Itoa(i)},
+		}
+	}
+	notifications[3].Tags = []string{"user:fail"}
+
+	sender := azurepush.NewSender(client, azurepush.SenderOptions{Concurrency: 4, ThroughputMode: true})
+	results := sender.SendBatch(context.Background(), notifications)
+
+	if len(results) != len(notifications) {
+		t.Fatalf("expected %d results, got: %d", len(notifications), len(results))
+	}
+	for i, result := range results {
+		if result.ID != strconv.Itoa(i) {
+			t.Errorf("expected results in input order, got ID %s at %d", result.ID, i)
+		}
+		if (result.Err != nil) != (i == 3) {
+			t.Errorf("unexpected result error at %d: %v", i, result.Err)
+		}
+	}
+
+	if probes.Load() != 4 {
+		t.Errorf("expected one pre-warm request per worker, got: %d", probes.Load())
+	}
+	if sends.Load() < 19 {
+		t.Errorf("expected sends for both platforms, got: %d", sends.Load())
+	}
+}
+
+func TestSender_SendBatch_Canceled(t *testing.T) {
+	client := newSenderTestClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := azurepush.NewSender(client, azurepush.SenderOptions{}).SendBatch(ctx, []azurepush.TargetedNotification{{ID: "1"}, {ID: "2"}})
+	for _, result := range results {
+		if result.Err != context.Canceled {
+			t.Errorf("expected context canceled, got: %v", result.Err)
+		}
+	}
+}
+
+func TestSender_SendDrip(t *testing.T) {
+	var sends atomic.Int32
+	client := newSenderTestClient(func(r *http.Request) *http.Response {
+		sends.Add(1)
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	notifications := make([]azurepush.TargetedNotification, 5)
+	for i := range notifications {
+		notifications[i] = azurepush.TargetedNotification{ID: strconv.Itoa(i), Notification: azurepush.Notification{Title: "Drip"}, Tags: []string{"user:" + strconv.Itoa(i)}}
+	}
+
+	sender := azurepush.NewSender(client, azurepush.SenderOptions{})
+
+	start := time.Now()
+	results := sender.SendDrip(context.Background(), notifications, 200*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 160*time.Millisecond {
+		t.Errorf("expected the sends to be spread over the duration, took: %s", elapsed)
+	}
+
+	if len(results) != len(notifications) {
+		t.Fatalf("expected %d results, got: %d", len(notifications), len(results))
+	}
+	for i, result := range results {
+		if result.ID != strconv.Itoa(i) || result.Err != nil {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	results = sender.SendDrip(ctx, notifications, time.Second)
+	if len(results) == 0 || len(results) >= len(notifications) {
+		t.Fatalf("expected a prefix of the results when the context is done, got: %d", len(results))
+	}
+}
+
+func BenchmarkSender_SendBatch(b *testing.B) {
+	client := newSenderTestClient(func(r *http.Request) *http.Response {
+		if r.Body != nil {
+			_, _ = io.Copy(io.Discard, r.Body)
+			r.Body.Close()
+		}
+		return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody, Header: make(http.Header)}
+	})
+
+	notifications := make([]azurepush.TargetedNotification, 1000)
+	for i := range notifications {
+		notifications[i] = azurepush.TargetedNotification{
+			Notification: azurepush.Notification{Title: "Breaking news", Body: "Something happened", Data: map[string]any{"articleId": i}},
+			Tags:         []string{"topic:news"},
+		}
+	}
+
+	sender := azurepush.NewSender(client, azurepush.SenderOptions{ThroughputMode: true})
+
+	b.ReportAllocs()
+	for b.Loop() {
+		sender.SendBatch(context.Background(), notifications)
+	}
+}
+
+func TestSender_SendStream(t *testing.T) {
+	client := newSenderTestClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	const total = 20
+	input := make(chan azurepush.TargetedNotification)
+	go func() {
+		defer close(input)
+		for i := range total {
+			input <- azurepush.TargetedNotification{ID: strconv.Itoa(i), Notification: azurepush.Notification{Title: "Hi"}}
+		}
+	}()
+
+	sender := azurepush.NewSender(client, azurepush.SenderOptions{Concurrency: 4, RateLimit: 1000})
+
+	start := time.Now()
+	seen := make(map[string]bool)
+	for result := range sender.SendStream(context.Background(), input) {
+		if result.Err != nil {
+			t.Errorf("unexpected error for %s: %v", result.ID, result.Err)
+		}
+		seen[result.ID] = true
+	}
+
+	if len(seen) != total {
+		t.Errorf("expected %d results, got: %d", total, len(seen))
+	}
+
+	// 20 notifications at 1000/s are spread over at least 19ms.
+	if elapsed := time.Since(start); elapsed < 19*time.Millisecond {
+		t.Errorf("expected the rate limit to pace sends, took: %s", elapsed)
+	}
+}
+
+func TestSender_SendStream_Canceled(t *testing.T) {
+	client := newSenderTestClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusCreated, "")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	input := make(chan azurepush.TargetedNotification) // never closed.
+
+	output := azurepush.NewSender(client, azurepush.SenderOptions{}).SendStream(ctx, input)
+	cancel()
+
+	select {
+	case _, ok := <-output:
+		if ok {
+			t.Error("expected no results")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the output to be closed when the context is done")
+	}
+}
@@ -0,0 +1,201 @@
+// Package servicebus consumes notifications from an Azure Service Bus queue or topic subscription
+// and dispatches them through an azurepush Client, turning azurepush into a deployable push worker.
+//
+// Each message body is a JSON Message:
+//
+//	{
+//	  "notification": {"title": "Order shipped", "body": "Your order is on its way", "data": {"orderId": "7"}},
+//	  "tagExpression": "user:42 && lang:en"
+//	}
+//
+// Example usage:
+//
+//	sb, _ := azservicebus.NewClientFromConnectionString(serviceBusConnectionString, nil)
+//	receiver, _ := sb.NewReceiverForQueue("notifications", nil)
+//
+//	bridge := servicebus.NewBridge(client, receiver, servicebus.Options{})
+//	err := bridge.Run(ctx)
+package servicebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/kataras/azurepush"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+// Message is the JSON schema of the consumed messages.
+type Message struct {
+	Notification Notification `json:"notification"`
+	// TagExpression targets the devices, e.g. "user:42" or "(follows:RedSox || follows:Cardinals) && location:Boston".
+	// An empty expression broadcasts the notification to all devices.
+	TagExpression string `json:"tagExpression,omitempty"`
+}
+
+// Notification is the JSON schema of a Message's notification.
+type Notification struct {
+	Title string         `json:"title"`
+	Body  string         `json:"body"`
+	Data  map[string]any `json:"data,omitempty"`
+}
+
+// Receiver receives and settles Service Bus messages.
+// It is implemented by *azservicebus.Receiver.
+type Receiver interface {
+	ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	CompleteMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error
+	AbandonMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error
+	DeadLetterMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error
+}
+
+var _ Receiver = (*azservicebus.Receiver)(nil)
+
+// Dead-letter reasons set by the Bridge.
+const (
+	// ReasonInvalidMessage is set when the message body is not a valid Message.
+	ReasonInvalidMessage = "InvalidMessage"
+	// ReasonInvalidNotification is set when the notification is rejected as invalid (see azurepush.ErrInvalidNotification),
+	// e.g. it has no content, so it would fail on every delivery.
+	ReasonInvalidNotification = "InvalidNotification"
+	// ReasonMaxDeliveries is set when the message failed to be sent MaxDeliveries times.
+	ReasonMaxDeliveries = "MaxDeliveriesExceeded"
+	// ReasonUnsupported is set when the hub's tier does not support the notification.
+	ReasonUnsupported = "Unsupported"
+)
+
+// Options holds the settings of the Bridge.
+type Options struct {
+	// BatchSize is the maximum number of messages received at once,
+	// the messages of a batch are sent concurrently.
+	//
+	// Defaults to 10.
+	BatchSize int
+
+	// MaxDeliveries is the number of delivery attempts of a message before it is dead-lettered.
+	// Failed sends are abandoned, so Service Bus redelivers them, until this limit is reached.
+	// It should be lower than the queue's own max delivery count.
+	//
+	// Defaults to 5.
+	MaxDeliveries uint32
+
+	// OnError is called (if not nil) on every failed send or settlement, e.g. to log the error.
+	OnError func(message *azservicebus.ReceivedMessage, err error)
+}
+
+// Bridge consumes Service Bus messages and sends them through the client.
+type Bridge struct {
+	client   azurepush.HubClient
+	receiver Receiver
+	opts     Options
+}
+
+// NewBridge creates a new Bridge.
+func NewBridge(client azurepush.HubClient, receiver Receiver, opts Options) *Bridge {
+	if client == nil || receiver == nil {
+		panic("azurepush/servicebus: nil client or receiver")
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10
+	}
+
+	if opts.MaxDeliveries == 0 {
+		opts.MaxDeliveries = 5
+	}
+
+	return &Bridge{client: client, receiver: receiver, opts: opts}
+}
+
+// Run receives and dispatches messages until the context is canceled or receiving fails.
+// It returns nil when the context is canceled.
+func (b *Bridge) Run(ctx context.Context) error {
+	for {
+		messages, err := b.receiver.ReceiveMessages(ctx, b.opts.BatchSize, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to receive messages: %w", err)
+		}
+
+		var wg sync.WaitGroup
+		for _, message := range messages {
+			wg.Go(func() {
+				b.handle(ctx, message)
+			})
+		}
+		wg.Wait()
+	}
+}
+
+// handle sends the message and settles it:
+// completed on success, dead-lettered on permanent failures and abandoned (redelivered) otherwise.
+func (b *Bridge) handle(ctx context.Context, message *azservicebus.ReceivedMessage) {
+	var msg Message
+	if err := json.Unmarshal(message.Body, &msg); err != nil {
+		b.deadLetter(ctx, message, ReasonInvalidMessage, err)
+		return
+	}
+
+	notification := azurepush.Notification{
+		Title: msg.Notification.Title,
+		Body:  msg.Notification.Body,
+		Data:  msg.Notification.Data,
+	}
+
+	var tags []string
+	if msg.TagExpression != "" {
+		tags = []string{msg.TagExpression}
+	}
+
+	err := b.client.SendNotification(ctx, notification, tags...)
+	if err == nil {
+		if err = b.receiver.CompleteMessage(ctx, message, nil); err != nil {
+			b.error(message, fmt.Errorf("failed to complete message: %w", err))
+		}
+		return
+	}
+
+	b.error(message, err)
+
+	switch {
+	case isTierError(err):
+		b.deadLetter(ctx, message, ReasonUnsupported, err)
+	case errors.Is(err, azurepush.ErrInvalidNotification):
+		b.deadLetter(ctx, message, ReasonInvalidNotification, err)
+	case message.DeliveryCount >= b.opts.MaxDeliveries:
+		b.deadLetter(ctx, message, ReasonMaxDeliveries, err)
+	default:
+		// Use a fresh context, the message must be released even if the bridge is stopping.
+		if err = b.receiver.AbandonMessage(context.WithoutCancel(ctx), message, nil); err != nil {
+			b.error(message, fmt.Errorf("failed to abandon message: %w", err))
+		}
+	}
+}
+
+func (b *Bridge) deadLetter(ctx context.Context, message *azservicebus.ReceivedMessage, reason string, cause error) {
+	description := cause.Error()
+	err := b.receiver.DeadLetterMessage(context.WithoutCancel(ctx), message, &azservicebus.DeadLetterOptions{
+		Reason:           &reason,
+		ErrorDescription: &description,
+	})
+	if err != nil {
+		b.error(message, fmt.Errorf("failed to dead-letter message: %w", err))
+	}
+}
+
+func (b *Bridge) error(message *azservicebus.ReceivedMessage, err error) {
+	if b.opts.OnError != nil {
+		b.opts.OnError(message, err)
+	}
+}
+
+func isTierError(err error) bool {
+	_, ok := errors.AsType[*azurepush.TierError](err)
+	return ok
+}
@@ -0,0 +1,135 @@
+package servicebus_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush/azurepushtest"
+	"github.com/kataras/azurepush/servicebus"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+// fakeReceiver serves its messages once, then blocks until the context is canceled.
+type fakeReceiver struct {
+	mu          sync.Mutex
+	messages    []*azservicebus.ReceivedMessage
+	completed   []string
+	abandoned   []string
+	deadLetters map[string]string // message ID -> reason.
+}
+
+func (r *fakeReceiver) ReceiveMessages(ctx context.Context, maxMessages int, _ *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	r.mu.Lock()
+	n := min(maxMessages, len(r.messages))
+	messages := r.messages[:n]
+	r.messages = r.messages[n:]
+	r.mu.Unlock()
+
+	if len(messages) == 0 {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return messages, nil
+}
+
+func (r *fakeReceiver) CompleteMessage(_ context.Context, message *azservicebus.ReceivedMessage, _ *azservicebus.CompleteMessageOptions) error {
+	r.mu.Lock()
+	r.completed = append(r.completed, message.MessageID)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fakeReceiver) AbandonMessage(_ context.Context, message *azservicebus.ReceivedMessage, _ *azservicebus.AbandonMessageOptions) error {
+	r.mu.Lock()
+	r.abandoned = append(r.abandoned, message.MessageID)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *fakeReceiver) DeadLetterMessage(_ context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error {
+	r.mu.Lock()
+	r.deadLetters[message.MessageID] = *options.Reason
+	r.mu.Unlock()
+	return nil
+}
+
+func run(t *testing.T, bridge *servicebus.Bridge) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := bridge.Run(ctx); err != nil {
+		t.Fatalf("unexpected run error: %v", err)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	receiver := &fakeReceiver{
+		messages: []*azservicebus.ReceivedMessage{
+			{MessageID: "1", DeliveryCount: 1, Body: []byte(`{"notification":{"title":"Hi","body":"Hello"},"tagExpression":"user:42 && lang:en"}`)},
+			{MessageID: "2", DeliveryCount: 1, Body: []byte(`not json`)},
+			{MessageID: "3", DeliveryCount: 1, Body: []byte(`{"notification":{}}`)},
+		},
+		deadLetters: make(map[string]string),
+	}
+
+	run(t, servicebus.NewBridge(srv.NewClient(), receiver, servicebus.Options{}))
+
+	if len(receiver.completed) != 1 || receiver.completed[0] != "1" {
+		t.Errorf("expected message 1 to be completed, got: %v", receiver.completed)
+	}
+	if receiver.deadLetters["2"] != servicebus.ReasonInvalidMessage {
+		t.Errorf("expected message 2 to be dead-lettered as invalid, got: %v", receiver.deadLetters)
+	}
+	if receiver.deadLetters["3"] != servicebus.ReasonInvalidNotification {
+		t.Errorf("expected message 3 to be dead-lettered on its first delivery, got: %v", receiver.deadLetters)
+	}
+	if sent := srv.Sent("user:42 && lang:en"); len(sent) != 2 {
+		t.Errorf("expected the tag expression to be sent to both platforms, got: %d", len(sent))
+	}
+}
+
+func TestBridge_Retries(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+	srv.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "internal error")
+
+	body := []byte(`{"notification":{"title":"Hi"}}`)
+	receiver := &fakeReceiver{
+		messages: []*azservicebus.ReceivedMessage{
+			{MessageID: "retry", DeliveryCount: 1, Body: body},
+			{MessageID: "exhausted", DeliveryCount: 3, Body: body},
+		},
+		deadLetters: make(map[string]string),
+	}
+
+	var errs int
+	var mu sync.Mutex
+	run(t, servicebus.NewBridge(srv.NewClient(), receiver, servicebus.Options{
+		MaxDeliveries: 3,
+		OnError: func(message *azservicebus.ReceivedMessage, err error) {
+			mu.Lock()
+			errs++
+			mu.Unlock()
+		},
+	}))
+
+	if len(receiver.abandoned) != 1 || receiver.abandoned[0] != "retry" {
+		t.Errorf("expected the failed message to be abandoned for redelivery, got: %v", receiver.abandoned)
+	}
+	if receiver.deadLetters["exhausted"] != servicebus.ReasonMaxDeliveries {
+		t.Errorf("expected the exhausted message to be dead-lettered, got: %v", receiver.deadLetters)
+	}
+	if errs != 2 {
+		t.Errorf("expected 2 reported errors, got: %d", errs)
+	}
+}
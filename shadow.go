@@ -0,0 +1,232 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// ShadowOptions holds the settings of a ShadowClient.
+type ShadowOptions struct {
+	// ShadowSends enables sending the notifications to the shadow hub too.
+	// When false only registrations and deletions are mirrored.
+	ShadowSends bool
+
+	// MarkKey is the notification data key set to "true" on the shadow sends,
+	// so the apps can tell them apart (e.g. to not display them).
+	//
+	// Defaults to "shadow".
+	MarkKey string
+
+	// OnMismatch is called (if not nil) when the shadow hub's outcome of an operation differs from the primary's.
+	OnMismatch func(mismatch ShadowMismatch)
+}
+
+// ShadowMismatch describes an operation whose outcome differs between the primary and the shadow hub.
+type ShadowMismatch struct {
+	// Op is the operation: "register", "delete", "exists" or "send".
+	Op string
+	// Target is the installation ID, or the tags of a send.
+	Target string
+	// PrimaryErr and ShadowErr are the errors of the hubs (if any).
+	PrimaryErr, ShadowErr error
+	// Detail describes a mismatch of successful operations, e.g. different existence.
+	Detail string
+}
+
+// ShadowCounts holds the comparison counters of an operation.
+type ShadowCounts struct {
+	Total         int
+	Mismatches    int
+	PrimaryErrors int
+	ShadowErrors  int
+}
+
+// ShadowReport is the comparison report of a ShadowClient.
+type ShadowReport struct {
+	Registrations ShadowCounts
+	Deletions     ShadowCounts
+	Checks        ShadowCounts
+	Sends         ShadowCounts
+}
+
+// Parity reports whether no operation had a different outcome on the shadow hub.
+func (r ShadowReport) Parity() bool {
+	return r.Registrations.Mismatches == 0 && r.Deletions.Mismatches == 0 &&
+		r.Checks.Mismatches == 0 && r.Sends.Mismatches == 0
+}
+
+// ShadowClient helps migrating from one hub (or namespace) to another: it serves from the primary (current) hub
+// and mirrors registrations and deletions to the shadow (new) hub, optionally sending the notifications
+// to both hubs with marked shadow sends. The outcomes are compared into a ShadowReport,
+// so the parity of the new hub can be validated before cutting over.
+//
+// The shadow hub never affects the results: its errors are only reported.
+//
+// Example usage:
+//
+//	sc := azurepush.NewShadowClient(oldClient, newClient, azurepush.ShadowOptions{
+//		ShadowSends: true,
+//		OnMismatch: func(m azurepush.ShadowMismatch) {
+//			log.Printf("shadow mismatch: %s %s: %v / %v %s", m.Op, m.Target, m.PrimaryErr, m.ShadowErr, m.Detail)
+//		},
+//	})
+//	err := sc.SendNotification(ctx, notification, "user:42")
+//	report := sc.Report()
+type ShadowClient struct {
+	Primary *Client
+	Shadow  *Client
+
+	opts ShadowOptions
+
+	mu     sync.Mutex
+	report ShadowReport
+}
+
+var _ HubClient = (*ShadowClient)(nil)
+
+// NewShadowClient creates a new ShadowClient.
+func NewShadowClient(primary, shadow *Client, opts ShadowOptions) *ShadowClient {
+	if primary == nil || shadow == nil {
+		panic("azurepush: shadow requires a primary and a shadow client")
+	}
+
+	if opts.MarkKey == "" {
+		opts.MarkKey = "shadow"
+	}
+
+	return &ShadowClient{
+		Primary: primary,
+		Shadow:  shadow,
+		opts:    opts,
+	}
+}
+
+// Report returns a snapshot of the comparison report.
+func (sc *ShadowClient) Report() ShadowReport {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	return sc.report
+}
+
+// ResetReport clears the comparison report.
+func (sc *ShadowClient) ResetReport() {
+	sc.mu.Lock()
+	sc.report = ShadowReport{}
+	sc.mu.Unlock()
+}
+
+// ValidateToken validates the SAS token of the primary hub.
+func (sc *ShadowClient) ValidateToken(ctx context.Context) error {
+	return sc.Primary.ValidateToken(ctx)
+}
+
+// RegisterDevice registers the installation to both hubs under the same installation ID
+// and returns the primary hub's result.
+func (sc *ShadowClient) RegisterDevice(ctx context.Context, installation Installation) (string, error) {
+	if installation.InstallationID == "" {
+		installation.InstallationID = uuid.NewString()
+	}
+
+	id, err := sc.Primary.RegisterDevice(ctx, installation)
+	_, shadowErr := sc.Shadow.RegisterDevice(ctx, installation)
+
+	sc.compare(&sc.report.Registrations, "register", installation.InstallationID, err, shadowErr, "")
+	return id, err
+}
+
+// DeleteDevice deletes the installation from both hubs and returns the primary hub's result.
+func (sc *ShadowClient) DeleteDevice(ctx context.Context, installationID string) error {
+	err := sc.Primary.DeleteDevice(ctx, installationID)
+	shadowErr := sc.Shadow.DeleteDevice(ctx, installationID)
+
+	sc.compare(&sc.report.Deletions, "delete", installationID, err, shadowErr, "")
+	return err
+}
+
+// DeviceExists checks the installation on both hubs and returns the primary hub's result.
+func (sc *ShadowClient) DeviceExists(ctx context.Context, installationID string) (bool, error) {
+	exists, err := sc.Primary.DeviceExists(ctx, installationID)
+	shadowExists, shadowErr := sc.Shadow.DeviceExists(ctx, installationID)
+
+	var detail string
+	if err == nil && shadowErr == nil && exists != shadowExists {
+		detail = fmt.Sprintf("exists: %t on the primary hub and %t on the shadow hub", exists, shadowExists)
+	}
+
+	sc.compare(&sc.report.Checks, "exists", installationID, err, shadowErr, detail)
+	return exists, err
+}
+
+// SendNotification sends the notification through the primary hub and returns its result.
+// When ShadowSends is enabled the marked notification is sent to the shadow hub in parallel.
+func (sc *ShadowClient) SendNotification(ctx context.Context, notification Notification, tags ...string) error {
+	if !sc.opts.ShadowSends {
+		return sc.Primary.SendNotification(ctx, notification, tags...)
+	}
+
+	shadow := notification
+	shadow.Data = maps.Clone(notification.Data)
+	if shadow.Data == nil {
+		shadow.Data = make(map[string]any, 1)
+	}
+	shadow.Data[sc.opts.MarkKey] = "true"
+
+	var (
+		wg        sync.WaitGroup
+		shadowErr error
+	)
+	wg.Go(func() {
+		shadowErr = sc.Shadow.SendNotification(ctx, shadow, tags...)
+	})
+	err := sc.Primary.SendNotification(ctx, notification, tags...)
+	wg.Wait()
+
+	sc.compare(&sc.report.Sends, "send", strings.Join(tags, ", "), err, shadowErr, "")
+	return err
+}
+
+// CompareAudience estimates the audience of a tag expression on both hubs, see Client.EstimateAudience.
+func (sc *ShadowClient) CompareAudience(ctx context.Context, tagExpression string) (primary, shadow *AudienceEstimate, err error) {
+	primary, err = sc.Primary.EstimateAudience(ctx, tagExpression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("primary hub: %w", err)
+	}
+
+	shadow, err = sc.Shadow.EstimateAudience(ctx, tagExpression)
+	if err != nil {
+		return nil, nil, fmt.Errorf("shadow hub: %w", err)
+	}
+
+	return primary, shadow, nil
+}
+
+// compare records the outcomes of an operation and reports a mismatch.
+func (sc *ShadowClient) compare(counts *ShadowCounts, op, target string, err, shadowErr error, detail string) {
+	mismatch := (err == nil) != (shadowErr == nil) || detail != "" ||
+		// Both failed for different reasons, e.g. no device on the shadow hub only but an outage on the primary.
+		(err != nil && shadowErr != nil && errors.Is(err, errDeviceNotFound) != errors.Is(shadowErr, errDeviceNotFound))
+
+	sc.mu.Lock()
+	counts.Total++
+	if err != nil {
+		counts.PrimaryErrors++
+	}
+	if shadowErr != nil {
+		counts.ShadowErrors++
+	}
+	if mismatch {
+		counts.Mismatches++
+	}
+	sc.mu.Unlock()
+
+	if mismatch && sc.opts.OnMismatch != nil {
+		sc.opts.OnMismatch(ShadowMismatch{Op: op, Target: target, PrimaryErr: err, ShadowErr: shadowErr, Detail: detail})
+	}
+}
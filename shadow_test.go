@@ -0,0 +1,97 @@
+package azurepush_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestShadowClient(t *testing.T) {
+	primary, shadow := azurepushtest.NewServer(), azurepushtest.NewServer()
+	defer primary.Close()
+	defer shadow.Close()
+
+	var mismatches []azurepush.ShadowMismatch
+	sc := azurepush.NewShadowClient(primary.NewClient(), shadow.NewClient(), azurepush.ShadowOptions{
+		ShadowSends: true,
+		OnMismatch: func(m azurepush.ShadowMismatch) {
+			mismatches = append(mismatches, m)
+		},
+	})
+
+	ctx := context.Background()
+	id, err := sc.RegisterDevice(ctx, azurepush.Installation{
+		Platform:    azurepush.InstallationApple,
+		PushChannel: "token",
+		Tags:        []string{"user:42"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := shadow.Installation(id); !ok {
+		t.Error("expected the registration to be mirrored to the shadow hub")
+	}
+
+	notification := azurepush.Notification{Title: "Hello", Data: map[string]any{"orderId": "42"}}
+	if err = sc.SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := notification.Data["shadow"]; ok {
+		t.Error("expected the caller's notification data to be left untouched")
+	}
+
+	primarySent, shadowSent := primary.Sent("user:42"), shadow.Sent("user:42")
+	if len(primarySent) == 0 || len(shadowSent) == 0 {
+		t.Fatalf("expected the notification on both hubs, got: %d and %d", len(primarySent), len(shadowSent))
+	}
+
+	var payload map[string]any
+	if err = json.Unmarshal(shadowSent[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["shadow"] != "true" {
+		t.Errorf("expected the shadow send to be marked, got: %s", shadowSent[0].Payload)
+	}
+	payload = nil
+	if err = json.Unmarshal(primarySent[0].Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := payload["shadow"]; ok {
+		t.Errorf("expected the primary send not to be marked, got: %s", primarySent[0].Payload)
+	}
+
+	if report := sc.Report(); !report.Parity() || report.Registrations.Total != 1 || report.Sends.Total != 1 {
+		t.Errorf("expected parity, got: %+v, mismatches: %+v", report, mismatches)
+	}
+
+	// The shadow hub fails: the primary's result is returned and the mismatch is reported.
+	shadow.SetError(azurepushtest.OpSend, http.StatusInternalServerError, "outage")
+	if err = sc.SendNotification(ctx, notification, "user:42"); err != nil {
+		t.Fatalf("expected the shadow hub not to affect the result, got: %v", err)
+	}
+
+	shadow.Reset() // the installation is lost on the shadow hub.
+	exists, err := sc.DeviceExists(ctx, id)
+	if err != nil || !exists {
+		t.Fatalf("expected the primary hub's result, got: %v, %v", exists, err)
+	}
+
+	report := sc.Report()
+	if report.Parity() || report.Sends.Mismatches != 1 || report.Sends.ShadowErrors != 1 || report.Checks.Mismatches != 1 {
+		t.Errorf("expected the send and existence mismatches, got: %+v", report)
+	}
+	if len(mismatches) != 2 || mismatches[0].Op != "send" || mismatches[1].Op != "exists" || mismatches[1].Detail == "" {
+		t.Errorf("unexpected mismatches: %+v", mismatches)
+	}
+
+	sc.ResetReport()
+	if report = sc.Report(); report.Sends.Total != 0 {
+		t.Errorf("expected an empty report, got: %+v", report)
+	}
+}
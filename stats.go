@@ -0,0 +1,187 @@
+package azurepush
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatsWindow is the interval of the send counters of ClientStats.
+const StatsWindow = 5 * time.Minute
+
+const (
+	statsBucketSize = time.Minute // StatsWindow is counted in buckets of this size.
+	statsBuckets    = int(StatsWindow / statsBucketSize)
+	statsMaxErrors  = 10
+)
+
+// ClientStats is a snapshot of the operational state of a Client, see Client.Stats.
+// It is encoded to JSON for dashboards, e.g. by the stats endpoint of azurepushd.
+type ClientStats struct {
+	// Time is the time of the snapshot.
+	Time time.Time `json:"time"`
+	// Window is the interval of Sends, StatsWindow. It is encoded as a duration string in JSON.
+	Window time.Duration `json:"window"`
+	// Sends counts the SendNotification calls of the last Window by outcome:
+	// "ok", or the ErrorClass of the failure (e.g. "throttled", "no device").
+	Sends map[string]int `json:"sends"`
+
+	// InFlight is the number of in-flight hub requests.
+	InFlight int `json:"inFlight"`
+	// Queued is the number of hub requests waiting for a slot of MaxConcurrentRequests (the queue depth).
+	Queued int `json:"queued"`
+	// MaxConcurrentRequests is the limit of InFlight, zero if not limited (see Configuration.MaxConcurrentRequests).
+	MaxConcurrentRequests int `json:"maxConcurrentRequests"`
+
+	// TokenExpiresAt is the expiration time of the hub's SAS token (see TokenManager.ExpiresAt),
+	// zero if there is none yet or the client uses a custom TokenProvider.
+	TokenExpiresAt time.Time `json:"tokenExpiresAt,omitzero"`
+	// TokenRefreshFailures is the number of failed token generations of the TokenManager.
+	TokenRefreshFailures uint64 `json:"tokenRefreshFailures"`
+
+	// Available reports the state of the hub as seen by the client: a hub call succeeded within the ReadinessWindow.
+	// The client has no circuit breaker, an unavailable hub is still called (see ReadinessHandler).
+	Available bool `json:"available"`
+	// LastSuccess is the time of the last successful hub call, zero after a failed one.
+	LastSuccess time.Time `json:"lastSuccess,omitzero"`
+	// Closed reports whether the client is closed, see Close.
+	Closed bool `json:"closed"`
+
+	// LastErrors holds the last failures of the hub calls, newest first.
+	LastErrors []ErrorRecord `json:"lastErrors"`
+}
+
+// MarshalJSON encodes the Window as a duration string, e.g. "5m0s".
+func (s ClientStats) MarshalJSON() ([]byte, error) {
+	type stats ClientStats
+	return json.Marshal(struct {
+		stats
+		Window jsonDuration `json:"window"`
+	}{stats(s), jsonDuration(s.Window)})
+}
+
+// ErrorRecord is a failed hub call of ClientStats.LastErrors.
+type ErrorRecord struct {
+	Time time.Time `json:"time"`
+	// Operation is the class of the failed call, e.g. "send".
+	Operation OperationClass `json:"operation"`
+	// Class is the ErrorClass name of the failure, e.g. "throttled".
+	Class string `json:"class"`
+	Error string `json:"error"`
+}
+
+// clientStats holds the counters of ClientStats.
+type clientStats struct {
+	queued atomic.Int64 // the hub requests waiting for a concurrency slot.
+
+	mu      sync.Mutex
+	buckets [statsBuckets]sendBucket
+	errors  []ErrorRecord // oldest first.
+}
+
+type sendBucket struct {
+	start time.Time
+	sends map[string]int
+}
+
+// send counts the outcome of a SendNotification call.
+func (s *clientStats) send(now time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = ClassifyError(err).String()
+	}
+
+	start := now.Truncate(statsBucketSize)
+	s.mu.Lock()
+	bucket := &s.buckets[int(start.Unix()/int64(statsBucketSize/time.Second))%statsBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = sendBucket{start: start, sends: make(map[string]int)}
+	}
+	bucket.sends[outcome]++
+	s.mu.Unlock()
+}
+
+// failed records a failed hub call.
+func (s *clientStats) failed(now time.Time, req *http.Request, class ErrorClass, err error) {
+	s.mu.Lock()
+	if len(s.errors) == statsMaxErrors {
+		s.errors = append(s.errors[:0], s.errors[1:]...)
+	}
+	s.errors = append(s.errors, ErrorRecord{
+		Time:      now,
+		Operation: operationClassOf(req),
+		Class:     class.String(),
+		Error:     err.Error(),
+	})
+	s.mu.Unlock()
+}
+
+// recordAttempt records the failure of a hub request, if any, see attempt.
+func (c *Client) recordAttempt(req *http.Request, resp *http.Response, err error) {
+	switch {
+	case err != nil:
+		if req.Context().Err() == nil { // not canceled by the caller.
+			c.stats.failed(c.now(), req, ClassifyError(err), err)
+		}
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode == http.StatusTooManyRequests:
+		c.stats.failed(c.now(), req, classifyStatus(resp.StatusCode),
+			fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, resp.Status))
+	}
+}
+
+// Stats returns a snapshot of the client's operational state: the sends of the last StatsWindow by outcome,
+// the in-flight and queued hub requests, the SAS token's expiration, the hub's availability and the last errors.
+// It is cheap enough to be called by every scrape of a dashboard.
+//
+// Example usage:
+//
+//	http.HandleFunc("GET /admin/stats", func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("Content-Type", "application/json")
+//		json.NewEncoder(w).Encode(client.Stats())
+//	})
+func (c *Client) Stats() ClientStats {
+	now := c.now()
+
+	stats := ClientStats{
+		Time:   now,
+		Window: StatsWindow,
+		Sends:  make(map[string]int),
+		Queued: int(c.stats.queued.Load()),
+		Closed: c.isClosed(),
+	}
+	stats.InFlight, stats.MaxConcurrentRequests = c.InFlightRequests()
+
+	if _, provider := c.current(); provider != nil {
+		if tm, ok := provider.(*TokenManager); ok && tm != nil {
+			tokenStats := tm.Stats()
+			stats.TokenExpiresAt, stats.TokenRefreshFailures = tokenStats.ExpiresAt, tokenStats.RefreshFailures
+		}
+	}
+
+	if last := c.health.lastSuccess.Load(); last != 0 {
+		stats.LastSuccess = time.Unix(0, last)
+		stats.Available = now.Sub(stats.LastSuccess) < ReadinessWindow
+	}
+
+	since := now.Truncate(statsBucketSize).Add(-StatsWindow + statsBucketSize)
+	c.stats.mu.Lock()
+	for _, bucket := range c.stats.buckets {
+		if bucket.start.Before(since) || bucket.start.After(now) {
+			continue
+		}
+		for outcome, n := range bucket.sends {
+			stats.Sends[outcome] += n
+		}
+	}
+	stats.LastErrors = make([]ErrorRecord, 0, len(c.stats.errors))
+	for i := len(c.stats.errors) - 1; i >= 0; i-- {
+		stats.LastErrors = append(stats.LastErrors, c.stats.errors[i])
+	}
+	c.stats.mu.Unlock()
+
+	return stats
+}
@@ -0,0 +1,100 @@
+package azurepush_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_Stats(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	clock := azurepushtest.NewFakeClock(time.Now().Truncate(time.Minute))
+	srv.SetClock(clock)
+
+	client := srv.NewClient()
+	client.SetClock(clock)
+	ctx := context.Background()
+
+	if err := client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42"); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetError(azurepushtest.OpSend, http.StatusForbidden, "denied")
+	if err := client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42"); err == nil {
+		t.Fatal("expected a send error")
+	}
+
+	stats := client.Stats()
+	if stats.Sends["ok"] != 1 || stats.Sends["auth"] != 1 {
+		t.Errorf("expected 1 successful and 1 auth failed send, got: %v", stats.Sends)
+	}
+	if len(stats.LastErrors) != 1 || stats.LastErrors[0].Operation != azurepush.OperationSend || stats.LastErrors[0].Class != "auth" {
+		t.Errorf("expected the failed send in the last errors, got: %+v", stats.LastErrors)
+	}
+	if stats.Available || !stats.LastSuccess.IsZero() {
+		t.Errorf("expected the hub unavailable after a failed call, got: %v", stats.LastSuccess)
+	}
+	if !stats.TokenExpiresAt.After(clock.Now()) {
+		t.Errorf("expected the token expiration, got: %v", stats.TokenExpiresAt)
+	}
+	if stats.InFlight != 0 || stats.Queued != 0 || stats.Closed {
+		t.Errorf("expected an idle client, got: %+v", stats)
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"window":"5m0s"`) {
+		t.Errorf("expected the window as a duration string, got: %s", b)
+	}
+
+	// The sends leave the window.
+	clock.Advance(azurepush.StatsWindow)
+	if stats = client.Stats(); len(stats.Sends) != 0 {
+		t.Errorf("expected no sends in the window, got: %v", stats.Sends)
+	}
+}
+
+func TestClient_Stats_HubName(t *testing.T) {
+	for _, hubName := range []string{"messages-prod", "jobs-eu"} {
+		t.Run(hubName, func(t *testing.T) {
+			srv := azurepushtest.NewServer()
+			defer srv.Close()
+
+			cfg := srv.Configuration()
+			cfg.HubName = hubName
+			client := azurepush.NewClient(cfg)
+			client.HTTPClient = srv.HTTPClient()
+
+			srv.SetError(azurepushtest.OpRegister, http.StatusForbidden, "denied")
+			if _, err := client.RegisterDevice(t.Context(), azurepush.Installation{InstallationID: "a", Platform: azurepush.InstallationApple, PushChannel: "token-a"}); err == nil {
+				t.Fatal("expected a registration error")
+			}
+			srv.SetError(azurepushtest.OpSend, http.StatusForbidden, "denied")
+			if err := client.SendNotificationWithOptions(t.Context(), azurepush.Notification{Title: "Hi"}, azurepush.SendOptions{ScheduleTime: time.Now().Add(time.Hour)}, "user:1"); err == nil {
+				t.Fatal("expected a scheduled send error")
+			}
+
+			// The last errors are the newest first.
+			stats := client.Stats()
+			if len(stats.LastErrors) != 2 {
+				t.Fatalf("expected 2 last errors, got: %+v", stats.LastErrors)
+			}
+			if op := stats.LastErrors[0].Operation; op != azurepush.OperationSend {
+				t.Errorf("expected the failed scheduled send to be a send, got: %s", op)
+			}
+			if op := stats.LastErrors[1].Operation; op != azurepush.OperationRegistration {
+				t.Errorf("expected the failed registration to be a registration, got: %s", op)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrNotFound is returned by Store.Get when the key does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Store is the key-value storage of the stateful subsystems (schedules, deferred notifications,
+// campaign checkpoints), so they survive restarts and can be shared between instances.
+// Keys are slash-separated paths prefixed by the subsystem, e.g. "schedules/weekly-digest",
+// and values are JSON documents.
+//
+// The package provides an in-memory implementation, see NewMemoryStore.
+// Implementations for a database are straightforward, e.g.:
+//   - Redis: Put is SET, Get is GET (redis.Nil maps to ErrNotFound), Delete is DEL
+//     and List is a SCAN with the "<prefix>*" pattern followed by an MGET.
+//   - Postgres: a "kv (key text primary key, value bytea)" table, Put is an
+//     INSERT ... ON CONFLICT (key) DO UPDATE, List is a "WHERE key LIKE $1 || '%' ORDER BY key" query
+//     (escape the LIKE wildcards of the prefix).
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Put creates or replaces the value of a key.
+	Put(ctx context.Context, key string, value []byte) error
+	// Get returns the value of a key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the entries whose key starts with the prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]StoreEntry, error)
+	// Delete removes a key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// StoreEntry is a key-value pair of a Store.
+type StoreEntry struct {
+	Key   string
+	Value []byte
+}
+
+// MemoryStore is an in-memory Store, the state is lost when the process exits.
+// It is suitable for tests and single-instance deployments that can afford losing state.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string][]byte
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string][]byte)}
+}
+
+// Put implements the Store interface.
+func (s *MemoryStore) Put(ctx context.Context, key string, value []byte) error {
+	s.mu.Lock()
+	s.entries[key] = slices.Clone(value)
+	s.mu.Unlock()
+	return nil
+}
+
+// Get implements the Store interface.
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.entries[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return slices.Clone(value), nil
+}
+
+// List implements the Store interface.
+func (s *MemoryStore) List(ctx context.Context, prefix string) ([]StoreEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var entries []StoreEntry
+	for _, key := range slices.Sorted(maps.Keys(s.entries)) {
+		if strings.HasPrefix(key, prefix) {
+			entries = append(entries, StoreEntry{Key: key, Value: slices.Clone(s.entries[key])})
+		}
+	}
+
+	return entries, nil
+}
+
+// Delete implements the Store interface.
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,48 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := azurepush.NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, "schedules/a"); !errors.Is(err, azurepush.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got: %v", err)
+	}
+
+	for _, key := range []string{"schedules/b", "schedules/a", "campaigns/a"} {
+		if err := store.Put(ctx, key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	value, err := store.Get(ctx, "schedules/a")
+	if err != nil || string(value) != "schedules/a" {
+		t.Fatalf("unexpected value: %q, error: %v", value, err)
+	}
+	value[0] = 'X' // the store must not be affected.
+
+	entries, err := store.List(ctx, "schedules/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Key != "schedules/a" || string(entries[0].Value) != "schedules/a" || entries[1].Key != "schedules/b" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	if err = store.Delete(ctx, "schedules/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.Delete(ctx, "schedules/missing"); err != nil {
+		t.Fatalf("expected no error deleting a missing key, got: %v", err)
+	}
+	if entries, _ = store.List(ctx, ""); len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got: %+v", entries)
+	}
+}
@@ -0,0 +1,168 @@
+package azurepush
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxTagLength is the maximum length of a tag accepted by Azure Notification Hubs.
+const MaxTagLength = 120
+
+// Tag is a tag of a device installation (e.g. "user:42"), used to target sends.
+// By convention a tag is made of a kind and a value separated by a colon,
+// see NewTag and the UserTag, TopicTag and InstallationTag constructors.
+//
+// Tags can be passed to the send methods with Tags, e.g.
+//
+//	err := client.SendNotification(ctx, notification, azurepush.Tags(azurepush.UserTag("42"))...)
+type Tag string
+
+// Tag kinds of the constructors.
+const (
+	TagKindUser  = "user"
+	TagKindTopic = "topic"
+)
+
+// NewTag returns the "kind:value" tag.
+func NewTag(kind, value string) Tag {
+	return Tag(kind + ":" + value)
+}
+
+// UserTag returns the tag of a user's devices, e.g. "user:42".
+func UserTag(userID string) Tag {
+	return NewTag(TagKindUser, userID)
+}
+
+// TopicTag returns the tag of a topic's subscribers, e.g. "topic:news".
+func TopicTag(topic string) Tag {
+	return NewTag(TagKindTopic, topic)
+}
+
+// InstallationTag returns the built-in tag of a single installation, "$InstallationId:{id}".
+// It can only be used to target sends, it is not set on installations.
+func InstallationTag(installationID string) Tag {
+	return Tag("$InstallationId:{" + installationID + "}")
+}
+
+// ParseTag returns the tag of s, or an error if it is not a valid tag, see Tag.Validate.
+func ParseTag(s string) (Tag, error) {
+	tag := Tag(s)
+	if err := tag.Validate(); err != nil {
+		return "", err
+	}
+
+	return tag, nil
+}
+
+// Kind returns the kind of the tag, the part before the first colon (e.g. "user" of "user:42"),
+// or an empty string if the tag has no kind. It is "$InstallationId" for an InstallationTag.
+func (t Tag) Kind() string {
+	kind, _, ok := strings.Cut(string(t), ":")
+	if !ok {
+		return ""
+	}
+
+	return kind
+}
+
+// Value returns the value of the tag, the part after the first colon (e.g. "42" of "user:42"),
+// or the whole tag if it has no kind. It is the installation ID of an InstallationTag.
+func (t Tag) Value() string {
+	if id, ok := t.installationID(); ok {
+		return id
+	}
+
+	_, value, ok := strings.Cut(string(t), ":")
+	if !ok {
+		return string(t)
+	}
+
+	return value
+}
+
+// String returns the tag as a string.
+func (t Tag) String() string {
+	return string(t)
+}
+
+// Validate checks the tag against the rules of Azure Notification Hubs: a tag is not empty,
+// has at most MaxTagLength characters and is made of letters, digits and the '_', '@', '#', '.', ':', '-' characters.
+// An InstallationTag is valid if its installation ID is not empty.
+func (t Tag) Validate() error {
+	if id, ok := t.installationID(); ok {
+		if id == "" {
+			return fmt.Errorf("invalid tag: %q: empty installation ID", string(t))
+		}
+		return nil
+	}
+
+	if t == "" {
+		return fmt.Errorf("invalid tag: empty")
+	}
+
+	if len(t) > MaxTagLength {
+		return fmt.Errorf("invalid tag: %q: longer than %d characters", string(t), MaxTagLength)
+	}
+
+	for _, r := range string(t) {
+		if !isTagRune(r) {
+			return fmt.Errorf("invalid tag: %q: invalid character: %q", string(t), r)
+		}
+	}
+
+	return nil
+}
+
+func (t Tag) installationID() (string, bool) {
+	id, ok := strings.CutPrefix(string(t), "$InstallationId:{")
+	if !ok || !strings.HasSuffix(id, "}") {
+		return "", false
+	}
+
+	return strings.TrimSuffix(id, "}"), true
+}
+
+func isTagRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune("_@#.:-", r)
+	}
+}
+
+// validateSendTags checks the tags (or tag expressions) of a send before they are joined in the
+// ServiceBusNotification-Tags header: a tag with a space, a '+' or a non-ASCII character would be rejected by the hub,
+// or worse, parsed as another expression and mis-target the send. The IDs of the InstallationTags must be printable ASCII.
+func validateSendTags(tags []string) error {
+	for _, tag := range tags {
+		expr, err := ParseTagExpression(tag)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+		}
+
+		for _, t := range expr.Tags() {
+			if err = Tag(t).Validate(); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidNotification, err)
+			}
+		}
+
+		for _, r := range tag {
+			if (r < ' ' || r > '~') && r != '\t' {
+				return fmt.Errorf("%w: invalid tag expression %q: invalid character: %q", ErrInvalidNotification, tag, r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Tags converts tags to strings, for the send methods and the Installation's Tags.
+func Tags(tags ...Tag) []string {
+	s := make([]string, len(tags))
+	for i, tag := range tags {
+		s[i] = string(tag)
+	}
+
+	return s
+}
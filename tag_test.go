@@ -0,0 +1,48 @@
+package azurepush_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		tag         azurepush.Tag
+		expected    string
+		kind, value string
+	}{
+		{azurepush.UserTag("42"), "user:42", "user", "42"},
+		{azurepush.TopicTag("news"), "topic:news", "topic", "news"},
+		{azurepush.NewTag("location", "Boston"), "location:Boston", "location", "Boston"},
+		{azurepush.InstallationTag("device-1"), "$InstallationId:{device-1}", "$InstallationId", "device-1"},
+		{azurepush.Tag("beta"), "beta", "", "beta"},
+		{azurepush.Tag("time:12:00"), "time:12:00", "time", "12:00"},
+	}
+
+	for _, tt := range tests {
+		if tt.tag.String() != tt.expected || tt.tag.Kind() != tt.kind || tt.tag.Value() != tt.value {
+			t.Errorf("%s: expected: %s (%s, %s), got kind: %s, value: %s", tt.tag, tt.expected, tt.kind, tt.value, tt.tag.Kind(), tt.tag.Value())
+		}
+
+		if err := tt.tag.Validate(); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.tag, err)
+		}
+	}
+
+	for _, invalid := range []string{"", "user:john doe", "user:42!", strings.Repeat("a", azurepush.MaxTagLength+1), "$InstallationId:{}"} {
+		if _, err := azurepush.ParseTag(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+
+	if tag, err := azurepush.ParseTag("email:john@example.com"); err != nil || tag.Value() != "john@example.com" {
+		t.Errorf("unexpected parsed tag: %s, %v", tag, err)
+	}
+
+	if got := azurepush.Tags(azurepush.UserTag("1"), azurepush.TopicTag("news")); !slices.Equal(got, []string{"user:1", "topic:news"}) {
+		t.Errorf("unexpected tags: %v", got)
+	}
+}
@@ -0,0 +1,202 @@
+package azurepush
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TagExpression is a parsed tag expression, e.g. "follows:RedSox && !location:Boston".
+// It supports the && (AND), || (OR) and ! (NOT) operators and parentheses, like the hub does
+// (see https://learn.microsoft.com/en-us/azure/notification-hubs/notification-hubs-tags-segment-push-message).
+type TagExpression struct {
+	root tagNode
+	tags []string
+}
+
+// ParseTagExpression parses a tag expression.
+func ParseTagExpression(expr string) (*TagExpression, error) {
+	p := &tagParser{tokens: tokenizeTagExpression(expr)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("invalid tag expression %q: empty", expr)
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag expression %q: %w", expr, err)
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid tag expression %q: unexpected %q", expr, p.tokens[p.pos])
+	}
+
+	return &TagExpression{root: root, tags: p.tags}, nil
+}
+
+// Match reports whether a device with the given tags matches the expression.
+func (e *TagExpression) Match(tags []string) bool {
+	return e.root.match(tags)
+}
+
+// Tags returns the distinct tags of the expression, in order of appearance.
+func (e *TagExpression) Tags() []string {
+	return slices.Clone(e.tags)
+}
+
+// HasNegation reports whether the expression contains a ! (NOT) operator,
+// i.e. it may match devices that have none of its tags.
+func (e *TagExpression) HasNegation() bool {
+	return e.root.negated()
+}
+
+// conjuncts returns the tags AND-ed at the top level of the expression,
+// e.g. "region:eu" of "region:eu && (user:1 || user:2)".
+// Every device matched by an expression without negation has all of them.
+func (e *TagExpression) conjuncts() []string {
+	var tags []string
+	var walk func(n tagNode)
+	walk = func(n tagNode) {
+		switch n := n.(type) {
+		case tagLeaf:
+			tags = append(tags, string(n))
+		case tagAnd:
+			walk(n.left)
+			walk(n.right)
+		}
+	}
+	walk(e.root)
+
+	return tags
+}
+
+type tagNode interface {
+	match(tags []string) bool
+	negated() bool
+}
+
+type (
+	tagLeaf string
+	tagNot  struct{ node tagNode }
+	tagAnd  struct{ left, right tagNode }
+	tagOr   struct{ left, right tagNode }
+)
+
+func (n tagLeaf) match(tags []string) bool { return slices.Contains(tags, string(n)) }
+func (n tagNot) match(tags []string) bool  { return !n.node.match(tags) }
+func (n tagAnd) match(tags []string) bool  { return n.left.match(tags) && n.right.match(tags) }
+func (n tagOr) match(tags []string) bool   { return n.left.match(tags) || n.right.match(tags) }
+
+func (tagLeaf) negated() bool  { return false }
+func (tagNot) negated() bool   { return true }
+func (n tagAnd) negated() bool { return n.left.negated() || n.right.negated() }
+func (n tagOr) negated() bool  { return n.left.negated() || n.right.negated() }
+
+func tokenizeTagExpression(expr string) []string {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '!':
+			tokens = append(tokens, expr[i:i+1])
+			i++
+		case strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		default:
+			end := i
+			for end < len(expr) && !strings.ContainsRune(" \t()!&|", rune(expr[end])) {
+				end++
+			}
+			if end == i { // a single & or |.
+				end++
+			}
+			tokens = append(tokens, expr[i:end])
+			i = end
+		}
+	}
+
+	return tokens
+}
+
+type tagParser struct {
+	tokens []string
+	pos    int
+	tags   []string
+}
+
+func (p *tagParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *tagParser) parseOr() (tagNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = tagOr{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *tagParser) parseAnd() (tagNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = tagAnd{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *tagParser) parseUnary() (tagNode, error) {
+	switch token := p.peek(); token {
+	case "":
+		return nil, fmt.Errorf("unexpected end")
+	case "!":
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return tagNot{node}, nil
+	case "(":
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return node, nil
+	case ")", "&&", "||", "&", "|":
+		return nil, fmt.Errorf("unexpected %q", token)
+	default:
+		p.pos++
+		if !slices.Contains(p.tags, token) {
+			p.tags = append(p.tags, token)
+		}
+		return tagLeaf(token), nil
+	}
+}
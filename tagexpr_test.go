@@ -0,0 +1,52 @@
+package azurepush_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestParseTagExpression(t *testing.T) {
+	tests := []struct {
+		expr  string
+		tags  []string
+		match bool
+	}{
+		{"user:42", []string{"user:42"}, true},
+		{"user:42", []string{"user:43"}, false},
+		{"follows:RedSox && location:Boston", []string{"follows:RedSox", "location:Boston"}, true},
+		{"follows:RedSox && location:Boston", []string{"follows:RedSox"}, false},
+		{"follows:RedSox || follows:Cardinals", []string{"follows:Cardinals"}, true},
+		{"follows:RedSox && !location:Boston", []string{"follows:RedSox", "location:NYC"}, true},
+		{"follows:RedSox && !location:Boston", []string{"follows:RedSox", "location:Boston"}, false},
+		{"(a || b) && c", []string{"b", "c"}, true},
+		{"a || b && c", []string{"a"}, true}, // && binds tighter than ||.
+		{"!(a || b)", nil, true},
+	}
+
+	for _, tt := range tests {
+		expr, err := azurepush.ParseTagExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.expr, err)
+		}
+
+		if got := expr.Match(tt.tags); got != tt.match {
+			t.Errorf("%q: expected match %v for tags %v, got: %v", tt.expr, tt.match, tt.tags, got)
+		}
+	}
+
+	expr, _ := azurepush.ParseTagExpression("(a || b) && !a")
+	if tags := expr.Tags(); !slices.Equal(tags, []string{"a", "b"}) {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	if !expr.HasNegation() {
+		t.Error("expected the expression to have a negation")
+	}
+
+	for _, invalid := range []string{"", "a &&", "(a || b", "a b", "a & b", "|| a", ")"} {
+		if _, err := azurepush.ParseTagExpression(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package azurepush
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Notification states reported by the telemetry, see NotificationTelemetry.State.
+const (
+	NotificationStateEnqueued      = "Enqueued"
+	NotificationStateProcessing    = "Processing"
+	NotificationStateCompleted     = "Completed"
+	NotificationStateAbandoned     = "Abandoned"
+	NotificationStateNoTargetFound = "NoTargetFound"
+	NotificationStateCancelled     = "Cancelled"
+	NotificationStateUnknown       = "Unknown"
+)
+
+// NotificationTelemetry is the delivery telemetry of a sent notification, see Client.GetNotificationTelemetry.
+//
+// See https://learn.microsoft.com/en-us/rest/api/notificationhubs/get-notification-message-telemetry.
+type NotificationTelemetry struct {
+	NotificationID string
+	// State is the overall state of the notification, e.g. NotificationStateCompleted.
+	State       string
+	EnqueueTime time.Time
+	StartTime   time.Time
+	EndTime     time.Time
+	// TargetPlatforms holds the targeted platforms, e.g. "apple" and "fcmV1".
+	TargetPlatforms []string
+	// OutcomeCounts holds the number of outcomes per push notification service and outcome,
+	// e.g. "apns.Success" -> 10 or "fcmV1.InvalidCredentials" -> 1.
+	OutcomeCounts map[string]int
+	// PNSErrorDetailsURI is the SAS URI of the blob of the errors reported by the push notification services, if any.
+	PNSErrorDetailsURI string
+}
+
+// GetNotificationTelemetry returns the delivery telemetry of a sent notification by its ID,
+// as returned by SendNotificationWithIDs. Telemetry requires the Standard tier,
+// a lower tier is reported with a *TierError.
+//
+// Example usage:
+//
+//	ids, err := client.SendNotificationWithIDs(ctx, notification, azurepush.SendOptions{}, "user:42")
+//	// [...]
+//	telemetry, err := client.GetNotificationTelemetry(ctx, ids[0])
+//	if err != nil {
+//		return err
+//	}
+//	log.Printf("%s: %v", telemetry.State, telemetry.OutcomeCounts)
+func (c *Client) GetNotificationTelemetry(ctx context.Context, notificationID string) (*NotificationTelemetry, error) {
+	if notificationID == "" {
+		return nil, fmt.Errorf("notification ID cannot be empty")
+	}
+
+	if err := c.RequireTier(FeatureTelemetry, TierStandard); err != nil {
+		return nil, err
+	}
+
+	cfg, tm := c.current()
+
+	token, err := tm.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAS token: %w", err)
+	}
+
+	url := cfg.hubURL(hubPath("messages", notificationID), "2020-06")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		if err = tierErrorFromResponse(FeatureTelemetry, TierStandard, c.Tier(), resp.StatusCode, string(b)); err != nil {
+			return nil, fmt.Errorf("failed to get notification telemetry: %s: %w", notificationID, err)
+		}
+		return nil, fmt.Errorf("failed to get notification telemetry: %s: %s: %s", notificationID, resp.Status, string(b))
+	}
+
+	var details notificationDetails
+	if err = xml.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return nil, fmt.Errorf("failed to decode notification telemetry: %w", err)
+	}
+
+	return details.telemetry(), nil
+}
+
+// notificationDetails is the <NotificationDetails> telemetry response of the hub.
+type notificationDetails struct {
+	NotificationID     string        `xml:"NotificationId"`
+	State              string        `xml:"State"`
+	EnqueueTime        string        `xml:"EnqueueTime"`
+	StartTime          string        `xml:"StartTime"`
+	EndTime            string        `xml:"EndTime"`
+	TargetPlatforms    string        `xml:"TargetPlatforms"`
+	PNSErrorDetailsURI string        `xml:"PnsErrorDetailsUri"`
+	Apns               outcomeCounts `xml:"ApnsOutcomeCounts"`
+	Wns                outcomeCounts `xml:"WnsOutcomeCounts"`
+	Mpns               outcomeCounts `xml:"MpnsOutcomeCounts"`
+	Gcm                outcomeCounts `xml:"GcmOutcomeCounts"`
+	FcmV1              outcomeCounts `xml:"FcmV1OutcomeCounts"`
+	Adm                outcomeCounts `xml:"AdmOutcomeCounts"`
+	Baidu              outcomeCounts `xml:"BaiduOutcomeCounts"`
+}
+
+type outcomeCounts struct {
+	Outcomes []struct {
+		Name  string `xml:"Name"`
+		Count int    `xml:"Count"`
+	} `xml:"Outcome"`
+}
+
+func (d notificationDetails) telemetry() *NotificationTelemetry {
+	telemetry := &NotificationTelemetry{
+		NotificationID:     d.NotificationID,
+		State:              d.State,
+		PNSErrorDetailsURI: d.PNSErrorDetailsURI,
+		OutcomeCounts:      make(map[string]int),
+	}
+
+	telemetry.EnqueueTime, _ = time.Parse(time.RFC3339, d.EnqueueTime)
+	telemetry.StartTime, _ = time.Parse(time.RFC3339, d.StartTime)
+	telemetry.EndTime, _ = time.Parse(time.RFC3339, d.EndTime)
+
+	for platform := range strings.SplitSeq(d.TargetPlatforms, ",") {
+		if platform = strings.TrimSpace(platform); platform != "" {
+			telemetry.TargetPlatforms = append(telemetry.TargetPlatforms, platform)
+		}
+	}
+
+	for service, counts := range map[string]outcomeCounts{
+		"apns": d.Apns, "wns": d.Wns, "mpns": d.Mpns, "gcm": d.Gcm, "fcmV1": d.FcmV1, "adm": d.Adm, "baidu": d.Baidu,
+	} {
+		for _, outcome := range counts.Outcomes {
+			telemetry.OutcomeCounts[service+"."+outcome.Name] += outcome.Count
+		}
+	}
+
+	return telemetry
+}
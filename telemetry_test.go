@@ -0,0 +1,69 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_GetNotificationTelemetry(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	for _, platform := range []azurepush.InstallationPlatform{azurepush.InstallationApple, azurepush.InstallationFCMV1} {
+		installation := azurepushtest.RandomInstallation(platform)
+		installation.Tags = []string{"user:42"}
+		if _, err := client.RegisterDevice(ctx, installation); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := client.SendNotificationWithIDs(ctx, azurepush.Notification{Title: "Hi"}, azurepush.SendOptions{}, "user:42")
+	if err != nil {
+		t.Fatalf("unexpected send error: %v", err)
+	}
+	if len(ids) != 2 { // apple and fcmV1.
+		t.Fatalf("expected 2 notification IDs, got: %v", ids)
+	}
+
+	telemetry, err := client.GetNotificationTelemetry(ctx, ids[1])
+	if err != nil {
+		t.Fatalf("unexpected telemetry error: %v", err)
+	}
+	if telemetry.NotificationID != ids[1] || telemetry.State != azurepush.NotificationStateCompleted {
+		t.Errorf("unexpected telemetry: %+v", telemetry)
+	}
+	if telemetry.OutcomeCounts["fcmV1.Success"] != 1 || len(telemetry.TargetPlatforms) != 1 || telemetry.TargetPlatforms[0] != "fcmV1" {
+		t.Errorf("expected one fcmV1 success, got: %+v", telemetry)
+	}
+
+	if _, err = client.GetNotificationTelemetry(ctx, "404"); err == nil {
+		t.Error("expected an error for an unknown notification")
+	}
+}
+
+func TestClient_GetNotificationTelemetry_Tier(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	srv.SetError(azurepushtest.OpTelemetry, http.StatusForbidden, "Per message telemetry is not available on the Free tier")
+
+	_, err := client.GetNotificationTelemetry(context.Background(), "1")
+	if tierErr, ok := errors.AsType[*azurepush.TierError](err); !ok || tierErr.Feature != azurepush.FeatureTelemetry {
+		t.Fatalf("expected a telemetry tier error, got: %v", err)
+	}
+
+	client.SetTier(azurepush.TierBasic)
+	_, err = client.GetNotificationTelemetry(context.Background(), "1")
+	if tierErr, ok := errors.AsType[*azurepush.TierError](err); !ok || tierErr.Current != azurepush.TierBasic {
+		t.Fatalf("expected a tier error before the request, got: %v", err)
+	}
+}
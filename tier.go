@@ -0,0 +1,163 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Tier is the pricing tier of a Notification Hubs namespace.
+// It determines the available features and the device and push quotas.
+//
+// See https://azure.microsoft.com/pricing/details/notification-hubs.
+type Tier string
+
+const (
+	// TierUnknown is reported when the tier was not detected yet.
+	TierUnknown Tier = ""
+	// TierFree is the free tier.
+	TierFree Tier = "Free"
+	// TierBasic is the basic tier.
+	TierBasic Tier = "Basic"
+	// TierStandard is the standard tier, all features are available.
+	TierStandard Tier = "Standard"
+)
+
+// rank returns the order of the tier, higher is better.
+func (t Tier) rank() int {
+	switch t {
+	case TierFree:
+		return 1
+	case TierBasic:
+		return 2
+	case TierStandard:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// TierLimits holds the quotas of a tier.
+type TierLimits struct {
+	// ActiveDevices is the maximum number of active devices.
+	ActiveDevices int
+	// Pushes is the included number of pushes per month.
+	Pushes int
+}
+
+// Limits returns the quotas of the tier.
+// Zero values are returned for TierUnknown.
+func (t Tier) Limits() TierLimits {
+	switch t {
+	case TierFree:
+		return TierLimits{ActiveDevices: 500, Pushes: 1_000_000}
+	case TierBasic:
+		return TierLimits{ActiveDevices: 200_000, Pushes: 10_000_000}
+	case TierStandard:
+		return TierLimits{ActiveDevices: 10_000_000, Pushes: 10_000_000}
+	default:
+		return TierLimits{}
+	}
+}
+
+// Features that require a specific tier.
+const (
+	FeatureScheduledSend = "scheduled sends"
+	FeatureTelemetry     = "per message telemetry"
+	FeatureBulkJobs      = "bulk import and export jobs"
+)
+
+// TierError is returned when an operation is not available on the hub's tier.
+type TierError struct {
+	Feature  string
+	Required Tier
+	// Current is the detected tier of the hub, it may be TierUnknown.
+	Current Tier
+	// Detail holds Azure's response (if any).
+	Detail string
+}
+
+// Error implements the error interface.
+func (e *TierError) Error() string {
+	msg := fmt.Sprintf("%s require %s tier", e.Feature, e.Required)
+	if e.Current != TierUnknown {
+		msg += fmt.Sprintf(" (current: %s)", e.Current)
+	}
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+
+	return msg
+}
+
+// ErrQuotaExceeded is returned when the hub reports that a tier quota (devices or pushes) has been exceeded.
+var ErrQuotaExceeded = errors.New("tier quota exceeded")
+
+// Tier returns the pricing tier of the configured namespace.
+func (mc *ManagementClient) Tier(ctx context.Context) (Tier, error) {
+	ns, err := mc.GetNamespace(ctx)
+	if err != nil {
+		return TierUnknown, err
+	}
+
+	return Tier(ns.Sku.Name), nil
+}
+
+// Tier returns the cached tier of the hub.
+// It is set by the Configuration.Tier field, DetectTier or SetTier.
+func (c *Client) Tier() Tier {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.tier
+}
+
+// SetTier caches the tier of the hub, e.g. when it is known by the deployment.
+func (c *Client) SetTier(tier Tier) {
+	c.mu.Lock()
+	c.tier = tier
+	c.mu.Unlock()
+}
+
+// DetectTier fetches the tier through the management client and caches it on the client.
+func (c *Client) DetectTier(ctx context.Context, mc *ManagementClient) (Tier, error) {
+	tier, err := mc.Tier(ctx)
+	if err != nil {
+		return TierUnknown, fmt.Errorf("failed to detect tier: %w", err)
+	}
+
+	c.SetTier(tier)
+	return tier, nil
+}
+
+// RequireTier returns a *TierError if the cached tier is known and lower than the required one.
+// An unknown tier is not reported as an error, the hub decides.
+func (c *Client) RequireTier(feature string, required Tier) error {
+	current := c.Tier()
+	if current == TierUnknown || current.rank() >= required.rank() {
+		return nil
+	}
+
+	return &TierError{Feature: feature, Required: required, Current: current}
+}
+
+// tierErrorFromResponse converts Azure's tier and quota related 403 responses to actionable errors.
+// The feature and required tier are optional, they are used to report unsupported features.
+// It returns nil if the response is not tier related.
+func tierErrorFromResponse(feature string, required, current Tier, statusCode int, body string) error {
+	if statusCode != http.StatusForbidden {
+		return nil
+	}
+
+	lower := strings.ToLower(body)
+	switch {
+	case strings.Contains(lower, "quota"):
+		return fmt.Errorf("%w: %s", ErrQuotaExceeded, body)
+	case feature != "" && strings.Contains(lower, "tier"):
+		return &TierError{Feature: feature, Required: required, Current: current, Detail: body}
+	default:
+		return nil
+	}
+}
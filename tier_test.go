@@ -0,0 +1,115 @@
+package azurepush_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
+)
+
+func TestClient_DetectTier(t *testing.T) {
+	mc := azurepush.NewManagementClient(testManagementConfiguration, testAccessTokens())
+	mc.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusOK, `{"name":"namespace","sku":{"name":"Free"}}`)
+	})
+
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+
+	tier, err := client.DetectTier(context.Background(), mc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tier != azurepush.TierFree || client.Tier() != azurepush.TierFree {
+		t.Fatalf("expected Free tier to be cached, got: %q", client.Tier())
+	}
+
+	err = client.RequireTier(azurepush.FeatureScheduledSend, azurepush.TierStandard)
+	tierErr, ok := errors.AsType[*azurepush.TierError](err)
+	if !ok {
+		t.Fatalf("expected a tier error, got: %v", err)
+	}
+	if expected := "scheduled sends require Standard tier (current: Free)"; tierErr.Error() != expected {
+		t.Errorf("expected error %q, got: %q", expected, tierErr.Error())
+	}
+
+	if err = client.RequireTier(azurepush.FeatureScheduledSend, azurepush.TierFree); err != nil {
+		t.Errorf("expected no error for a satisfied tier, got: %v", err)
+	}
+}
+
+func TestClient_RegisterDevice_QuotaExceeded(t *testing.T) {
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		return jsonResponse(http.StatusForbidden, "<Error><Code>403</Code><Detail>The active devices quota of the namespace has been exceeded.</Detail></Error>")
+	})
+
+	_, err := client.RegisterDevice(context.Background(), azurepush.Installation{
+		Platform:    azurepush.InstallationApple,
+		PushChannel: "token",
+	})
+	if !errors.Is(err, azurepush.ErrQuotaExceeded) {
+		t.Fatalf("expected quota exceeded error, got: %v", err)
+	}
+
+	if limits := azurepush.TierFree.Limits(); limits.ActiveDevices != 500 {
+		t.Errorf("expected 500 active devices for the Free tier, got: %d", limits.ActiveDevices)
+	}
+}
+
+func TestClient_SendNotification_ScheduleTime(t *testing.T) {
+	srv := azurepushtest.NewServer()
+	defer srv.Close()
+
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	scheduleTime := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := azurepush.SendOptions{ScheduleTime: scheduleTime}
+	if err := client.SendNotificationWithOptions(ctx, azurepush.Notification{Title: "Hi"}, opts, "user:42"); err != nil {
+		t.Fatalf("unexpected send error: %v", err)
+	}
+
+	sent := srv.Sent("user:42")
+	if len(sent) != 2 { // apple and fcmV1.
+		t.Fatalf("expected 2 scheduled notifications, got: %d", len(sent))
+	}
+	for _, n := range sent {
+		if got := n.Header.Get("ServiceBusNotification-ScheduleTime"); got != "2030-01-02T03:04:05" {
+			t.Errorf("unexpected schedule time header: %q", got)
+		}
+	}
+
+	// The hub rejects scheduled sends on the Free and Basic tiers.
+	srv.SetError(azurepushtest.OpSend, http.StatusForbidden, "Scheduled notifications are not available on the Free tier")
+	err := client.SendNotificationWithOptions(ctx, azurepush.Notification{Title: "Hi"}, opts, "user:42")
+	if tierErr, ok := errors.AsType[*azurepush.TierError](err); !ok || tierErr.Feature != azurepush.FeatureScheduledSend || tierErr.Required != azurepush.TierStandard {
+		t.Fatalf("expected a scheduled sends tier error, got: %v", err)
+	}
+
+	// Immediate sends are available on every tier.
+	err = client.SendNotification(ctx, azurepush.Notification{Title: "Hi"}, "user:42")
+	if _, ok := errors.AsType[*azurepush.TierError](err); ok {
+		t.Fatalf("expected no tier error for an immediate send, got: %v", err)
+	}
+
+	// A known Free tier is reported before the request.
+	srv.ClearErrors()
+	client.SetTier(azurepush.TierFree)
+	err = client.SendNotificationWithOptions(ctx, azurepush.Notification{Title: "Hi"}, opts, "user:42")
+	if tierErr, ok := errors.AsType[*azurepush.TierError](err); !ok || tierErr.Current != azurepush.TierFree {
+		t.Fatalf("expected a Free tier error, got: %v", err)
+	}
+	if len(srv.Sent("user:42")) != 2 {
+		t.Errorf("expected no request on the Free tier, got: %d notifications", len(srv.Sent("user:42")))
+	}
+}
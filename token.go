@@ -5,50 +5,436 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// TokenProvider provides the SAS tokens which sign the Client's requests.
+// It is implemented by *TokenManager, custom implementations can be set
+// to the Client.TokenProvider field (e.g. in tests, see the azurepushtest package).
+type TokenProvider interface {
+	GetToken() (string, error)
+}
+
+// TokenProviderFunc is a function adapter for the TokenProvider interface.
+type TokenProviderFunc func() (string, error)
+
+// GetToken implements the TokenProvider interface.
+func (f TokenProviderFunc) GetToken() (string, error) {
+	return f()
+}
+
+var _ TokenProvider = (*TokenManager)(nil)
+
+// Clock reports the current time.
+// The default clock is the system's one, tests can replace it (see TokenManager.SetClock)
+// to trigger token refresh and expiry deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the default Clock, it reports time.Now.
+var SystemClock Clock = systemClock{}
+
+// TimerClock is a Clock which also provides the timers of the background runners (e.g. QuietHoursClient.Run),
+// so a fake clock controls when they fire. The runners of a Clock without timers use the system's timers.
+type TimerClock interface {
+	Clock
+	// After returns a channel which receives the clock's time once d has elapsed on the clock.
+	After(d time.Duration) <-chan time.Time
+}
+
+// newClockTimer returns a channel which receives the time once d has elapsed on the clock,
+// and a function which releases its timer.
+func newClockTimer(clock Clock, d time.Duration) (<-chan time.Time, func()) {
+	if tc, ok := clock.(TimerClock); ok {
+		return tc.After(d), func() {}
+	}
+
+	timer := time.NewTimer(d)
+	return timer.C, func() { timer.Stop() }
+}
+
 // TokenManager manages the lifecycle of SAS tokens.
+//
+// Tokens are cached per resource URI, each with its own expiry.
+// Reads of a cached token are lock-free, when a token is about to expire
+// a single goroutine regenerates it while the others wait for the new one.
+//
+// The cached tokens belong to a configuration generation, see Reconfigure.
 type TokenManager struct {
-	cfg       Configuration
-	token     string
-	expiresAt time.Time
-	mutex     sync.Mutex
+	cfg   atomic.Pointer[tokenConfig]
+	clock atomic.Pointer[Clock]
+
+	tokens sync.Map   // resource URI -> *cachedToken.
+	mutex  sync.Mutex // serializes refreshes, protects stats.
+	stats  TokenStats
+
+	hooksMu        sync.RWMutex
+	onRotate       []func(old, new string)
+	onRefreshError []func(resourceURI string, err error)
+}
+
+// TokenStats holds the counters of a TokenManager, see TokenManager.Stats.
+type TokenStats struct {
+	// Refreshes is the number of generated tokens, of all resources.
+	Refreshes uint64
+	// RefreshFailures is the number of failed token generations, e.g. of a misconfigured key.
+	RefreshFailures uint64
+	// Invalidations is the number of Invalidate calls.
+	Invalidations uint64
+	// LastRefresh is the time of the last generated token.
+	LastRefresh time.Time
+	// LastError is the error of the last failed token generation, if any.
+	LastError error
+	// ExpiresAt is the expiration time of the hub's token, see TokenManager.ExpiresAt.
+	ExpiresAt time.Time
+	// CachedTokens is the number of cached tokens, of all resources (see GetTokenFor).
+	CachedTokens int
+}
+
+// tokenConfig is an immutable configuration generation of a TokenManager.
+type tokenConfig struct {
+	Configuration
+	generation uint64
+}
+
+// cachedToken is an immutable generated SAS token.
+type cachedToken struct {
+	value      string
+	expiresAt  time.Time
+	generation uint64
+}
+
+// valid reports whether the token can still be used: it was generated by the current configuration generation
+// and it is not about to expire, tokens are refreshed 5 minutes before they expire.
+func (t *cachedToken) valid(now time.Time, generation uint64) bool {
+	return t != nil && t.generation == generation && !now.After(t.expiresAt.Add(-5*time.Minute))
 }
 
 // NewTokenManager creates a new TokenManager.
 func NewTokenManager(cfg Configuration) *TokenManager {
-	return &TokenManager{cfg: cfg}
+	tm := new(TokenManager)
+	tm.cfg.Store(&tokenConfig{Configuration: cfg, generation: 1})
+	tm.SetClock(SystemClock)
+	return tm
 }
 
-// GetToken returns a valid SAS token, refreshing it if necessary.
-func (tm *TokenManager) GetToken() (string, error) {
+// SetClock replaces the clock used to generate and expire tokens.
+func (tm *TokenManager) SetClock(clock Clock) {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	tm.clock.Store(&clock)
+}
+
+// Invalidate expires all the cached tokens, so the next requests are signed with newly generated ones,
+// e.g. after a 401 Unauthorized response or when the keys were rotated externally.
+func (tm *TokenManager) Invalidate() {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
-	if tm.token == "" || time.Now().After(tm.expiresAt.Add(-5*time.Minute)) {
-		resourceURI := "https://" + tm.cfg.Namespace + ".servicebus.windows.net/" + tm.cfg.HubName
-		token, err := GenerateSASToken(resourceURI, tm.cfg.KeyName, tm.cfg.KeyValue, tm.cfg.TokenValidity)
-		if err != nil {
-			return "", err
+	// The expired tokens are kept, so the rotation hooks receive them as the old ones.
+	for resourceURI, token := range tm.tokens.Range {
+		tm.tokens.Store(resourceURI, &cachedToken{value: token.(*cachedToken).value, generation: token.(*cachedToken).generation})
+	}
+	tm.stats.Invalidations++
+}
+
+// ExpiresAt returns the expiration time of the cached token of the hub (see ResourceURI),
+// or the zero time if there is none yet, it was invalidated or the configuration was replaced.
+// Dashboards can alert when it gets close, before the requests start failing.
+func (tm *TokenManager) ExpiresAt() time.Time {
+	if token := tm.load(tm.ResourceURI()); token != nil && token.generation == tm.Generation() {
+		return token.expiresAt
+	}
+
+	return time.Time{}
+}
+
+// Stats returns a snapshot of the token counters.
+func (tm *TokenManager) Stats() TokenStats {
+	tm.mutex.Lock()
+	stats := tm.stats
+	for range tm.tokens.Range {
+		stats.CachedTokens++
+	}
+	tm.mutex.Unlock()
+
+	stats.ExpiresAt = tm.ExpiresAt()
+	return stats
+}
+
+// OnRefreshError registers a hook called when a token cannot be generated,
+// e.g. to alert on an authentication misconfiguration before it turns into a send outage.
+// Hooks are called synchronously, they should not block.
+func (tm *TokenManager) OnRefreshError(fn func(resourceURI string, err error)) {
+	if fn == nil {
+		return
+	}
+
+	tm.hooksMu.Lock()
+	tm.onRefreshError = append(tm.onRefreshError, fn)
+	tm.hooksMu.Unlock()
+}
+
+// OnRotate registers a hook called after a token is generated, with the token it replaces
+// (empty for the first token of a resource) and the new one, e.g. to audit the rotations.
+// Hooks are called synchronously by the goroutine which generated the token, they should not block.
+func (tm *TokenManager) OnRotate(fn func(old, new string)) {
+	if fn == nil {
+		return
+	}
+
+	tm.hooksMu.Lock()
+	tm.onRotate = append(tm.onRotate, fn)
+	tm.hooksMu.Unlock()
+}
+
+// Reconfigure replaces the configuration (e.g. the keys) and starts a new configuration generation:
+// the cached tokens of the previous generations are stale, so the next requests are signed with the new credentials.
+// The stale tokens are reported as the old ones to the rotation hooks, see OnRotate.
+//
+// It is safe to call Reconfigure while other goroutines are requesting tokens,
+// a token generated concurrently with the previous configuration is never served after Reconfigure returns.
+// Client.Reload calls it.
+func (tm *TokenManager) Reconfigure(cfg Configuration) {
+	tm.mutex.Lock()
+	prev := tm.cfg.Load()
+	tm.cfg.Store(&tokenConfig{Configuration: cfg, generation: prev.generation + 1})
+	tm.mutex.Unlock()
+}
+
+// Generation returns the configuration generation of the TokenManager,
+// it starts at 1 and it is increased by each Reconfigure.
+func (tm *TokenManager) Generation() uint64 {
+	return tm.cfg.Load().generation
+}
+
+// config returns the current configuration generation.
+func (tm *TokenManager) config() *tokenConfig {
+	return tm.cfg.Load()
+}
+
+func (tm *TokenManager) now() time.Time {
+	return (*tm.clock.Load()).Now()
+}
+
+// GetToken returns a valid SAS token for the configured hub, refreshing it if necessary.
+func (tm *TokenManager) GetToken() (string, error) {
+	return tm.GetTokenFor(tm.ResourceURI())
+}
+
+// GetTokenWithValidity returns a SAS token for the configured hub which expires after the given validity,
+// instead of the configured TokenValidity: e.g. a long-lived token for management-style calls
+// or a very short-lived one for a semi-trusted component or a log line.
+//
+// The configured validity returns the cached token of GetToken. Other validities are generated on each call,
+// so they always have their full validity; they are not cached, counted as refreshes or reported as rotations.
+func (tm *TokenManager) GetTokenWithValidity(validity time.Duration) (string, error) {
+	if validity <= 0 {
+		return "", fmt.Errorf("invalid SAS token validity: %s", validity)
+	}
+
+	cfg := tm.config()
+	if validity == cfg.TokenValidity {
+		return tm.GetToken()
+	}
+
+	return generateSASToken(tm.now(), cfg.resourceURI(), cfg.KeyName, cfg.signingKey(), validity)
+}
+
+// ResourceURI returns the resource URI of the configured hub, signed by GetToken.
+func (tm *TokenManager) ResourceURI() string {
+	return tm.config().resourceURI()
+}
+
+func (cfg *tokenConfig) resourceURI() string {
+	return "https://" + cfg.Namespace + ".servicebus.windows.net/" + cfg.HubName
+}
+
+// GetTokenFor returns a valid SAS token for the given resource URI, refreshing it if necessary,
+// e.g. for another hub of the same namespace or a narrower path like an installation.
+// Tokens of different resources are cached independently, the expired ones are dropped
+// when a token is generated, so the cache holds at most the resources used within a TokenValidity.
+func (tm *TokenManager) GetTokenFor(resourceURI string) (string, error) {
+	return tm.getTokenValidFor(resourceURI, 0)
+}
+
+// getTokenValidFor returns a token for the resource which is still valid after the given duration,
+// plus the standard 5 minutes refresh margin.
+func (tm *TokenManager) getTokenValidFor(resourceURI string, d time.Duration) (string, error) {
+	now := tm.now()
+	if token := tm.load(resourceURI); token.valid(now.Add(d), tm.Generation()) {
+		return token.value, nil
+	}
+
+	tm.mutex.Lock()
+
+	// Another goroutine may have refreshed the token while we were waiting.
+	// The configuration cannot change while the mutex is held.
+	cfg := tm.config()
+	old := tm.load(resourceURI)
+	if old.valid(now.Add(d), cfg.generation) {
+		tm.mutex.Unlock()
+		return old.value, nil
+	}
+
+	value, err := generateSASToken(now, resourceURI, cfg.KeyName, cfg.signingKey(), cfg.TokenValidity)
+	if err != nil {
+		tm.stats.RefreshFailures++
+		tm.stats.LastError = err
+		tm.mutex.Unlock()
+
+		tm.refreshFailed(resourceURI, err)
+		return "", err
+	}
+
+	tm.tokens.Store(resourceURI, &cachedToken{value: value, expiresAt: now.Add(cfg.TokenValidity), generation: cfg.generation})
+	tm.dropExpired(now, cfg.resourceURI())
+	tm.stats.Refreshes++
+	tm.stats.LastRefresh = now
+	tm.mutex.Unlock()
+
+	var oldValue string
+	if old != nil {
+		oldValue = old.value
+	}
+	tm.rotated(oldValue, value)
+
+	return value, nil
+}
+
+// refreshFailed calls the refresh error hooks.
+func (tm *TokenManager) refreshFailed(resourceURI string, err error) {
+	tm.hooksMu.RLock()
+	hooks := tm.onRefreshError
+	tm.hooksMu.RUnlock()
+
+	for _, fn := range hooks {
+		fn(resourceURI, err)
+	}
+}
+
+// rotated calls the rotation hooks.
+func (tm *TokenManager) rotated(old, new string) {
+	tm.hooksMu.RLock()
+	hooks := tm.onRotate
+	tm.hooksMu.RUnlock()
+
+	for _, fn := range hooks {
+		fn(old, new)
+	}
+}
+
+// dropExpired removes the expired tokens of the resources other than the hub, the caller must hold the mutex.
+// The hub's token is kept, so the rotation hooks receive it as the old one.
+func (tm *TokenManager) dropExpired(now time.Time, hubResourceURI string) {
+	for resourceURI, token := range tm.tokens.Range {
+		if resourceURI != hubResourceURI && now.After(token.(*cachedToken).expiresAt) {
+			tm.tokens.Delete(resourceURI)
 		}
-		tm.token = token
-		tm.expiresAt = time.Now().Add(tm.cfg.TokenValidity)
 	}
-	return tm.token, nil
+}
+
+func (tm *TokenManager) load(resourceURI string) *cachedToken {
+	if token, ok := tm.tokens.Load(resourceURI); ok {
+		return token.(*cachedToken)
+	}
+
+	return nil
 }
 
 // GenerateSASToken creates a Shared Access Signature (SAS) token for Azure Notification Hub.
 //
 // Ported from: https://learn.microsoft.com/en-us/rest/api/eventhub/generate-sas-token#nodejs.
 func GenerateSASToken(resourceUri, keyName, key string, duration time.Duration) (string, error) {
+	return generateSASToken(time.Now(), resourceUri, keyName, key, duration)
+}
+
+// serviceBusHostSuffixes are the host suffixes of the Service Bus namespaces of the Azure clouds.
+var serviceBusHostSuffixes = []string{
+	".servicebus.windows.net",
+	".servicebus.chinacloudapi.cn",
+	".servicebus.usgovcloudapi.net",
+	".servicebus.cloudapi.de",
+}
+
+// GenerateSASTokenForResource is like GenerateSASToken but it validates and normalizes the resource URI first,
+// see NormalizeResourceURI, so the signature matches the one computed by the hub. The ttl must be positive.
+//
+// The signed resource is a prefix of the request URLs the token authorizes:
+//
+//   - the hub, https://{namespace}.servicebus.windows.net/{hub}, authorizes all the hub's endpoints,
+//     e.g. /{hub}/messages (sends), /{hub}/installations/{id} and /{hub}/registrations;
+//   - /{hub}/messages authorizes the sends only;
+//   - /{hub}/installations/{id} authorizes the operations of a single installation, e.g. for a device.
+//
+// The query string (e.g. "?api-version=2020-06") is never part of the signed resource.
+//
+// Example usage:
+//
+//	token, err := azurepush.GenerateSASTokenForResource(
+//		"https://my-namespace.servicebus.windows.net/my-hub/installations/device-1",
+//		"DefaultFullSharedAccessSignature", key, time.Hour)
+func GenerateSASTokenForResource(resourceURI, keyName, key string, ttl time.Duration) (string, error) {
+	normalized, err := NormalizeResourceURI(resourceURI)
+	if err != nil {
+		return "", err
+	}
+
+	if ttl <= 0 {
+		return "", fmt.Errorf("invalid SAS token ttl: %s", ttl)
+	}
+
+	return generateSASToken(time.Now(), normalized, keyName, key, ttl)
+}
+
+// NormalizeResourceURI validates a resource URI to sign and returns its normalized form:
+// the "https" or "sb" scheme, a Service Bus namespace host (e.g. "my-namespace.servicebus.windows.net"),
+// no query string or fragment, lowercase and without a trailing slash.
+func NormalizeResourceURI(resourceURI string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(resourceURI))
+	if err != nil {
+		return "", fmt.Errorf("invalid resource URI: %w", err)
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "https", "sb":
+	default:
+		return "", fmt.Errorf("invalid resource URI: %q: scheme must be https or sb", resourceURI)
+	}
+
+	host := strings.ToLower(u.Host)
+	if !slices.ContainsFunc(serviceBusHostSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}) {
+		return "", fmt.Errorf("invalid resource URI: %q: host must be a Service Bus namespace, e.g. my-namespace.servicebus.windows.net", resourceURI)
+	}
+
+	if u.RawQuery != "" || u.ForceQuery || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("invalid resource URI: %q: user info, query string and fragment are not allowed", resourceURI)
+	}
+
+	return strings.ToLower(u.Scheme + "://" + host + strings.TrimRight(u.EscapedPath(), "/")), nil
+}
+
+func generateSASToken(now time.Time, resourceUri, keyName, key string, duration time.Duration) (string, error) {
 	if resourceUri == "" || keyName == "" || key == "" {
 		return "", fmt.Errorf("missing required parameter")
 	}
@@ -58,7 +444,7 @@ func GenerateSASToken(resourceUri, keyName, key string, duration time.Duration)
 	// TTL: 1 week from now
 	// ttl := time.Now().Unix() + 60*60*24*7 // seconds
 
-	ttl := time.Now().Add(duration).Unix()
+	ttl := now.Add(duration).Unix()
 	// Signature: encoded URI + "\n" + expiry timestamp
 	signingString := fmt.Sprintf("%s\n%d", encodedURI, ttl)
 
@@ -78,40 +464,124 @@ func GenerateSASToken(resourceUri, keyName, key string, duration time.Duration)
 	return token, nil
 }
 
-// ValidateSASToken checks if a SAS token is valid.
-// Expecting 404 or 200 if token is valid
-func ValidateSASToken(ctx context.Context, httpClient *http.Client, namespace, hubName, token string) error {
-	// Dummy installation ID — Azure will return 404 if not found, which is OK
-	dummyInstallationID := uuid.NewString()
+// ValidateSASTokenOptions are the options of ValidateSASToken.
+type ValidateSASTokenOptions struct {
+	// Token is the SAS token to validate (the value of the Authorization header), required.
+	Token string
+	// Namespace and HubName locate the hub, they are required unless an Endpoint is set.
+	Namespace string
+	HubName   string
+	// Endpoint is the base URL of the hub, e.g. https://{namespace}.servicebus.windows.net/{hub}
+	// of another Azure cloud, or of an emulator or a proxy. It overrides the Namespace and the HubName.
+	Endpoint string
+	// HTTPClient is the client of the validation request.
+	//
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
 
-	url := fmt.Sprintf(
-		"https://%s.servicebus.windows.net/%s/installations/%s?api-version=2020-06",
-		namespace,
-		hubName,
-		dummyInstallationID,
-	)
+// SASTokenValidation is the result of ValidateSASToken.
+type SASTokenValidation struct {
+	// Authorized reports whether the hub accepted the token.
+	Authorized bool
+	// StatusCode is the status code of the validation request:
+	// 200 or 404 for an authorized token, 401 or 403 for an unauthorized one.
+	StatusCode int
+	// Reason is the reason the hub reported for an unauthorized token, e.g. "ExpiredToken: ...".
+	Reason string
+}
+
+// Err returns nil for an authorized token, otherwise an *UnauthorizedError.
+func (v *SASTokenValidation) Err() error {
+	if v.Authorized {
+		return nil
+	}
+
+	return &UnauthorizedError{StatusCode: v.StatusCode, Reason: v.Reason}
+}
+
+// UnauthorizedError is returned by Client.ValidateToken when the hub rejects the SAS token,
+// e.g. it is expired, signed by a wrong key or its policy lacks the required rights.
+type UnauthorizedError struct {
+	StatusCode int
+	Reason     string
+}
+
+// Error implements the error interface.
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized: SAS token is invalid or expired: %s", e.Reason)
+}
+
+// ValidateSASToken checks whether the hub accepts a SAS token, with a GET request of a random installation:
+// the hub responds with 404 (or 200) for an authorized token, 401 or 403 otherwise.
+// An unauthorized token is not an error, see the SASTokenValidation; an error is returned
+// when the request fails or the hub responds with an unexpected status code.
+//
+// Client.ValidateToken validates the client's token.
+//
+// Example usage:
+//
+//	result, err := azurepush.ValidateSASToken(ctx, azurepush.ValidateSASTokenOptions{
+//		Token:     token,
+//		Namespace: "my-namespace",
+//		HubName:   "my-hub",
+//	})
+//	if err == nil && !result.Authorized {
+//		log.Printf("rejected SAS token: %s", result.Reason)
+//	}
+func ValidateSASToken(ctx context.Context, opts ValidateSASTokenOptions) (*SASTokenValidation, error) {
+	if opts.Token == "" {
+		return nil, fmt.Errorf("missing SAS token")
+	}
+
+	endpoint := strings.TrimSuffix(opts.Endpoint, "/")
+	if endpoint == "" {
+		if opts.Namespace == "" || opts.HubName == "" {
+			return nil, fmt.Errorf("missing namespace or hub name")
+		}
+		endpoint = fmt.Sprintf("https://%s.servicebus.windows.net/%s", opts.Namespace, opts.HubName)
+	}
+
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// Dummy installation ID — Azure will return 404 if not found, which is OK
+	url := fmt.Sprintf("%s/installations/%s?api-version=2020-06", endpoint, uuid.NewString())
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create validation request: %w", err)
+		return nil, fmt.Errorf("failed to create validation request: %w", err)
 	}
-	req.Header.Set("Authorization", token)
+	req.Header.Set("Authorization", opts.Token)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send validation request: %w", err)
+		return nil, fmt.Errorf("failed to send validation request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusNotFound {
-		return nil
-	}
-
 	b, _ := io.ReadAll(resp.Body)
 	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return fmt.Errorf("unauthorized: SAS token is invalid or expired: %s", string(b))
+	case http.StatusOK, http.StatusNotFound:
+		return &SASTokenValidation{Authorized: true, StatusCode: resp.StatusCode}, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &SASTokenValidation{StatusCode: resp.StatusCode, Reason: unauthorizedReason(b)}, nil
 	default:
-		return fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, string(b))
+		return nil, fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, string(b))
 	}
 }
+
+// unauthorizedReason returns the detail of an error response of the hub,
+// e.g. <Error><Code>401</Code><Detail>ExpiredToken: ...</Detail></Error>, or the whole body.
+func unauthorizedReason(body []byte) string {
+	var hubErr struct {
+		Detail string `xml:"Detail"`
+	}
+	if err := xml.Unmarshal(body, &hubErr); err == nil && hubErr.Detail != "" {
+		return hubErr.Detail
+	}
+
+	return strings.TrimSpace(string(body))
+}
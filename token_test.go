@@ -1,11 +1,19 @@
 package azurepush_test
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/kataras/azurepush"
+	"github.com/kataras/azurepush/azurepushtest"
 )
 
 func TestGenerateSASToken(t *testing.T) {
@@ -32,6 +40,51 @@ func TestGenerateSASToken(t *testing.T) {
 	// }
 }
 
+func TestGenerateSASTokenForResource(t *testing.T) {
+	tests := []struct {
+		uri      string
+		expected string // the normalized URI, empty if invalid.
+	}{
+		{"https://MyNamespace.servicebus.windows.net/MyHub/", "https://mynamespace.servicebus.windows.net/myhub"},
+		{"sb://mynamespace.servicebus.windows.net/myhub/installations/device-1", "sb://mynamespace.servicebus.windows.net/myhub/installations/device-1"},
+		{"https://mynamespace.servicebus.chinacloudapi.cn/myhub", "https://mynamespace.servicebus.chinacloudapi.cn/myhub"},
+		{"https://mynamespace.servicebus.windows.net", "https://mynamespace.servicebus.windows.net"},
+		{"http://mynamespace.servicebus.windows.net/myhub", ""},
+		{"https://example.com/myhub", ""},
+		{"https://.servicebus.windows.net/myhub", ""},
+		{"https://mynamespace.servicebus.windows.net/myhub/messages?api-version=2020-06", ""},
+		{"mynamespace.servicebus.windows.net/myhub", ""},
+	}
+
+	for _, tt := range tests {
+		normalized, err := azurepush.NormalizeResourceURI(tt.uri)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got: %s", tt.uri, normalized)
+			}
+			continue
+		}
+
+		if err != nil || normalized != tt.expected {
+			t.Errorf("%s: expected: %s, got: %s (%v)", tt.uri, tt.expected, normalized, err)
+		}
+	}
+
+	key := "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE="
+	token, err := azurepush.GenerateSASTokenForResource("https://MyNamespace.servicebus.windows.net/MyHub/", "keyName", key, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(token, "sr=https%3A%2F%2Fmynamespace.servicebus.windows.net%2Fmyhub&") {
+		t.Errorf("expected the normalized resource, got: %s", token)
+	}
+
+	if _, err = azurepush.GenerateSASTokenForResource("https://mynamespace.servicebus.windows.net/myhub", "keyName", key, 0); err == nil {
+		t.Error("expected an invalid ttl error")
+	}
+}
+
 func TestTokenManager_AutoRefresh(t *testing.T) {
 	cfg := azurepush.Configuration{
 		HubName:       "myhub",
@@ -41,13 +94,15 @@ func TestTokenManager_AutoRefresh(t *testing.T) {
 		TokenValidity: time.Second * 1,
 	}
 	tm := azurepush.NewTokenManager(cfg)
+	clock := azurepushtest.NewFakeClock(time.Now())
+	tm.SetClock(clock)
 
 	token1, err := tm.GetToken()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	time.Sleep(2 * time.Second)
+	clock.Advance(2 * time.Second)
 	token2, err := tm.GetToken()
 	if err != nil {
 		t.Fatalf("unexpected error after refresh: %v", err)
@@ -57,3 +112,362 @@ func TestTokenManager_AutoRefresh(t *testing.T) {
 		t.Error("expected different tokens after expiration, got same")
 	}
 }
+
+func TestTokenManager_ConcurrentRefresh(t *testing.T) {
+	tm := azurepush.NewTokenManager(azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	})
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		tokens = make(map[string]struct{})
+	)
+	for range 64 {
+		wg.Go(func() {
+			token, err := tm.GetToken()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			tokens[token] = struct{}{}
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if len(tokens) != 1 {
+		t.Errorf("expected a single token to be generated and shared, got: %d", len(tokens))
+	}
+}
+
+func BenchmarkTokenManager_GetToken(b *testing.B) {
+	tm := azurepush.NewTokenManager(azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	})
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := tm.GetToken(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestTokenManager_GetTokenFor(t *testing.T) {
+	tm := azurepush.NewTokenManager(azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	})
+	clock := azurepushtest.NewFakeClock(time.Now())
+	tm.SetClock(clock)
+
+	hubToken, err := tm.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherHubToken, err := tm.GetTokenFor("https://mynamespace.servicebus.windows.net/otherhub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherHubToken == hubToken || !strings.Contains(otherHubToken, "otherhub") {
+		t.Errorf("expected a token signed for the other hub, got: %s", otherHubToken)
+	}
+
+	clock.Advance(time.Minute)
+	if token, _ := tm.GetTokenFor(tm.ResourceURI()); token != hubToken {
+		t.Error("expected the hub token to be cached independently")
+	}
+
+	// Per installation tokens must not grow the cache forever.
+	for i := range 100 {
+		if _, err = tm.GetTokenFor("https://mynamespace.servicebus.windows.net/myhub/installations/" + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cached := tm.Stats().CachedTokens; cached != 102 {
+		t.Fatalf("expected 102 cached tokens, got: %d", cached)
+	}
+
+	clock.Advance(2 * time.Hour)
+	if _, err = tm.GetTokenFor("https://mynamespace.servicebus.windows.net/myhub/installations/new"); err != nil {
+		t.Fatal(err)
+	}
+	if cached := tm.Stats().CachedTokens; cached != 2 { // the hub's and the new one.
+		t.Errorf("expected the expired tokens to be dropped, got: %d cached tokens", cached)
+	}
+}
+
+func TestTokenManager_InvalidateAndOnRotate(t *testing.T) {
+	cfg := azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	}
+	client := azurepush.NewClient(cfg)
+	clock := azurepushtest.NewFakeClock(time.Now())
+	client.SetClock(clock)
+
+	var rotations [][2]string
+	client.TokenManager.OnRotate(func(old, new string) {
+		rotations = append(rotations, [2]string{old, new})
+	})
+
+	first, err := client.TokenManager.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.TokenManager.GetToken(); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Second)
+	client.TokenManager.Invalidate()
+
+	second, err := client.TokenManager.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == first {
+		t.Fatal("expected a new token after Invalidate")
+	}
+
+	// Rotated keys: the hooks are kept and the rotation to the new credentials is reported.
+	cfg.KeyValue = "YmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmI="
+	if err = client.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	third, err := client.TokenManager.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]string{{"", first}, {first, second}, {second, third}}
+	if !slices.Equal(rotations, expected) {
+		t.Errorf("unexpected rotations:\nexpected: %v\ngot:      %v", expected, rotations)
+	}
+}
+
+func TestTokenManager_Reconfigure(t *testing.T) {
+	cfg := azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	}
+	tm := azurepush.NewTokenManager(cfg)
+
+	first, err := tm.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Go(func() {
+			for range 100 {
+				if _, err := tm.GetToken(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		})
+	}
+
+	for i := range 10 {
+		cfg.KeyName = "policy-" + strconv.Itoa(i)
+		tm.Reconfigure(cfg)
+
+		// A token of the previous configuration is never served after Reconfigure returns.
+		token, err := tm.GetToken()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(token, "&skn="+cfg.KeyName) {
+			t.Fatalf("expected a token of the %s policy, got: %s", cfg.KeyName, token)
+		}
+	}
+	wg.Wait()
+
+	if generation := tm.Generation(); generation != 11 {
+		t.Errorf("expected generation 11, got: %d", generation)
+	}
+
+	// The client keeps its TokenManager on Reload, so its holders see the new credentials too.
+	client := azurepush.NewClient(cfg)
+	held := client.TokenManager
+	if first, err = held.GetToken(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.KeyName = "rotated"
+	if err = client.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := held.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == first || !strings.HasSuffix(token, "&skn=rotated") {
+		t.Errorf("expected a token of the reloaded configuration, got: %s", token)
+	}
+}
+
+func TestTokenManager_Stats(t *testing.T) {
+	tm := azurepush.NewTokenManager(azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	})
+	clock := azurepushtest.NewFakeClock(time.Now())
+	tm.SetClock(clock)
+
+	if !tm.ExpiresAt().IsZero() {
+		t.Fatal("expected no expiry before the first token")
+	}
+
+	if _, err := tm.GetToken(); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := tm.Stats()
+	if stats.Refreshes != 1 || !stats.LastRefresh.Equal(clock.Now()) || !stats.ExpiresAt.Equal(clock.Now().Add(time.Hour)) {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	tm.Invalidate()
+	if stats = tm.Stats(); stats.Invalidations != 1 || !stats.ExpiresAt.IsZero() {
+		t.Errorf("unexpected stats after Invalidate: %+v", stats)
+	}
+
+	// A misconfigured key.
+	broken := azurepush.NewTokenManager(azurepush.Configuration{HubName: "myhub", Namespace: "mynamespace", TokenValidity: time.Hour})
+	var failed string
+	broken.OnRefreshError(func(resourceURI string, err error) {
+		failed = resourceURI
+	})
+
+	if _, err := broken.GetToken(); err == nil {
+		t.Fatal("expected a token generation error")
+	}
+
+	if stats = broken.Stats(); stats.RefreshFailures != 1 || stats.LastError == nil || failed != broken.ResourceURI() {
+		t.Errorf("unexpected failure stats: %+v (hook: %q)", stats, failed)
+	}
+}
+
+func TestTokenManager_GetTokenWithValidity(t *testing.T) {
+	tm := azurepush.NewTokenManager(azurepush.Configuration{
+		HubName:       "myhub",
+		Namespace:     "mynamespace",
+		KeyName:       "DefaultFullSharedAccessSignature",
+		KeyValue:      "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=", // dummy
+		TokenValidity: time.Hour,
+	})
+	clock := azurepushtest.NewFakeClock(time.Now())
+	tm.SetClock(clock)
+
+	expiry := func(token string) int64 {
+		params, _ := url.ParseQuery(strings.TrimPrefix(token, "SharedAccessSignature "))
+		se, _ := strconv.ParseInt(params.Get("se"), 10, 64)
+		return se
+	}
+
+	short, err := tm.GetTokenWithValidity(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := clock.Now().Add(time.Minute).Unix(); expiry(short) != expected {
+		t.Errorf("expected expiry: %d, got: %d", expected, expiry(short))
+	}
+
+	cached, err := tm.GetToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := tm.GetTokenWithValidity(time.Hour); token != cached {
+		t.Error("expected the cached token for the configured validity")
+	}
+
+	if stats := tm.Stats(); stats.Refreshes != 1 {
+		t.Errorf("expected the custom validity tokens not to be cached, got %d refreshes", stats.Refreshes)
+	}
+
+	if _, err = tm.GetTokenWithValidity(0); err == nil {
+		t.Error("expected an invalid validity error")
+	}
+}
+
+func TestValidateSASToken(t *testing.T) {
+	var requested []string
+	status, body := http.StatusNotFound, ""
+	httpClient := mockHTTPClient(func(r *http.Request) *http.Response {
+		requested = append(requested, r.URL.Scheme+"://"+r.URL.Host+r.URL.Path)
+		return jsonResponse(status, body)
+	})
+
+	ctx := context.Background()
+	result, err := azurepush.ValidateSASToken(ctx, azurepush.ValidateSASTokenOptions{
+		Token:      "SharedAccessSignature sr=x&sig=y&se=1&skn=z",
+		Endpoint:   "https://emulator.local/myhub/",
+		HTTPClient: httpClient,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Authorized || result.StatusCode != http.StatusNotFound || result.Err() != nil {
+		t.Errorf("expected an authorized token, got: %#v", result)
+	}
+	if len(requested) != 1 || !strings.HasPrefix(requested[0], "https://emulator.local/myhub/installations/") {
+		t.Errorf("expected a request to the custom endpoint, got: %v", requested)
+	}
+
+	status, body = http.StatusUnauthorized, "<Error><Code>401</Code><Detail>ExpiredToken: The token is expired.</Detail></Error>"
+	client := azurepush.NewClient(azurepush.Configuration{HubName: "hub", ConnectionString: testConnectionString})
+	client.HTTPClient = httpClient
+
+	err = client.ValidateToken(ctx)
+	unauthorizedErr, ok := errors.AsType[*azurepush.UnauthorizedError](err)
+	if !ok || unauthorizedErr.Reason != "ExpiredToken: The token is expired." {
+		t.Fatalf("expected an unauthorized error with the hub's reason, got: %v", err)
+	}
+	if class := azurepush.ClassifyError(err); class != azurepush.ErrorClassAuth {
+		t.Errorf("expected an auth error class, got: %s", class)
+	}
+	if !strings.HasPrefix(requested[1], "https://namespace.servicebus.windows.net/hub/installations/") {
+		t.Errorf("unexpected request: %s", requested[1])
+	}
+
+	status = http.StatusInternalServerError
+	if _, err = azurepush.ValidateSASToken(ctx, azurepush.ValidateSASTokenOptions{
+		Token: "token", Namespace: "namespace", HubName: "hub", HTTPClient: httpClient,
+	}); err == nil {
+		t.Error("expected an unexpected status code error")
+	}
+
+	if _, err = azurepush.ValidateSASToken(ctx, azurepush.ValidateSASTokenOptions{Token: "token"}); err == nil {
+		t.Error("expected a missing hub error")
+	}
+}
@@ -0,0 +1,102 @@
+package azurepush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Default HTTP transport settings, see the Configuration's HTTP fields.
+var (
+	DefaultRequestTimeout      = 10 * time.Second
+	DefaultMaxIdleConnsPerHost = 100
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// NewHTTPClient returns the HTTP client used by NewClient, tuned for high-throughput sends:
+// all requests of a client go to the single hub host, so the idle connections per host limit
+// is raised well above the http.DefaultTransport's 2, keep-alives are enabled and HTTP/2 is attempted.
+//
+// The Configuration's RequestTimeout, MaxIdleConnsPerHost, MaxConnsPerHost, IdleConnTimeout, KeepWarm
+// and DisableHTTP2 fields are applied.
+func NewHTTPClient(cfg Configuration) *http.Client {
+	requestTimeout := cfg.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = DefaultRequestTimeout
+	}
+
+	maxIdleConnsPerHost := cfg.MaxIdleConnsPerHost
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+
+	idleConnTimeout := cfg.IdleConnTimeout
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = DefaultIdleConnTimeout
+	}
+	if cfg.KeepWarm {
+		idleConnTimeout = 0 // no limit.
+	}
+
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(!cfg.DisableHTTP2)
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		Protocols:             &protocols,
+		MaxIdleConns:          maxIdleConnsPerHost,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+	}
+}
+
+// Warm pre-resolves the hub's host and establishes up to n connections to it in parallel
+// (by validating the SAS token concurrently), the connections are then kept alive for reuse.
+// It is called by NewClient when Configuration.WarmConnections is set,
+// so the first burst of sends after a deploy doesn't pay DNS and TLS latency on every connection.
+func (c *Client) Warm(ctx context.Context, n int) error {
+	cfg, _ := c.current()
+
+	// A resolution failure is reported but does not stop the warm up,
+	// custom transports (e.g. proxies) may not need it.
+	var resolveErr error
+	host := cfg.Namespace + ".servicebus.windows.net"
+	if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+		resolveErr = fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Go(func() {
+			errs[i] = c.ValidateToken(ctx)
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return errors.Join(resolveErr, fmt.Errorf("failed to warm connection: %w", err))
+		}
+	}
+
+	return resolveErr
+}
@@ -0,0 +1,64 @@
+package azurepush_test
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kataras/azurepush"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	client := azurepush.NewHTTPClient(azurepush.Configuration{})
+	transport := client.Transport.(*http.Transport)
+
+	if client.Timeout != azurepush.DefaultRequestTimeout {
+		t.Errorf("expected default request timeout, got: %s", client.Timeout)
+	}
+	if transport.MaxIdleConnsPerHost != azurepush.DefaultMaxIdleConnsPerHost {
+		t.Errorf("expected default idle connections per host, got: %d", transport.MaxIdleConnsPerHost)
+	}
+	if !transport.Protocols.HTTP2() {
+		t.Error("expected HTTP/2 to be enabled by default")
+	}
+
+	client = azurepush.NewHTTPClient(azurepush.Configuration{
+		RequestTimeout:      time.Second,
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     20,
+		DisableHTTP2:        true,
+	})
+	transport = client.Transport.(*http.Transport)
+
+	if client.Timeout != time.Second || transport.MaxIdleConnsPerHost != 10 || transport.MaxConnsPerHost != 20 {
+		t.Errorf("expected configured values, got: %s, %d, %d", client.Timeout, transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost)
+	}
+	if transport.Protocols.HTTP2() {
+		t.Error("expected HTTP/2 to be disabled")
+	}
+}
+
+func TestClient_Warm(t *testing.T) {
+	var probes atomic.Int32
+	client := azurepush.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: testConnectionString,
+	})
+	client.HTTPClient = mockHTTPClient(func(r *http.Request) *http.Response {
+		probes.Add(1)
+		return jsonResponse(http.StatusNotFound, "")
+	})
+
+	// The host resolution may fail in the test environment, the connections are still warmed.
+	_ = client.Warm(context.Background(), 3)
+	if probes.Load() != 3 {
+		t.Errorf("expected 3 warm up requests, got: %d", probes.Load())
+	}
+
+	transport := azurepush.NewHTTPClient(azurepush.Configuration{KeepWarm: true}).Transport.(*http.Transport)
+	if transport.IdleConnTimeout != 0 {
+		t.Errorf("expected no idle timeout when keeping connections warm, got: %s", transport.IdleConnTimeout)
+	}
+}
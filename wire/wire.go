@@ -0,0 +1,42 @@
+// Package wire provides the google/wire providers of the azurepush package.
+//
+// Example usage:
+//
+//	func initializeApp() (*App, error) {
+//		wire.Build(azurepushwire.ProviderSet, NewApp)
+//		return nil, nil
+//	}
+//
+// The client is configured from the environment, see azurepush.ConfigurationFromEnv.
+// Apps depend on the azurepush.HubClient interface or the *azurepush.Client.
+package wire
+
+import (
+	"github.com/kataras/azurepush"
+
+	"github.com/google/wire"
+)
+
+// ProviderSet provides a *azurepush.Client configured from the environment
+// and binds it to the azurepush.HubClient interface.
+var ProviderSet = wire.NewSet(
+	azurepush.NewClientFromEnv,
+	wire.Bind(new(azurepush.HubClient), new(*azurepush.Client)),
+)
+
+// ConfigurationProviderSet provides a *azurepush.Client from an azurepush.Configuration
+// provided by the app, and binds it to the azurepush.HubClient interface.
+var ConfigurationProviderSet = wire.NewSet(
+	NewClient,
+	wire.Bind(new(azurepush.HubClient), new(*azurepush.Client)),
+)
+
+// NewClient is a wire provider which validates the configuration
+// and returns an error instead of azurepush.NewClient's panic.
+func NewClient(cfg azurepush.Configuration) (*azurepush.Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return azurepush.NewClient(cfg), nil
+}
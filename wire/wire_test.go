@@ -0,0 +1,22 @@
+package wire_test
+
+import (
+	"testing"
+
+	"github.com/kataras/azurepush"
+	azurepushwire "github.com/kataras/azurepush/wire"
+)
+
+func TestNewClient(t *testing.T) {
+	client, err := azurepushwire.NewClient(azurepush.Configuration{
+		HubName:          "hub",
+		ConnectionString: "Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=DefaultFullSharedAccessSignature;SharedAccessKey=secret",
+	})
+	if err != nil || client == nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = azurepushwire.NewClient(azurepush.Configuration{HubName: "hub"}); err == nil {
+		t.Error("expected error instead of a panic for an invalid configuration")
+	}
+}